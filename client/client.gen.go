@@ -0,0 +1,2275 @@
+// Code generated by cmd/openapi from the route table in internal/server. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	_ = json.RawMessage{}
+	_ = io.EOF
+	_ = http.MethodGet
+	_ = url.PathEscape
+	_ = strconv.Itoa
+	_ = time.Time{}
+)
+
+type APIError struct {
+	Code      ErrorCode    `json:"code"`
+	Message   string       `json:"message"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+type APIKey struct {
+	ID             int64      `json:"id"`
+	OrganizationID int64      `json:"organization_id"`
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix"`
+	Permissions    []string   `json:"permissions"`
+	CreatedByID    int64      `json:"created_by_id"`
+	RotatedFromID  *int64     `json:"rotated_from_id,omitempty"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP     string     `json:"last_used_ip,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type APIKeyRequest struct {
+	Name        string     `json:"name"`
+	Permissions []string   `json:"permissions"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+type Accommodation struct {
+	ID             int64      `json:"id"`
+	OrganizationID int64      `json:"organization_id"`
+	UserID         *int64     `json:"user_id,omitempty"`
+	InvitationID   *int64     `json:"invitation_id,omitempty"`
+	TimeMultiplier float64    `json:"time_multiplier"`
+	Reason         string     `json:"reason"`
+	GrantedByID    *int64     `json:"granted_by_id,omitempty"`
+	GrantedByKeyID *int64     `json:"granted_by_api_key_id,omitempty"`
+	GrantedAt      time.Time  `json:"granted_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	RevokedByID    *int64     `json:"revoked_by_id,omitempty"`
+	RevokedByKeyID *int64     `json:"revoked_by_api_key_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type AccommodationRequest struct {
+	UserID         *int64  `json:"user_id,omitempty"`
+	InvitationID   *int64  `json:"invitation_id,omitempty"`
+	TimeMultiplier float64 `json:"time_multiplier"`
+	Reason         string  `json:"reason"`
+}
+
+type ActivityRequest struct {
+	Type          string `json:"type"`
+	QuestionIndex *int64 `json:"question_index,omitempty"`
+}
+
+type Answer struct {
+	ID           int64      `json:"id"`
+	TestResultID int64      `json:"test_result_id"`
+	QuestionID   int64      `json:"question_id"`
+	UserAnswer   string     `json:"user_answer"`
+	IsCorrect    bool       `json:"is_correct"`
+	ResponseTime int64      `json:"response_time"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	TestResult   TestResult `json:"test_result"`
+	Question     Question   `json:"question"`
+}
+
+type AnswerReveal string
+
+const (
+	AnswerRevealImmediately AnswerReveal = "immediately"
+	AnswerRevealAfterClose  AnswerReveal = "after_close"
+	AnswerRevealNever       AnswerReveal = "never"
+)
+
+type AnswerStatistics struct {
+	Answer        string  `json:"answer"`
+	Label         string  `json:"label,omitempty"`
+	Count         int64   `json:"count"`
+	Proportion    float64 `json:"proportion"`
+	MeanRestScore float64 `json:"mean_rest_score"`
+	IsKey         bool    `json:"is_key"`
+}
+
+type AttemptEligibility struct {
+	TestID        int64      `json:"test_id"`
+	AttemptsUsed  int64      `json:"attempts_used"`
+	MaxAttempts   int64      `json:"max_attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	Allowed       bool       `json:"allowed"`
+	Reason        string     `json:"reason,omitempty"`
+}
+
+type AttemptProgress struct {
+	ResultID       int64           `json:"result_id"`
+	Attempt        int64           `json:"attempt"`
+	CompletedAt    time.Time       `json:"completed_at"`
+	Score          int64           `json:"score"`
+	TotalQuestions int64           `json:"total_questions"`
+	Percentage     float64         `json:"percentage"`
+	Categories     []CategoryScore `json:"categories"`
+}
+
+type AuthResponse struct {
+	User  *User  `json:"user,omitempty"`
+	Token string `json:"token"`
+}
+
+type BulkInvitationsRequest struct {
+	TestID    int64     `json:"test_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type Category string
+
+const (
+	CategoryAnalyticalReasoning Category = "analytical_reasoning"
+	CategoryWorkingMemory       Category = "working_memory"
+	CategoryProcessingSpeed     Category = "processing_speed"
+	CategoryAttentionFocus      Category = "attention_focus"
+	CategoryEmotionalRegulation Category = "emotional_regulation"
+)
+
+type CategoryCorrelation struct {
+	A Category `json:"a"`
+	B Category `json:"b"`
+	R *float64 `json:"r,omitempty"`
+}
+
+type CategoryMean struct {
+	Category       Category `json:"category"`
+	MeanPercentage *float64 `json:"mean_percentage,omitempty"`
+	Attempts       int64    `json:"attempts"`
+}
+
+type CategoryScore struct {
+	Category        Category `json:"category"`
+	Correct         int64    `json:"correct"`
+	Total           int64    `json:"total"`
+	Percentage      float64  `json:"percentage"`
+	AvgResponseTime float64  `json:"avg_response_time"`
+}
+
+type CertificatePayload struct {
+	Slug           string    `json:"certificate_id"`
+	Issuer         string    `json:"issuer"`
+	Name           string    `json:"name"`
+	TestName       string    `json:"test_name"`
+	Score          int64     `json:"score"`
+	TotalQuestions int64     `json:"total_questions"`
+	Percentage     float64   `json:"percentage"`
+	CompletedAt    time.Time `json:"completed_at"`
+	IssuedAt       time.Time `json:"issued_at"`
+}
+
+type CertificatePublicKey struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+}
+
+type CertificateVerification struct {
+	Valid       bool                `json:"valid"`
+	Revoked     bool                `json:"revoked"`
+	Certificate *CertificatePayload `json:"certificate,omitempty"`
+}
+
+type CreateInvitationsRequest struct {
+	TestID     int64                 `json:"test_id"`
+	ExpiresAt  time.Time             `json:"expires_at"`
+	Recipients []InvitationRecipient `json:"recipients"`
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+type CreateTestRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Duration    int64  `json:"duration,omitempty"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusDelivered DeliveryStatus = "delivered"
+	DeliveryStatusFailed    DeliveryStatus = "failed"
+)
+
+type DropOffPoint struct {
+	Position int64   `json:"position"`
+	Reached  int64   `json:"reached"`
+	Rate     float64 `json:"rate"`
+}
+
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest           ErrorCode = "invalid_request"
+	ErrorCodeUnauthorized             ErrorCode = "unauthorized"
+	ErrorCodeForbidden                ErrorCode = "forbidden"
+	ErrorCodeNotFound                 ErrorCode = "not_found"
+	ErrorCodeConflict                 ErrorCode = "conflict"
+	ErrorCodeGone                     ErrorCode = "gone"
+	ErrorCodeUnprocessable            ErrorCode = "unprocessable"
+	ErrorCodeRateLimited              ErrorCode = "rate_limited"
+	ErrorCodeInternalError            ErrorCode = "internal_error"
+	ErrorCodeValidationFailed         ErrorCode = "validation_failed"
+	ErrorCodeMalformedBody            ErrorCode = "malformed_body"
+	ErrorCodeAPIVersionRemoved        ErrorCode = "api_version_removed"
+	ErrorCodeTokenRequired            ErrorCode = "token_required"
+	ErrorCodeTokenInvalid             ErrorCode = "token_invalid"
+	ErrorCodeTokenRevoked             ErrorCode = "token_revoked"
+	ErrorCodeAPIKeyInvalid            ErrorCode = "api_key_invalid"
+	ErrorCodeTenantMismatch           ErrorCode = "tenant_mismatch"
+	ErrorCodePermissionDenied         ErrorCode = "permission_denied"
+	ErrorCodeOrganizationNotFound     ErrorCode = "organization_not_found"
+	ErrorCodeEmailTaken               ErrorCode = "email_taken"
+	ErrorCodeInvalidCredentials       ErrorCode = "invalid_credentials"
+	ErrorCodeTestNotOpen              ErrorCode = "test_not_open"
+	ErrorCodeTestClosed               ErrorCode = "test_closed"
+	ErrorCodeAttemptLimitReached      ErrorCode = "attempt_limit_reached"
+	ErrorCodeAttemptCooldown          ErrorCode = "attempt_cooldown"
+	ErrorCodeAttemptNotFound          ErrorCode = "attempt_not_found"
+	ErrorCodeAttemptCompleted         ErrorCode = "attempt_completed"
+	ErrorCodeAttemptExpired           ErrorCode = "attempt_expired"
+	ErrorCodeInvalidSubmission        ErrorCode = "invalid_submission"
+	ErrorCodeNoQuestions              ErrorCode = "no_questions"
+	ErrorCodeInvalidActivity          ErrorCode = "invalid_activity"
+	ErrorCodePracticeDisabled         ErrorCode = "practice_disabled"
+	ErrorCodePracticeSessionNotFound  ErrorCode = "practice_session_not_found"
+	ErrorCodePracticeSessionFinished  ErrorCode = "practice_session_finished"
+	ErrorCodePracticeAttemptOpen      ErrorCode = "practice_attempt_open"
+	ErrorCodeQuestionAlreadyAnswered  ErrorCode = "question_already_answered"
+	ErrorCodeQuestionNotInSession     ErrorCode = "question_not_in_session"
+	ErrorCodeSessionNotFound          ErrorCode = "session_not_found"
+	ErrorCodeSessionStateConflict     ErrorCode = "session_state_conflict"
+	ErrorCodeTestUntimed              ErrorCode = "test_untimed"
+	ErrorCodeNotASessionCandidate     ErrorCode = "not_a_session_candidate"
+	ErrorCodeSessionOver              ErrorCode = "session_over"
+	ErrorCodeInvalidSession           ErrorCode = "invalid_session"
+	ErrorCodeSessionRequired          ErrorCode = "session_required"
+	ErrorCodeSessionPaused            ErrorCode = "session_paused"
+	ErrorCodeAccommodationNotFound    ErrorCode = "accommodation_not_found"
+	ErrorCodeInvalidAccommodation     ErrorCode = "invalid_accommodation"
+	ErrorCodeInvitationNotFound       ErrorCode = "invitation_not_found"
+	ErrorCodeInvitationExpired        ErrorCode = "invitation_expired"
+	ErrorCodeInvitationUsed           ErrorCode = "invitation_used"
+	ErrorCodeEmailInOtherOrganization ErrorCode = "email_in_other_organization"
+	ErrorCodeEmailIsStaffAccount      ErrorCode = "email_is_staff_account"
+	ErrorCodeResultIncomplete         ErrorCode = "result_incomplete"
+	ErrorCodeCertificateRevoked       ErrorCode = "certificate_revoked"
+	ErrorCodeAPIKeyNotFound           ErrorCode = "api_key_not_found"
+	ErrorCodeAPIKeyInactive           ErrorCode = "api_key_inactive"
+	ErrorCodeAPIKeyScope              ErrorCode = "api_key_scope"
+	ErrorCodeWebhookNotFound          ErrorCode = "webhook_not_found"
+	ErrorCodeDeliveryNotFound         ErrorCode = "delivery_not_found"
+	ErrorCodeInvalidWebhook           ErrorCode = "invalid_webhook"
+	ErrorCodeLtiPlatformNotFound      ErrorCode = "lti_platform_not_found"
+	ErrorCodeLtiPlatformExists        ErrorCode = "lti_platform_exists"
+	ErrorCodeLtiInvalidStaffRole      ErrorCode = "lti_invalid_staff_role"
+	ErrorCodeUnknownRole              ErrorCode = "unknown_role"
+	ErrorCodeUnknownPermission        ErrorCode = "unknown_permission"
+)
+
+type ExtendTimeRequest struct {
+	UserID  int64 `json:"user_id"`
+	Minutes int64 `json:"minutes"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type GraphQLRequest struct {
+	Query         string                     `json:"query"`
+	OperationName string                     `json:"operationName,omitempty"`
+	Variables     map[string]json.RawMessage `json:"variables,omitempty"`
+}
+
+type HandlersSubmitAnswerRequest struct {
+	QuestionID   int64  `json:"question_id"`
+	UserAnswer   string `json:"user_answer,omitempty"`
+	ResponseTime int64  `json:"response_time,omitempty"`
+}
+
+type HistogramBin struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count *int64  `json:"count,omitempty"`
+}
+
+type Invitation struct {
+	ID             int64            `json:"id"`
+	OrganizationID int64            `json:"organization_id"`
+	TestID         int64            `json:"test_id"`
+	InvitedByID    int64            `json:"invited_by_id"`
+	InvitedByKeyID *int64           `json:"invited_by_api_key_id,omitempty"`
+	Email          string           `json:"email"`
+	Name           string           `json:"name"`
+	Status         InvitationStatus `json:"status"`
+	ExpiresAt      time.Time        `json:"expires_at"`
+	OpenedAt       *time.Time       `json:"opened_at,omitempty"`
+	StartedAt      *time.Time       `json:"started_at,omitempty"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
+	UserID         *int64           `json:"user_id,omitempty"`
+	TestResultID   *int64           `json:"test_result_id,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	Test           Test             `json:"test"`
+	TestResult     *TestResult      `json:"test_result,omitempty"`
+}
+
+type InvitationLink struct {
+	ID             int64            `json:"id"`
+	OrganizationID int64            `json:"organization_id"`
+	TestID         int64            `json:"test_id"`
+	InvitedByID    int64            `json:"invited_by_id"`
+	InvitedByKeyID *int64           `json:"invited_by_api_key_id,omitempty"`
+	Email          string           `json:"email"`
+	Name           string           `json:"name"`
+	Status         InvitationStatus `json:"status"`
+	ExpiresAt      time.Time        `json:"expires_at"`
+	OpenedAt       *time.Time       `json:"opened_at,omitempty"`
+	StartedAt      *time.Time       `json:"started_at,omitempty"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
+	UserID         *int64           `json:"user_id,omitempty"`
+	TestResultID   *int64           `json:"test_result_id,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	Test           Test             `json:"test"`
+	TestResult     *TestResult      `json:"test_result,omitempty"`
+	Token          string           `json:"token"`
+	URL            string           `json:"url"`
+}
+
+type InvitationRecipient struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+type InvitationStatus string
+
+const (
+	InvitationStatusSent      InvitationStatus = "sent"
+	InvitationStatusOpened    InvitationStatus = "opened"
+	InvitationStatusStarted   InvitationStatus = "started"
+	InvitationStatusCompleted InvitationStatus = "completed"
+)
+
+type IssuedAPIKey struct {
+	APIKey *APIKey `json:"api_key,omitempty"`
+	Key    string  `json:"key"`
+}
+
+type ItemAnalysisReport struct {
+	GeneratedAt          time.Time        `json:"generated_at"`
+	Attempts             int64            `json:"attempts"`
+	AccommodatedAttempts int64            `json:"accommodated_attempts"`
+	MinResponses         int64            `json:"min_responses"`
+	Items                []ItemStatistics `json:"items"`
+}
+
+type ItemStatistics struct {
+	QuestionID         int64              `json:"question_id"`
+	TestID             int64              `json:"test_id"`
+	OrderIndex         int64              `json:"order_index"`
+	QuestionText       string             `json:"question_text"`
+	QuestionType       QuestionType       `json:"question_type"`
+	Category           Category           `json:"category"`
+	CorrectAnswer      string             `json:"correct_answer"`
+	Responses          int64              `json:"responses"`
+	PValue             float64            `json:"p_value"`
+	Discrimination     *float64           `json:"discrimination,omitempty"`
+	OmissionRate       float64            `json:"omission_rate"`
+	MeanResponseTimeMs float64            `json:"mean_response_time_ms"`
+	Answers            []AnswerStatistics `json:"answers"`
+	Flags              []string           `json:"flags"`
+}
+
+type LTIPlatform struct {
+	ID             int64               `json:"id"`
+	OrganizationID int64               `json:"organization_id"`
+	Name           string              `json:"name"`
+	Issuer         string              `json:"issuer"`
+	ClientID       string              `json:"client_id"`
+	DeploymentIDs  []string            `json:"deployment_ids"`
+	AuthLoginURL   string              `json:"auth_login_url"`
+	AccessTokenURL string              `json:"access_token_url"`
+	JWKSURL        string              `json:"jwks_url"`
+	Active         bool                `json:"active"`
+	LinkByEmail    bool                `json:"link_by_email"`
+	StaffRoles     map[string][]string `json:"staff_roles"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+}
+
+type LTIPlatformRequest struct {
+	Name           string              `json:"name"`
+	Issuer         string              `json:"issuer"`
+	ClientID       string              `json:"client_id"`
+	DeploymentIDs  []string            `json:"deployment_ids"`
+	AuthLoginURL   string              `json:"auth_login_url"`
+	AccessTokenURL string              `json:"access_token_url"`
+	JWKSURL        string              `json:"jwks_url"`
+	Active         *bool               `json:"active,omitempty"`
+	LinkByEmail    bool                `json:"link_by_email,omitempty"`
+	StaffRoles     map[string][]string `json:"staff_roles,omitempty"`
+}
+
+type LTIToolConfiguration struct {
+	LoginURL       string   `json:"login_url"`
+	LaunchURL      string   `json:"launch_url"`
+	DeepLinkingURL string   `json:"deep_linking_url"`
+	RedirectURIs   []string `json:"redirect_uris"`
+	JWKSURL        string   `json:"jwks_url"`
+}
+
+type Leaderboard struct {
+	TestID      int64             `json:"test_id,omitempty"`
+	Category    Category          `json:"category,omitempty"`
+	Scope       string            `json:"scope"`
+	Period      LeaderboardPeriod `json:"period"`
+	PeriodStart *time.Time        `json:"period_start,omitempty"`
+	PeriodEnd   *time.Time        `json:"period_end,omitempty"`
+	Entries     []LeaderboardRow  `json:"entries"`
+	You         *LeaderboardRow   `json:"you,omitempty"`
+}
+
+type LeaderboardPeriod string
+
+const (
+	LeaderboardPeriodAll   LeaderboardPeriod = "all"
+	LeaderboardPeriodWeek  LeaderboardPeriod = "week"
+	LeaderboardPeriodMonth LeaderboardPeriod = "month"
+)
+
+type LeaderboardProfile struct {
+	UserID    int64     `json:"user_id"`
+	OptedIn   bool      `json:"opted_in"`
+	Pseudonym string    `json:"pseudonym"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type LeaderboardProfileRequest struct {
+	OptedIn             bool `json:"opted_in,omitempty"`
+	RegeneratePseudonym bool `json:"regenerate_pseudonym,omitempty"`
+}
+
+type LeaderboardRow struct {
+	Rank       int64     `json:"rank"`
+	Pseudonym  string    `json:"pseudonym"`
+	Score      float64   `json:"score"`
+	TimeTaken  int64     `json:"time_taken"`
+	AchievedAt time.Time `json:"achieved_at"`
+	IsYou      bool      `json:"is_you,omitempty"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type Organization struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OrganizationDashboard struct {
+	From         *time.Time    `json:"from,omitempty"`
+	To           *time.Time    `json:"to,omitempty"`
+	MinGroupSize int64         `json:"min_group_size"`
+	GeneratedAt  time.Time     `json:"generated_at"`
+	Tests        []TestSummary `json:"tests"`
+}
+
+type Permission struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type PracticeAnswer struct {
+	ID                int64     `json:"id"`
+	PracticeSessionID int64     `json:"practice_session_id"`
+	QuestionID        int64     `json:"question_id"`
+	UserAnswer        string    `json:"user_answer"`
+	IsCorrect         bool      `json:"is_correct"`
+	ResponseTime      int64     `json:"response_time"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type PracticeFeedback struct {
+	QuestionID    int64  `json:"question_id"`
+	IsCorrect     *bool  `json:"is_correct,omitempty"`
+	CorrectAnswer string `json:"correct_answer,omitempty"`
+	Explanation   string `json:"explanation,omitempty"`
+	Reference     string `json:"reference,omitempty"`
+	Score         *int64 `json:"score,omitempty"`
+	Answered      int64  `json:"answered"`
+}
+
+type PracticeSession struct {
+	ID             int64            `json:"id"`
+	OrganizationID int64            `json:"organization_id"`
+	UserID         int64            `json:"user_id"`
+	TestID         int64            `json:"test_id"`
+	Score          int64            `json:"score"`
+	ScoreHidden    bool             `json:"score_hidden,omitempty"`
+	Answered       int64            `json:"answered"`
+	TotalQuestions int64            `json:"total_questions"`
+	StartedAt      time.Time        `json:"started_at"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	Test           Test             `json:"test"`
+	Answers        []PracticeAnswer `json:"answers,omitempty"`
+}
+
+type ProgressReport struct {
+	UserID int64          `json:"user_id"`
+	Tests  []TestProgress `json:"tests"`
+}
+
+type PublishedCertificate struct {
+	ID             int64      `json:"id"`
+	OrganizationID int64      `json:"organization_id"`
+	UserID         int64      `json:"user_id"`
+	TestResultID   int64      `json:"test_result_id"`
+	Slug           string     `json:"slug"`
+	Payload        string     `json:"payload"`
+	Signature      string     `json:"signature"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	URL            string     `json:"url"`
+}
+
+type Question struct {
+	ID            int64        `json:"id"`
+	TestID        int64        `json:"test_id"`
+	QuestionText  string       `json:"question_text"`
+	QuestionType  QuestionType `json:"question_type"`
+	Category      Category     `json:"category"`
+	Options       string       `json:"options,omitempty"`
+	CorrectAnswer string       `json:"correct_answer"`
+	Explanation   string       `json:"explanation,omitempty"`
+	Reference     string       `json:"reference,omitempty"`
+	TimeLimit     int64        `json:"time_limit"`
+	DisplayTime   int64        `json:"display_time"`
+	OrderIndex    int64        `json:"order_index"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	Test          Test         `json:"test"`
+}
+
+type QuestionRequest struct {
+	TestID        int64        `json:"test_id"`
+	QuestionText  string       `json:"question_text"`
+	QuestionType  QuestionType `json:"question_type"`
+	Category      Category     `json:"category"`
+	Options       string       `json:"options,omitempty"`
+	CorrectAnswer string       `json:"correct_answer"`
+	Explanation   string       `json:"explanation,omitempty"`
+	Reference     string       `json:"reference,omitempty"`
+	TimeLimit     int64        `json:"time_limit,omitempty"`
+	DisplayTime   int64        `json:"display_time,omitempty"`
+	OrderIndex    int64        `json:"order_index,omitempty"`
+}
+
+type QuestionType string
+
+const (
+	QuestionTypeMultipleChoice QuestionType = "multiple_choice"
+	QuestionTypeTextInput      QuestionType = "text_input"
+	QuestionTypeNumberInput    QuestionType = "number_input"
+	QuestionTypeKeySequence    QuestionType = "key_sequence"
+)
+
+type RegisterRequest struct {
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+type ReliabilityReport struct {
+	ID                 int64                 `json:"id"`
+	OrganizationID     int64                 `json:"organization_id"`
+	TestID             int64                 `json:"test_id"`
+	Version            int64                 `json:"version"`
+	ExcludedCount      int64                 `json:"excluded_attempts"`
+	Accommodated       int64                 `json:"accommodated_attempts"`
+	ComputedAt         time.Time             `json:"computed_at"`
+	ResultCount        int64                 `json:"result_count"`
+	ResultsUpdatedAt   *time.Time            `json:"results_updated_at,omitempty"`
+	QuestionCount      int64                 `json:"question_count"`
+	QuestionsUpdatedAt *time.Time            `json:"questions_updated_at,omitempty"`
+	Overall            ScaleReliability      `json:"overall"`
+	Subscales          []ScaleReliability    `json:"subscales"`
+	Correlations       []CategoryCorrelation `json:"correlations"`
+}
+
+type ReportTemplate struct {
+	ID             int64     `json:"id"`
+	OrganizationID int64     `json:"organization_id"`
+	Title          string    `json:"title"`
+	Subtitle       string    `json:"subtitle"`
+	PrimaryColor   string    `json:"primary_color"`
+	FooterText     string    `json:"footer_text"`
+	ShowReview     bool      `json:"show_review"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type ReportTemplateRequest struct {
+	Title        string `json:"title"`
+	Subtitle     string `json:"subtitle,omitempty"`
+	PrimaryColor string `json:"primary_color,omitempty"`
+	FooterText   string `json:"footer_text,omitempty"`
+	ShowReview   bool   `json:"show_review,omitempty"`
+}
+
+type Response struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Error   *APIError       `json:"error,omitempty"`
+}
+
+type ResultReview struct {
+	ResultID        int64        `json:"result_id"`
+	TestID          int64        `json:"test_id"`
+	TestName        string       `json:"test_name"`
+	Score           int64        `json:"score"`
+	TotalQuestions  int64        `json:"total_questions"`
+	CompletedAt     *time.Time   `json:"completed_at,omitempty"`
+	AnswerReveal    AnswerReveal `json:"answer_reveal"`
+	AnswersRevealed bool         `json:"answers_revealed"`
+	RevealsAt       *time.Time   `json:"reveals_at,omitempty"`
+	Items           []ReviewItem `json:"items"`
+}
+
+type ReviewItem struct {
+	Position      int64        `json:"position"`
+	QuestionID    int64        `json:"question_id"`
+	QuestionText  string       `json:"question_text"`
+	QuestionType  QuestionType `json:"question_type"`
+	Category      Category     `json:"category"`
+	Options       string       `json:"options,omitempty"`
+	UserAnswer    string       `json:"user_answer"`
+	IsCorrect     bool         `json:"is_correct"`
+	ResponseTime  int64        `json:"response_time"`
+	CorrectAnswer string       `json:"correct_answer,omitempty"`
+	Explanation   string       `json:"explanation,omitempty"`
+	Reference     string       `json:"reference,omitempty"`
+}
+
+type Role struct {
+	ID          int64        `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Permissions []Permission `json:"permissions,omitempty"`
+}
+
+type ScaleReliability struct {
+	Category      Category `json:"category,omitempty"`
+	Items         int64    `json:"items"`
+	Attempts      int64    `json:"attempts"`
+	Mean          float64  `json:"mean"`
+	SD            float64  `json:"sd"`
+	CronbachAlpha *float64 `json:"cronbach_alpha,omitempty"`
+	SplitHalf     *float64 `json:"split_half,omitempty"`
+	SEM           *float64 `json:"sem,omitempty"`
+}
+
+type ScoreTrend struct {
+	Category            Category     `json:"category,omitempty"`
+	Points              []TrendPoint `json:"points"`
+	Latest              float64      `json:"latest"`
+	DeltaSinceLast      *float64     `json:"delta_since_last,omitempty"`
+	ChangeSinceFirst    *float64     `json:"change_since_first,omitempty"`
+	ExpectedPractice    float64      `json:"expected_practice_effect"`
+	AdjustedChange      *float64     `json:"adjusted_change,omitempty"`
+	ReliableChangeIndex *float64     `json:"reliable_change_index,omitempty"`
+	Trend               Trend        `json:"trend"`
+}
+
+type ScoringPolicy string
+
+const (
+	ScoringPolicyFirst  ScoringPolicy = "first"
+	ScoringPolicyBest   ScoringPolicy = "best"
+	ScoringPolicyLatest ScoringPolicy = "latest"
+)
+
+type Session struct {
+	ID             int64              `json:"id"`
+	OrganizationID int64              `json:"organization_id"`
+	TestID         int64              `json:"test_id"`
+	ProctorID      int64              `json:"proctor_id"`
+	Name           string             `json:"name"`
+	Status         string             `json:"status"`
+	ScheduledAt    time.Time          `json:"scheduled_at"`
+	StartedAt      *time.Time         `json:"started_at,omitempty"`
+	EndsAt         *time.Time         `json:"ends_at,omitempty"`
+	PausedAt       *time.Time         `json:"paused_at,omitempty"`
+	EndedAt        *time.Time         `json:"ended_at,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	Test           Test               `json:"test"`
+	Candidates     []SessionCandidate `json:"candidates,omitempty"`
+}
+
+type SessionCandidate struct {
+	ID                   int64       `json:"id"`
+	SessionID            int64       `json:"session_id"`
+	UserID               int64       `json:"user_id"`
+	ExtraMinutes         int64       `json:"extra_minutes"`
+	AccommodationMinutes int64       `json:"accommodation_minutes"`
+	JoinedAt             *time.Time  `json:"joined_at,omitempty"`
+	TestResultID         *int64      `json:"test_result_id,omitempty"`
+	TerminatedAt         *time.Time  `json:"terminated_at,omitempty"`
+	TerminatedByID       *int64      `json:"terminated_by_id,omitempty"`
+	TerminationReason    string      `json:"termination_reason,omitempty"`
+	CreatedAt            time.Time   `json:"created_at"`
+	UpdatedAt            time.Time   `json:"updated_at"`
+	User                 *User       `json:"user,omitempty"`
+	TestResult           *TestResult `json:"test_result,omitempty"`
+}
+
+type SessionLobby struct {
+	SessionID   int64      `json:"session_id"`
+	TestID      int64      `json:"test_id"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	Deadline    *time.Time `json:"deadline,omitempty"`
+	AttemptID   *int64     `json:"attempt_id,omitempty"`
+	Terminated  bool       `json:"terminated"`
+}
+
+type SessionRequest struct {
+	TestID      int64     `json:"test_id"`
+	Name        string    `json:"name"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	UserIDs     []int64   `json:"user_ids"`
+}
+
+type SetUserRolesRequest struct {
+	Roles []string `json:"roles"`
+}
+
+type SignedCertificate struct {
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+type StartedAttempt struct {
+	Attempt   *TestResult `json:"attempt,omitempty"`
+	Questions []Question  `json:"questions"`
+}
+
+type StartedInvitation struct {
+	Invitation *Invitation `json:"invitation,omitempty"`
+	Token      string      `json:"token"`
+}
+
+type StartedPractice struct {
+	Session   *PracticeSession `json:"session,omitempty"`
+	Questions []Question       `json:"questions"`
+}
+
+type SubmitAnswerRequest struct {
+	QuestionID   int64  `json:"question_id"`
+	UserAnswer   string `json:"user_answer,omitempty"`
+	ResponseTime int64  `json:"response_time,omitempty"`
+}
+
+type SubmitTestRequest struct {
+	TestID    int64                         `json:"test_id"`
+	AttemptID int64                         `json:"attempt_id"`
+	Answers   []HandlersSubmitAnswerRequest `json:"answers"`
+}
+
+type TerminateAttemptRequest struct {
+	UserID int64  `json:"user_id"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type Test struct {
+	ID              int64         `json:"id"`
+	OrganizationID  int64         `json:"organization_id"`
+	Name            string        `json:"name"`
+	Description     string        `json:"description"`
+	Duration        int64         `json:"duration"`
+	MaxAttempts     int64         `json:"max_attempts"`
+	CooldownMinutes int64         `json:"cooldown_minutes"`
+	OpensAt         *time.Time    `json:"opens_at,omitempty"`
+	ClosesAt        *time.Time    `json:"closes_at,omitempty"`
+	ScoringPolicy   ScoringPolicy `json:"scoring_policy"`
+	AnswerReveal    AnswerReveal  `json:"answer_reveal"`
+	PracticeEnabled bool          `json:"practice_enabled"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	Questions       []Question    `json:"questions,omitempty"`
+}
+
+type TestDashboard struct {
+	TestID                 int64          `json:"test_id"`
+	TestName               string         `json:"test_name"`
+	From                   *time.Time     `json:"from,omitempty"`
+	To                     *time.Time     `json:"to,omitempty"`
+	MinGroupSize           int64          `json:"min_group_size"`
+	GeneratedAt            time.Time      `json:"generated_at"`
+	Started                int64          `json:"started"`
+	Completed              int64          `json:"completed"`
+	CompletionRate         float64        `json:"completion_rate"`
+	Accommodated           *int64         `json:"accommodated,omitempty"`
+	Suppressed             bool           `json:"suppressed"`
+	MeanPercentage         *float64       `json:"mean_percentage,omitempty"`
+	MedianTimeTakenSeconds *float64       `json:"median_time_taken_seconds,omitempty"`
+	ScoreDistribution      []HistogramBin `json:"score_distribution"`
+	CategoryMeans          []CategoryMean `json:"category_means"`
+	DropOff                []DropOffPoint `json:"drop_off"`
+}
+
+type TestProgress struct {
+	TestID     int64             `json:"test_id"`
+	TestName   string            `json:"test_name"`
+	Attempts   []AttemptProgress `json:"attempts"`
+	Overall    ScoreTrend        `json:"overall"`
+	Categories []ScoreTrend      `json:"categories"`
+}
+
+type TestResult struct {
+	ID              int64      `json:"id"`
+	OrganizationID  int64      `json:"organization_id"`
+	UserID          int64      `json:"user_id"`
+	TestID          int64      `json:"test_id"`
+	Score           int64      `json:"score"`
+	TotalQuestions  int64      `json:"total_questions"`
+	TimeTaken       int64      `json:"time_taken"`
+	Counted         bool       `json:"counted"`
+	StartedAt       time.Time  `json:"started_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	SessionID       *int64     `json:"session_id,omitempty"`
+	Deadline        *time.Time `json:"deadline,omitempty"`
+	PausedAt        *time.Time `json:"paused_at,omitempty"`
+	EndReason       string     `json:"end_reason,omitempty"`
+	AccommodationID *int64     `json:"accommodation_id,omitempty"`
+	TimeMultiplier  float64    `json:"time_multiplier"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	User            *User      `json:"user,omitempty"`
+	Test            Test       `json:"test"`
+	Answers         []Answer   `json:"answers,omitempty"`
+}
+
+type TestSummary struct {
+	TestID                 int64    `json:"test_id"`
+	TestName               string   `json:"test_name"`
+	Started                int64    `json:"started"`
+	Completed              int64    `json:"completed"`
+	CompletionRate         float64  `json:"completion_rate"`
+	Accommodated           *int64   `json:"accommodated,omitempty"`
+	MeanPercentage         *float64 `json:"mean_percentage,omitempty"`
+	MedianTimeTakenSeconds *float64 `json:"median_time_taken_seconds,omitempty"`
+}
+
+type Trend string
+
+const (
+	TrendImproving        Trend = "improving"
+	TrendDeclining        Trend = "declining"
+	TrendStable           Trend = "stable"
+	TrendInsufficientData Trend = "insufficient_data"
+)
+
+type TrendPoint struct {
+	Attempt    int64     `json:"attempt"`
+	Date       time.Time `json:"date"`
+	Percentage float64   `json:"percentage"`
+}
+
+type UpdatePolicyRequest struct {
+	MaxAttempts     int64         `json:"max_attempts,omitempty"`
+	CooldownMinutes int64         `json:"cooldown_minutes,omitempty"`
+	OpensAt         *time.Time    `json:"opens_at,omitempty"`
+	ClosesAt        *time.Time    `json:"closes_at,omitempty"`
+	ScoringPolicy   ScoringPolicy `json:"scoring_policy"`
+	AnswerReveal    AnswerReveal  `json:"answer_reveal,omitempty"`
+	PracticeEnabled bool          `json:"practice_enabled,omitempty"`
+}
+
+type UpdateRoleRequest struct {
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+type User struct {
+	ID             int64         `json:"id"`
+	OrganizationID int64         `json:"organization_id"`
+	Email          string        `json:"email"`
+	FirstName      string        `json:"first_name"`
+	LastName       string        `json:"last_name"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	Organization   *Organization `json:"organization,omitempty"`
+	Roles          []Role        `json:"roles,omitempty"`
+	TestResults    []TestResult  `json:"test_results,omitempty"`
+}
+
+type VerifyCertificateRequest struct {
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+type WebhookDelivery struct {
+	ID             int64                    `json:"id"`
+	OrganizationID int64                    `json:"organization_id"`
+	EndpointID     int64                    `json:"endpoint_id"`
+	EventID        string                   `json:"event_id"`
+	Event          WebhookEvent             `json:"event"`
+	Payload        string                   `json:"payload"`
+	Status         DeliveryStatus           `json:"status"`
+	Attempts       int64                    `json:"attempts"`
+	NextAttemptAt  time.Time                `json:"next_attempt_at"`
+	LastStatusCode int64                    `json:"last_status_code"`
+	LastError      string                   `json:"last_error"`
+	DeliveredAt    *time.Time               `json:"delivered_at,omitempty"`
+	ReplayOf       *int64                   `json:"replay_of,omitempty"`
+	CreatedAt      time.Time                `json:"created_at"`
+	UpdatedAt      time.Time                `json:"updated_at"`
+	Log            []WebhookDeliveryAttempt `json:"log,omitempty"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID          int64     `json:"id"`
+	DeliveryID  int64     `json:"delivery_id"`
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  int64     `json:"status_code"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+}
+
+type WebhookEndpoint struct {
+	ID             int64          `json:"id"`
+	OrganizationID int64          `json:"organization_id"`
+	URL            string         `json:"url"`
+	Description    string         `json:"description"`
+	Events         []WebhookEvent `json:"events"`
+	Active         bool           `json:"active"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+type WebhookEvent string
+
+const (
+	WebhookEventAttemptStarted   WebhookEvent = "attempt.started"
+	WebhookEventAttemptSubmitted WebhookEvent = "attempt.submitted"
+	WebhookEventResultScored     WebhookEvent = "result.scored"
+)
+
+type WebhookRequest struct {
+	URL         string         `json:"url"`
+	Description string         `json:"description,omitempty"`
+	Events      []WebhookEvent `json:"events"`
+	Active      *bool          `json:"active,omitempty"`
+}
+
+type WebhookWithSecret struct {
+	Webhook *WebhookEndpoint `json:"webhook,omitempty"`
+	Secret  string           `json:"secret"`
+}
+
+// GetOpenAPIDocument calls GET /api/v1/openapi.json.
+// The OpenAPI document for this API.
+// The caller must close the returned body.
+func (c *Client) GetOpenAPIDocument(ctx context.Context) (io.ReadCloser, error) {
+	return c.stream(ctx, http.MethodGet, "/api/v1/openapi.json", nil, nil)
+}
+
+// Register calls POST /api/v1/register.
+// Register a user.
+func (c *Client) Register(ctx context.Context, body RegisterRequest) (*AuthResponse, error) {
+	out := new(AuthResponse)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/register", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Login calls POST /api/v1/login.
+// Log in and receive a token.
+func (c *Client) Login(ctx context.Context, body LoginRequest) (*AuthResponse, error) {
+	out := new(AuthResponse)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/login", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Logout calls POST /api/v1/logout.
+// Clear the session cookie.
+func (c *Client) Logout(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/logout", nil, nil, nil)
+}
+
+// OpenInvitation calls GET /api/v1/invite/{token}.
+// Open an invitation link.
+func (c *Client) OpenInvitation(ctx context.Context, token string) (*Invitation, error) {
+	out := new(Invitation)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/invite/"+url.PathEscape(token), nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StartInvitation calls POST /api/v1/invite/{token}/start.
+// Start the invited test and receive a candidate token.
+func (c *Client) StartInvitation(ctx context.Context, token string) (*StartedInvitation, error) {
+	out := new(StartedInvitation)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/invite/"+url.PathEscape(token)+"/start", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetPublicCertificate calls GET /api/v1/certificates/public/{slug}.
+// Fetch a published certificate and its signature.
+func (c *Client) GetPublicCertificate(ctx context.Context, slug string) (*SignedCertificate, error) {
+	out := new(SignedCertificate)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/certificates/public/"+url.PathEscape(slug), nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VerifyCertificate calls POST /api/v1/certificates/verify.
+// Verify a certificate signature.
+func (c *Client) VerifyCertificate(ctx context.Context, body VerifyCertificateRequest) (*CertificateVerification, error) {
+	out := new(CertificateVerification)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/certificates/verify", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetCertificatePublicKey calls GET /api/v1/certificates/public-key.
+// The key certificates are signed with.
+func (c *Client) GetCertificatePublicKey(ctx context.Context) (*CertificatePublicKey, error) {
+	out := new(CertificatePublicKey)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/certificates/public-key", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListTests calls GET /api/v1/tests.
+// List tests.
+func (c *Client) ListTests(ctx context.Context) ([]Test, error) {
+	var out []Test
+	if err := c.do(ctx, http.MethodGet, "/api/v1/tests", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateTest calls POST /api/v1/tests.
+// Create a test.
+// It requires the tests:manage permission.
+func (c *Client) CreateTest(ctx context.Context, body CreateTestRequest) (*Test, error) {
+	out := new(Test)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/tests", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateTestPolicy calls PUT /api/v1/tests/{id}/policy.
+// Update a test's attempt policy.
+// It requires the tests:manage permission.
+func (c *Client) UpdateTestPolicy(ctx context.Context, id int64, body UpdatePolicyRequest) (*Test, error) {
+	out := new(Test)
+	if err := c.do(ctx, http.MethodPut, "/api/v1/tests/"+strconv.FormatInt(id, 10)+"/policy", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetEligibility calls GET /api/v1/tests/{id}/eligibility.
+// Whether the caller may start another attempt.
+func (c *Client) GetEligibility(ctx context.Context, id int64) (*AttemptEligibility, error) {
+	out := new(AttemptEligibility)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/tests/"+strconv.FormatInt(id, 10)+"/eligibility", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StartAttempt calls POST /api/v1/tests/{id}/start.
+// Start an attempt.
+// It requires the tests:take permission.
+func (c *Client) StartAttempt(ctx context.Context, id int64) (*StartedAttempt, error) {
+	out := new(StartedAttempt)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/tests/"+strconv.FormatInt(id, 10)+"/start", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StartPractice calls POST /api/v1/tests/{id}/practice.
+// Start a practice session.
+// It requires the tests:take permission.
+func (c *Client) StartPractice(ctx context.Context, id int64) (*StartedPractice, error) {
+	out := new(StartedPractice)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/tests/"+strconv.FormatInt(id, 10)+"/practice", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListPracticeSessions calls GET /api/v1/practice.
+// List the caller's practice sessions.
+func (c *Client) ListPracticeSessions(ctx context.Context) ([]PracticeSession, error) {
+	var out []PracticeSession
+	if err := c.do(ctx, http.MethodGet, "/api/v1/practice", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AnswerPractice calls POST /api/v1/practice/{id}/answers.
+// Answer a practice question and receive feedback.
+// It requires the tests:take permission.
+func (c *Client) AnswerPractice(ctx context.Context, id int64, body SubmitAnswerRequest) (*PracticeFeedback, error) {
+	out := new(PracticeFeedback)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/practice/"+strconv.FormatInt(id, 10)+"/answers", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FinishPractice calls POST /api/v1/practice/{id}/finish.
+// Finish a practice session.
+// It requires the tests:take permission.
+func (c *Client) FinishPractice(ctx context.Context, id int64) (*PracticeSession, error) {
+	out := new(PracticeSession)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/practice/"+strconv.FormatInt(id, 10)+"/finish", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetQuestions calls GET /api/v1/questions.
+// List a test's questions without answers.
+// It requires the tests:take permission.
+func (c *Client) GetQuestions(ctx context.Context, params *GetQuestionsParams) ([]Question, error) {
+	var out []Question
+	if err := c.do(ctx, http.MethodGet, "/api/v1/questions", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SubmitTest calls POST /api/v1/submit.
+// Submit an attempt for scoring.
+// It requires the tests:take permission.
+func (c *Client) SubmitTest(ctx context.Context, body SubmitTestRequest) (*TestResult, error) {
+	out := new(TestResult)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/submit", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReportActivity calls POST /api/v1/attempts/{id}/activity.
+// Report what the candidate does in an attempt in progress.
+// It requires the tests:take permission.
+func (c *Client) ReportActivity(ctx context.Context, id int64, body ActivityRequest) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/attempts/"+strconv.FormatInt(id, 10)+"/activity", nil, body, nil)
+}
+
+// StreamTestEvents calls GET /api/v1/tests/{id}/events.
+// Stream the events of every attempt at a test as Server-Sent Events.
+// It requires the sessions:proctor permission.
+// The caller must close the returned body.
+func (c *Client) StreamTestEvents(ctx context.Context, id int64) (io.ReadCloser, error) {
+	return c.stream(ctx, http.MethodGet, "/api/v1/tests/"+strconv.FormatInt(id, 10)+"/events", nil, nil)
+}
+
+// CreateSession calls POST /api/v1/sessions.
+// Schedule a test for a group of candidates.
+// It requires the sessions:proctor permission.
+func (c *Client) CreateSession(ctx context.Context, body SessionRequest) (*Session, error) {
+	out := new(Session)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListSessions calls GET /api/v1/sessions.
+// List the organization's sessions.
+// It requires the sessions:proctor permission.
+func (c *Client) ListSessions(ctx context.Context) ([]Session, error) {
+	var out []Session
+	if err := c.do(ctx, http.MethodGet, "/api/v1/sessions", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetSession calls GET /api/v1/sessions/{id}.
+// Fetch a session with its candidates and their attempts.
+// It requires the sessions:proctor permission.
+func (c *Client) GetSession(ctx context.Context, id int64) (*Session, error) {
+	out := new(Session)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/sessions/"+strconv.FormatInt(id, 10), nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StartSession calls POST /api/v1/sessions/{id}/start.
+// Start a session for every candidate.
+// It requires the sessions:proctor permission.
+func (c *Client) StartSession(ctx context.Context, id int64) (*Session, error) {
+	out := new(Session)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions/"+strconv.FormatInt(id, 10)+"/start", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PauseSession calls POST /api/v1/sessions/{id}/pause.
+// Pause a session's clock.
+// It requires the sessions:proctor permission.
+func (c *Client) PauseSession(ctx context.Context, id int64) (*Session, error) {
+	out := new(Session)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions/"+strconv.FormatInt(id, 10)+"/pause", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ResumeSession calls POST /api/v1/sessions/{id}/resume.
+// Resume a paused session.
+// It requires the sessions:proctor permission.
+func (c *Client) ResumeSession(ctx context.Context, id int64) (*Session, error) {
+	out := new(Session)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions/"+strconv.FormatInt(id, 10)+"/resume", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EndSession calls POST /api/v1/sessions/{id}/end.
+// End a session, terminating attempts in progress.
+// It requires the sessions:proctor permission.
+func (c *Client) EndSession(ctx context.Context, id int64) (*Session, error) {
+	out := new(Session)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions/"+strconv.FormatInt(id, 10)+"/end", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExtendSessionTime calls POST /api/v1/sessions/{id}/extend.
+// Give one candidate extra time.
+// It requires the sessions:proctor permission.
+func (c *Client) ExtendSessionTime(ctx context.Context, id int64, body ExtendTimeRequest) (*Session, error) {
+	out := new(Session)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions/"+strconv.FormatInt(id, 10)+"/extend", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TerminateSessionAttempt calls POST /api/v1/sessions/{id}/terminate.
+// Terminate one candidate's attempt.
+// It requires the sessions:proctor permission.
+func (c *Client) TerminateSessionAttempt(ctx context.Context, id int64, body TerminateAttemptRequest) (*Session, error) {
+	out := new(Session)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions/"+strconv.FormatInt(id, 10)+"/terminate", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// JoinSession calls POST /api/v1/sessions/{id}/join.
+// Enter a session's lobby.
+// It requires the tests:take permission.
+func (c *Client) JoinSession(ctx context.Context, id int64) (*SessionLobby, error) {
+	out := new(SessionLobby)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions/"+strconv.FormatInt(id, 10)+"/join", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetSessionLobby calls GET /api/v1/sessions/{id}/lobby.
+// The candidate's view of a session.
+// It requires the tests:take permission.
+func (c *Client) GetSessionLobby(ctx context.Context, id int64) (*SessionLobby, error) {
+	out := new(SessionLobby)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/sessions/"+strconv.FormatInt(id, 10)+"/lobby", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StartSessionAttempt calls POST /api/v1/sessions/{id}/attempt.
+// Start or resume the candidate's attempt in a started session.
+// It requires the tests:take permission.
+func (c *Client) StartSessionAttempt(ctx context.Context, id int64) (*StartedAttempt, error) {
+	out := new(StartedAttempt)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions/"+strconv.FormatInt(id, 10)+"/attempt", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListResults calls GET /api/v1/results.
+// List the caller's results.
+func (c *Client) ListResults(ctx context.Context) ([]TestResult, error) {
+	var out []TestResult
+	if err := c.do(ctx, http.MethodGet, "/api/v1/results", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetResult calls GET /api/v1/results/{id}.
+// Fetch a result.
+func (c *Client) GetResult(ctx context.Context, id int64) (*TestResult, error) {
+	out := new(TestResult)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/results/"+strconv.FormatInt(id, 10), nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetProgress calls GET /api/v1/progress.
+// The caller's score trends.
+func (c *Client) GetProgress(ctx context.Context, params *GetProgressParams) (*ProgressReport, error) {
+	out := new(ProgressReport)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/progress", params.values(), nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetReview calls GET /api/v1/results/{id}/review.
+// Review a result's answers as far as the reveal policy allows.
+func (c *Client) GetReview(ctx context.Context, id int64) (*ResultReview, error) {
+	out := new(ResultReview)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/results/"+strconv.FormatInt(id, 10)+"/review", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetReport calls GET /api/v1/results/{id}/report.pdf.
+// Download a result as a PDF report.
+// The caller must close the returned body.
+func (c *Client) GetReport(ctx context.Context, id int64) (io.ReadCloser, error) {
+	return c.stream(ctx, http.MethodGet, "/api/v1/results/"+strconv.FormatInt(id, 10)+"/report.pdf", nil, nil)
+}
+
+// PublishResult calls POST /api/v1/results/{id}/certificate.
+// Publish a signed certificate for a result.
+func (c *Client) PublishResult(ctx context.Context, id int64) (*PublishedCertificate, error) {
+	out := new(PublishedCertificate)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/results/"+strconv.FormatInt(id, 10)+"/certificate", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListCertificates calls GET /api/v1/certificates.
+// List the caller's certificates.
+func (c *Client) ListCertificates(ctx context.Context) ([]PublishedCertificate, error) {
+	var out []PublishedCertificate
+	if err := c.do(ctx, http.MethodGet, "/api/v1/certificates", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RevokeCertificate calls DELETE /api/v1/certificates/{id}.
+// Revoke a certificate.
+func (c *Client) RevokeCertificate(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/certificates/"+strconv.FormatInt(id, 10), nil, nil, nil)
+}
+
+// GetReportTemplate calls GET /api/v1/report-template.
+// Fetch the organization's report branding.
+// It requires the tests:manage permission.
+func (c *Client) GetReportTemplate(ctx context.Context) (*ReportTemplate, error) {
+	out := new(ReportTemplate)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/report-template", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateReportTemplate calls PUT /api/v1/report-template.
+// Save the organization's report branding.
+// It requires the tests:manage permission.
+func (c *Client) UpdateReportTemplate(ctx context.Context, body ReportTemplateRequest) (*ReportTemplate, error) {
+	out := new(ReportTemplate)
+	if err := c.do(ctx, http.MethodPut, "/api/v1/report-template", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetLeaderboardProfile calls GET /api/v1/leaderboards/profile.
+// The caller's leaderboard profile, if they opted in.
+func (c *Client) GetLeaderboardProfile(ctx context.Context) (*LeaderboardProfile, error) {
+	out := new(LeaderboardProfile)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/leaderboards/profile", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateLeaderboardProfile calls PUT /api/v1/leaderboards/profile.
+// Opt in to or out of leaderboards.
+func (c *Client) UpdateLeaderboardProfile(ctx context.Context, body LeaderboardProfileRequest) (*LeaderboardProfile, error) {
+	out := new(LeaderboardProfile)
+	if err := c.do(ctx, http.MethodPut, "/api/v1/leaderboards/profile", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetTestLeaderboard calls GET /api/v1/leaderboards/tests/{id}.
+// Rank users on a test.
+func (c *Client) GetTestLeaderboard(ctx context.Context, id int64, params *GetTestLeaderboardParams) (*Leaderboard, error) {
+	out := new(Leaderboard)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/leaderboards/tests/"+strconv.FormatInt(id, 10), params.values(), nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetCategoryLeaderboard calls GET /api/v1/leaderboards/categories/{category}.
+// Rank users on a category across tests.
+func (c *Client) GetCategoryLeaderboard(ctx context.Context, category string, params *GetCategoryLeaderboardParams) (*Leaderboard, error) {
+	out := new(Leaderboard)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/leaderboards/categories/"+url.PathEscape(category), params.values(), nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateQuestion calls POST /api/v1/questions.
+// Add a question to a test.
+// It requires the questions:manage permission.
+func (c *Client) CreateQuestion(ctx context.Context, body QuestionRequest) (*Question, error) {
+	out := new(Question)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/questions", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateQuestion calls PUT /api/v1/questions/{id}.
+// Update a question.
+// It requires the questions:manage permission.
+func (c *Client) UpdateQuestion(ctx context.Context, id int64, body QuestionRequest) (*Question, error) {
+	out := new(Question)
+	if err := c.do(ctx, http.MethodPut, "/api/v1/questions/"+strconv.FormatInt(id, 10), nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteQuestion calls DELETE /api/v1/questions/{id}.
+// Delete a question.
+// It requires the questions:manage permission.
+func (c *Client) DeleteQuestion(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/questions/"+strconv.FormatInt(id, 10), nil, nil, nil)
+}
+
+// ListUserResults calls GET /api/v1/users/{id}/results.
+// List a user's results.
+// It requires the results:read_all permission.
+func (c *Client) ListUserResults(ctx context.Context, id int64) ([]TestResult, error) {
+	var out []TestResult
+	if err := c.do(ctx, http.MethodGet, "/api/v1/users/"+strconv.FormatInt(id, 10)+"/results", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetUserProgress calls GET /api/v1/users/{id}/progress.
+// A user's score trends.
+// It requires the results:read_all permission.
+func (c *Client) GetUserProgress(ctx context.Context, id int64, params *GetUserProgressParams) (*ProgressReport, error) {
+	out := new(ProgressReport)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/users/"+strconv.FormatInt(id, 10)+"/progress", params.values(), nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetUserRoles calls PUT /api/v1/users/{id}/roles.
+// Replace a user's roles.
+// It requires the roles:assign permission.
+func (c *Client) SetUserRoles(ctx context.Context, id int64, body SetUserRolesRequest) (*User, error) {
+	out := new(User)
+	if err := c.do(ctx, http.MethodPut, "/api/v1/users/"+strconv.FormatInt(id, 10)+"/roles", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListRoles calls GET /api/v1/roles.
+// List roles.
+// It requires the roles:manage permission.
+func (c *Client) ListRoles(ctx context.Context) ([]Role, error) {
+	var out []Role
+	if err := c.do(ctx, http.MethodGet, "/api/v1/roles", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateRole calls POST /api/v1/roles.
+// Create a role.
+// It requires the roles:manage permission.
+func (c *Client) CreateRole(ctx context.Context, body CreateRoleRequest) (*Role, error) {
+	out := new(Role)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/roles", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateRole calls PUT /api/v1/roles/{id}.
+// Update a role.
+// It requires the roles:manage permission.
+func (c *Client) UpdateRole(ctx context.Context, id int64, body UpdateRoleRequest) (*Role, error) {
+	out := new(Role)
+	if err := c.do(ctx, http.MethodPut, "/api/v1/roles/"+strconv.FormatInt(id, 10), nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListPermissions calls GET /api/v1/permissions.
+// List permissions.
+// It requires the roles:manage permission.
+func (c *Client) ListPermissions(ctx context.Context) ([]Permission, error) {
+	var out []Permission
+	if err := c.do(ctx, http.MethodGet, "/api/v1/permissions", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListInvitations calls GET /api/v1/invitations.
+// List invitations.
+// It requires the invitations:manage permission.
+func (c *Client) ListInvitations(ctx context.Context, params *ListInvitationsParams) ([]Invitation, error) {
+	var out []Invitation
+	if err := c.do(ctx, http.MethodGet, "/api/v1/invitations", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateInvitations calls POST /api/v1/invitations.
+// Invite candidates to a test.
+// It requires the invitations:manage permission.
+func (c *Client) CreateInvitations(ctx context.Context, body CreateInvitationsRequest) ([]InvitationLink, error) {
+	var out []InvitationLink
+	if err := c.do(ctx, http.MethodPost, "/api/v1/invitations", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BulkCreateInvitations calls POST /api/v1/invitations/bulk.
+// Invite the candidates in an uploaded CSV.
+// It requires the invitations:manage permission.
+func (c *Client) BulkCreateInvitations(ctx context.Context, body BulkInvitationsRequest, file io.Reader, filename string) ([]InvitationLink, error) {
+	var out []InvitationLink
+	if err := c.doMultipart(ctx, http.MethodPost, "/api/v1/invitations/bulk", nil, body, file, filename, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetInvitation calls GET /api/v1/invitations/{id}.
+// Fetch an invitation.
+// It requires the invitations:manage permission.
+func (c *Client) GetInvitation(ctx context.Context, id int64) (*Invitation, error) {
+	out := new(Invitation)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/invitations/"+strconv.FormatInt(id, 10), nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListAccommodations calls GET /api/v1/accommodations.
+// List accommodations, revoked ones included.
+// It requires the accommodations:manage permission.
+func (c *Client) ListAccommodations(ctx context.Context, params *ListAccommodationsParams) ([]Accommodation, error) {
+	var out []Accommodation
+	if err := c.do(ctx, http.MethodGet, "/api/v1/accommodations", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GrantAccommodation calls POST /api/v1/accommodations.
+// Grant a user or an invitation a time multiplier.
+// It requires the accommodations:manage permission.
+func (c *Client) GrantAccommodation(ctx context.Context, body AccommodationRequest) (*Accommodation, error) {
+	out := new(Accommodation)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/accommodations", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RevokeAccommodation calls DELETE /api/v1/accommodations/{id}.
+// Revoke an accommodation.
+// It requires the accommodations:manage permission.
+func (c *Client) RevokeAccommodation(ctx context.Context, id int64) (*Accommodation, error) {
+	out := new(Accommodation)
+	if err := c.do(ctx, http.MethodDelete, "/api/v1/accommodations/"+strconv.FormatInt(id, 10), nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExportResults calls GET /api/v1/admin/export.
+// Stream answer-level results as CSV or JSON lines.
+// It requires the results:export permission.
+// The caller must close the returned body.
+func (c *Client) ExportResults(ctx context.Context, params *ExportResultsParams) (io.ReadCloser, error) {
+	return c.stream(ctx, http.MethodGet, "/api/v1/admin/export", params.values(), nil)
+}
+
+// GetItemAnalysis calls GET /api/v1/admin/item-analysis.
+// Item statistics for the question bank.
+// It requires the questions:manage permission.
+func (c *Client) GetItemAnalysis(ctx context.Context, params *GetItemAnalysisParams) (*ItemAnalysisReport, error) {
+	out := new(ItemAnalysisReport)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/item-analysis", params.values(), nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListReliabilityReports calls GET /api/v1/admin/tests/{id}/reliability.
+// List a test's reliability reports.
+// It requires the tests:manage permission.
+func (c *Client) ListReliabilityReports(ctx context.Context, id int64) ([]ReliabilityReport, error) {
+	var out []ReliabilityReport
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/tests/"+strconv.FormatInt(id, 10)+"/reliability", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ComputeReliability calls POST /api/v1/admin/tests/{id}/reliability.
+// Compute a reliability report, unless no results arrived since the latest.
+// It requires the tests:manage permission.
+func (c *Client) ComputeReliability(ctx context.Context, id int64) (*ReliabilityReport, error) {
+	out := new(ReliabilityReport)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/admin/tests/"+strconv.FormatInt(id, 10)+"/reliability", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetOrganizationDashboard calls GET /api/v1/dashboards/organization.
+// Organization-wide aggregates.
+// It requires the results:read_all permission.
+func (c *Client) GetOrganizationDashboard(ctx context.Context, params *GetOrganizationDashboardParams) (*OrganizationDashboard, error) {
+	out := new(OrganizationDashboard)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/dashboards/organization", params.values(), nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetTestDashboard calls GET /api/v1/dashboards/tests/{id}.
+// Aggregates for one test.
+// It requires the results:read_all permission.
+func (c *Client) GetTestDashboard(ctx context.Context, id int64, params *GetTestDashboardParams) (*TestDashboard, error) {
+	out := new(TestDashboard)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/dashboards/tests/"+strconv.FormatInt(id, 10), params.values(), nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// QueryGraphQL calls POST /api/v1/graphql.
+// Run a GraphQL query; the response is a GraphQL response, not the envelope.
+// The caller must close the returned body.
+func (c *Client) QueryGraphQL(ctx context.Context, body GraphQLRequest) (io.ReadCloser, error) {
+	return c.stream(ctx, http.MethodPost, "/api/v1/graphql", nil, body)
+}
+
+// GetGraphQLSchema calls GET /api/v1/graphql/schema.
+// The GraphQL schema in the schema definition language.
+// The caller must close the returned body.
+func (c *Client) GetGraphQLSchema(ctx context.Context) (io.ReadCloser, error) {
+	return c.stream(ctx, http.MethodGet, "/api/v1/graphql/schema", nil, nil)
+}
+
+// ListWebhooks calls GET /api/v1/admin/webhooks.
+// List webhook endpoints.
+// It requires the webhooks:manage permission.
+func (c *Client) ListWebhooks(ctx context.Context) ([]WebhookEndpoint, error) {
+	var out []WebhookEndpoint
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/webhooks", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateWebhook calls POST /api/v1/admin/webhooks.
+// Add a webhook endpoint; the signing secret is only returned here.
+// It requires the webhooks:manage permission.
+func (c *Client) CreateWebhook(ctx context.Context, body WebhookRequest) (*WebhookWithSecret, error) {
+	out := new(WebhookWithSecret)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/admin/webhooks", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateWebhook calls PUT /api/v1/admin/webhooks/{id}.
+// Update a webhook endpoint.
+// It requires the webhooks:manage permission.
+func (c *Client) UpdateWebhook(ctx context.Context, id int64, body WebhookRequest) (*WebhookEndpoint, error) {
+	out := new(WebhookEndpoint)
+	if err := c.do(ctx, http.MethodPut, "/api/v1/admin/webhooks/"+strconv.FormatInt(id, 10), nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteWebhook calls DELETE /api/v1/admin/webhooks/{id}.
+// Delete a webhook endpoint.
+// It requires the webhooks:manage permission.
+func (c *Client) DeleteWebhook(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/admin/webhooks/"+strconv.FormatInt(id, 10), nil, nil, nil)
+}
+
+// RotateWebhookSecret calls POST /api/v1/admin/webhooks/{id}/rotate-secret.
+// Replace a webhook endpoint's signing secret.
+// It requires the webhooks:manage permission.
+func (c *Client) RotateWebhookSecret(ctx context.Context, id int64) (*WebhookWithSecret, error) {
+	out := new(WebhookWithSecret)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/admin/webhooks/"+strconv.FormatInt(id, 10)+"/rotate-secret", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListWebhookDeliveries calls GET /api/v1/admin/webhooks/{id}/deliveries.
+// List an endpoint's deliveries, newest first.
+// It requires the webhooks:manage permission.
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id int64, params *ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	var out []WebhookDelivery
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/webhooks/"+strconv.FormatInt(id, 10)+"/deliveries", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetWebhookDelivery calls GET /api/v1/admin/webhook-deliveries/{id}.
+// Fetch a delivery with its attempt log.
+// It requires the webhooks:manage permission.
+func (c *Client) GetWebhookDelivery(ctx context.Context, id int64) (*WebhookDelivery, error) {
+	out := new(WebhookDelivery)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/webhook-deliveries/"+strconv.FormatInt(id, 10), nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReplayWebhookDelivery calls POST /api/v1/admin/webhook-deliveries/{id}/replay.
+// Queue a delivery to be sent again.
+// It requires the webhooks:manage permission.
+func (c *Client) ReplayWebhookDelivery(ctx context.Context, id int64) (*WebhookDelivery, error) {
+	out := new(WebhookDelivery)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/admin/webhook-deliveries/"+strconv.FormatInt(id, 10)+"/replay", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListAPIKeys calls GET /api/v1/admin/api-keys.
+// List API keys.
+// It requires the api_keys:manage permission.
+func (c *Client) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var out []APIKey
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/api-keys", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateAPIKey calls POST /api/v1/admin/api-keys.
+// Issue an API key; the key is only returned here.
+// It requires the api_keys:manage permission.
+func (c *Client) CreateAPIKey(ctx context.Context, body APIKeyRequest) (*IssuedAPIKey, error) {
+	out := new(IssuedAPIKey)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/admin/api-keys", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RotateAPIKey calls POST /api/v1/admin/api-keys/{id}/rotate.
+// Replace an API key.
+// It requires the api_keys:manage permission.
+func (c *Client) RotateAPIKey(ctx context.Context, id int64, params *RotateAPIKeyParams) (*IssuedAPIKey, error) {
+	out := new(IssuedAPIKey)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/admin/api-keys/"+strconv.FormatInt(id, 10)+"/rotate", params.values(), nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RevokeAPIKey calls DELETE /api/v1/admin/api-keys/{id}.
+// Revoke an API key.
+// It requires the api_keys:manage permission.
+func (c *Client) RevokeAPIKey(ctx context.Context, id int64) (*APIKey, error) {
+	out := new(APIKey)
+	if err := c.do(ctx, http.MethodDelete, "/api/v1/admin/api-keys/"+strconv.FormatInt(id, 10), nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetLTIToolConfiguration calls GET /api/v1/admin/lti/tool.
+// Fetch the URLs to register the tool with on a learning platform.
+// It requires the lti:manage permission.
+func (c *Client) GetLTIToolConfiguration(ctx context.Context) (*LTIToolConfiguration, error) {
+	out := new(LTIToolConfiguration)
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/lti/tool", nil, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListLTIPlatforms calls GET /api/v1/admin/lti/platforms.
+// List registered learning platforms.
+// It requires the lti:manage permission.
+func (c *Client) ListLTIPlatforms(ctx context.Context) ([]LTIPlatform, error) {
+	var out []LTIPlatform
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/lti/platforms", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateLTIPlatform calls POST /api/v1/admin/lti/platforms.
+// Register a learning platform.
+// It requires the lti:manage permission.
+func (c *Client) CreateLTIPlatform(ctx context.Context, body LTIPlatformRequest) (*LTIPlatform, error) {
+	out := new(LTIPlatform)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/admin/lti/platforms", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateLTIPlatform calls PUT /api/v1/admin/lti/platforms/{id}.
+// Update a learning platform.
+// It requires the lti:manage permission.
+func (c *Client) UpdateLTIPlatform(ctx context.Context, id int64, body LTIPlatformRequest) (*LTIPlatform, error) {
+	out := new(LTIPlatform)
+	if err := c.do(ctx, http.MethodPut, "/api/v1/admin/lti/platforms/"+strconv.FormatInt(id, 10), nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteLTIPlatform calls DELETE /api/v1/admin/lti/platforms/{id}.
+// Remove a learning platform.
+// It requires the lti:manage permission.
+func (c *Client) DeleteLTIPlatform(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/admin/lti/platforms/"+strconv.FormatInt(id, 10), nil, nil, nil)
+}
+
+// ListOrganizations calls GET /api/v1/organizations.
+// List organizations.
+// It requires the organizations:manage permission.
+func (c *Client) ListOrganizations(ctx context.Context) ([]Organization, error) {
+	var out []Organization
+	if err := c.do(ctx, http.MethodGet, "/api/v1/organizations", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateOrganization calls POST /api/v1/organizations.
+// Create an organization.
+// It requires the organizations:manage permission.
+func (c *Client) CreateOrganization(ctx context.Context, body CreateOrganizationRequest) (*Organization, error) {
+	out := new(Organization)
+	if err := c.do(ctx, http.MethodPost, "/api/v1/organizations", nil, body, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type GetQuestionsParams struct {
+	// Defaults to the invited test, or test 1
+	TestID int64
+}
+
+func (p *GetQuestionsParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	values := url.Values{}
+	if p.TestID != 0 {
+		values.Set("test_id", strconv.FormatInt(p.TestID, 10))
+	}
+	return values
+}
+
+type GetProgressParams struct {
+	TestID int64
+}
+
+func (p *GetProgressParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	values := url.Values{}
+	if p.TestID != 0 {
+		values.Set("test_id", strconv.FormatInt(p.TestID, 10))
+	}
+	return values
+}
+
+type GetTestLeaderboardParams struct {
+	// Rank on one category instead of the overall score
+	Category string
+	Period   string
+	// Day within the period, as a date (YYYY-MM-DD) or RFC 3339 timestamp
+	Date string
+	// Number of rows, 1 to 100
+	Limit int64
+}
+
+func (p *GetTestLeaderboardParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	values := url.Values{}
+	if p.Category != "" {
+		values.Set("category", p.Category)
+	}
+	if p.Period != "" {
+		values.Set("period", p.Period)
+	}
+	if p.Date != "" {
+		values.Set("date", p.Date)
+	}
+	if p.Limit != 0 {
+		values.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	return values
+}
+
+type GetCategoryLeaderboardParams struct {
+	Scope  string
+	Period string
+	// Day within the period, as a date (YYYY-MM-DD) or RFC 3339 timestamp
+	Date string
+	// Number of rows, 1 to 100
+	Limit int64
+}
+
+func (p *GetCategoryLeaderboardParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	values := url.Values{}
+	if p.Scope != "" {
+		values.Set("scope", p.Scope)
+	}
+	if p.Period != "" {
+		values.Set("period", p.Period)
+	}
+	if p.Date != "" {
+		values.Set("date", p.Date)
+	}
+	if p.Limit != 0 {
+		values.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	return values
+}
+
+type GetUserProgressParams struct {
+	TestID int64
+}
+
+func (p *GetUserProgressParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	values := url.Values{}
+	if p.TestID != 0 {
+		values.Set("test_id", strconv.FormatInt(p.TestID, 10))
+	}
+	return values
+}
+
+type ListInvitationsParams struct {
+	TestID int64
+}
+
+func (p *ListInvitationsParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	values := url.Values{}
+	if p.TestID != 0 {
+		values.Set("test_id", strconv.FormatInt(p.TestID, 10))
+	}
+	return values
+}
+
+type ListAccommodationsParams struct {
+	UserID       int64
+	InvitationID int64
+}
+
+func (p *ListAccommodationsParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	values := url.Values{}
+	if p.UserID != 0 {
+		values.Set("user_id", strconv.FormatInt(p.UserID, 10))
+	}
+	if p.InvitationID != 0 {
+		values.Set("invitation_id", strconv.FormatInt(p.InvitationID, 10))
+	}
+	return values
+}
+
+type ExportResultsParams struct {
+	Format string
+	// Organization to report on; platform admins only
+	OrganizationID int64
+	TestID         int64
+	// Earliest completion, as a date (YYYY-MM-DD) or RFC 3339 timestamp
+	From string
+	// Latest completion, as a date (YYYY-MM-DD) or RFC 3339 timestamp
+	To string
+}
+
+func (p *ExportResultsParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	values := url.Values{}
+	if p.Format != "" {
+		values.Set("format", p.Format)
+	}
+	if p.OrganizationID != 0 {
+		values.Set("organization_id", strconv.FormatInt(p.OrganizationID, 10))
+	}
+	if p.TestID != 0 {
+		values.Set("test_id", strconv.FormatInt(p.TestID, 10))
+	}
+	if p.From != "" {
+		values.Set("from", p.From)
+	}
+	if p.To != "" {
+		values.Set("to", p.To)
+	}
+	return values
+}
+
+type GetItemAnalysisParams struct {
+	// Responses below which items are not flagged
+	MinResponses int64
+	// Organization to report on; platform admins only
+	OrganizationID int64
+	TestID         int64
+	// Earliest completion, as a date (YYYY-MM-DD) or RFC 3339 timestamp
+	From string
+	// Latest completion, as a date (YYYY-MM-DD) or RFC 3339 timestamp
+	To string
+}
+
+func (p *GetItemAnalysisParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	values := url.Values{}
+	if p.MinResponses != 0 {
+		values.Set("min_responses", strconv.FormatInt(p.MinResponses, 10))
+	}
+	if p.OrganizationID != 0 {
+		values.Set("organization_id", strconv.FormatInt(p.OrganizationID, 10))
+	}
+	if p.TestID != 0 {
+		values.Set("test_id", strconv.FormatInt(p.TestID, 10))
+	}
+	if p.From != "" {
+		values.Set("from", p.From)
+	}
+	if p.To != "" {
+		values.Set("to", p.To)
+	}
+	return values
+}
+
+type GetOrganizationDashboardParams struct {
+	// Organization to report on; platform admins only
+	OrganizationID int64
+	TestID         int64
+	// Earliest completion, as a date (YYYY-MM-DD) or RFC 3339 timestamp
+	From string
+	// Latest completion, as a date (YYYY-MM-DD) or RFC 3339 timestamp
+	To string
+}
+
+func (p *GetOrganizationDashboardParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	values := url.Values{}
+	if p.OrganizationID != 0 {
+		values.Set("organization_id", strconv.FormatInt(p.OrganizationID, 10))
+	}
+	if p.TestID != 0 {
+		values.Set("test_id", strconv.FormatInt(p.TestID, 10))
+	}
+	if p.From != "" {
+		values.Set("from", p.From)
+	}
+	if p.To != "" {
+		values.Set("to", p.To)
+	}
+	return values
+}
+
+type GetTestDashboardParams struct {
+	// Histogram bins, 1 to 50
+	Bins int64
+	// Organization to report on; platform admins only
+	OrganizationID int64
+	TestID         int64
+	// Earliest completion, as a date (YYYY-MM-DD) or RFC 3339 timestamp
+	From string
+	// Latest completion, as a date (YYYY-MM-DD) or RFC 3339 timestamp
+	To string
+}
+
+func (p *GetTestDashboardParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	values := url.Values{}
+	if p.Bins != 0 {
+		values.Set("bins", strconv.FormatInt(p.Bins, 10))
+	}
+	if p.OrganizationID != 0 {
+		values.Set("organization_id", strconv.FormatInt(p.OrganizationID, 10))
+	}
+	if p.TestID != 0 {
+		values.Set("test_id", strconv.FormatInt(p.TestID, 10))
+	}
+	if p.From != "" {
+		values.Set("from", p.From)
+	}
+	if p.To != "" {
+		values.Set("to", p.To)
+	}
+	return values
+}
+
+type ListWebhookDeliveriesParams struct {
+	Status string
+	// Number of deliveries, 1 to 200
+	Limit int64
+}
+
+func (p *ListWebhookDeliveriesParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	values := url.Values{}
+	if p.Status != "" {
+		values.Set("status", p.Status)
+	}
+	if p.Limit != 0 {
+		values.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	return values
+}
+
+type RotateAPIKeyParams struct {
+	// How long the old key keeps working, e.g. 24h; it is revoked at once when omitted
+	Grace string
+}
+
+func (p *RotateAPIKeyParams) values() url.Values {
+	if p == nil {
+		return nil
+	}
+	values := url.Values{}
+	if p.Grace != "" {
+		values.Set("grace", p.Grace)
+	}
+	return values
+}
@@ -0,0 +1,215 @@
+// Package client is a Go client for the IQ test API. The types and methods in
+// client.gen.go are generated from the API's OpenAPI route table by cmd/openapi;
+// this file holds the transport they share.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Client calls the API at BaseURL. Set Token to a JWT from Login or an API key;
+// keys can also be sent in the X-API-Key header by setting APIKey instead.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	Token  string
+	APIKey string
+
+	// Organization is the slug sent in the X-Organization header, when the base
+	// URL's subdomain does not already select the organization.
+	Organization string
+}
+
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// Error is returned for every response that is not a success. Branch on Code,
+// which is stable, rather than on Message.
+type Error struct {
+	StatusCode int
+	Code       ErrorCode
+	Message    string
+	Details    []FieldError
+	RequestID  string
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("api: %d %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("api: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// envelope mirrors Response with the data left undecoded.
+type envelope struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	Error   *APIError       `json:"error"`
+}
+
+// do sends a JSON request and decodes the data of the response into out.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}, out interface{}) error {
+	var reader io.Reader
+	contentType := ""
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+		contentType = "application/json"
+	}
+	return c.send(ctx, method, path, query, reader, contentType, out)
+}
+
+// doMultipart uploads a file named "file" alongside the fields of body.
+func (c *Client) doMultipart(ctx context.Context, method, path string, query url.Values, body interface{}, file io.Reader, filename string, out interface{}) error {
+	fields, err := formValues(body)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for name, values := range fields {
+		for _, value := range values {
+			if err := writer.WriteField(name, value); err != nil {
+				return err
+			}
+		}
+	}
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return c.send(ctx, method, path, query, &buf, writer.FormDataContentType(), out)
+}
+
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string, out interface{}) error {
+	resp, err := c.request(ctx, method, path, query, body, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return &Error{StatusCode: resp.StatusCode, Message: "invalid response: " + err.Error()}
+	}
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(env.Data, out)
+}
+
+// stream returns the body of a route that does not answer with the JSON envelope.
+func (c *Client) stream(ctx context.Context, method, path string, query url.Values, body interface{}) (io.ReadCloser, error) {
+	var reader io.Reader
+	contentType := ""
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+		contentType = "application/json"
+	}
+	resp, err := c.request(ctx, method, path, query, reader, contentType)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// request sends a request and turns error responses into *Error.
+func (c *Client) request(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+	if c.Organization != "" {
+		req.Header.Set("X-Organization", c.Organization)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	apiErr := &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err == nil && env.Error != nil {
+		apiErr.Code = env.Error.Code
+		apiErr.Message = env.Error.Message
+		apiErr.Details = env.Error.Details
+		apiErr.RequestID = env.Error.RequestID
+	}
+	return nil, apiErr
+}
+
+// formValues flattens a request struct into form fields using its JSON encoding.
+func formValues(body interface{}) (url.Values, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	for name, value := range fields {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			values.Set(name, v)
+		case float64:
+			values.Set(name, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			values.Set(name, strconv.FormatBool(v))
+		default:
+			return nil, fmt.Errorf("form field %s cannot be encoded", name)
+		}
+	}
+	return values, nil
+}
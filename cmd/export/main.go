@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"iq-go/internal/config"
+	"iq-go/internal/database"
+	"iq-go/internal/export"
+	"iq-go/internal/services"
+)
+
+func main() {
+	format := flag.String("format", "csv", "output format: csv or jsonl")
+	orgID := flag.Uint("org", 0, "organization ID (0 exports all organizations)")
+	testID := flag.Uint("test", 0, "test ID (0 exports all tests)")
+	from := flag.String("from", "", "only results completed on or after this date (YYYY-MM-DD)")
+	to := flag.String("to", "", "only results completed on or before this date (YYYY-MM-DD)")
+	out := flag.String("out", "", "output file (defaults to stdout)")
+	flag.Parse()
+
+	cfg := config.Load()
+	db, err := database.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	filter := services.ResultFilter{
+		OrganizationID: *orgID,
+		TestID:         *testID,
+		From:           parseDate(*from),
+		To:             parseEndDate(*to),
+	}
+
+	file := os.Stdout
+	if *out != "" {
+		file, err = os.Create(*out)
+		if err != nil {
+			log.Fatal("Failed to create output file:", err)
+		}
+		defer file.Close()
+	}
+	buffered := bufio.NewWriter(file)
+
+	writer, err := export.NewWriter(*format, buffered)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rows := 0
+	err = services.NewExportService(db).StreamAnswers(filter, func(row *services.ExportRow) error {
+		rows++
+		return writer.Write(row)
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		log.Fatal("Export failed:", err)
+	}
+
+	log.Printf("Exported %d rows", rows)
+}
+
+func parseDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		log.Fatalf("Invalid date %q: %v", value, err)
+	}
+	return &t
+}
+
+// parseEndDate parses an inclusive end date as the following midnight.
+func parseEndDate(value string) *time.Time {
+	t := parseDate(value)
+	if t != nil {
+		*t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
@@ -0,0 +1,50 @@
+// Command grantrole gives an existing account a role, such as the first platform
+// admin of a new deployment. The account registers as a candidate first.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"iq-go/internal/config"
+	"iq-go/internal/database"
+	"iq-go/internal/models"
+	"iq-go/internal/services"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the account to grant the role to")
+	org := flag.String("org", "", "organization slug (defaults to DEFAULT_ORGANIZATION)")
+	role := flag.String("role", models.RoleAdmin, "role to grant")
+	flag.Parse()
+
+	if *email == "" {
+		log.Fatal("-email is required")
+	}
+
+	cfg := config.Load()
+	if *org == "" {
+		*org = cfg.DefaultOrganization
+	}
+	db, err := database.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := database.RunMigrations(db); err != nil {
+		log.Fatal("Failed to run migrations:", err)
+	}
+	if err := database.SeedRoles(db); err != nil {
+		log.Fatal("Failed to seed roles:", err)
+	}
+
+	organization, err := services.NewOrganizationService(db).GetOrganizationBySlug(*org)
+	if err != nil {
+		log.Fatalf("Organization %q not found: %v", *org, err)
+	}
+	user, err := services.NewUserService(db).AddUserRole(organization.ID, *email, *role)
+	if err != nil {
+		log.Fatal("Failed to grant role:", err)
+	}
+
+	log.Printf("Granted %s to user %d (%s) in %s", *role, user.ID, user.Email, organization.Slug)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"iq-go/internal/config"
+	"iq-go/internal/database"
+	"iq-go/internal/services"
+)
+
+func main() {
+	format := flag.String("format", "table", "output format: table or json")
+	orgID := flag.Uint("org", 0, "organization ID (0 analyses all organizations)")
+	testID := flag.Uint("test", 0, "test ID (0 analyses all tests)")
+	from := flag.String("from", "", "only results completed on or after this date (YYYY-MM-DD)")
+	to := flag.String("to", "", "only results completed on or before this date (YYYY-MM-DD)")
+	minResponses := flag.Int("min-responses", services.DefaultMinItemResponses, "responses needed before an item is flagged")
+	flagged := flag.Bool("flagged", false, "only list items with flags")
+	flag.Parse()
+
+	cfg := config.Load()
+	db, err := database.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	filter := services.ResultFilter{
+		OrganizationID: *orgID,
+		TestID:         *testID,
+		From:           parseDate(*from),
+		To:             parseEndDate(*to),
+	}
+
+	report, err := services.NewAnalyticsService(db).ItemAnalysis(filter, *minResponses)
+	if err != nil {
+		log.Fatal("Item analysis failed:", err)
+	}
+
+	if *flagged {
+		items := report.Items[:0]
+		for _, item := range report.Items {
+			if len(item.Flags) > 0 {
+				items = append(items, item)
+			}
+		}
+		report.Items = items
+	}
+
+	switch *format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatal(err)
+		}
+	case "table":
+		writeTable(report)
+	default:
+		log.Fatalf("Unknown format %q", *format)
+	}
+}
+
+func writeTable(report *services.ItemAnalysisReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "QUESTION\tTEST\t#\tCATEGORY\tN\tP\tR_PB\tOMIT\tRT_MS\tFLAGS\tTEXT")
+	for _, item := range report.Items {
+		discrimination := "-"
+		if item.Discrimination != nil {
+			discrimination = fmt.Sprintf("%.2f", *item.Discrimination)
+		}
+		fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%d\t%.2f\t%s\t%.2f\t%.0f\t%s\t%s\n",
+			item.QuestionID, item.TestID, item.OrderIndex, item.Category, item.Responses,
+			item.PValue, discrimination, item.OmissionRate, item.MeanResponseTimeMs,
+			strings.Join(item.Flags, ","), truncate(item.QuestionText, 50))
+	}
+	w.Flush()
+	log.Printf("Analysed %d questions over %d attempts", len(report.Items), report.Attempts)
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+func parseDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		log.Fatalf("Invalid date %q: %v", value, err)
+	}
+	return &t
+}
+
+// parseEndDate parses an inclusive end date as the following midnight.
+func parseEndDate(value string) *time.Time {
+	t := parseDate(value)
+	if t != nil {
+		*t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
@@ -0,0 +1,34 @@
+// Command leaderboards rebuilds leaderboard entries from completed results. Entries
+// are kept current as results are scored, so this is only needed to backfill
+// results scored before leaderboards existed, or after results are deleted.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"iq-go/internal/config"
+	"iq-go/internal/database"
+	"iq-go/internal/services"
+)
+
+func main() {
+	orgID := flag.Uint("org", 0, "organization ID (0 processes all organizations)")
+	flag.Parse()
+
+	cfg := config.Load()
+	db, err := database.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := database.RunMigrations(db); err != nil {
+		log.Fatal("Failed to run migrations:", err)
+	}
+
+	processed, err := services.NewLeaderboardService(db).RebuildEntries(*orgID)
+	if err != nil {
+		log.Fatal("Leaderboard rebuild failed:", err)
+	}
+
+	log.Printf("Rebuilt leaderboard entries from %d results", processed)
+}
@@ -0,0 +1,281 @@
+// Command ltimock is a minimal LTI 1.3 platform for trying the tool locally. It
+// launches the tool as a learner or an instructor, runs deep linking to pick the
+// test, and prints the scores the tool posts back. Register it with the printed
+// JSON and open the mock in a browser:
+//
+//	go run ./cmd/ltimock -tool http://localhost:8080
+//
+// It keeps everything in memory and is not meant for anything but development.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"iq-go/internal/lti"
+	"iq-go/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type user struct {
+	Subject, Email, GivenName, FamilyName string
+	Roles                                 []string
+}
+
+var users = map[string]user{
+	"learner": {"learner-1", "learner@example.com", "Lee", "Learner",
+		[]string{"http://purl.imsglobal.org/vocab/lis/v2/membership#Learner"}},
+	"instructor": {"instructor-1", "instructor@example.com", "Ina", "Instructor",
+		[]string{"http://purl.imsglobal.org/vocab/lis/v2/membership#Instructor"}},
+}
+
+type platform struct {
+	url, tool, clientID, deployment string
+	key                             *rsa.PrivateKey
+	client                          *http.Client
+
+	mu     sync.Mutex
+	testID string
+	events []string
+}
+
+func main() {
+	addr := flag.String("addr", ":9090", "address to listen on")
+	platformURL := flag.String("url", "http://localhost:9090", "URL the mock is reached at")
+	tool := flag.String("tool", "http://localhost:8080", "base URL of the tool (APP_URL)")
+	clientID := flag.String("client-id", "iq-go", "client ID the tool is registered with")
+	deployment := flag.String("deployment", "1", "deployment ID")
+	flag.Parse()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatal("Failed to generate key:", err)
+	}
+	p := &platform{
+		url:        strings.TrimSuffix(*platformURL, "/"),
+		tool:       strings.TrimSuffix(*tool, "/"),
+		clientID:   *clientID,
+		deployment: *deployment,
+		key:        key,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+
+	registration, _ := json.MarshalIndent(map[string]interface{}{
+		"name":             "LTI mock",
+		"issuer":           p.url,
+		"client_id":        p.clientID,
+		"deployment_ids":   []string{p.deployment},
+		"auth_login_url":   p.url + "/auth",
+		"access_token_url": p.url + "/token",
+		"jwks_url":         p.url + "/jwks",
+		"staff_roles":      map[string][]string{"Instructor": {models.RoleContentAuthor}},
+	}, "", "  ")
+	fmt.Printf("Register the mock with POST /api/v1/admin/lti/platforms:\n%s\n\n", registration)
+
+	http.HandleFunc("/", p.index)
+	http.HandleFunc("/start", p.start)
+	http.HandleFunc("/auth", p.auth)
+	http.HandleFunc("/jwks", p.jwks)
+	http.HandleFunc("/token", p.token)
+	http.HandleFunc("/lineitems/1/scores", p.scores)
+	http.HandleFunc("/deep-link-return", p.deepLinkReturn)
+
+	log.Printf("LTI mock listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+var indexPage = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html><head><title>LTI mock</title></head>
+<body>
+<h1>LTI mock platform</h1>
+<p>Linked test: {{if .TestID}}{{.TestID}}{{else}}none, add one with deep linking first{{end}}</p>
+<ul>
+<li><a href="/start?as=instructor&amp;message=deep-link">Add a test (deep linking, as instructor)</a></li>
+<li><a href="/start?as=learner">Launch as learner</a></li>
+<li><a href="/start?as=instructor">Launch as instructor</a></li>
+</ul>
+<h2>Received</h2>
+<ul>{{range .Events}}<li><code>{{.}}</code></li>{{else}}<li>Nothing yet</li>{{end}}</ul>
+</body></html>`))
+
+func (p *platform) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	indexPage.Execute(w, map[string]interface{}{"TestID": p.testID, "Events": p.events})
+}
+
+// start begins a third-party initiated login. The login hint names the user and
+// the message hint the message type, which is how real platforms use them too.
+func (p *platform) start(w http.ResponseWriter, r *http.Request) {
+	as := r.URL.Query().Get("as")
+	if _, ok := users[as]; !ok {
+		http.Error(w, "unknown user", http.StatusBadRequest)
+		return
+	}
+	params := url.Values{
+		"iss":               {p.url},
+		"login_hint":        {as},
+		"lti_message_hint":  {r.URL.Query().Get("message")},
+		"target_link_uri":   {p.tool + "/lti/launch"},
+		"client_id":         {p.clientID},
+		"lti_deployment_id": {p.deployment},
+	}
+	http.Redirect(w, r, p.tool+"/lti/login?"+params.Encode(), http.StatusFound)
+}
+
+// auth is the platform's authorization endpoint: it signs the launch and posts it
+// to the tool from the browser.
+func (p *platform) auth(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	u, ok := users[query.Get("login_hint")]
+	if !ok || query.Get("client_id") != p.clientID || query.Get("redirect_uri") != p.tool+"/lti/launch" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	claims := lti.LaunchClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    p.url,
+			Subject:   u.Subject,
+			Audience:  jwt.ClaimStrings{p.clientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		},
+		Nonce:        query.Get("nonce"),
+		Email:        u.Email,
+		Name:         u.GivenName + " " + u.FamilyName,
+		GivenName:    u.GivenName,
+		FamilyName:   u.FamilyName,
+		Version:      lti.Version,
+		DeploymentID: p.deployment,
+		Roles:        u.Roles,
+		Context:      &lti.Context{ID: "course-1", Label: "PSY101", Title: "Introduction to Psychology"},
+	}
+	if query.Get("lti_message_hint") == "deep-link" {
+		claims.MessageType = lti.MessageDeepLinking
+		claims.DeepLinkingSettings = &lti.DeepLinkingSettings{
+			ReturnURL:   p.url + "/deep-link-return",
+			AcceptTypes: []string{lti.ContentItemLink},
+			Data:        "mock-session",
+		}
+	} else {
+		p.mu.Lock()
+		testID := p.testID
+		p.mu.Unlock()
+		claims.MessageType = lti.MessageResourceLink
+		claims.TargetLinkURI = p.tool + "/lti/launch"
+		claims.ResourceLink = &lti.ResourceLink{ID: "link-1", Title: "Assessment"}
+		claims.Custom = map[string]interface{}{"test_id": testID}
+		claims.Endpoint = &lti.Endpoint{
+			Scope:     []string{lti.ScopeScore, lti.ScopeLineItem},
+			LineItems: p.url + "/lineitems",
+			LineItem:  p.url + "/lineitems/1",
+		}
+	}
+
+	idToken, err := lti.Sign(p.key, claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	autoPost(w, query.Get("redirect_uri"), map[string]string{"id_token": idToken, "state": query.Get("state")})
+}
+
+func (p *platform) jwks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lti.KeySet{Keys: []lti.JWK{lti.PublicJWK(&p.key.PublicKey)}})
+}
+
+// token grants an access token for a client assertion signed with the tool's key.
+func (p *platform) token(w http.ResponseWriter, r *http.Request) {
+	var claims jwt.RegisteredClaims
+	if err := p.verifyToolToken(r, r.PostFormValue("client_assertion"), &claims, p.url+"/token"); err != nil {
+		p.record("token request rejected: %v", err)
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+	p.record("issued a token for scopes %q", r.PostFormValue("scope"))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lti.TokenResponse{
+		AccessToken: "mock-" + lti.NewJTI(),
+		TokenType:   "Bearer",
+		ExpiresIn:   3600,
+		Scope:       r.PostFormValue("scope"),
+	})
+}
+
+func (p *platform) scores(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer mock-") || r.Header.Get("Content-Type") != lti.ScoreMediaType {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	p.record("score: %s", body)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (p *platform) deepLinkReturn(w http.ResponseWriter, r *http.Request) {
+	var response lti.DeepLinkingResponse
+	if err := p.verifyToolToken(r, r.PostFormValue("JWT"), &response, p.url); err != nil {
+		p.record("deep linking response rejected: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, item := range response.ContentItems {
+		p.mu.Lock()
+		p.testID = item.Custom["test_id"]
+		p.mu.Unlock()
+		p.record("deep linking selected %q (test_id %s)", item.Title, item.Custom["test_id"])
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// verifyToolToken checks a JWT the tool signed as this client, against the key set
+// the tool publishes.
+func (p *platform) verifyToolToken(r *http.Request, token string, claims jwt.Claims, audience string) error {
+	set, err := lti.FetchKeySet(r.Context(), p.client, p.tool+"/lti/jwks")
+	if err != nil {
+		return err
+	}
+	_, err = jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return set.Key(kid)
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithIssuer(p.clientID), jwt.WithAudience(audience))
+	return err
+}
+
+func (p *platform) record(format string, args ...interface{}) {
+	event := time.Now().Format("15:04:05 ") + fmt.Sprintf(format, args...)
+	log.Print(event)
+	p.mu.Lock()
+	p.events = append(p.events, event)
+	p.mu.Unlock()
+}
+
+var autoPostPage = template.Must(template.New("post").Parse(`<!DOCTYPE html>
+<html><body onload="document.forms[0].submit()">
+<form method="POST" action="{{.Action}}">{{range $name, $value := .Fields}}
+<input type="hidden" name="{{$name}}" value="{{$value}}">{{end}}
+<noscript><button type="submit">Continue</button></noscript>
+</form></body></html>`))
+
+func autoPost(w http.ResponseWriter, action string, fields map[string]string) {
+	autoPostPage.Execute(w, map[string]interface{}{"Action": action, "Fields": fields})
+}
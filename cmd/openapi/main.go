@@ -0,0 +1,77 @@
+// Command openapi writes the OpenAPI document and the generated Go client, and
+// checks both against the router. Run it from the repository root:
+//
+//	go run ./cmd/openapi -out openapi.json   # write the document
+//	go run ./cmd/openapi -client             # regenerate client/client.gen.go
+//	go run ./cmd/openapi -check              # fail if routes or the client drifted
+//
+// The check needs no database and belongs in CI.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"iq-go/internal/config"
+	"iq-go/internal/openapi"
+	"iq-go/internal/server"
+
+	"github.com/gin-gonic/gin"
+)
+
+const clientFile = "client/client.gen.go"
+
+func main() {
+	out := flag.String("out", "", "write the OpenAPI document to this file (- for stdout)")
+	client := flag.Bool("client", false, "regenerate "+clientFile)
+	check := flag.Bool("check", false, "fail if the router or "+clientFile+" no longer match the spec")
+	flag.Parse()
+
+	spec := server.APISpec()
+
+	if *out != "" {
+		document, err := json.MarshalIndent(spec.Document(), "", "  ")
+		if err != nil {
+			log.Fatal("Failed to encode document:", err)
+		}
+		document = append(document, '\n')
+		if *out == "-" {
+			os.Stdout.Write(document)
+		} else if err := os.WriteFile(*out, document, 0o644); err != nil {
+			log.Fatal("Failed to write document:", err)
+		}
+	}
+
+	generated, err := spec.GenerateClient("client")
+	if err != nil {
+		log.Fatal("Failed to generate client:", err)
+	}
+	if *client {
+		if err := os.WriteFile(clientFile, generated, 0o644); err != nil {
+			log.Fatal("Failed to write client:", err)
+		}
+	}
+
+	if *check {
+		gin.SetMode(gin.ReleaseMode)
+		// Handlers are only registered, never called, so no database is needed.
+		router := server.NewRouter(nil, config.Load())
+		problems := openapi.CheckRoutes(spec.Routes, router.Routes(), "/api/v1/")
+
+		committed, err := os.ReadFile(clientFile)
+		if err != nil || !bytes.Equal(committed, generated) {
+			problems = append(problems, clientFile+" is out of date; run go run ./cmd/openapi -client")
+		}
+
+		for _, problem := range problems {
+			log.Println(problem)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		log.Printf("%d routes match the spec", len(spec.Routes))
+	}
+}
@@ -0,0 +1,41 @@
+// Command reliability computes a new version of the reliability report for every
+// test with newly counted results. It is meant to be run periodically, e.g. from cron.
+package main
+
+import (
+	"flag"
+	"log"
+	"strconv"
+
+	"iq-go/internal/config"
+	"iq-go/internal/database"
+	"iq-go/internal/services"
+)
+
+func main() {
+	orgID := flag.Uint("org", 0, "organization ID (0 processes all organizations)")
+	flag.Parse()
+
+	cfg := config.Load()
+	db, err := database.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := database.RunMigrations(db); err != nil {
+		log.Fatal("Failed to run migrations:", err)
+	}
+
+	reports, err := services.NewAnalyticsService(db).ComputeAllReliability(*orgID)
+	for _, report := range reports {
+		alpha := "n/a"
+		if report.Overall.CronbachAlpha != nil {
+			alpha = strconv.FormatFloat(*report.Overall.CronbachAlpha, 'f', 3, 64)
+		}
+		log.Printf("Test %d: version %d, %d attempts, alpha %s", report.TestID, report.Version, report.Overall.Attempts, alpha)
+	}
+	if err != nil {
+		log.Fatal("Reliability job failed:", err)
+	}
+
+	log.Printf("Created %d reliability reports", len(reports))
+}
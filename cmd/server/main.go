@@ -1,17 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
-	"net/http"
 
-	"iq-go/internal/auth"
 	"iq-go/internal/config"
 	"iq-go/internal/database"
-	"iq-go/internal/handlers"
+	"iq-go/internal/lti"
+	"iq-go/internal/server"
 	"iq-go/internal/services"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
 )
 
 func main() {
@@ -24,62 +21,29 @@ func main() {
 
 	database.RunMigrations(db)
 
-	userService := services.NewUserService(db)
-	testService := services.NewTestService(db)
-	resultService := services.NewResultService(db)
-
-	authHandler := handlers.NewAuthHandler(userService)
-	testHandler := handlers.NewTestHandler(testService)
-	resultHandler := handlers.NewResultHandler(resultService)
-
-	r := gin.Default()
-
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"*"},
-		AllowCredentials: true,
-	}))
-
-	r.LoadHTMLGlob("web/templates/*")
-	r.Static("/static", "./web/static")
-
-	// Web routes
-	r.GET("/", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "dashboard.html", nil)
-	})
-	r.GET("/login", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "login.html", nil)
-	})
-	r.GET("/register", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "register.html", nil)
-	})
-	r.GET("/test", auth.RequireAuth, func(c *gin.Context) {
-		c.HTML(http.StatusOK, "test.html", nil)
-	})
-	r.GET("/results", auth.RequireAuth, func(c *gin.Context) {
-		c.HTML(http.StatusOK, "results.html", nil)
-	})
+	if err := database.SeedRoles(db); err != nil {
+		log.Fatal("Failed to seed roles:", err)
+	}
+	if _, err := database.SeedDefaultOrganization(db, cfg.DefaultOrganization); err != nil {
+		log.Fatal("Failed to seed default organization:", err)
+	}
 
-	// API routes
-	api := r.Group("/api")
-	{
-		// Auth routes
-		api.POST("/register", authHandler.Register)
-		api.POST("/login", authHandler.Login)
-		api.POST("/logout", authHandler.Logout)
+	// Webhook deliveries are sent in the background; a zero interval leaves that to another instance.
+	if cfg.WebhookDispatchInterval > 0 {
+		go services.NewWebhookService(db).RunDispatcher(context.Background(), cfg.WebhookDispatchInterval)
+	}
 
-		// Protected routes
-		protected := api.Group("/")
-		protected.Use(auth.RequireAuth)
-		{
-			protected.GET("/questions", testHandler.GetQuestions)
-			protected.POST("/submit", testHandler.SubmitTest)
-			protected.GET("/results", resultHandler.GetResults)
-			protected.GET("/results/:id", resultHandler.GetResult)
+	// Scores for learning platforms' gradebooks are sent the same way.
+	if cfg.LTIScoreDispatchInterval > 0 {
+		tool, err := lti.NewTool(cfg.AppURL, cfg.LTIPrivateKey)
+		if err != nil {
+			log.Fatal("Failed to load LTI key:", err)
 		}
+		go services.NewLTIService(db, tool, cfg.LTIAllowPrivateHosts).RunScoreDispatcher(context.Background(), cfg.LTIScoreDispatchInterval)
 	}
 
+	r := server.NewRouter(db, cfg)
+
 	log.Printf("Server starting on port %s", cfg.Port)
 	log.Fatal(r.Run(":" + cfg.Port))
 }
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// RequireAuthOrAPIKey accepts an API key, sent in the X-API-Key header or as a
+// bearer token, and otherwise falls back to RequireAuth. Key requests get the
+// key's organization and permissions and an api_key_id instead of a user_id, so
+// handlers that act for a user reject them.
+func RequireAuthOrAPIKey(apiKeyService *services.APIKeyService, userService *services.UserService) gin.HandlerFunc {
+	requireAuth := RequireAuth(userService)
+	return func(c *gin.Context) {
+		key := c.GetHeader(APIKeyHeader)
+		if token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "); key == "" && strings.HasPrefix(token, services.APIKeyPrefix) {
+			key = token
+		}
+		if key == "" {
+			requireAuth(c)
+			return
+		}
+
+		apiKey, err := apiKeyService.Authenticate(key, c.ClientIP())
+		if err != nil {
+			if errors.Is(err, services.ErrAPIKeyInvalid) {
+				utils.CodedErrorResponse(c, http.StatusUnauthorized, utils.CodeAPIKeyInvalid, "Invalid API key")
+			} else {
+				utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to verify API key")
+			}
+			c.Abort()
+			return
+		}
+
+		if tenantID := c.GetUint("tenant_id"); tenantID != 0 && tenantID != apiKey.OrganizationID {
+			utils.CodedErrorResponse(c, http.StatusForbidden, utils.CodeTenantMismatch, "API key does not belong to this organization")
+			c.Abort()
+			return
+		}
+
+		c.Set("api_key_id", apiKey.ID)
+		c.Set("organization_id", apiKey.OrganizationID)
+		c.Set("roles", []string{})
+		c.Set("permissions", apiKey.Permissions)
+		c.Next()
+	}
+}
@@ -1,42 +1,101 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"iq-go/internal/models"
+	"iq-go/internal/services"
 	"iq-go/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RequireAuth(c *gin.Context) {
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		// Check for token in cookie for web requests
-		token, err := c.Cookie("token")
+// RequireAuth accepts a signed token of an active user whose roles have not
+// changed since it was issued, from the Authorization header or the token cookie.
+func RequireAuth(userService *services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			// Check for token in cookie for web requests
+			token, err := c.Cookie("token")
+			if err != nil {
+				c.Redirect(http.StatusFound, "/login")
+				c.Abort()
+				return
+			}
+			authHeader = "Bearer " + token
+		}
+
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if tokenString == "" {
+			utils.CodedErrorResponse(c, http.StatusUnauthorized, utils.CodeTokenRequired, "Authorization token required")
+			c.Abort()
+			return
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
 		if err != nil {
-			c.Redirect(http.StatusFound, "/login")
+			utils.CodedErrorResponse(c, http.StatusUnauthorized, utils.CodeTokenInvalid, "Invalid token")
 			c.Abort()
 			return
 		}
-		authHeader = "Bearer " + token
-	}
+		if err := userService.CheckToken(claims.UserID, claims.TokenVersion); err != nil {
+			if errors.Is(err, services.ErrTokenRevoked) {
+				utils.CodedErrorResponse(c, http.StatusUnauthorized, utils.CodeTokenRevoked, "Token has been revoked; sign in again")
+			} else {
+				utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to verify token")
+			}
+			c.Abort()
+			return
+		}
+
+		// A token is only valid for the organization it was issued in, except for
+		// platform admins who may act inside any tenant.
+		organizationID := claims.OrganizationID
+		if tenantID := c.GetUint("tenant_id"); tenantID != 0 && tenantID != organizationID {
+			if !HasPermission(claims.Permissions, models.PermManageOrgs) {
+				utils.CodedErrorResponse(c, http.StatusForbidden, utils.CodeTenantMismatch, "Token does not belong to this organization")
+				c.Abort()
+				return
+			}
+			organizationID = tenantID
+		}
 
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == "" {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "Authorization token required")
-		c.Abort()
-		return
+		c.Set("user_id", claims.UserID)
+		c.Set("organization_id", organizationID)
+		c.Set("email", claims.Email)
+		c.Set("roles", claims.Roles)
+		c.Set("permissions", claims.Permissions)
+		if claims.InvitationID != 0 {
+			c.Set("invitation_id", claims.InvitationID)
+		}
+		c.Next()
 	}
+}
 
-	claims, err := utils.ValidateToken(tokenString)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid token")
-		c.Abort()
-		return
+// RequirePermission aborts the request unless the authenticated user holds every
+// listed permission. It must run after RequireAuth.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted := c.GetStringSlice("permissions")
+		for _, permission := range permissions {
+			if !HasPermission(granted, permission) {
+				utils.CodedErrorResponse(c, http.StatusForbidden, utils.CodePermissionDenied, "Insufficient permissions")
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
 	}
+}
 
-	c.Set("user_id", claims.UserID)
-	c.Set("email", claims.Email)
-	c.Next()
+func HasPermission(granted []string, permission string) bool {
+	for _, p := range granted {
+		if p == permission {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"iq-go/internal/models"
+	"iq-go/internal/scim"
+	"iq-go/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireSCIMToken authenticates an identity provider. Its bearer token is an API
+// key with the provisioning permission, and the key's organization is the one
+// being provisioned. Errors are SCIM error responses, which providers expect.
+func RequireSCIMToken(apiKeyService *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !strings.HasPrefix(token, services.APIKeyPrefix) {
+			c.Header("WWW-Authenticate", `Bearer realm="SCIM"`)
+			scim.WriteJSON(c.Writer, http.StatusUnauthorized, scim.NewError(http.StatusUnauthorized, "", "Bearer token required"))
+			c.Abort()
+			return
+		}
+
+		apiKey, err := apiKeyService.Authenticate(token, c.ClientIP())
+		if err != nil {
+			status, detail := http.StatusInternalServerError, "Failed to verify token"
+			if errors.Is(err, services.ErrAPIKeyInvalid) {
+				status, detail = http.StatusUnauthorized, "Invalid token"
+			}
+			scim.WriteJSON(c.Writer, status, scim.NewError(status, "", detail))
+			c.Abort()
+			return
+		}
+		if !HasPermission(apiKey.Permissions, models.PermProvisionUsers) {
+			scim.WriteJSON(c.Writer, http.StatusForbidden, scim.NewError(http.StatusForbidden, "", "Token lacks the "+models.PermProvisionUsers+" permission"))
+			c.Abort()
+			return
+		}
+
+		c.Set("api_key_id", apiKey.ID)
+		c.Set("organization_id", apiKey.OrganizationID)
+		c.Set("permissions", apiKey.Permissions)
+		c.Next()
+	}
+}
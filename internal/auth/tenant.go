@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"iq-go/internal/config"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const TenantHeader = "X-Organization"
+
+// ResolveTenant determines the organization a request is addressed to, from the
+// X-Organization header, then the subdomain of TENANT_BASE_DOMAIN, and finally the
+// default organization, and stores its ID as tenant_id.
+func ResolveTenant(organizationService *services.OrganizationService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		slug := c.GetHeader(TenantHeader)
+		if slug == "" {
+			slug = subdomain(c.Request.Host, cfg.TenantBaseDomain)
+		}
+		if slug == "" {
+			slug = cfg.DefaultOrganization
+		}
+
+		organization, err := organizationService.GetOrganizationBySlug(strings.ToLower(slug))
+		if err != nil {
+			utils.CodedErrorResponse(c, http.StatusNotFound, utils.CodeOrganizationNotFound, "Organization not found")
+			c.Abort()
+			return
+		}
+
+		c.Set("tenant_id", organization.ID)
+		c.Next()
+	}
+}
+
+func subdomain(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	suffix := "." + baseDomain
+	if !strings.HasSuffix(host, suffix) {
+		return ""
+	}
+	label := strings.TrimSuffix(host, suffix)
+	if strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
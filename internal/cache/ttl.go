@@ -0,0 +1,69 @@
+// Package cache provides a small in-memory cache for computed values that may be
+// slightly stale.
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// TTL caches values for a fixed time. Expired entries are dropped lazily and
+// whenever the cache grows past its size limit.
+type TTL[K comparable, V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxSize int
+	entries map[K]entry[V]
+}
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func NewTTL[K comparable, V any](ttl time.Duration, maxSize int) *TTL[K, V] {
+	return &TTL[K, V]{
+		ttl:     ttl,
+		maxSize: maxSize,
+		entries: make(map[K]entry[V]),
+	}
+}
+
+func (c *TTL[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expiresAt) {
+		delete(c.entries, key)
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+func (c *TTL[K, V]) Set(key K, value V) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.maxSize {
+		for k, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) >= c.maxSize {
+		// Still full of live entries: evict an arbitrary one.
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = entry[V]{value: value, expiresAt: now.Add(c.ttl)}
+}
@@ -18,10 +18,36 @@ func Connect(databaseURL string) (*gorm.DB, error) {
 
 func RunMigrations(db *gorm.DB) error {
 	return db.AutoMigrate(
+		&models.Organization{},
+		&models.Permission{},
+		&models.Role{},
 		&models.User{},
 		&models.Test{},
 		&models.Question{},
 		&models.TestResult{},
 		&models.Answer{},
+		&models.AttemptQuestion{},
+		&models.Invitation{},
+		&models.ReportTemplate{},
+		&models.Certificate{},
+		&models.ReliabilityReport{},
+		&models.PracticeSession{},
+		&models.PracticeAnswer{},
+		&models.LeaderboardProfile{},
+		&models.LeaderboardEntry{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.WebhookDeliveryAttempt{},
+		&models.APIKey{},
+		&models.LTIPlatform{},
+		&models.LTILogin{},
+		&models.LTIIdentity{},
+		&models.LTIResourceLink{},
+		&models.LTILaunch{},
+		&models.LTIScore{},
+		&models.SCIMUser{},
+		&models.Session{},
+		&models.SessionCandidate{},
+		&models.Accommodation{},
 	)
 }
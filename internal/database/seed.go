@@ -0,0 +1,55 @@
+package database
+
+import (
+	"iq-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SeedRoles makes sure the built-in roles and permissions exist. Permissions that
+// were granted to a built-in role by hand are left untouched.
+func SeedRoles(db *gorm.DB) error {
+	for roleName, permissionNames := range models.DefaultRolePermissions {
+		var permissions []models.Permission
+		for _, name := range permissionNames {
+			permission := models.Permission{Name: name}
+			if err := db.Where("name = ?", name).FirstOrCreate(&permission).Error; err != nil {
+				return err
+			}
+			permissions = append(permissions, permission)
+		}
+
+		role := models.Role{Name: roleName}
+		if err := db.Where("name = ?", roleName).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+		if err := db.Model(&role).Association("Permissions").Append(permissions); err != nil {
+			return err
+		}
+	}
+
+	// Accounts created before roles existed become candidates.
+	return db.Exec(`INSERT INTO user_roles (user_id, role_id)
+		SELECT users.id, roles.id FROM users, roles
+		WHERE roles.name = ? AND NOT EXISTS (SELECT 1 FROM user_roles WHERE user_roles.user_id = users.id)`,
+		models.RoleCandidate).Error
+}
+
+// SeedDefaultOrganization creates the organization that single-tenant deployments run under
+// and moves rows created before organizations existed into it.
+func SeedDefaultOrganization(db *gorm.DB, slug string) (*models.Organization, error) {
+	organization := models.Organization{Name: slug, Slug: slug}
+	if err := db.Where("slug = ?", slug).FirstOrCreate(&organization).Error; err != nil {
+		return nil, err
+	}
+
+	for _, model := range []interface{}{&models.User{}, &models.Test{}, &models.TestResult{}} {
+		err := db.Model(model).
+			Where("organization_id IS NULL OR organization_id = 0").
+			Update("organization_id", organization.ID).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &organization, nil
+}
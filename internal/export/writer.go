@@ -0,0 +1,104 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"iq-go/internal/services"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Header lists the CSV columns in the order they are written.
+var Header = []string{
+	"result_id", "organization_id", "user_id", "user_email", "user_first_name", "user_last_name",
+	"test_id", "test_name", "started_at", "completed_at", "score", "total_questions",
+	"time_taken_seconds", "counted", "time_multiplier", "answer_id", "question_id", "question_order",
+	"category", "question_type", "user_answer", "is_correct", "response_time_ms",
+}
+
+// RowWriter encodes export rows in one output format.
+type RowWriter interface {
+	Write(row *services.ExportRow) error
+	Flush() error
+}
+
+// NewWriter returns a writer for "csv" or "jsonl".
+func NewWriter(format string, w io.Writer) (RowWriter, error) {
+	switch format {
+	case "csv":
+		return newCSVWriter(w)
+	case "jsonl":
+		return &jsonlWriter{encoder: json.NewEncoder(w)}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+func ContentType(format string) string {
+	if format == "csv" {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(Header); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer}, nil
+}
+
+func (w *csvWriter) Write(row *services.ExportRow) error {
+	return w.writer.Write([]string{
+		uintString(row.ResultID), uintString(row.OrganizationID), uintString(row.UserID),
+		safeCell(row.UserEmail), safeCell(row.UserFirstName), safeCell(row.UserLastName),
+		uintString(row.TestID), safeCell(row.TestName),
+		row.StartedAt.UTC().Format(time.RFC3339), row.CompletedAt.UTC().Format(time.RFC3339),
+		strconv.Itoa(row.Score), strconv.Itoa(row.TotalQuestions), strconv.Itoa(row.TimeTakenSeconds),
+		strconv.FormatBool(row.Counted), strconv.FormatFloat(row.TimeMultiplier, 'f', -1, 64),
+		uintString(row.AnswerID), uintString(row.QuestionID),
+		strconv.Itoa(row.QuestionOrder), string(row.Category), row.QuestionType, safeCell(row.UserAnswer),
+		strconv.FormatBool(row.IsCorrect), strconv.Itoa(row.ResponseTimeMs),
+	})
+}
+
+// safeCell prefixes text a spreadsheet would run as a formula with a quote, so
+// candidate-supplied names and answers open as plain text.
+func safeCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonlWriter) Write(row *services.ExportRow) error {
+	return w.encoder.Encode(row)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return nil
+}
+
+func uintString(value uint) string {
+	return strconv.FormatUint(uint64(value), 10)
+}
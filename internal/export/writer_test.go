@@ -0,0 +1,59 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"iq-go/internal/services"
+)
+
+func TestCSVWriterEscapesFormulas(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+1+1", "'+1+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"plain", "plain"},
+		{"a=b", "a=b"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.answer, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewWriter("csv", &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			row := &services.ExportRow{
+				UserFirstName: tt.answer,
+				TestName:      tt.answer,
+				UserAnswer:    tt.answer,
+				StartedAt:     time.Unix(0, 0),
+				CompletedAt:   time.Unix(0, 0),
+			}
+			if err := writer.Write(row); err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			records, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			record := records[1]
+			for _, column := range []int{4, 7, 20} {
+				if record[column] != tt.want {
+					t.Errorf("%s = %q, want %q", Header[column], record[column], tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,687 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Request is a GraphQL request as POSTed in JSON.
+type Request struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Response holds the data of an executed operation and any errors. Data is left
+// out entirely when the request was rejected before execution.
+type Response struct {
+	Data     interface{}
+	Errors   []*Error
+	executed bool
+}
+
+func (r *Response) MarshalJSON() ([]byte, error) {
+	if !r.executed {
+		return json.Marshal(struct {
+			Errors []*Error `json:"errors"`
+		}{r.Errors})
+	}
+	return json.Marshal(struct {
+		Data   interface{} `json:"data"`
+		Errors []*Error    `json:"errors,omitempty"`
+	}{r.Data, r.Errors})
+}
+
+type Error struct {
+	Message   string        `json:"message"`
+	Locations []Location    `json:"locations,omitempty"`
+	Path      []interface{} `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Limits bound the work a query may cause. Depth counts nested fields;
+// complexity counts the fields that would be resolved, multiplying what is
+// selected below a list by its expected size.
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// Execute runs the query of a request. Requests that do not parse, do not match
+// the schema or exceed the limits are rejected without running any resolver.
+func (s *Schema) Execute(ctx context.Context, req Request, limits Limits) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return rejected(err)
+	}
+
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return rejected(err)
+	}
+	if op.Type != "query" {
+		return rejected(&Error{Message: "only queries are supported", Locations: []Location{op.Location}})
+	}
+	if err := checkFragmentCycles(doc); err != nil {
+		return rejected(err)
+	}
+
+	e := &executor{schema: s, doc: doc, args: map[*Field]map[string]interface{}{}}
+	if e.variables, err = s.coerceVariables(op.Variables, req.Variables); err != nil {
+		return rejected(err)
+	}
+
+	cost := e.analyze(s.Query, op.Selections, 1, limits.MaxDepth)
+	if len(e.errors) > 0 {
+		return &Response{Errors: e.errors}
+	}
+	if limits.MaxComplexity > 0 && cost > limits.MaxComplexity {
+		return rejected(&Error{Message: fmt.Sprintf("query complexity %d exceeds the limit of %d", cost, limits.MaxComplexity)})
+	}
+
+	e.ctx = ctx
+	data := e.executeSet(s.Query, []interface{}{nil}, [][]interface{}{nil}, op.Selections)
+	return &Response{Data: data[0], Errors: e.errors, executed: true}
+}
+
+func rejected(err error) *Response {
+	gqlErr, ok := err.(*Error)
+	if !ok {
+		gqlErr = &Error{Message: err.Error()}
+	}
+	return &Response{Errors: []*Error{gqlErr}}
+}
+
+func selectOperation(doc *Document, name string) (*Operation, error) {
+	if name == "" {
+		if len(doc.Operations) > 1 {
+			return nil, &Error{Message: "operationName is required when the document has several operations"}
+		}
+		return doc.Operations[0], nil
+	}
+	for _, op := range doc.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("unknown operation %q", name)}
+}
+
+// checkFragmentCycles rejects fragments that spread themselves, directly or
+// through other fragments, which would otherwise never finish expanding.
+func checkFragmentCycles(doc *Document) error {
+	done := map[string]bool{}
+	var visit func(name string, stack map[string]bool) error
+	var walk func(selections []Selection, stack map[string]bool) error
+	walk = func(selections []Selection, stack map[string]bool) error {
+		for _, selection := range selections {
+			switch sel := selection.(type) {
+			case *Field:
+				if err := walk(sel.Selections, stack); err != nil {
+					return err
+				}
+			case *InlineFragment:
+				if err := walk(sel.Selections, stack); err != nil {
+					return err
+				}
+			case *FragmentSpread:
+				if stack[sel.Name] {
+					return &Error{Message: fmt.Sprintf("fragment %q spreads itself", sel.Name), Locations: []Location{sel.Location}}
+				}
+				if err := visit(sel.Name, stack); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	visit = func(name string, stack map[string]bool) error {
+		fragment, ok := doc.Fragments[name]
+		if !ok || done[name] {
+			return nil
+		}
+		stack[name] = true
+		err := walk(fragment.Selections, stack)
+		delete(stack, name)
+		done[name] = true
+		return err
+	}
+	// Visit in name order so the same document always reports the same fragment.
+	names := make([]string, 0, len(doc.Fragments))
+	for name := range doc.Fragments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := visit(name, map[string]bool{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type executor struct {
+	ctx       context.Context
+	schema    *Schema
+	doc       *Document
+	variables map[string]interface{}
+	args      map[*Field]map[string]interface{} // coerced arguments, filled in by analyze
+	errors    []*Error
+}
+
+func (e *executor) fail(message string, loc Location) {
+	e.errors = append(e.errors, &Error{Message: message, Locations: []Location{loc}})
+}
+
+// fieldGroup is the fields selected under one response key, merged as GraphQL
+// requires.
+type fieldGroup struct {
+	key    string
+	fields []*Field
+}
+
+func (g *fieldGroup) selections() []Selection {
+	var selections []Selection
+	for _, field := range g.fields {
+		selections = append(selections, field.Selections...)
+	}
+	return selections
+}
+
+// collect expands fragments and applies @skip and @include, grouping the fields
+// of a selection set by response key in the order they first appear.
+func (e *executor) collect(object *Object, selections []Selection) []*fieldGroup {
+	var groups []*fieldGroup
+	index := map[string]*fieldGroup{}
+	var add func(selections []Selection)
+	add = func(selections []Selection) {
+		for _, selection := range selections {
+			switch sel := selection.(type) {
+			case *Field:
+				if !e.included(sel.Directives) {
+					continue
+				}
+				group, ok := index[sel.ResponseKey()]
+				if !ok {
+					group = &fieldGroup{key: sel.ResponseKey()}
+					index[group.key] = group
+					groups = append(groups, group)
+				}
+				group.fields = append(group.fields, sel)
+			case *FragmentSpread:
+				fragment, ok := e.doc.Fragments[sel.Name]
+				if !ok {
+					e.fail(fmt.Sprintf("unknown fragment %q", sel.Name), sel.Location)
+					continue
+				}
+				if !e.included(sel.Directives) || !e.included(fragment.Directives) || !e.matches(object, fragment.TypeCondition, sel.Location) {
+					continue
+				}
+				add(fragment.Selections)
+			case *InlineFragment:
+				if !e.included(sel.Directives) || !e.matches(object, sel.TypeCondition, sel.Location) {
+					continue
+				}
+				add(sel.Selections)
+			}
+		}
+	}
+	add(selections)
+	return groups
+}
+
+// matches checks a fragment's type condition. The schema has no interfaces or
+// unions, so a fragment applies only to the type it names.
+func (e *executor) matches(object *Object, condition string, loc Location) bool {
+	if condition == "" || condition == object.Name {
+		return true
+	}
+	if _, ok := e.schema.types[condition]; !ok {
+		e.fail(fmt.Sprintf("unknown type %q", condition), loc)
+	} else {
+		e.fail(fmt.Sprintf("fragment on %s cannot be spread on %s", condition, object.Name), loc)
+	}
+	return false
+}
+
+// included evaluates @skip and @include.
+func (e *executor) included(directives []*Directive) bool {
+	for _, directive := range directives {
+		if directive.Name != "skip" && directive.Name != "include" {
+			e.fail(fmt.Sprintf("unknown directive @%s", directive.Name), directive.Location)
+			continue
+		}
+		args, ok := e.coerceArgs([]*ArgumentDefinition{{Name: "if", Type: NonNull{Boolean}}}, directive.Arguments, directive.Location)
+		if !ok {
+			continue
+		}
+		if args["if"].(bool) == (directive.Name == "skip") {
+			return false
+		}
+	}
+	return true
+}
+
+// analyze validates a selection set against its type, coerces the arguments of
+// its fields and returns its complexity.
+func (e *executor) analyze(object *Object, selections []Selection, depth, maxDepth int) int {
+	cost := 0
+	for _, group := range e.collect(object, selections) {
+		field := group.fields[0]
+		for _, other := range group.fields[1:] {
+			if other.Name != field.Name {
+				e.fail(fmt.Sprintf("%s selects both %s and %s", group.key, field.Name, other.Name), other.Location)
+			}
+		}
+		if field.Name == "__typename" {
+			continue
+		}
+
+		def := object.Field(field.Name)
+		if def == nil {
+			e.fail(fmt.Sprintf("cannot query field %q on type %s", field.Name, object.Name), field.Location)
+			continue
+		}
+		if maxDepth > 0 && depth > maxDepth {
+			e.fail(fmt.Sprintf("query is nested deeper than %d fields", maxDepth), field.Location)
+			return cost
+		}
+		args, ok := e.coerceArgs(def.Args, field.Arguments, field.Location)
+		if !ok {
+			continue
+		}
+		e.args[field] = args
+
+		named := namedType(def.Type)
+		sub := group.selections()
+		child, isObject := named.(*Object)
+		switch {
+		case isObject && len(sub) == 0:
+			e.fail(fmt.Sprintf("field %q of type %s must have a selection of subfields", field.Name, def.Type), field.Location)
+			continue
+		case !isObject && len(sub) > 0:
+			e.fail(fmt.Sprintf("field %q of type %s cannot have a selection of subfields", field.Name, def.Type), field.Location)
+			continue
+		}
+
+		childCost := 0
+		if isObject {
+			childCost = e.analyze(child, sub, depth+1, maxDepth)
+		}
+		if isList(def.Type) {
+			size := e.schema.DefaultListSize
+			if def.Size != nil {
+				size = def.Size(args)
+			}
+			childCost *= size
+		}
+		cost += 1 + childCost
+	}
+	return cost
+}
+
+func isList(t Type) bool {
+	if nonNull, ok := t.(NonNull); ok {
+		t = nonNull.Of
+	}
+	_, ok := t.(List)
+	return ok
+}
+
+// coerceArgs coerces the arguments of a field or directive, applying defaults.
+func (e *executor) coerceArgs(defs []*ArgumentDefinition, arguments []*Argument, loc Location) (map[string]interface{}, bool) {
+	ok := true
+	given := map[string]*Argument{}
+	for _, argument := range arguments {
+		given[argument.Name] = argument
+		if !hasArgument(defs, argument.Name) {
+			e.fail(fmt.Sprintf("unknown argument %q", argument.Name), argument.Location)
+			ok = false
+		}
+	}
+
+	args := map[string]interface{}{}
+	for _, def := range defs {
+		argument, present := given[def.Name]
+		var raw interface{}
+		if present {
+			raw, present = e.resolveValue(argument.Value)
+		}
+		if !present {
+			if def.Default != nil {
+				args[def.Name] = def.Default
+			} else if _, required := def.Type.(NonNull); required {
+				e.fail(fmt.Sprintf("argument %q of type %s is required", def.Name, def.Type), loc)
+				ok = false
+			}
+			continue
+		}
+		value, valid := coerceInput(def.Type, raw)
+		if !valid {
+			e.fail(fmt.Sprintf("invalid value for argument %q of type %s", def.Name, def.Type), argument.Location)
+			ok = false
+			continue
+		}
+		args[def.Name] = value
+	}
+	return args, ok
+}
+
+func hasArgument(defs []*ArgumentDefinition, name string) bool {
+	for _, def := range defs {
+		if def.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveValue replaces variables in a literal with their raw values. It
+// reports false for a variable that was not provided.
+func (e *executor) resolveValue(value Value) (interface{}, bool) {
+	switch v := value.(type) {
+	case Variable:
+		raw, ok := e.variables[string(v)]
+		return raw, ok
+	case ListValue:
+		items := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			raw, ok := e.resolveValue(item)
+			if !ok {
+				raw = nil
+			}
+			items = append(items, raw)
+		}
+		return items, true
+	}
+	return value, true
+}
+
+// coerceVariables checks the request's variables against their definitions. The
+// raw values are kept and coerced again by each argument they are passed to.
+func (s *Schema) coerceVariables(defs []*VariableDefinition, provided map[string]interface{}) (map[string]interface{}, error) {
+	variables := map[string]interface{}{}
+	for _, def := range defs {
+		t, err := s.inputType(def.Type)
+		if err != nil {
+			return nil, &Error{Message: err.Error(), Locations: []Location{def.Location}}
+		}
+		raw, ok := provided[def.Name]
+		if !ok && def.Default != nil {
+			raw, ok = constant(def.Default), true
+		}
+		if !ok {
+			if def.Type.NonNull {
+				return nil, &Error{Message: fmt.Sprintf("variable $%s of type %s is required", def.Name, def.Type), Locations: []Location{def.Location}}
+			}
+			continue
+		}
+		if _, valid := coerceInput(t, raw); !valid {
+			return nil, &Error{Message: fmt.Sprintf("invalid value for variable $%s of type %s", def.Name, def.Type), Locations: []Location{def.Location}}
+		}
+		variables[def.Name] = raw
+	}
+	return variables, nil
+}
+
+func (s *Schema) inputType(ref TypeRef) (Type, error) {
+	var t Type
+	if ref.Elem != nil {
+		elem, err := s.inputType(*ref.Elem)
+		if err != nil {
+			return nil, err
+		}
+		t = List{elem}
+	} else {
+		scalar, ok := s.types[ref.Name].(*Scalar)
+		if !ok {
+			return nil, fmt.Errorf("%s is not an input type", ref.Name)
+		}
+		t = scalar
+	}
+	if ref.NonNull {
+		t = NonNull{t}
+	}
+	return t, nil
+}
+
+// constant turns a literal without variables into a raw value.
+func constant(value Value) interface{} {
+	if list, ok := value.(ListValue); ok {
+		items := make([]interface{}, len(list))
+		for i, item := range list {
+			items[i] = constant(item)
+		}
+		return items
+	}
+	return value
+}
+
+// coerceInput coerces a raw value, from a literal or JSON, to an input type.
+// A single value is accepted where a list is expected.
+func coerceInput(t Type, raw interface{}) (interface{}, bool) {
+	if nonNull, ok := t.(NonNull); ok {
+		if raw == nil {
+			return nil, false
+		}
+		return coerceInput(nonNull.Of, raw)
+	}
+	if raw == nil {
+		return nil, true
+	}
+	switch t := t.(type) {
+	case List:
+		items, isList := raw.([]interface{})
+		if !isList {
+			items = []interface{}{raw}
+		}
+		values := make([]interface{}, len(items))
+		for i, item := range items {
+			value, ok := coerceInput(t.Of, item)
+			if !ok {
+				return nil, false
+			}
+			values[i] = value
+		}
+		return values, true
+	case *Scalar:
+		return t.Coerce(raw)
+	}
+	return nil, false
+}
+
+// errNull marks a null in a non-null position. It makes the nearest nullable
+// parent null instead.
+type nullMarker struct{}
+
+var errNull interface{} = nullMarker{}
+
+// executeSet resolves a selection set on every source at once, returning an
+// object per source, or nil where a non-null field turned out null.
+func (e *executor) executeSet(object *Object, sources []interface{}, paths [][]interface{}, selections []Selection) []interface{} {
+	results := make([]*orderedMap, len(sources))
+	for i := range results {
+		results[i] = &orderedMap{}
+	}
+	nulled := make([]bool, len(sources))
+
+	for _, group := range e.collect(object, selections) {
+		field := group.fields[0]
+		var values []interface{}
+		if field.Name == "__typename" {
+			values = make([]interface{}, len(sources))
+			for i := range values {
+				values[i] = object.Name
+			}
+		} else {
+			def := object.Field(field.Name)
+			fieldPaths := make([][]interface{}, len(paths))
+			for i, path := range paths {
+				fieldPaths[i] = appendPath(path, group.key)
+			}
+
+			resolved, err := def.Resolve(e.ctx, sources, e.args[field])
+			if err == nil && len(resolved) != len(sources) {
+				err = fmt.Errorf("resolver of %s.%s returned %d values for %d objects", object.Name, def.Name, len(resolved), len(sources))
+			}
+			failed := err != nil
+			if failed {
+				// A batched resolver fails for every source; the error is reported once.
+				e.errors = append(e.errors, &Error{Message: err.Error(), Locations: []Location{field.Location}, Path: fieldPaths[0]})
+				resolved = make([]interface{}, len(sources))
+			}
+			values = e.complete(def.Type, resolved, fieldPaths, group.selections(), field, failed)
+		}
+
+		for i, value := range values {
+			if value == errNull {
+				nulled[i] = true
+			} else {
+				results[i].set(group.key, value)
+			}
+		}
+	}
+
+	out := make([]interface{}, len(sources))
+	for i := range out {
+		if !nulled[i] {
+			out[i] = results[i]
+		}
+	}
+	return out
+}
+
+// complete turns resolved values into their JSON form, resolving the fields
+// selected on objects.
+func (e *executor) complete(t Type, values []interface{}, paths [][]interface{}, selections []Selection, field *Field, failed bool) []interface{} {
+	out := make([]interface{}, len(values))
+	switch t := t.(type) {
+	case NonNull:
+		inner := e.complete(t.Of, values, paths, selections, field, failed)
+		for i, value := range inner {
+			if value != nil {
+				out[i] = value
+				continue
+			}
+			if !failed && isNil(values[i]) {
+				e.errors = append(e.errors, &Error{
+					Message:   fmt.Sprintf("cannot return null for non-null field %s", field.Name),
+					Locations: []Location{field.Location},
+					Path:      paths[i],
+				})
+			}
+			out[i] = errNull
+		}
+
+	case List:
+		var items []interface{}
+		var itemPaths [][]interface{}
+		lengths := make([]int, len(values))
+		for i, value := range values {
+			if isNil(value) {
+				lengths[i] = -1
+				continue
+			}
+			list := reflect.ValueOf(value)
+			lengths[i] = list.Len()
+			for j := 0; j < list.Len(); j++ {
+				items = append(items, list.Index(j).Interface())
+				itemPaths = append(itemPaths, appendPath(paths[i], j))
+			}
+		}
+		completed := e.complete(t.Of, items, itemPaths, selections, field, failed)
+		for i, length := range lengths {
+			if length < 0 {
+				continue
+			}
+			list := append([]interface{}{}, completed[:length]...)
+			completed = completed[length:]
+			out[i] = list
+			for _, item := range list {
+				if item == errNull {
+					out[i] = nil
+					break
+				}
+			}
+		}
+
+	case *Object:
+		var sources []interface{}
+		var sourcePaths [][]interface{}
+		var positions []int
+		for i, value := range values {
+			if !isNil(value) {
+				sources = append(sources, value)
+				sourcePaths = append(sourcePaths, paths[i])
+				positions = append(positions, i)
+			}
+		}
+		if len(sources) > 0 {
+			for j, object := range e.executeSet(t, sources, sourcePaths, selections) {
+				out[positions[j]] = object
+			}
+		}
+
+	case *Scalar:
+		for i, value := range values {
+			if !isNil(value) {
+				out[i] = t.Serialize(value)
+			}
+		}
+	}
+	return out
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+func appendPath(path []interface{}, segment interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(path)+1), path...), segment)
+}
+
+// orderedMap is a response object, which keeps fields in the order selected.
+type orderedMap struct {
+	keys   []string
+	values []interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	m.keys = append(m.keys, key)
+	m.values = append(m.values, value)
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, _ := json.Marshal(key)
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		value, err := json.Marshal(m.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,385 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testBook struct {
+	ID       uint
+	Title    string
+	AuthorID uint
+	Tags     []string
+	ISBN     string
+}
+
+type testAuthor struct {
+	ID   uint
+	Name string
+}
+
+// library is a small schema over in-memory books and authors. It counts how often
+// each batched resolver runs.
+type library struct {
+	books   []*testBook
+	authors map[uint]*testAuthor
+	calls   map[string]int
+	schema  *Schema
+}
+
+func newLibrary(t *testing.T) *library {
+	l := &library{
+		books: []*testBook{
+			{ID: 1, Title: "Dune", AuthorID: 1, Tags: []string{"scifi", "classic"}, ISBN: "978-0441013593"},
+			{ID: 2, Title: "Emma", AuthorID: 2, Tags: []string{"classic"}, ISBN: "978-0141439587"},
+			{ID: 3, Title: "Children of Dune", AuthorID: 1, Tags: []string{}},
+		},
+		authors: map[uint]*testAuthor{1: {ID: 1, Name: "Frank Herbert"}, 2: {ID: 2, Name: "Jane Austen"}},
+		calls:   map[string]int{},
+	}
+
+	book := &Object{Name: "Book"}
+	author := &Object{Name: "Author"}
+	bookProperty := func(get func(*testBook) interface{}) Resolver {
+		return Property(func(source interface{}) interface{} { return get(source.(*testBook)) })
+	}
+	book.Fields = []*FieldDefinition{
+		{Name: "id", Type: NonNull{ID}, Resolve: bookProperty(func(b *testBook) interface{} { return b.ID })},
+		{Name: "title", Type: NonNull{String}, Resolve: bookProperty(func(b *testBook) interface{} { return b.Title })},
+		{Name: "tags", Type: NonNull{List{NonNull{String}}}, Resolve: bookProperty(func(b *testBook) interface{} { return b.Tags })},
+		{Name: "isbn", Type: NonNull{String}, Resolve: bookProperty(func(b *testBook) interface{} {
+			if b.ISBN == "" {
+				return nil
+			}
+			return b.ISBN
+		})},
+		{Name: "author", Type: author, Resolve: func(_ context.Context, sources []interface{}, _ map[string]interface{}) ([]interface{}, error) {
+			l.calls["Book.author"]++
+			values := make([]interface{}, len(sources))
+			for i, source := range sources {
+				values[i] = l.authors[source.(*testBook).AuthorID]
+			}
+			return values, nil
+		}},
+	}
+	author.Fields = []*FieldDefinition{
+		{Name: "id", Type: NonNull{ID}, Resolve: Property(func(source interface{}) interface{} { return source.(*testAuthor).ID })},
+		{Name: "name", Type: NonNull{String}, Resolve: Property(func(source interface{}) interface{} { return source.(*testAuthor).Name })},
+		{Name: "books", Type: NonNull{List{NonNull{book}}}, Resolve: func(_ context.Context, sources []interface{}, _ map[string]interface{}) ([]interface{}, error) {
+			l.calls["Author.books"]++
+			values := make([]interface{}, len(sources))
+			for i, source := range sources {
+				var books []*testBook
+				for _, b := range l.books {
+					if b.AuthorID == source.(*testAuthor).ID {
+						books = append(books, b)
+					}
+				}
+				values[i] = books
+			}
+			return values, nil
+		}},
+	}
+
+	query := &Object{
+		Name: "Query",
+		Fields: []*FieldDefinition{
+			{Name: "book", Type: book, Args: []*ArgumentDefinition{{Name: "id", Type: NonNull{ID}}},
+				Resolve: func(_ context.Context, _ []interface{}, args map[string]interface{}) ([]interface{}, error) {
+					for _, b := range l.books {
+						if b.ID == args["id"].(uint) {
+							return []interface{}{b}, nil
+						}
+					}
+					return []interface{}{nil}, nil
+				}},
+			{Name: "books", Type: NonNull{List{NonNull{book}}}, Args: []*ArgumentDefinition{{Name: "first", Type: Int, Default: 10}},
+				Resolve: func(_ context.Context, _ []interface{}, args map[string]interface{}) ([]interface{}, error) {
+					l.calls["Query.books"]++
+					first := args["first"].(int)
+					if first > len(l.books) {
+						first = len(l.books)
+					}
+					return []interface{}{l.books[:first]}, nil
+				},
+				Size: func(args map[string]interface{}) int { return args["first"].(int) }},
+			{Name: "fail", Type: String, Resolve: func(context.Context, []interface{}, map[string]interface{}) ([]interface{}, error) {
+				return nil, errors.New("boom")
+			}},
+		},
+	}
+
+	schema, err := NewSchema(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.schema = schema
+	return l
+}
+
+func (l *library) execute(query string, variables map[string]interface{}, operationName string, limits Limits) *Response {
+	return l.schema.Execute(context.Background(), Request{Query: query, Variables: variables, OperationName: operationName}, limits)
+}
+
+func messages(errs []*Error) []string {
+	var out []string
+	for _, err := range errs {
+		out = append(out, err.Message)
+	}
+	return out
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		variables     map[string]interface{}
+		operationName string
+		data          string
+		errors        []string
+	}{
+		{
+			name:  "aliases",
+			query: `{ first: book(id: 1) { title } second: book(id: "2") { title } missing: book(id: 9) { title } }`,
+			data:  `{"first":{"title":"Dune"},"second":{"title":"Emma"},"missing":null}`,
+		},
+		{
+			name:  "named fragment",
+			query: `query { book(id: 1) { ...Info } } fragment Info on Book { id title tags }`,
+			data:  `{"book":{"id":"1","title":"Dune","tags":["scifi","classic"]}}`,
+		},
+		{
+			name:  "nested and inline fragments",
+			query: `{ book(id: 2) { ...A ... on Book { id } } } fragment A on Book { ...B } fragment B on Book { title }`,
+			data:  `{"book":{"title":"Emma","id":"2"}}`,
+		},
+		{
+			name:  "fields merged across fragments",
+			query: `{ book(id: 1) { title ... on Book { title author { name } } author { id } } }`,
+			data:  `{"book":{"title":"Dune","author":{"name":"Frank Herbert","id":"1"}}}`,
+		},
+		{
+			name:      "skip and include",
+			query:     `query ($skip: Boolean!) { book(id: 1) { id title @skip(if: $skip) tags @include(if: false) ...F @include(if: true) } } fragment F on Book { isbn }`,
+			variables: map[string]interface{}{"skip": true},
+			data:      `{"book":{"id":"1","isbn":"978-0441013593"}}`,
+		},
+		{
+			name:  "typename",
+			query: `{ book(id: 1) { __typename kind: __typename } }`,
+			data:  `{"book":{"__typename":"Book","kind":"Book"}}`,
+		},
+		{
+			name:      "variables and variable defaults",
+			query:     `query Find($id: ID!, $first: Int = 2) { book(id: $id) { title } books(first: $first) { id } }`,
+			variables: map[string]interface{}{"id": "3"},
+			data:      `{"book":{"title":"Children of Dune"},"books":[{"id":"1"},{"id":"2"}]}`,
+		},
+		{
+			name:      "JSON numbers as Int",
+			query:     `query ($first: Int) { books(first: $first) { id } }`,
+			variables: map[string]interface{}{"first": float64(1)},
+			data:      `{"books":[{"id":"1"}]}`,
+		},
+		{
+			name:  "absent variable falls back to the argument default",
+			query: `query ($first: Int) { books(first: $first) { id } }`,
+			data:  `{"books":[{"id":"1"},{"id":"2"},{"id":"3"}]}`,
+		},
+		{
+			name:          "operation name",
+			query:         `query A { book(id: 1) { title } } query B { book(id: 2) { title } }`,
+			operationName: "B",
+			data:          `{"book":{"title":"Emma"}}`,
+		},
+		{
+			name:   "null in a non-null field nulls the nearest nullable parent",
+			query:  `{ book(id: 3) { title isbn } }`,
+			data:   `{"book":null}`,
+			errors: []string{"cannot return null for non-null field isbn"},
+		},
+		{
+			name:   "null reaching the root nulls the data",
+			query:  `{ books { isbn } }`,
+			data:   `null`,
+			errors: []string{"cannot return null for non-null field isbn"},
+		},
+		{
+			name:   "resolver error",
+			query:  `{ fail ok: book(id: 1) { id } }`,
+			data:   `{"fail":null,"ok":{"id":"1"}}`,
+			errors: []string{"boom"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := newLibrary(t).execute(tt.query, tt.variables, tt.operationName, Limits{})
+			if got := messages(resp.Errors); !reflect.DeepEqual(got, tt.errors) {
+				t.Errorf("errors %q, want %q", got, tt.errors)
+			}
+			data, err := json.Marshal(resp.Data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.data {
+				t.Errorf("data %s, want %s", data, tt.data)
+			}
+		})
+	}
+}
+
+func TestExecuteRejects(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		variables     map[string]interface{}
+		operationName string
+		limits        Limits
+		errors        []string
+	}{
+		{name: "syntax error", query: `{ book(id: 1) { id }`, errors: []string{"unexpected end of document"}},
+		{name: "mutation", query: `mutation { book(id: 1) { id } }`, errors: []string{"only queries are supported"}},
+		{name: "several operations without a name", query: `query A { books { id } } query B { books { id } }`,
+			errors: []string{"operationName is required when the document has several operations"}},
+		{name: "unknown operation", query: `query A { books { id } }`, operationName: "B", errors: []string{`unknown operation "B"`}},
+		{name: "unknown field", query: `{ nope }`, errors: []string{`cannot query field "nope" on type Query`}},
+		{name: "object without subfields", query: `{ book(id: 1) }`, errors: []string{`field "book" of type Book must have a selection of subfields`}},
+		{name: "scalar with subfields", query: `{ book(id: 1) { title { length } } }`, errors: []string{`field "title" of type String! cannot have a selection of subfields`}},
+		{name: "missing argument", query: `{ book { id } }`, errors: []string{`argument "id" of type ID! is required`}},
+		{name: "unknown argument", query: `{ books(last: 2) { id } }`, errors: []string{`unknown argument "last"`}},
+		{name: "invalid argument", query: `{ books(first: "ten") { id } }`, errors: []string{`invalid value for argument "first" of type Int`}},
+		{name: "conflicting response keys", query: `{ book(id: 1) { x: title x: id } }`, errors: []string{"x selects both title and id"}},
+		{name: "unknown directive", query: `{ books @cached { id } }`, errors: []string{"unknown directive @cached"}},
+		{name: "missing required variable", query: `query ($id: ID!) { book(id: $id) { id } }`, errors: []string{"variable $id of type ID! is required"}},
+		{name: "invalid variable", query: `query ($first: Int) { books(first: $first) { id } }`, variables: map[string]interface{}{"first": 1.5},
+			errors: []string{"invalid value for variable $first of type Int"}},
+		{name: "out of range variable", query: `query ($first: Int) { books(first: $first) { id } }`, variables: map[string]interface{}{"first": float64(1 << 40)},
+			errors: []string{"invalid value for variable $first of type Int"}},
+		{name: "variable of an output type", query: `query ($b: Book) { books { id } }`, errors: []string{"Book is not an input type"}},
+		{name: "unknown fragment", query: `{ book(id: 1) { ...Missing } }`, errors: []string{`unknown fragment "Missing"`}},
+		{name: "fragment on another type", query: `{ book(id: 1) { ...A } } fragment A on Author { name }`,
+			errors: []string{"fragment on Author cannot be spread on Book"}},
+		{name: "fragment on an unknown type", query: `{ book(id: 1) { ... on Magazine { id } } }`, errors: []string{`unknown type "Magazine"`}},
+		{name: "fragment cycle", query: `{ book(id: 1) { ...A } } fragment A on Book { id ...B } fragment B on Book { author { books { ...A } } }`,
+			errors: []string{`fragment "A" spreads itself`}},
+		{name: "self-spreading fragment", query: `{ book(id: 1) { ...A } } fragment A on Book { ...A }`, errors: []string{`fragment "A" spreads itself`}},
+		{
+			name:   "too deep",
+			query:  `{ book(id: 1) { author { books { title } } } }`,
+			limits: Limits{MaxDepth: 3},
+			errors: []string{"query is nested deeper than 3 fields"},
+		},
+		{
+			name:   "too deep through a fragment",
+			query:  `{ book(id: 1) { ...Deep } } fragment Deep on Book { author { books { id } } }`,
+			limits: Limits{MaxDepth: 3},
+			errors: []string{"query is nested deeper than 3 fields"},
+		},
+		{
+			// books: 1 + 5 * (title 1 + author (1 + name 1)) = 16
+			name:   "too complex",
+			query:  `{ books(first: 5) { title author { name } } }`,
+			limits: Limits{MaxComplexity: 15},
+			errors: []string{"query complexity 16 exceeds the limit of 15"},
+		},
+		{
+			// Author.books has no size estimate, so it counts as DefaultListSize (10):
+			// 1 + 2 * (author 1 + (books 1 + 10 * id 1)) = 25
+			name:   "too complex with the default list size",
+			query:  `{ books(first: 2) { author { books { id } } } }`,
+			limits: Limits{MaxComplexity: 24},
+			errors: []string{"query complexity 25 exceeds the limit of 24"},
+		},
+		{
+			name:      "complexity of a size from a variable",
+			query:     `query ($n: Int) { books(first: $n) { id } }`,
+			variables: map[string]interface{}{"n": float64(100)},
+			limits:    Limits{MaxComplexity: 100},
+			errors:    []string{"query complexity 101 exceeds the limit of 100"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLibrary(t)
+			resp := l.execute(tt.query, tt.variables, tt.operationName, tt.limits)
+			if got := messages(resp.Errors); !reflect.DeepEqual(got, tt.errors) {
+				t.Errorf("errors %q, want %q", got, tt.errors)
+			}
+			if len(l.calls) > 0 {
+				t.Errorf("resolvers ran for a rejected request: %v", l.calls)
+			}
+
+			body, err := json.Marshal(resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(body, &fields); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := fields["data"]; ok {
+				t.Errorf("rejected response has data: %s", body)
+			}
+		})
+	}
+}
+
+func TestExecuteWithinLimits(t *testing.T) {
+	l := newLibrary(t)
+	resp := l.execute(`{ books(first: 5) { title author { name } } }`, nil, "", Limits{MaxDepth: 3, MaxComplexity: 16})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors %q", messages(resp.Errors))
+	}
+	data, _ := json.Marshal(resp.Data)
+	want := `{"books":[{"title":"Dune","author":{"name":"Frank Herbert"}},{"title":"Emma","author":{"name":"Jane Austen"}},` +
+		`{"title":"Children of Dune","author":{"name":"Frank Herbert"}}]}`
+	if string(data) != want {
+		t.Errorf("data %s, want %s", data, want)
+	}
+}
+
+func TestExecuteBatchesResolvers(t *testing.T) {
+	l := newLibrary(t)
+	resp := l.execute(`{ books { author { name books { title author { name } } } } }`, nil, "", Limits{})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors %q", messages(resp.Errors))
+	}
+	// Each field runs once per level however many objects it is selected on.
+	want := map[string]int{"Query.books": 1, "Book.author": 2, "Author.books": 1}
+	if !reflect.DeepEqual(l.calls, want) {
+		t.Errorf("resolver calls %v, want %v", l.calls, want)
+	}
+}
+
+func TestResolverValueCount(t *testing.T) {
+	query := &Object{Name: "Query", Fields: []*FieldDefinition{{
+		Name: "broken", Type: List{Of: String},
+		Resolve: func(context.Context, []interface{}, map[string]interface{}) ([]interface{}, error) {
+			return []interface{}{}, nil
+		},
+	}}}
+	schema, err := NewSchema(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := schema.Execute(context.Background(), Request{Query: `{ broken }`}, Limits{})
+	want := []string{"resolver of Query.broken returned 0 values for 1 objects"}
+	if got := messages(resp.Errors); !reflect.DeepEqual(got, want) {
+		t.Errorf("errors %q, want %q", got, want)
+	}
+}
+
+func TestNewSchemaRejectsDuplicateTypeNames(t *testing.T) {
+	query := &Object{Name: "Query", Fields: []*FieldDefinition{
+		{Name: "a", Type: &Object{Name: "Thing", Fields: []*FieldDefinition{{Name: "x", Type: Int}}}},
+		{Name: "b", Type: &Object{Name: "Thing", Fields: []*FieldDefinition{{Name: "y", Type: Int}}}},
+	}}
+	if _, err := NewSchema(query); err == nil {
+		t.Error("NewSchema accepted two types named Thing")
+	}
+}
@@ -0,0 +1,710 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Document is a parsed request: its operations and the fragments they spread.
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+type Operation struct {
+	Type       string // query, mutation or subscription
+	Name       string
+	Variables  []*VariableDefinition
+	Selections []Selection
+	Location   Location
+}
+
+type VariableDefinition struct {
+	Name     string
+	Type     TypeRef
+	Default  Value
+	Location Location
+}
+
+// TypeRef is a type as written in a variable definition, e.g. [ID!]!.
+type TypeRef struct {
+	Name    string
+	Elem    *TypeRef // set for lists
+	NonNull bool
+}
+
+func (t TypeRef) String() string {
+	s := t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	Selections    []Selection
+	Location      Location
+}
+
+// Selection is a *Field, *FragmentSpread or *InlineFragment.
+type Selection interface {
+	location() Location
+}
+
+type Field struct {
+	Alias      string
+	Name       string
+	Arguments  []*Argument
+	Directives []*Directive
+	Selections []Selection
+	Location   Location
+}
+
+// ResponseKey is the name the field's value is returned under.
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Location   Location
+}
+
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	Selections    []Selection
+	Location      Location
+}
+
+func (f *Field) location() Location          { return f.Location }
+func (f *FragmentSpread) location() Location { return f.Location }
+func (f *InlineFragment) location() Location { return f.Location }
+
+type Argument struct {
+	Name     string
+	Value    Value
+	Location Location
+}
+
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+	Location  Location
+}
+
+// Value is an input value literal. Variable refers to a variable by name;
+// everything else is a constant.
+type Value interface{}
+
+type (
+	Variable  string
+	EnumValue string
+	ListValue []Value
+	// ObjectValue keeps its fields in order.
+	ObjectValue []*Argument
+)
+
+// Location is a 1-based position in the request, reported with errors.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Parse parses an executable document: operations and fragments.
+func Parse(source string) (*Document, error) {
+	p := &parser{lexer: lexer{source: strings.TrimPrefix(source, "\ufeff"), line: 1}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{Fragments: map[string]*Fragment{}}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.tok.kind == tokPunct && p.tok.value == "{":
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		case p.tok.kind == tokName && p.tok.value == "fragment":
+			fragment, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.Fragments[fragment.Name]; ok {
+				return nil, p.errorAt(fragment.Location, "fragment %q is defined more than once", fragment.Name)
+			}
+			doc.Fragments[fragment.Name] = fragment
+		case p.tok.kind == tokName && (p.tok.value == "query" || p.tok.value == "mutation" || p.tok.value == "subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.Operations) == 0 {
+		return nil, &Error{Message: "the document has no operation"}
+	}
+	return doc, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	loc   Location
+}
+
+type lexer struct {
+	source    string
+	pos       int
+	line      int
+	lineStart int
+}
+
+func (l *lexer) next() (token, error) {
+	// Skip whitespace, commas and comments, which are insignificant.
+	for l.pos < len(l.source) {
+		switch ch := l.source[l.pos]; {
+		case ch == '\n':
+			l.pos++
+			l.line++
+			l.lineStart = l.pos
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == ',':
+			l.pos++
+		case ch == '#':
+			for l.pos < len(l.source) && l.source[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return l.read()
+		}
+	}
+	return token{kind: tokEOF, loc: l.loc()}, nil
+}
+
+func (l *lexer) loc() Location {
+	return Location{Line: l.line, Column: l.pos - l.lineStart + 1}
+}
+
+func (l *lexer) read() (token, error) {
+	loc := l.loc()
+	start := l.pos
+	ch := l.source[l.pos]
+	switch {
+	case strings.HasPrefix(l.source[l.pos:], "..."):
+		l.pos += 3
+		return token{tokPunct, "...", loc}, nil
+	case strings.IndexByte("!$&()[]{}:=@|", ch) >= 0:
+		l.pos++
+		return token{tokPunct, string(ch), loc}, nil
+	case ch == '_' || isLetter(ch):
+		for l.pos < len(l.source) && (l.source[l.pos] == '_' || isLetter(l.source[l.pos]) || isDigit(l.source[l.pos])) {
+			l.pos++
+		}
+		return token{tokName, l.source[start:l.pos], loc}, nil
+	case ch == '-' || isDigit(ch):
+		kind := tokInt
+		if ch == '-' {
+			l.pos++
+		}
+		l.digits()
+		if l.pos < len(l.source) && l.source[l.pos] == '.' {
+			kind = tokFloat
+			l.pos++
+			l.digits()
+		}
+		if l.pos < len(l.source) && (l.source[l.pos] == 'e' || l.source[l.pos] == 'E') {
+			kind = tokFloat
+			l.pos++
+			if l.pos < len(l.source) && (l.source[l.pos] == '+' || l.source[l.pos] == '-') {
+				l.pos++
+			}
+			l.digits()
+		}
+		text := l.source[start:l.pos]
+		if _, err := strconv.ParseFloat(text, 64); err != nil {
+			return token{}, &Error{Message: fmt.Sprintf("invalid number %q", text), Locations: []Location{loc}}
+		}
+		return token{kind, text, loc}, nil
+	case ch == '"':
+		return l.readString(loc)
+	}
+	r, _ := utf8.DecodeRuneInString(l.source[l.pos:])
+	return token{}, &Error{Message: fmt.Sprintf("unexpected character %q", r), Locations: []Location{loc}}
+}
+
+func (l *lexer) digits() {
+	for l.pos < len(l.source) && isDigit(l.source[l.pos]) {
+		l.pos++
+	}
+}
+
+// readString reads a string literal. Block strings ("""...""") are kept as
+// written apart from their common indentation, which is not removed.
+func (l *lexer) readString(loc Location) (token, error) {
+	if strings.HasPrefix(l.source[l.pos:], `"""`) {
+		end := strings.Index(l.source[l.pos+3:], `"""`)
+		if end < 0 {
+			return token{}, &Error{Message: "unterminated string", Locations: []Location{loc}}
+		}
+		value := l.source[l.pos+3 : l.pos+3+end]
+		for i := 0; i < len(value); i++ {
+			if value[i] == '\n' {
+				l.line++
+				l.lineStart = l.pos + 3 + i + 1
+			}
+		}
+		l.pos += end + 6
+		return token{tokString, strings.TrimSpace(value), loc}, nil
+	}
+
+	var b strings.Builder
+	for l.pos++; l.pos < len(l.source); {
+		ch := l.source[l.pos]
+		switch {
+		case ch == '"':
+			l.pos++
+			return token{tokString, b.String(), loc}, nil
+		case ch == '\n':
+			return token{}, &Error{Message: "unterminated string", Locations: []Location{loc}}
+		case ch == '\\' && l.pos+1 < len(l.source):
+			escape := l.source[l.pos+1]
+			l.pos += 2
+			switch escape {
+			case '"', '\\', '/':
+				b.WriteByte(escape)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.source) {
+					return token{}, &Error{Message: "invalid escape sequence", Locations: []Location{loc}}
+				}
+				code, err := strconv.ParseUint(l.source[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return token{}, &Error{Message: "invalid escape sequence", Locations: []Location{loc}}
+				}
+				b.WriteRune(rune(code))
+				l.pos += 4
+			default:
+				return token{}, &Error{Message: "invalid escape sequence", Locations: []Location{loc}}
+			}
+		default:
+			b.WriteByte(ch)
+			l.pos++
+		}
+	}
+	return token{}, &Error{Message: "unterminated string", Locations: []Location{loc}}
+}
+
+func isLetter(ch byte) bool { return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' }
+func isDigit(ch byte) bool  { return ch >= '0' && ch <= '9' }
+
+type parser struct {
+	lexer lexer
+	tok   token
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) errorAt(loc Location, format string, args ...interface{}) error {
+	return &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}}
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokEOF {
+		return p.errorAt(p.tok.loc, "unexpected end of document")
+	}
+	return p.errorAt(p.tok.loc, "unexpected %q", p.tok.value)
+}
+
+func (p *parser) peek(punct string) bool {
+	return p.tok.kind == tokPunct && p.tok.value == punct
+}
+
+func (p *parser) skip(punct string) (bool, error) {
+	if !p.peek(punct) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) expect(punct string) error {
+	if !p.peek(punct) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokName {
+		return "", p.unexpected()
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) parseOperation() (*Operation, error) {
+	op := &Operation{Type: "query", Location: p.tok.loc}
+	if p.tok.kind == tokName {
+		op.Type = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokName {
+			op.Name = p.tok.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		if p.peek("(") {
+			variables, err := p.parseVariableDefinitions()
+			if err != nil {
+				return nil, err
+			}
+			op.Variables = variables
+		}
+		if _, err := p.parseDirectives(); err != nil {
+			return nil, err
+		}
+	}
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.Selections = selections
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinitions() ([]*VariableDefinition, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var definitions []*VariableDefinition
+	for !p.peek(")") {
+		definition := &VariableDefinition{Location: p.tok.loc}
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		definition.Name = name
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if definition.Type, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip("="); err != nil {
+			return nil, err
+		} else if ok {
+			if definition.Default, err = p.parseValue(true); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := p.parseDirectives(); err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions, p.advance()
+}
+
+func (p *parser) parseType() (TypeRef, error) {
+	var t TypeRef
+	if ok, err := p.skip("["); err != nil {
+		return t, err
+	} else if ok {
+		elem, err := p.parseType()
+		if err != nil {
+			return t, err
+		}
+		t.Elem = &elem
+		if err := p.expect("]"); err != nil {
+			return t, err
+		}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return t, err
+		}
+		t.Name = name
+	}
+	nonNull, err := p.skip("!")
+	t.NonNull = nonNull
+	return t, err
+}
+
+func (p *parser) parseFragment() (*Fragment, error) {
+	fragment := &Fragment{Location: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, p.errorAt(fragment.Location, "a fragment cannot be named on")
+	}
+	fragment.Name = name
+	if p.tok.kind != tokName || p.tok.value != "on" {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if fragment.TypeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if fragment.Directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if fragment.Selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
+func (p *parser) parseSelectionSet() ([]Selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []Selection
+	for !p.peek("}") {
+		selection, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+	if len(selections) == 0 {
+		return nil, p.errorAt(p.tok.loc, "empty selection set")
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) parseSelection() (Selection, error) {
+	loc := p.tok.loc
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		if p.tok.kind == tokName && p.tok.value != "on" {
+			spread := &FragmentSpread{Name: p.tok.value, Location: loc}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			spread.Directives, err = p.parseDirectives()
+			return spread, err
+		}
+		inline := &InlineFragment{Location: loc}
+		if p.tok.kind == tokName {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if inline.TypeCondition, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if inline.Directives, err = p.parseDirectives(); err != nil {
+			return nil, err
+		}
+		inline.Selections, err = p.parseSelectionSet()
+		return inline, err
+	}
+
+	field := &Field{Location: loc}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		field.Alias = name
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	field.Name = name
+	if field.Arguments, err = p.parseArguments(false); err != nil {
+		return nil, err
+	}
+	if field.Directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		if field.Selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+func (p *parser) parseArguments(constant bool) ([]*Argument, error) {
+	if ok, err := p.skip("("); err != nil || !ok {
+		return nil, err
+	}
+	var arguments []*Argument
+	for !p.peek(")") {
+		argument := &Argument{Location: p.tok.loc}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		argument.Name = name
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if argument.Value, err = p.parseValue(constant); err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, argument)
+	}
+	if len(arguments) == 0 {
+		return nil, p.errorAt(p.tok.loc, "empty argument list")
+	}
+	return arguments, p.advance()
+}
+
+func (p *parser) parseDirectives() ([]*Directive, error) {
+	var directives []*Directive
+	for p.peek("@") {
+		directive := &Directive{Location: p.tok.loc}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		directive.Name = name
+		if directive.Arguments, err = p.parseArguments(false); err != nil {
+			return nil, err
+		}
+		directives = append(directives, directive)
+	}
+	return directives, nil
+}
+
+// parseValue parses a value literal. Variables are not allowed in constants,
+// such as variable defaults.
+func (p *parser) parseValue(constant bool) (Value, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokPunct:
+		switch tok.value {
+		case "$":
+			if constant {
+				return nil, p.unexpected()
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			return Variable(name), err
+		case "[":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			list := ListValue{}
+			for !p.peek("]") {
+				item, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			}
+			return list, p.advance()
+		case "{":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			object := ObjectValue{}
+			for !p.peek("}") {
+				field := &Argument{Location: p.tok.loc}
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				field.Name = name
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if field.Value, err = p.parseValue(constant); err != nil {
+					return nil, err
+				}
+				object = append(object, field)
+			}
+			return object, p.advance()
+		}
+	case tokInt:
+		value, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, p.errorAt(tok.loc, "integer %s is out of range", tok.value)
+		}
+		return value, p.advance()
+	case tokFloat:
+		value, _ := strconv.ParseFloat(tok.value, 64)
+		return value, p.advance()
+	case tokString:
+		return tok.value, p.advance()
+	case tokName:
+		var value Value
+		switch tok.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			value = EnumValue(tok.value)
+		}
+		return value, p.advance()
+	}
+	return nil, p.unexpected()
+}
@@ -0,0 +1,169 @@
+package graphql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(`
+		# a comment
+		query Find($id: ID!, $tags: [String!] = ["a", "b"], $first: Int) {
+			b: book(id: $id) {
+				...Info @include(if: true)
+				... on Book { id }
+				... @skip(if: false) { title }
+			}
+		}
+		fragment Info on Book { title }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(doc.Operations) != 1 {
+		t.Fatalf("got %d operations, want 1", len(doc.Operations))
+	}
+	op := doc.Operations[0]
+	if op.Type != "query" || op.Name != "Find" {
+		t.Errorf("operation is %s %s, want query Find", op.Type, op.Name)
+	}
+	if op.Location != (Location{Line: 3, Column: 3}) {
+		t.Errorf("operation location %+v, want 3:3", op.Location)
+	}
+
+	var types []string
+	for _, variable := range op.Variables {
+		types = append(types, "$"+variable.Name+": "+variable.Type.String())
+	}
+	if want := []string{"$id: ID!", "$tags: [String!]", "$first: Int"}; !reflect.DeepEqual(types, want) {
+		t.Errorf("variables %v, want %v", types, want)
+	}
+	if want := (ListValue{"a", "b"}); !reflect.DeepEqual(op.Variables[1].Default, want) {
+		t.Errorf("default %#v, want %#v", op.Variables[1].Default, want)
+	}
+
+	field := op.Selections[0].(*Field)
+	if field.Alias != "b" || field.Name != "book" || field.ResponseKey() != "b" {
+		t.Errorf("field %s: %s, want b: book", field.Alias, field.Name)
+	}
+	if len(field.Arguments) != 1 || field.Arguments[0].Name != "id" || field.Arguments[0].Value != Variable("id") {
+		t.Errorf("arguments %#v, want id: $id", field.Arguments)
+	}
+
+	spread, ok := field.Selections[0].(*FragmentSpread)
+	if !ok || spread.Name != "Info" || len(spread.Directives) != 1 || spread.Directives[0].Name != "include" {
+		t.Errorf("first selection %#v, want ...Info @include", field.Selections[0])
+	}
+	inline, ok := field.Selections[1].(*InlineFragment)
+	if !ok || inline.TypeCondition != "Book" {
+		t.Errorf("second selection %#v, want ... on Book", field.Selections[1])
+	}
+	untyped, ok := field.Selections[2].(*InlineFragment)
+	if !ok || untyped.TypeCondition != "" || len(untyped.Directives) != 1 {
+		t.Errorf("third selection %#v, want ... @skip", field.Selections[2])
+	}
+
+	fragment := doc.Fragments["Info"]
+	if fragment == nil || fragment.TypeCondition != "Book" || fragment.Selections[0].(*Field).Name != "title" {
+		t.Errorf("fragment Info %#v, want Info on Book { title }", fragment)
+	}
+}
+
+func TestParseShorthandQuery(t *testing.T) {
+	doc, err := Parse(`{ books { id } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op := doc.Operations[0]; op.Type != "query" || op.Name != "" {
+		t.Errorf("operation is %q %q, want an anonymous query", op.Type, op.Name)
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	tests := []struct {
+		literal string
+		want    Value
+	}{
+		{`42`, int64(42)},
+		{`-7`, int64(-7)},
+		{`1.5`, 1.5},
+		{`2e3`, 2000.0},
+		{`-1.25E-2`, -0.0125},
+		{`"plain"`, "plain"},
+		{`"tab\tquote\"slash\/unicodeé"`, "tab\tquote\"slash/unicodeé"},
+		{`"""  block "quoted" \n kept  """`, `block "quoted" \n kept`},
+		{`true`, true},
+		{`false`, false},
+		{`null`, nil},
+		{`RED`, EnumValue("RED")},
+		{`$var`, Variable("var")},
+		{`[1, "two", [$three]]`, ListValue{int64(1), "two", ListValue{Variable("three")}}},
+		{`[]`, ListValue{}},
+		{`{a: 1, b: {c: $d}}`, ObjectValue{
+			{Name: "a", Value: int64(1), Location: Location{Line: 1, Column: 9}},
+			{Name: "b", Value: ObjectValue{{Name: "c", Value: Variable("d"), Location: Location{Line: 1, Column: 19}}}, Location: Location{Line: 1, Column: 15}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.literal, func(t *testing.T) {
+			doc, err := Parse(`{ f(v: ` + tt.literal + `) }`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := doc.Operations[0].Selections[0].(*Field).Arguments[0].Value
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		message  string
+		location Location
+	}{
+		{name: "empty document", source: ``, message: "the document has no operation"},
+		{name: "only fragments", source: `fragment F on Book { id }`, message: "the document has no operation"},
+		{name: "unclosed selection set", source: "{\n  book(id: 1) {\n    id\n  }\n", message: "unexpected end of document", location: Location{Line: 5, Column: 1}},
+		{name: "empty selection set", source: `{ book { } }`, message: "empty selection set", location: Location{Line: 1, Column: 10}},
+		{name: "empty argument list", source: `{ book() { id } }`, message: "empty argument list", location: Location{Line: 1, Column: 8}},
+		{name: "missing argument value", source: `{ book(id:) { id } }`, message: `unexpected ")"`, location: Location{Line: 1, Column: 11}},
+		{name: "unexpected character", source: `{ book ^ }`, message: `unexpected character '^'`, location: Location{Line: 1, Column: 8}},
+		{name: "unterminated string", source: `{ f(v: "abc) }`, message: "unterminated string", location: Location{Line: 1, Column: 8}},
+		{name: "newline in string", source: "{ f(v: \"a\nb\") }", message: "unterminated string", location: Location{Line: 1, Column: 8}},
+		{name: "invalid escape", source: `{ f(v: "\q") }`, message: "invalid escape sequence", location: Location{Line: 1, Column: 8}},
+		{name: "short unicode escape", source: `{ f(v: "\u12") }`, message: "invalid escape sequence", location: Location{Line: 1, Column: 8}},
+		{name: "integer out of range", source: `{ f(v: 99999999999999999999) }`, message: "integer 99999999999999999999 is out of range", location: Location{Line: 1, Column: 8}},
+		{name: "invalid number", source: `{ f(v: 1e) }`, message: `invalid number "1e"`, location: Location{Line: 1, Column: 8}},
+		{name: "variable in a default", source: `query ($a: Int = $b) { f }`, message: `unexpected "$"`, location: Location{Line: 1, Column: 18}},
+		{name: "fragment named on", source: `{ f } fragment on on Book { id }`, message: "a fragment cannot be named on", location: Location{Line: 1, Column: 7}},
+		{name: "fragment without type condition", source: `{ f } fragment F { id }`, message: `unexpected "{"`, location: Location{Line: 1, Column: 18}},
+		{name: "duplicate fragment", source: `{ f } fragment F on Book { id } fragment F on Book { id }`, message: `fragment "F" is defined more than once`, location: Location{Line: 1, Column: 33}},
+		{name: "stray token", source: `{ f } }`, message: `unexpected "}"`, location: Location{Line: 1, Column: 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse(tt.source)
+			if err == nil {
+				t.Fatalf("Parse(%q) = %#v, want an error", tt.source, doc)
+			}
+			gqlErr, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("error %T, want *Error", err)
+			}
+			if !strings.Contains(gqlErr.Message, tt.message) {
+				t.Errorf("message %q, want %q", gqlErr.Message, tt.message)
+			}
+			if tt.location != (Location{}) && (len(gqlErr.Locations) != 1 || gqlErr.Locations[0] != tt.location) {
+				t.Errorf("locations %+v, want %+v", gqlErr.Locations, tt.location)
+			}
+		})
+	}
+}
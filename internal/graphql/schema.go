@@ -0,0 +1,318 @@
+// Package graphql implements the parts of GraphQL needed to serve read-only
+// queries: parsing, validation against a schema, complexity limits and
+// execution. Resolvers are batched: a field is resolved once for every object
+// it is selected on at that level, so related rows load in one query instead of
+// one per object. Like packages scim and lti it knows nothing about the
+// application's models; the schema is defined by package services.
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type is a *Scalar, *Object, List or NonNull.
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type. Coerce turns an input value, from a literal or the
+// request's variables, into the value resolvers receive; Serialize turns a
+// resolved value into JSON.
+type Scalar struct {
+	Name        string
+	Description string
+	Coerce      func(value interface{}) (interface{}, bool)
+	Serialize   func(value interface{}) interface{}
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*FieldDefinition
+}
+
+func (o *Object) String() string { return o.Name }
+
+// Field returns the named field, or nil.
+func (o *Object) Field(name string) *FieldDefinition {
+	for _, field := range o.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+type List struct{ Of Type }
+type NonNull struct{ Of Type }
+
+func (l List) String() string    { return "[" + l.Of.String() + "]" }
+func (n NonNull) String() string { return n.Of.String() + "!" }
+
+// Resolver resolves a field for every source it is selected on, returning one
+// value per source in the same order. Args hold the coerced arguments, with
+// defaults applied.
+type Resolver func(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error)
+
+type FieldDefinition struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*ArgumentDefinition
+	Resolve     Resolver
+
+	// Size estimates how many values a list field returns, for the complexity of
+	// what is selected below it. Without it a list counts as DefaultListSize.
+	Size func(args map[string]interface{}) int
+}
+
+type ArgumentDefinition struct {
+	Name        string
+	Description string
+	Type        Type
+	Default     interface{} // coerced value used when the argument is absent
+}
+
+// Property resolves a field from each source on its own, for values that need
+// no loading.
+func Property(get func(source interface{}) interface{}) Resolver {
+	return func(_ context.Context, sources []interface{}, _ map[string]interface{}) ([]interface{}, error) {
+		values := make([]interface{}, len(sources))
+		for i, source := range sources {
+			values[i] = get(source)
+		}
+		return values, nil
+	}
+}
+
+// Built-in scalars
+var (
+	Int = &Scalar{
+		Name: "Int",
+		Coerce: func(value interface{}) (interface{}, bool) {
+			switch v := value.(type) {
+			case int64:
+				return int(v), v >= math.MinInt32 && v <= math.MaxInt32
+			case float64: // from JSON variables
+				return int(v), v == math.Trunc(v) && v >= math.MinInt32 && v <= math.MaxInt32
+			}
+			return nil, false
+		},
+		Serialize: func(value interface{}) interface{} { return value },
+	}
+	Float = &Scalar{
+		Name: "Float",
+		Coerce: func(value interface{}) (interface{}, bool) {
+			switch v := value.(type) {
+			case int64:
+				return float64(v), true
+			case float64:
+				return v, true
+			}
+			return nil, false
+		},
+		Serialize: func(value interface{}) interface{} { return value },
+	}
+	String = &Scalar{
+		Name: "String",
+		Coerce: func(value interface{}) (interface{}, bool) {
+			v, ok := value.(string)
+			return v, ok
+		},
+		Serialize: func(value interface{}) interface{} { return fmt.Sprint(value) },
+	}
+	Boolean = &Scalar{
+		Name: "Boolean",
+		Coerce: func(value interface{}) (interface{}, bool) {
+			v, ok := value.(bool)
+			return v, ok
+		},
+		Serialize: func(value interface{}) interface{} { return value },
+	}
+	// ID values are uint. They are serialized as strings and accepted as either.
+	ID = &Scalar{
+		Name: "ID",
+		Coerce: func(value interface{}) (interface{}, bool) {
+			switch v := value.(type) {
+			case string:
+				id, err := strconv.ParseUint(v, 10, 32)
+				return uint(id), err == nil
+			case int64:
+				return uint(v), v >= 0 && v <= math.MaxUint32
+			case float64:
+				return uint(v), v == math.Trunc(v) && v >= 0 && v <= math.MaxUint32
+			}
+			return nil, false
+		},
+		Serialize: func(value interface{}) interface{} { return fmt.Sprint(value) },
+	}
+	// Time is an RFC 3339 timestamp.
+	Time = &Scalar{
+		Name:        "Time",
+		Description: "An RFC 3339 timestamp",
+		Coerce: func(value interface{}) (interface{}, bool) {
+			v, ok := value.(string)
+			if !ok {
+				return nil, false
+			}
+			t, err := time.Parse(time.RFC3339, v)
+			return t, err == nil
+		},
+		Serialize: func(value interface{}) interface{} {
+			switch v := value.(type) {
+			case time.Time:
+				return v.Format(time.RFC3339)
+			case *time.Time:
+				if v != nil {
+					return v.Format(time.RFC3339)
+				}
+			}
+			return nil
+		},
+	}
+)
+
+var builtinScalars = map[string]*Scalar{"Int": Int, "Float": Float, "String": String, "Boolean": Boolean, "ID": ID}
+
+// Schema is a query root and the types reachable from it.
+type Schema struct {
+	Query *Object
+
+	// DefaultListSize is the assumed size of lists without a Size estimate.
+	DefaultListSize int
+
+	types map[string]Type
+}
+
+// NewSchema collects the types reachable from the query root. Type names must be
+// unique.
+func NewSchema(query *Object) (*Schema, error) {
+	s := &Schema{Query: query, DefaultListSize: 10, types: map[string]Type{}}
+	for name, scalar := range builtinScalars {
+		s.types[name] = scalar
+	}
+	if err := s.collect(query); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Schema) collect(t Type) error {
+	named := namedType(t)
+	name := named.String()
+	if existing, ok := s.types[name]; ok {
+		if existing != named {
+			return fmt.Errorf("graphql: two types are named %s", name)
+		}
+		return nil
+	}
+	s.types[name] = named
+	if object, ok := named.(*Object); ok {
+		for _, field := range object.Fields {
+			if err := s.collect(field.Type); err != nil {
+				return err
+			}
+			for _, arg := range field.Args {
+				if err := s.collect(arg.Type); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// namedType strips the list and non-null wrappers of a type.
+func namedType(t Type) Type {
+	for {
+		switch wrapper := t.(type) {
+		case List:
+			t = wrapper.Of
+		case NonNull:
+			t = wrapper.Of
+		default:
+			return t
+		}
+	}
+}
+
+// SDL returns the schema in the GraphQL schema definition language.
+func (s *Schema) SDL() string {
+	names := make([]string, 0, len(s.types))
+	for name := range s.types {
+		if _, builtin := builtinScalars[name]; !builtin {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		// The query root comes first, then the other types by name.
+		if (names[i] == s.Query.Name) != (names[j] == s.Query.Name) {
+			return names[i] == s.Query.Name
+		}
+		return names[i] < names[j]
+	})
+
+	var b strings.Builder
+	b.WriteString("schema {\n  query: " + s.Query.Name + "\n}\n")
+	for _, name := range names {
+		b.WriteString("\n")
+		switch t := s.types[name].(type) {
+		case *Scalar:
+			writeDescription(&b, t.Description, "")
+			b.WriteString("scalar " + t.Name + "\n")
+		case *Object:
+			writeDescription(&b, t.Description, "")
+			b.WriteString("type " + t.Name + " {\n")
+			for _, field := range t.Fields {
+				writeDescription(&b, field.Description, "  ")
+				b.WriteString("  " + field.Name)
+				if len(field.Args) > 0 {
+					args := make([]string, len(field.Args))
+					for i, arg := range field.Args {
+						args[i] = arg.Name + ": " + arg.Type.String()
+						if arg.Default != nil {
+							args[i] += " = " + literal(arg.Default)
+						}
+					}
+					b.WriteString("(" + strings.Join(args, ", ") + ")")
+				}
+				b.WriteString(": " + field.Type.String() + "\n")
+			}
+			b.WriteString("}\n")
+		}
+	}
+	return b.String()
+}
+
+func writeDescription(b *strings.Builder, description, indent string) {
+	if description != "" {
+		b.WriteString(indent + strconv.Quote(description) + "\n")
+	}
+}
+
+func literal(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case uint:
+		return strconv.Quote(strconv.FormatUint(uint64(v), 10))
+	}
+	if reflect.TypeOf(value).Kind() == reflect.Slice {
+		items := reflect.ValueOf(value)
+		parts := make([]string, items.Len())
+		for i := range parts {
+			parts[i] = literal(items.Index(i).Interface())
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccommodationHandler struct {
+	accommodationService *services.AccommodationService
+}
+
+func NewAccommodationHandler(accommodationService *services.AccommodationService) *AccommodationHandler {
+	return &AccommodationHandler{
+		accommodationService: accommodationService,
+	}
+}
+
+// ListAccommodations returns every accommodation granted in the organization,
+// revoked ones included, optionally for one user_id or invitation_id.
+func (h *AccommodationHandler) ListAccommodations(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 32)
+	invitationID, _ := strconv.ParseUint(c.Query("invitation_id"), 10, 32)
+
+	accommodations, err := h.accommodationService.ListAccommodations(c.GetUint("organization_id"), services.AccommodationFilter{
+		UserID:       uint(userID),
+		InvitationID: uint(invitationID),
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch accommodations")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Accommodations fetched successfully", accommodations)
+}
+
+func (h *AccommodationHandler) GrantAccommodation(c *gin.Context) {
+	var req services.AccommodationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	accommodation, err := h.accommodationService.GrantAccommodation(c.GetUint("organization_id"), c.GetUint("user_id"), c.GetUint("api_key_id"), req)
+	if err != nil {
+		if accommodationErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to grant accommodation")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Accommodation granted successfully", accommodation)
+}
+
+func (h *AccommodationHandler) RevokeAccommodation(c *gin.Context) {
+	accommodationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid accommodation ID")
+		return
+	}
+
+	accommodation, err := h.accommodationService.RevokeAccommodation(c.GetUint("organization_id"), uint(accommodationID), c.GetUint("user_id"), c.GetUint("api_key_id"))
+	if err != nil {
+		if accommodationErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke accommodation")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Accommodation revoked successfully", accommodation)
+}
+
+// accommodationErrorResponse maps accommodation errors to responses and reports
+// whether it wrote one.
+func accommodationErrorResponse(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrAccommodationNotFound):
+		utils.CodedErrorResponse(c, http.StatusNotFound, utils.CodeAccommodationNotFound, "Accommodation not found")
+	case errors.Is(err, services.ErrInvalidAccommodation):
+		utils.CodedErrorResponse(c, http.StatusUnprocessableEntity, utils.CodeInvalidAccommodation, err.Error())
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AnalyticsHandler struct {
+	analyticsService *services.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService *services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+// GetItemAnalysis reports item statistics for the question bank. It accepts the
+// same filters as the export, plus min_responses below which items are not flagged.
+func (h *AnalyticsHandler) GetItemAnalysis(c *gin.Context) {
+	filter, err := resultFilterFromQuery(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	minResponses := services.DefaultMinItemResponses
+	if value := c.Query("min_responses"); value != "" {
+		minResponses, err = strconv.Atoi(value)
+		if err != nil || minResponses < 1 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "min_responses must be a positive number")
+			return
+		}
+	}
+
+	report, err := h.analyticsService.ItemAnalysis(filter, minResponses)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to compute item analysis")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Item analysis computed", report)
+}
+
+// ComputeReliability stores a new version of the test's reliability report. When
+// no results have been counted since the latest version it is returned unchanged.
+func (h *AnalyticsHandler) ComputeReliability(c *gin.Context) {
+	testID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid test ID")
+		return
+	}
+
+	report, created, err := h.analyticsService.ComputeReliability(c.GetUint("organization_id"), uint(testID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Test not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to compute reliability")
+		return
+	}
+
+	if !created {
+		utils.SuccessResponse(c, http.StatusOK, "No new results since the latest report", report)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusCreated, "Reliability report computed", report)
+}
+
+func (h *AnalyticsHandler) ListReliabilityReports(c *gin.Context) {
+	testID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid test ID")
+		return
+	}
+
+	reports, err := h.analyticsService.ListReliabilityReports(c.GetUint("organization_id"), uint(testID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch reliability reports")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reliability reports fetched successfully", reports)
+}
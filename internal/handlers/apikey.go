@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.ListAPIKeys(c.GetUint("organization_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch API keys")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API keys fetched successfully", keys)
+}
+
+// CreateAPIKey responds with the plaintext key. It is not shown again.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req services.APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	key, plaintext, err := h.apiKeyService.CreateAPIKey(c.GetUint("organization_id"), userID.(uint), c.GetStringSlice("permissions"), req)
+	if err != nil {
+		if apiKeyErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "API key created successfully", map[string]interface{}{
+		"api_key": key,
+		"key":     plaintext,
+	})
+}
+
+// RotateAPIKey issues a replacement key. The optional grace query parameter (a
+// duration such as 24h) keeps the old key valid while integrations switch over.
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	var grace time.Duration
+	if value := c.Query("grace"); value != "" {
+		grace, err = time.ParseDuration(value)
+		if err != nil || grace < 0 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "grace must be a duration such as 24h")
+			return
+		}
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	key, plaintext, err := h.apiKeyService.RotateAPIKey(c.GetUint("organization_id"), uint(keyID), userID.(uint), c.GetStringSlice("permissions"), grace)
+	if err != nil {
+		if apiKeyErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to rotate API key")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "API key rotated successfully", map[string]interface{}{
+		"api_key": key,
+		"key":     plaintext,
+	})
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	key, err := h.apiKeyService.RevokeAPIKey(c.GetUint("organization_id"), uint(keyID))
+	if err != nil {
+		if apiKeyErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API key revoked successfully", key)
+}
+
+// apiKeyErrorResponse maps API key errors to responses and reports whether it wrote one.
+func apiKeyErrorResponse(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrAPIKeyNotFound):
+		utils.CodedErrorResponse(c, http.StatusNotFound, utils.CodeAPIKeyNotFound, "API key not found")
+	case errors.Is(err, services.ErrAPIKeyInvalid):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeAPIKeyInactive, "API key is expired or revoked")
+	case errors.Is(err, services.ErrAPIKeyScope):
+		utils.CodedErrorResponse(c, http.StatusBadRequest, utils.CodeAPIKeyScope, err.Error())
+	default:
+		return false
+	}
+	return true
+}
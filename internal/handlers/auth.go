@@ -35,7 +35,7 @@ type LoginRequest struct {
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.BindingErrorResponse(c, err)
 		return
 	}
 
@@ -46,18 +46,19 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	user := &models.User{
-		Email:     req.Email,
-		Password:  hashedPassword,
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
+		OrganizationID: c.GetUint("tenant_id"),
+		Email:          req.Email,
+		Password:       hashedPassword,
+		FirstName:      req.FirstName,
+		LastName:       req.LastName,
 	}
 
-	if err := h.userService.CreateUser(user); err != nil {
-		utils.ErrorResponse(c, http.StatusConflict, "User already exists")
+	if err := h.userService.CreateUser(user, models.RoleCandidate); err != nil {
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeEmailTaken, "User already exists")
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Email)
+	token, err := utils.GenerateToken(user)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
 		return
@@ -74,22 +75,22 @@ func (h *AuthHandler) Register(c *gin.Context) {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.BindingErrorResponse(c, err)
 		return
 	}
 
-	user, err := h.userService.GetUserByEmail(req.Email)
+	user, err := h.userService.GetUserByEmail(c.GetUint("tenant_id"), req.Email)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid credentials")
+		utils.CodedErrorResponse(c, http.StatusUnauthorized, utils.CodeInvalidCredentials, "Invalid credentials")
 		return
 	}
 
 	if err := utils.CheckPassword(user.Password, req.Password); err != nil {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid credentials")
+		utils.CodedErrorResponse(c, http.StatusUnauthorized, utils.CodeInvalidCredentials, "Invalid credentials")
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Email)
+	token, err := utils.GenerateToken(user)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
 		return
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"iq-go/internal/config"
+	"iq-go/internal/models"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CertificateHandler struct {
+	certificateService *services.CertificateService
+	cfg                *config.Config
+}
+
+func NewCertificateHandler(certificateService *services.CertificateService, cfg *config.Config) *CertificateHandler {
+	return &CertificateHandler{
+		certificateService: certificateService,
+		cfg:                cfg,
+	}
+}
+
+type VerifyCertificateRequest struct {
+	Payload   string `json:"payload" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+type PublishedCertificate struct {
+	*models.Certificate
+	URL string `json:"url"`
+}
+
+func (h *CertificateHandler) PublishResult(c *gin.Context) {
+	resultID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid result ID")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	certificate, err := h.certificateService.PublishResult(c.GetUint("organization_id"), userID.(uint), uint(resultID))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "Result not found")
+		case errors.Is(err, services.ErrResultIncomplete):
+			utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeResultIncomplete, "Result has not been completed")
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to publish certificate")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Certificate published successfully", h.published(certificate))
+}
+
+func (h *CertificateHandler) ListCertificates(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	certificates, err := h.certificateService.ListCertificates(c.GetUint("organization_id"), userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch certificates")
+		return
+	}
+
+	published := make([]PublishedCertificate, len(certificates))
+	for i := range certificates {
+		published[i] = h.published(&certificates[i])
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Certificates fetched successfully", published)
+}
+
+func (h *CertificateHandler) RevokeCertificate(c *gin.Context) {
+	certificateID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid certificate ID")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	if err := h.certificateService.RevokeCertificate(c.GetUint("organization_id"), userID.(uint), uint(certificateID)); err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Certificate not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Certificate revoked successfully", nil)
+}
+
+func (h *CertificateHandler) GetPublicCertificate(c *gin.Context) {
+	certificate, err := h.certificateService.GetPublicCertificate(c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, services.ErrCertificateRevoked) {
+			utils.CodedErrorResponse(c, http.StatusGone, utils.CodeCertificateRevoked, "Certificate has been revoked")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusNotFound, "Certificate not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Certificate fetched successfully", map[string]interface{}{
+		"payload":   certificate.Payload,
+		"signature": certificate.Signature,
+	})
+}
+
+func (h *CertificateHandler) VerifyCertificate(c *gin.Context) {
+	var req VerifyCertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	verification, err := h.certificateService.Verify(req.Payload, req.Signature)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to verify certificate")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Certificate verified", verification)
+}
+
+func (h *CertificateHandler) GetPublicKey(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "Public key fetched successfully", map[string]string{
+		"algorithm":  "Ed25519",
+		"public_key": base64.StdEncoding.EncodeToString(utils.SigningPublicKey()),
+	})
+}
+
+// ShowCertificatePage renders the public, read-only certificate page.
+func (h *CertificateHandler) ShowCertificatePage(c *gin.Context) {
+	certificate, err := h.certificateService.GetPublicCertificate(c.Param("slug"))
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, services.ErrCertificateRevoked) {
+			status = http.StatusGone
+		}
+		c.HTML(status, "certificate.html", gin.H{"unavailable": true})
+		return
+	}
+
+	var payload models.CertificatePayload
+	if err := json.Unmarshal([]byte(certificate.Payload), &payload); err != nil {
+		c.HTML(http.StatusInternalServerError, "certificate.html", gin.H{"unavailable": true})
+		return
+	}
+
+	c.HTML(http.StatusOK, "certificate.html", gin.H{
+		"certificate": payload,
+		"payload":     certificate.Payload,
+		"signature":   certificate.Signature,
+	})
+}
+
+func (h *CertificateHandler) published(certificate *models.Certificate) PublishedCertificate {
+	return PublishedCertificate{
+		Certificate: certificate,
+		URL:         h.cfg.AppURL + "/certificates/" + certificate.Slug,
+	}
+}
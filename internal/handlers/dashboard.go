@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type DashboardHandler struct {
+	dashboardService *services.DashboardService
+}
+
+func NewDashboardHandler(dashboardService *services.DashboardService) *DashboardHandler {
+	return &DashboardHandler{
+		dashboardService: dashboardService,
+	}
+}
+
+// GetOrganizationDashboard summarises every test for attempts started between the
+// optional from and to dates.
+func (h *DashboardHandler) GetOrganizationDashboard(c *gin.Context) {
+	filter, err := resultFilterFromQuery(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dashboard, err := h.dashboardService.GetOrganizationDashboard(filter)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load dashboard")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Dashboard fetched successfully", dashboard)
+}
+
+func (h *DashboardHandler) GetTestDashboard(c *gin.Context) {
+	testID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid test ID")
+		return
+	}
+
+	filter, err := resultFilterFromQuery(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.TestID = uint(testID)
+
+	bins := services.DefaultHistogramBins
+	if value := c.Query("bins"); value != "" {
+		bins, err = strconv.Atoi(value)
+		if err != nil || bins < 1 || bins > services.MaxHistogramBins {
+			utils.ErrorResponse(c, http.StatusBadRequest, "bins must be between 1 and 50")
+			return
+		}
+	}
+
+	dashboard, err := h.dashboardService.GetTestDashboard(filter, bins)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Test not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load dashboard")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Dashboard fetched successfully", dashboard)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"iq-go/internal/auth"
+	"iq-go/internal/export"
+	"iq-go/internal/models"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rows written between flushes of a streamed export
+const exportFlushInterval = 500
+
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// ExportResults streams answers joined with their results, questions and users as
+// CSV or JSONL. Platform admins may pass organization_id=0 to export every organization.
+func (h *ExportHandler) ExportResults(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+
+	filter, err := resultFilterFromQuery(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writer, err := export.NewWriter(format, c.Writer)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Format must be csv or jsonl")
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="results-%s.%s"`, time.Now().Format("20060102"), format))
+	c.Status(http.StatusOK)
+
+	written := 0
+	err = h.exportService.StreamAnswers(filter, func(row *services.ExportRow) error {
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+		if written++; written%exportFlushInterval == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		// Headers are already sent, so the client only sees a truncated body.
+		log.Printf("Export failed after %d rows: %v", written, err)
+		c.Abort()
+	}
+}
+
+func resultFilterFromQuery(c *gin.Context) (services.ResultFilter, error) {
+	filter := services.ResultFilter{OrganizationID: c.GetUint("organization_id")}
+
+	if value, given := c.GetQuery("organization_id"); given {
+		if !auth.HasPermission(c.GetStringSlice("permissions"), models.PermManageOrgs) {
+			return filter, errors.New("organization_id is only available to platform admins")
+		}
+		orgID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return filter, errors.New("invalid organization ID")
+		}
+		filter.OrganizationID = uint(orgID)
+	}
+
+	if value := c.Query("test_id"); value != "" {
+		testID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return filter, errors.New("invalid test ID")
+		}
+		filter.TestID = uint(testID)
+	}
+
+	var err error
+	if filter.From, err = parseDateParam(c.Query("from")); err != nil {
+		return filter, errors.New("from must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+	}
+	if filter.To, err = parseEndDateParam(c.Query("to")); err != nil {
+		return filter, errors.New("to must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+	}
+
+	return filter, nil
+}
+
+// parseDateParam accepts an RFC 3339 timestamp or a plain date. Empty input is no bound.
+func parseDateParam(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// parseEndDateParam is parseDateParam for an exclusive upper bound. A plain date
+// includes the whole day, so it becomes the following midnight.
+func parseEndDateParam(value string) (*time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		t = t.AddDate(0, 0, 1)
+		return &t, nil
+	}
+	return parseDateParam(value)
+}
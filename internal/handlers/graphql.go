@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"iq-go/internal/auth"
+	"iq-go/internal/graphql"
+	"iq-go/internal/models"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GraphQLHandler struct {
+	graphQLService *services.GraphQLService
+}
+
+func NewGraphQLHandler(graphQLService *services.GraphQLService) *GraphQLHandler {
+	return &GraphQLHandler{
+		graphQLService: graphQLService,
+	}
+}
+
+// GraphQLRequest is a GraphQL request as POSTed in JSON.
+type GraphQLRequest struct {
+	graphql.Request
+}
+
+// Query executes a GraphQL query. The response is a GraphQL response rather
+// than the API's envelope; errors in the query are reported inside it.
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var req GraphQLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	viewer := services.GraphQLViewer{
+		OrganizationID: c.GetUint("organization_id"),
+		UserID:         c.GetUint("user_id"),
+		ReadAllResults: auth.HasPermission(c.GetStringSlice("permissions"), models.PermReadAllResults),
+	}
+	c.JSON(http.StatusOK, h.graphQLService.Execute(c.Request.Context(), viewer, req.Request))
+}
+
+func (h *GraphQLHandler) GetSchema(c *gin.Context) {
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(h.graphQLService.Schema()))
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"iq-go/internal/config"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxBulkInvitations = 1000
+
+type InvitationHandler struct {
+	invitationService *services.InvitationService
+	cfg               *config.Config
+}
+
+func NewInvitationHandler(invitationService *services.InvitationService, cfg *config.Config) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+		cfg:               cfg,
+	}
+}
+
+type CreateInvitationsRequest struct {
+	TestID     uint                           `json:"test_id" binding:"required"`
+	ExpiresAt  time.Time                      `json:"expires_at" binding:"required"`
+	Recipients []services.InvitationRecipient `json:"recipients" binding:"required,min=1,dive"`
+}
+
+type BulkInvitationsRequest struct {
+	TestID    uint      `form:"test_id" binding:"required"`
+	ExpiresAt time.Time `form:"expires_at" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type InvitationLink struct {
+	services.IssuedInvitation
+	URL string `json:"url"`
+}
+
+func (h *InvitationHandler) CreateInvitations(c *gin.Context) {
+	var req CreateInvitationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	h.issue(c, req.TestID, req.ExpiresAt, req.Recipients)
+}
+
+// BulkCreateInvitations accepts a multipart upload with a "file" CSV of email,name rows.
+// A header row is detected and skipped.
+func (h *InvitationHandler) BulkCreateInvitations(c *gin.Context) {
+	var req BulkInvitationsRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "CSV file is required")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to read CSV file")
+		return
+	}
+	defer file.Close()
+
+	recipients, err := parseRecipientsCSV(file)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.issue(c, req.TestID, req.ExpiresAt, recipients)
+}
+
+func (h *InvitationHandler) ListInvitations(c *gin.Context) {
+	testID, _ := strconv.ParseUint(c.Query("test_id"), 10, 32)
+
+	invitations, err := h.invitationService.ListInvitations(c.GetUint("organization_id"), uint(testID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch invitations")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Invitations fetched successfully", invitations)
+}
+
+func (h *InvitationHandler) GetInvitation(c *gin.Context) {
+	invitationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	invitation, err := h.invitationService.GetInvitationByID(c.GetUint("organization_id"), uint(invitationID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Invitation not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Invitation fetched successfully", invitation)
+}
+
+func (h *InvitationHandler) OpenInvitation(c *gin.Context) {
+	invitation, err := h.invitationService.OpenInvitation(c.Param("token"))
+	if err != nil {
+		invitationErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Invitation fetched successfully", invitation)
+}
+
+func (h *InvitationHandler) StartInvitation(c *gin.Context) {
+	invitation, user, err := h.invitationService.StartInvitation(c.Param("token"))
+	if err != nil {
+		invitationErrorResponse(c, err)
+		return
+	}
+
+	token, err := utils.GenerateInvitationToken(user, invitation.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	c.SetCookie("token", token, 86400, "/", "", false, true)
+
+	utils.SuccessResponse(c, http.StatusOK, "Invitation started successfully", map[string]interface{}{
+		"invitation": invitation,
+		"token":      token,
+	})
+}
+
+func (h *InvitationHandler) issue(c *gin.Context, testID uint, expiresAt time.Time, recipients []services.InvitationRecipient) {
+	if !expiresAt.After(time.Now()) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Expiry must be in the future")
+		return
+	}
+	if len(recipients) > maxBulkInvitations {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Too many recipients")
+		return
+	}
+
+	issued, err := h.invitationService.CreateInvitations(c.GetUint("organization_id"), c.GetUint("user_id"), c.GetUint("api_key_id"), testID, recipients, expiresAt)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Test not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create invitations")
+		return
+	}
+
+	links := make([]InvitationLink, len(issued))
+	for i, invitation := range issued {
+		links[i] = InvitationLink{
+			IssuedInvitation: invitation,
+			URL:              h.cfg.AppURL + "/invite/" + invitation.Token,
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Invitations created successfully", links)
+}
+
+func invitationErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvitationInvalid):
+		utils.CodedErrorResponse(c, http.StatusNotFound, utils.CodeInvitationNotFound, "Invitation not found")
+	case errors.Is(err, services.ErrInvitationExpired):
+		utils.CodedErrorResponse(c, http.StatusGone, utils.CodeInvitationExpired, "Invitation has expired")
+	case errors.Is(err, services.ErrInvitationUsed):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeInvitationUsed, "Invitation has already been used")
+	case errors.Is(err, services.ErrInvitationEmailUsed):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeEmailInOtherOrg, "Email is registered with another organization")
+	case errors.Is(err, services.ErrInvitationStaff):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeEmailIsStaff, "Email belongs to a staff account and cannot take invited tests")
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process invitation")
+	}
+}
+
+func parseRecipientsCSV(r io.Reader) ([]services.InvitationRecipient, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var recipients []services.InvitationRecipient
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("Malformed CSV file")
+		}
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		email := strings.TrimSpace(record[0])
+		if line == 1 && strings.EqualFold(email, "email") {
+			continue
+		}
+		if !strings.Contains(email, "@") {
+			return nil, errors.New("Invalid email on line " + strconv.Itoa(line))
+		}
+
+		recipient := services.InvitationRecipient{Email: email}
+		if len(record) > 1 {
+			recipient.Name = strings.TrimSpace(record[1])
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	if len(recipients) == 0 {
+		return nil, errors.New("CSV file contains no recipients")
+	}
+	return recipients, nil
+}
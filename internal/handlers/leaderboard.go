@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"iq-go/internal/models"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type LeaderboardHandler struct {
+	leaderboardService *services.LeaderboardService
+}
+
+func NewLeaderboardHandler(leaderboardService *services.LeaderboardService) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		leaderboardService: leaderboardService,
+	}
+}
+
+type LeaderboardProfileRequest struct {
+	OptedIn             bool `json:"opted_in"`
+	RegeneratePseudonym bool `json:"regenerate_pseudonym"`
+}
+
+func (h *LeaderboardHandler) GetProfile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	profile, err := h.leaderboardService.GetProfile(userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch leaderboard profile")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leaderboard profile fetched successfully", profile)
+}
+
+// UpdateProfile opts the user in or out of leaderboards. Opting out hides the
+// user's entries immediately; they reappear if the user opts back in.
+func (h *LeaderboardHandler) UpdateProfile(c *gin.Context) {
+	var req LeaderboardProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	profile, err := h.leaderboardService.UpdateProfile(userID.(uint), req.OptedIn, req.RegeneratePseudonym)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update leaderboard profile")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leaderboard profile updated successfully", profile)
+}
+
+// GetTestLeaderboard ranks the organization's users on one test, overall or in the
+// category given by the category query parameter.
+func (h *LeaderboardHandler) GetTestLeaderboard(c *gin.Context) {
+	testID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid test ID")
+		return
+	}
+
+	query, ok := leaderboardQueryFromRequest(c, models.Category(c.Query("category")))
+	if !ok {
+		return
+	}
+	query.TestID = uint(testID)
+
+	h.respondWithLeaderboard(c, query)
+}
+
+// GetCategoryLeaderboard ranks users on their best score in a category across
+// tests, within the organization or, with scope=global, across organizations.
+func (h *LeaderboardHandler) GetCategoryLeaderboard(c *gin.Context) {
+	query, ok := leaderboardQueryFromRequest(c, models.Category(c.Param("category")))
+	if !ok {
+		return
+	}
+	if query.Category == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid category")
+		return
+	}
+
+	switch c.DefaultQuery("scope", "organization") {
+	case "organization":
+	case "global":
+		query.Global = true
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "scope must be organization or global")
+		return
+	}
+
+	h.respondWithLeaderboard(c, query)
+}
+
+func (h *LeaderboardHandler) respondWithLeaderboard(c *gin.Context, query services.LeaderboardQuery) {
+	board, err := h.leaderboardService.GetLeaderboard(query)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Test not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch leaderboard")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Leaderboard fetched successfully", board)
+}
+
+// leaderboardQueryFromRequest reads the parameters shared by every leaderboard and
+// responds with an error when one is invalid.
+func leaderboardQueryFromRequest(c *gin.Context, category models.Category) (services.LeaderboardQuery, bool) {
+	userID, _ := c.Get("user_id")
+	viewerID, _ := userID.(uint)
+	query := services.LeaderboardQuery{
+		OrganizationID: c.GetUint("organization_id"),
+		Category:       category,
+		Period:         models.LeaderboardPeriod(c.DefaultQuery("period", string(models.PeriodAllTime))),
+		Date:           time.Now(),
+		Limit:          services.DefaultLeaderboardSize,
+		ViewerID:       viewerID,
+	}
+
+	switch category {
+	case "", models.AnalyticalReasoning, models.WorkingMemory, models.ProcessingSpeed,
+		models.AttentionFocus, models.EmotionalRegulation:
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid category")
+		return query, false
+	}
+
+	switch query.Period {
+	case models.PeriodAllTime, models.PeriodWeek, models.PeriodMonth:
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "period must be all, week or month")
+		return query, false
+	}
+
+	date, err := parseDateParam(c.Query("date"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "date must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+		return query, false
+	}
+	if date != nil {
+		query.Date = *date
+	}
+
+	if value := c.Query("limit"); value != "" {
+		query.Limit, err = strconv.Atoi(value)
+		if err != nil || query.Limit < 1 || query.Limit > services.MaxLeaderboardSize {
+			utils.ErrorResponse(c, http.StatusBadRequest, "limit must be between 1 and 100")
+			return query, false
+		}
+	}
+
+	return query, true
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"iq-go/internal/lti"
+	"iq-go/internal/models"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ltiStateCookie prefixes the cookie that binds a login's state to the browser that
+// started it.
+const ltiStateCookie = "lti_state_"
+
+type LTIHandler struct {
+	ltiService *services.LTIService
+}
+
+func NewLTIHandler(ltiService *services.LTIService) *LTIHandler {
+	return &LTIHandler{
+		ltiService: ltiService,
+	}
+}
+
+// GetToolConfiguration returns the URLs a platform is registered with.
+func (h *LTIHandler) GetToolConfiguration(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "Tool configuration fetched successfully", h.ltiService.ToolConfiguration())
+}
+
+func (h *LTIHandler) ListPlatforms(c *gin.Context) {
+	platforms, err := h.ltiService.ListPlatforms(c.GetUint("organization_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch LTI platforms")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "LTI platforms fetched successfully", platforms)
+}
+
+func (h *LTIHandler) CreatePlatform(c *gin.Context) {
+	var req services.LTIPlatformRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	platform, err := h.ltiService.CreatePlatform(c.GetUint("organization_id"), req)
+	if err != nil {
+		if ltiErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to register LTI platform")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "LTI platform registered successfully", platform)
+}
+
+func (h *LTIHandler) UpdatePlatform(c *gin.Context) {
+	platformID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid platform ID")
+		return
+	}
+
+	var req services.LTIPlatformRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	platform, err := h.ltiService.UpdatePlatform(c.GetUint("organization_id"), uint(platformID), req)
+	if err != nil {
+		if ltiErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update LTI platform")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "LTI platform updated successfully", platform)
+}
+
+func (h *LTIHandler) DeletePlatform(c *gin.Context) {
+	platformID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid platform ID")
+		return
+	}
+
+	if err := h.ltiService.DeletePlatform(c.GetUint("organization_id"), uint(platformID)); err != nil {
+		if ltiErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete LTI platform")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "LTI platform deleted successfully", nil)
+}
+
+// GetJWKS serves the tool's public key as a bare key set, the format platforms fetch.
+func (h *LTIHandler) GetJWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, h.ltiService.KeySet())
+}
+
+// Login answers a platform's third-party initiated login by sending the browser to
+// the platform's authorization endpoint. Platforms may use GET or POST.
+func (h *LTIHandler) Login(c *gin.Context) {
+	var req services.LTILoginRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.launchError(c, http.StatusBadRequest, "The learning platform sent an incomplete login request.", err)
+		return
+	}
+
+	authURL, state, err := h.ltiService.StartLogin(req)
+	if err != nil {
+		h.launchError(c, launchErrorStatus(err), "This learning platform is not registered.", err)
+		return
+	}
+
+	// The platform posts the launch back from its own site, often inside a frame.
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie(ltiStateCookie+state, state, 0, "/lti/launch", "", true, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Launch signs in the user of a verified launch and opens the linked test, or the
+// test picker for deep linking requests.
+func (h *LTIHandler) Launch(c *gin.Context) {
+	// A launch for a login started in another browser would sign this one in as
+	// someone else.
+	state := c.PostForm("state")
+	if cookie, err := c.Cookie(ltiStateCookie + state); err != nil || cookie != state {
+		h.launchError(c, http.StatusUnauthorized, launchErrorMessage(services.ErrLTILoginInvalid), errors.New("login was not started in this browser"))
+		return
+	}
+	c.SetSameSite(http.SameSiteNoneMode)
+	c.SetCookie(ltiStateCookie+state, "", -1, "/lti/launch", "", true, true)
+	c.SetSameSite(http.SameSiteDefaultMode)
+
+	launch, err := h.ltiService.Launch(c.Request.Context(), state, c.PostForm("id_token"))
+	if err != nil {
+		h.launchError(c, launchErrorStatus(err), launchErrorMessage(err), err)
+		return
+	}
+
+	if launch.MessageType == lti.MessageDeepLinking {
+		tests, err := h.ltiService.DeepLinkTests(launch.Token)
+		if err != nil {
+			h.launchError(c, http.StatusInternalServerError, "Tests could not be loaded.", err)
+			return
+		}
+		c.HTML(http.StatusOK, "lti_deep_link.html", gin.H{"token": launch.Token, "tests": tests})
+		return
+	}
+
+	token, err := utils.GenerateToken(launch.User)
+	if err != nil {
+		h.launchError(c, http.StatusInternalServerError, "Sign in failed.", err)
+		return
+	}
+	c.SetCookie("token", token, 86400, "/", "", false, true)
+
+	redirect := "/"
+	for _, permission := range launch.User.PermissionNames() {
+		if permission == models.PermTakeTests {
+			redirect = "/test?test_id=" + strconv.FormatUint(uint64(launch.TestID), 10)
+		}
+	}
+	c.HTML(http.StatusOK, "lti_launch.html", gin.H{"token": token, "redirect": redirect})
+}
+
+// DeepLink sends the test picked for a deep linking request back to the platform.
+func (h *LTIHandler) DeepLink(c *gin.Context) {
+	testID, err := strconv.ParseUint(c.PostForm("test_id"), 10, 32)
+	if err != nil {
+		h.launchError(c, http.StatusBadRequest, "Choose a test to add.", err)
+		return
+	}
+
+	returnURL, response, err := h.ltiService.DeepLinkResponse(c.PostForm("token"), uint(testID))
+	if err != nil {
+		h.launchError(c, launchErrorStatus(err), launchErrorMessage(err), err)
+		return
+	}
+
+	c.HTML(http.StatusOK, "lti_deep_link.html", gin.H{"returnURL": returnURL, "response": response})
+}
+
+// launchError shows a failed launch in the platform's frame. The cause is logged
+// rather than shown, as it may reveal why a forged launch was rejected.
+func (h *LTIHandler) launchError(c *gin.Context, status int, message string, err error) {
+	log.Printf("LTI %s failed: %v", c.Request.URL.Path, err)
+	c.HTML(status, "lti_launch.html", gin.H{"error": message})
+}
+
+func launchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrLTIPlatformNotFound), errors.Is(err, services.ErrLTINoTest):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrLTILoginInvalid), errors.Is(err, services.ErrLTILaunchInvalid):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrLTIDeepLinkDenied):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrLTIEmailUsed), errors.Is(err, services.ErrLTIAccountExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func launchErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, services.ErrLTIPlatformNotFound):
+		return "This learning platform is not registered."
+	case errors.Is(err, services.ErrLTINoTest):
+		return "No test has been linked here yet. Ask your instructor to choose one."
+	case errors.Is(err, services.ErrLTILoginInvalid):
+		return "The launch has expired. Please open the activity again."
+	case errors.Is(err, services.ErrLTILaunchInvalid):
+		return "The launch could not be verified."
+	case errors.Is(err, services.ErrLTIDeepLinkDenied):
+		return "You do not have permission to add tests to this course."
+	case errors.Is(err, services.ErrLTIEmailUsed):
+		return "Your email address is registered with another organization."
+	case errors.Is(err, services.ErrLTIAccountExists):
+		return "An account with your email address already exists. Ask an administrator to let this platform link accounts by email."
+	default:
+		return "Something went wrong. Please try again."
+	}
+}
+
+// ltiErrorResponse maps LTI platform errors to responses and reports whether it wrote one.
+func ltiErrorResponse(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrLTIPlatformNotFound):
+		utils.CodedErrorResponse(c, http.StatusNotFound, utils.CodeLTIPlatformNotFound, "LTI platform not found")
+	case errors.Is(err, services.ErrLTIPlatformExists):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeLTIPlatformExists, "An LTI platform with this issuer and client ID is already registered")
+	case errors.Is(err, services.ErrLTIStaffRole):
+		utils.CodedErrorResponse(c, http.StatusBadRequest, utils.CodeLTIStaffRole, "Staff roles must name existing roles that act within one organization")
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"iq-go/internal/models"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationHandler struct {
+	organizationService *services.OrganizationService
+}
+
+func NewOrganizationHandler(organizationService *services.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+	}
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+	Slug string `json:"slug" binding:"required,alphanum"`
+}
+
+func (h *OrganizationHandler) ListOrganizations(c *gin.Context) {
+	organizations, err := h.organizationService.ListOrganizations()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch organizations")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Organizations fetched successfully", organizations)
+}
+
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var req CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	organization := &models.Organization{
+		Name: req.Name,
+		Slug: strings.ToLower(req.Slug),
+	}
+
+	if err := h.organizationService.CreateOrganization(organization); err != nil {
+		utils.ErrorResponse(c, http.StatusConflict, "Organization already exists")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Organization created successfully", organization)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func (h *TestHandler) StartPractice(c *gin.Context) {
+	testID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid test ID")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	if !h.checkInvitedTest(c, uint(testID)) {
+		return
+	}
+
+	session, questions, err := h.testService.StartPractice(c.GetUint("organization_id"), userID.(uint), uint(testID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Test not found")
+			return
+		}
+		if practiceErrorResponse(c, err) || attemptErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start practice")
+		return
+	}
+
+	// Answers are revealed one at a time as they are submitted.
+	for i := range questions {
+		questions[i].HideAnswer()
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Practice started successfully", map[string]interface{}{
+		"session":   session,
+		"questions": questions,
+	})
+}
+
+// AnswerPractice grades a single practice answer and responds with the feedback.
+func (h *TestHandler) AnswerPractice(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid practice session ID")
+		return
+	}
+
+	var req services.SubmitAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	feedback, err := h.testService.AnswerPractice(c.GetUint("organization_id"), userID.(uint), uint(sessionID), req)
+	if err != nil {
+		if practiceErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to grade answer")
+		return
+	}
+
+	message := "Answer recorded"
+	if feedback.IsCorrect != nil {
+		message = "Incorrect"
+		if *feedback.IsCorrect {
+			message = "Correct"
+		}
+	}
+	utils.SuccessResponse(c, http.StatusOK, message, feedback)
+}
+
+func (h *TestHandler) FinishPractice(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid practice session ID")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	session, err := h.testService.FinishPractice(c.GetUint("organization_id"), userID.(uint), uint(sessionID))
+	if err != nil {
+		if practiceErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to finish practice")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Practice finished", session)
+}
+
+func (h *TestHandler) ListPracticeSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	sessions, err := h.testService.ListPracticeSessions(c.GetUint("organization_id"), userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch practice sessions")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Practice sessions fetched successfully", sessions)
+}
+
+// practiceErrorResponse writes the response for practice errors and reports whether err was one.
+func practiceErrorResponse(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrPracticeDisabled):
+		utils.CodedErrorResponse(c, http.StatusForbidden, utils.CodePracticeDisabled, "Practice is not enabled for this test")
+	case errors.Is(err, services.ErrPracticeNotFound):
+		utils.CodedErrorResponse(c, http.StatusNotFound, utils.CodePracticeNotFound, "Practice session not found")
+	case errors.Is(err, services.ErrPracticeFinished):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodePracticeFinished, "Practice session has already finished")
+	case errors.Is(err, services.ErrPracticeAttemptOpen):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodePracticeAttemptOpen, "Practice is unavailable while an attempt at this test is open")
+	case errors.Is(err, services.ErrPracticeAnswered):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeQuestionAnswered, "Question has already been answered")
+	case errors.Is(err, services.ErrQuestionNotInSession):
+		utils.CodedErrorResponse(c, http.StatusUnprocessableEntity, utils.CodeQuestionNotInSession, "Question is not part of this practice session")
+	default:
+		return false
+	}
+	return true
+}
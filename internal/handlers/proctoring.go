@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// how often an idle event stream sends a comment, so proxies keep it open
+const proctoringHeartbeat = 15 * time.Second
+
+type ActivityRequest struct {
+	Type          services.ProctoringEventType `json:"type" binding:"required,oneof=question_advanced answer_saved focus_lost"`
+	QuestionIndex *int                         `json:"question_index"`
+}
+
+// ReportActivity lets a candidate report what they do in an attempt in progress,
+// for the proctors watching it.
+func (h *TestHandler) ReportActivity(c *gin.Context) {
+	attemptID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attempt ID")
+		return
+	}
+
+	var req ActivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	err = h.proctoringService.RecordActivity(c.Request.Context(), c.GetUint("organization_id"), userID.(uint), uint(attemptID), services.Activity{
+		Type:          req.Type,
+		QuestionIndex: req.QuestionIndex,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidActivity) {
+			utils.CodedErrorResponse(c, http.StatusUnprocessableEntity, utils.CodeInvalidActivity, err.Error())
+			return
+		}
+		if attemptErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to record activity")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Activity recorded", nil)
+}
+
+// StreamTestEvents streams the events of every attempt at a test as Server-Sent
+// Events until the client disconnects. Each event is a JSON ProctoringEvent in
+// the data field. Events from before the client connected are not replayed.
+func (h *TestHandler) StreamTestEvents(c *gin.Context) {
+	testID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid test ID")
+		return
+	}
+
+	events, err := h.proctoringService.Subscribe(c.Request.Context(), c.GetUint("organization_id"), uint(testID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Test not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to subscribe to events")
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	io.WriteString(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(proctoringHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case message, ok := <-events:
+			if !ok {
+				return // the client disconnected
+			}
+			fmt.Fprintf(c.Writer, "data: %s\n\n", message)
+		case <-heartbeat.C:
+			io.WriteString(c.Writer, ": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
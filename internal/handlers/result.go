@@ -1,9 +1,15 @@
 package handlers
 
 import (
+	"bytes"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"iq-go/internal/auth"
+	"iq-go/internal/models"
+	"iq-go/internal/reports"
 	"iq-go/internal/services"
 	"iq-go/internal/utils"
 
@@ -12,14 +18,24 @@ import (
 
 type ResultHandler struct {
 	resultService *services.ResultService
+	reportService *services.ReportService
 }
 
-func NewResultHandler(resultService *services.ResultService) *ResultHandler {
+func NewResultHandler(resultService *services.ResultService, reportService *services.ReportService) *ResultHandler {
 	return &ResultHandler{
 		resultService: resultService,
+		reportService: reportService,
 	}
 }
 
+type ReportTemplateRequest struct {
+	Title        string `json:"title" binding:"required,max=100"`
+	Subtitle     string `json:"subtitle" binding:"max=100"`
+	PrimaryColor string `json:"primary_color" binding:"omitempty,hexcolor"`
+	FooterText   string `json:"footer_text" binding:"max=200"`
+	ShowReview   bool   `json:"show_review"`
+}
+
 func (h *ResultHandler) GetResults(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -27,7 +43,7 @@ func (h *ResultHandler) GetResults(c *gin.Context) {
 		return
 	}
 
-	results, err := h.resultService.GetResultsByUserID(userID.(uint))
+	results, err := h.resultService.GetResultsByUserID(c.GetUint("organization_id"), userID.(uint))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch results")
 		return
@@ -37,24 +53,192 @@ func (h *ResultHandler) GetResults(c *gin.Context) {
 }
 
 func (h *ResultHandler) GetResult(c *gin.Context) {
+	result, ok := h.loadResult(c)
+	if !ok {
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Result fetched successfully", result)
+}
+
+func (h *ResultHandler) GetReport(c *gin.Context) {
+	result, ok := h.loadResult(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.reportService.GetReportTemplate(result.OrganizationID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to load report template")
+		return
+	}
+
+	organization := ""
+	if result.User.Organization != nil {
+		organization = result.User.Organization.Name
+	}
+
+	var buf bytes.Buffer
+	err = reports.WriteResultPDF(&buf, reports.ResultReport{
+		Result:       result,
+		User:         result.User,
+		Organization: organization,
+		Template:     template,
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate report")
+		return
+	}
+
+	filename := fmt.Sprintf("result-%d.pdf", result.ID)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// GetReview lists the attempt's answers with the correct answers and explanations,
+// as far as the test's reveal policy allows.
+func (h *ResultHandler) GetReview(c *gin.Context) {
+	result, ok := h.loadResult(c)
+	if !ok {
+		return
+	}
+
+	review := services.BuildReview(result, answersVisible(c, result))
+	utils.SuccessResponse(c, http.StatusOK, "Review fetched successfully", review)
+}
+
+// loadResult fetches the result named by the :id parameter. Staff who may read all
+// results get any result in the organization; everyone else only their own.
+func (h *ResultHandler) loadResult(c *gin.Context) (*models.TestResult, bool) {
 	resultIDStr := c.Param("id")
 	resultID, err := strconv.ParseUint(resultIDStr, 10, 32)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid result ID")
+		return nil, false
+	}
+
+	var result *models.TestResult
+	if auth.HasPermission(c.GetStringSlice("permissions"), models.PermReadAllResults) {
+		result, err = h.resultService.GetAnyResultByID(c.GetUint("organization_id"), uint(resultID))
+	} else {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+			return nil, false
+		}
+		result, err = h.resultService.GetResultByID(c.GetUint("organization_id"), uint(resultID), userID.(uint))
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Result not found")
+		return nil, false
+	}
+
+	// Candidates only see correct answers once the test's reveal policy allows it.
+	if !answersVisible(c, result) {
+		for i := range result.Answers {
+			result.Answers[i].Question.HideAnswer()
+		}
+	}
+
+	return result, true
+}
+
+// answersVisible reports whether the current user may see the result's correct
+// answers. Staff who may read all results always can.
+func answersVisible(c *gin.Context, result *models.TestResult) bool {
+	if auth.HasPermission(c.GetStringSlice("permissions"), models.PermReadAllResults) {
+		return true
+	}
+	return result.Test.AnswersRevealed(time.Now())
+}
+
+func (h *ResultHandler) GetUserResults(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	results, err := h.resultService.GetResultsByUserID(c.GetUint("organization_id"), uint(userID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch results")
 		return
 	}
 
+	utils.SuccessResponse(c, http.StatusOK, "Results fetched successfully", results)
+}
+
+// GetProgress reports the current user's scores across attempts. An optional
+// test_id limits it to one test.
+func (h *ResultHandler) GetProgress(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	result, err := h.resultService.GetResultByID(uint(resultID), userID.(uint))
+	h.respondWithProgress(c, userID.(uint))
+}
+
+func (h *ResultHandler) GetUserProgress(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusNotFound, "Result not found")
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Result fetched successfully", result)
+	h.respondWithProgress(c, uint(userID))
+}
+
+func (h *ResultHandler) respondWithProgress(c *gin.Context, userID uint) {
+	var testID uint64
+	if value := c.Query("test_id"); value != "" {
+		var err error
+		if testID, err = strconv.ParseUint(value, 10, 32); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid test ID")
+			return
+		}
+	}
+
+	progress, err := h.resultService.GetProgress(c.GetUint("organization_id"), userID, uint(testID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch progress")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Progress fetched successfully", progress)
+}
+
+func (h *ResultHandler) GetReportTemplate(c *gin.Context) {
+	template, err := h.reportService.GetReportTemplate(c.GetUint("organization_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch report template")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Report template fetched successfully", template)
+}
+
+func (h *ResultHandler) UpdateReportTemplate(c *gin.Context) {
+	var req ReportTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	template := &models.ReportTemplate{
+		OrganizationID: c.GetUint("organization_id"),
+		Title:          req.Title,
+		Subtitle:       req.Subtitle,
+		PrimaryColor:   req.PrimaryColor,
+		FooterText:     req.FooterText,
+		ShowReview:     req.ShowReview,
+	}
+
+	if err := h.reportService.SaveReportTemplate(template); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to save report template")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Report template saved successfully", template)
 }
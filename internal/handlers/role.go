@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"iq-go/internal/auth"
+	"iq-go/internal/models"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	roleService *services.RoleService
+}
+
+func NewRoleHandler(roleService *services.RoleService) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+	}
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type SetUserRolesRequest struct {
+	Roles []string `json:"roles" binding:"required"`
+}
+
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch roles")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Roles fetched successfully", roles)
+}
+
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.roleService.ListPermissions()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch permissions")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Permissions fetched successfully", permissions)
+}
+
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	if err := h.roleService.CreateRole(role, req.Permissions); err != nil {
+		if errors.Is(err, services.ErrUnknownPermission) {
+			utils.CodedErrorResponse(c, http.StatusBadRequest, utils.CodeUnknownPermission, "Unknown permission")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusConflict, "Role already exists")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Role created successfully", role)
+}
+
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid role ID")
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(uint(roleID), req.Description, req.Permissions)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownPermission) {
+			utils.CodedErrorResponse(c, http.StatusBadRequest, utils.CodeUnknownPermission, "Unknown permission")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusNotFound, "Role not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Role updated successfully", role)
+}
+
+func (h *RoleHandler) SetUserRoles(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req SetUserRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	// Only platform admins may hand out or take away roles that act across organizations.
+	allowGlobal := auth.HasPermission(c.GetStringSlice("permissions"), models.PermManageOrgs)
+	user, err := h.roleService.SetUserRoles(c.GetUint("organization_id"), uint(userID), req.Roles, allowGlobal)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownRole) {
+			utils.CodedErrorResponse(c, http.StatusBadRequest, utils.CodeUnknownRole, "Unknown role")
+			return
+		}
+		if errors.Is(err, services.ErrGlobalRole) {
+			utils.CodedErrorResponse(c, http.StatusForbidden, utils.CodePermissionDenied, "Insufficient permissions")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "User roles updated successfully", user)
+}
@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"iq-go/internal/models"
+	"iq-go/internal/scim"
+	"iq-go/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SCIMHandler serves the SCIM 2.0 endpoints identity providers provision users
+// and groups through. Responses are SCIM documents rather than the API's envelope.
+type SCIMHandler struct {
+	scimService *services.SCIMService
+	baseURL     string
+}
+
+func NewSCIMHandler(scimService *services.SCIMService, appURL string) *SCIMHandler {
+	return &SCIMHandler{
+		scimService: scimService,
+		baseURL:     strings.TrimSuffix(appURL, "/") + "/scim/v2",
+	}
+}
+
+func (h *SCIMHandler) ServiceProviderConfig(c *gin.Context) {
+	scim.WriteJSON(c.Writer, http.StatusOK, scim.ServiceProviderConfig{
+		Schemas:        []string{scim.SchemaServiceProviderConfig},
+		Patch:          scim.Support{Supported: true},
+		Filter:         scim.Filter{Supported: true, MaxResults: services.SCIMMaxPageSize},
+		ChangePassword: scim.Support{Supported: true},
+		AuthenticationSchemes: []scim.Scheme{{
+			Type:        "oauthbearertoken",
+			Name:        "API key",
+			Description: "An organization API key with the " + models.PermProvisionUsers + " permission, sent as a bearer token",
+			Primary:     true,
+		}},
+	})
+}
+
+func (h *SCIMHandler) ResourceTypes(c *gin.Context) {
+	types := []interface{}{
+		scim.ResourceType{
+			Schemas:  []string{scim.SchemaResourceType},
+			ID:       "User",
+			Name:     "User",
+			Endpoint: "/Users",
+			Schema:   scim.SchemaUser,
+			Meta:     &scim.Meta{ResourceType: "ResourceType", Location: h.baseURL + "/ResourceTypes/User"},
+		},
+		scim.ResourceType{
+			Schemas:  []string{scim.SchemaResourceType},
+			ID:       "Group",
+			Name:     "Group",
+			Endpoint: "/Groups",
+			Schema:   scim.SchemaGroup,
+			Meta:     &scim.Meta{ResourceType: "ResourceType", Location: h.baseURL + "/ResourceTypes/Group"},
+		},
+	}
+	scim.WriteJSON(c.Writer, http.StatusOK, scim.NewListResponse(int64(len(types)), 1, types))
+}
+
+// Schemas describes the attributes the application stores. Others sent by a
+// provider are accepted and ignored.
+func (h *SCIMHandler) Schemas(c *gin.Context) {
+	reference := func(name string) scim.Attribute {
+		return scim.Attribute{
+			Name: name, Type: "complex", MultiValued: true, Mutability: "readWrite", Returned: "default", Uniqueness: "none",
+			SubAttributes: []scim.Attribute{
+				scim.StringAttribute("value", "immutable", true),
+				scim.StringAttribute("display", "readOnly", false),
+			},
+		}
+	}
+
+	groups := reference("groups")
+	groups.Mutability = "readOnly"
+	userName := scim.StringAttribute("userName", "readWrite", true)
+	userName.Uniqueness = "server"
+	password := scim.StringAttribute("password", "writeOnly", false)
+	password.Returned = "never"
+
+	schemas := []interface{}{
+		scim.Schema{
+			Schemas:     []string{scim.SchemaSchema},
+			ID:          scim.SchemaUser,
+			Name:        "User",
+			Description: "User account; userName is the email address",
+			Attributes: []scim.Attribute{
+				userName,
+				scim.StringAttribute("externalId", "readWrite", false),
+				{
+					Name: "name", Type: "complex", Mutability: "readWrite", Returned: "default", Uniqueness: "none",
+					SubAttributes: []scim.Attribute{
+						scim.StringAttribute("givenName", "readWrite", false),
+						scim.StringAttribute("familyName", "readWrite", false),
+					},
+				},
+				scim.StringAttribute("displayName", "readOnly", false),
+				{
+					Name: "emails", Type: "complex", MultiValued: true, Mutability: "readWrite", Returned: "default", Uniqueness: "none",
+					SubAttributes: []scim.Attribute{scim.StringAttribute("value", "readWrite", true)},
+				},
+				{Name: "active", Type: "boolean", Mutability: "readWrite", Returned: "default", Uniqueness: "none"},
+				password,
+				groups,
+			},
+		},
+		scim.Schema{
+			Schemas:     []string{scim.SchemaSchema},
+			ID:          scim.SchemaGroup,
+			Name:        "Group",
+			Description: "Role; members are the organization's users holding it",
+			Attributes: []scim.Attribute{
+				scim.StringAttribute("displayName", "immutable", true),
+				reference("members"),
+			},
+		},
+	}
+	scim.WriteJSON(c.Writer, http.StatusOK, scim.NewListResponse(int64(len(schemas)), 1, schemas))
+}
+
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	startIndex, count := scimPage(c)
+	users, total, err := h.scimService.ListUsers(c.GetUint("organization_id"), c.Query("filter"), startIndex, count)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to fetch users")
+		return
+	}
+
+	resources := make([]interface{}, len(users))
+	for i := range users {
+		resources[i] = h.userResource(&users[i])
+	}
+	scim.WriteJSON(c.Writer, http.StatusOK, scim.NewListResponse(total, startIndex, resources))
+}
+
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	user, err := h.scimService.GetUser(c.GetUint("organization_id"), id)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to fetch user")
+		return
+	}
+	scim.WriteJSON(c.Writer, http.StatusOK, h.userResource(user))
+}
+
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var resource scim.User
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		scimBindingError(c, err)
+		return
+	}
+
+	user, err := h.scimService.CreateUser(c.GetUint("organization_id"), resource)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to create user")
+		return
+	}
+	c.Header("Location", h.baseURL+"/Users/"+strconv.FormatUint(uint64(user.User.ID), 10))
+	scim.WriteJSON(c.Writer, http.StatusCreated, h.userResource(user))
+}
+
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	var resource scim.User
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		scimBindingError(c, err)
+		return
+	}
+
+	user, err := h.scimService.ReplaceUser(c.GetUint("organization_id"), id, resource)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to update user")
+		return
+	}
+	scim.WriteJSON(c.Writer, http.StatusOK, h.userResource(user))
+}
+
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	var req scim.PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimBindingError(c, err)
+		return
+	}
+
+	user, err := h.scimService.PatchUser(c.GetUint("organization_id"), id, req.Operations)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to update user")
+		return
+	}
+	scim.WriteJSON(c.Writer, http.StatusOK, h.userResource(user))
+}
+
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	if err := h.scimService.DeleteUser(c.GetUint("organization_id"), id); err != nil {
+		h.errorResponse(c, err, "Failed to delete user")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListGroups leaves members out when excludedAttributes=members, which providers
+// use to look groups up by name cheaply.
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	startIndex, count := scimPage(c)
+	groups, total, err := h.scimService.ListGroups(c.GetUint("organization_id"), c.Query("filter"), startIndex, count, withMembers(c))
+	if err != nil {
+		h.errorResponse(c, err, "Failed to fetch groups")
+		return
+	}
+
+	resources := make([]interface{}, len(groups))
+	for i := range groups {
+		resources[i] = h.groupResource(&groups[i])
+	}
+	scim.WriteJSON(c.Writer, http.StatusOK, scim.NewListResponse(total, startIndex, resources))
+}
+
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	group, err := h.scimService.GetGroup(c.GetUint("organization_id"), id, withMembers(c))
+	if err != nil {
+		h.errorResponse(c, err, "Failed to fetch group")
+		return
+	}
+	scim.WriteJSON(c.Writer, http.StatusOK, h.groupResource(group))
+}
+
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	var resource scim.Group
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		scimBindingError(c, err)
+		return
+	}
+
+	group, err := h.scimService.ReplaceGroup(c.GetUint("organization_id"), id, resource)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to update group")
+		return
+	}
+	scim.WriteJSON(c.Writer, http.StatusOK, h.groupResource(group))
+}
+
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	id, ok := scimID(c)
+	if !ok {
+		return
+	}
+	var req scim.PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		scimBindingError(c, err)
+		return
+	}
+
+	group, err := h.scimService.PatchGroup(c.GetUint("organization_id"), id, req.Operations)
+	if err != nil {
+		h.errorResponse(c, err, "Failed to update group")
+		return
+	}
+	scim.WriteJSON(c.Writer, http.StatusOK, h.groupResource(group))
+}
+
+// GroupsReadOnly answers attempts to create or delete groups. Groups are roles
+// shared by all organizations, so only their members can be provisioned.
+func (h *SCIMHandler) GroupsReadOnly(c *gin.Context) {
+	scim.WriteJSON(c.Writer, http.StatusForbidden, scim.NewError(http.StatusForbidden, "",
+		"Groups are the application's roles; only their members can be provisioned"))
+}
+
+func (h *SCIMHandler) userResource(provisioned *services.ProvisionedUser) scim.User {
+	user := provisioned.User
+	id := strconv.FormatUint(uint64(user.ID), 10)
+	active := !user.DeletedAt.Valid
+
+	resource := scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          id,
+		ExternalID:  provisioned.ExternalID,
+		UserName:    user.Email,
+		Name:        &scim.Name{GivenName: user.FirstName, FamilyName: user.LastName},
+		DisplayName: strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Emails:      []scim.Email{{Value: user.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      user.CreatedAt,
+			LastModified: user.UpdatedAt,
+			Location:     h.baseURL + "/Users/" + id,
+		},
+	}
+	for _, role := range provisioned.Groups {
+		groupID := strconv.FormatUint(uint64(role.ID), 10)
+		resource.Groups = append(resource.Groups, scim.Reference{
+			Value:   groupID,
+			Ref:     h.baseURL + "/Groups/" + groupID,
+			Display: role.Name,
+		})
+	}
+	return resource
+}
+
+func (h *SCIMHandler) groupResource(group *services.ProvisionedGroup) scim.Group {
+	id := strconv.FormatUint(uint64(group.Role.ID), 10)
+	resource := scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          id,
+		DisplayName: group.Role.Name,
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Created:      group.Role.CreatedAt,
+			LastModified: group.Role.UpdatedAt,
+			Location:     h.baseURL + "/Groups/" + id,
+		},
+	}
+	for _, member := range group.Members {
+		userID := strconv.FormatUint(uint64(member.ID), 10)
+		resource.Members = append(resource.Members, scim.Reference{
+			Value:   userID,
+			Ref:     h.baseURL + "/Users/" + userID,
+			Display: member.Email,
+		})
+	}
+	return resource
+}
+
+// errorResponse maps service errors to SCIM errors; anything else is logged and
+// reported as a server error.
+func (h *SCIMHandler) errorResponse(c *gin.Context, err error, message string) {
+	status, scimType := http.StatusBadRequest, ""
+	switch {
+	case errors.Is(err, services.ErrSCIMNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrSCIMUniqueness):
+		status, scimType = http.StatusConflict, scim.ErrUniqueness
+	case errors.Is(err, services.ErrSCIMInvalid):
+		scimType = scim.ErrInvalidValue
+	case errors.Is(err, services.ErrSCIMFilter):
+		scimType = scim.ErrInvalidFilter
+	case errors.Is(err, services.ErrSCIMPath):
+		scimType = scim.ErrInvalidPath
+	case errors.Is(err, services.ErrSCIMMutability):
+		scimType = scim.ErrMutability
+	default:
+		log.Printf("SCIM: %s: %v", message, err)
+		scim.WriteJSON(c.Writer, http.StatusInternalServerError, scim.NewError(http.StatusInternalServerError, "", message))
+		return
+	}
+	scim.WriteJSON(c.Writer, status, scim.NewError(status, scimType, err.Error()))
+}
+
+func scimBindingError(c *gin.Context, err error) {
+	scim.WriteJSON(c.Writer, http.StatusBadRequest, scim.NewError(http.StatusBadRequest, scim.ErrInvalidSyntax, err.Error()))
+}
+
+// scimID reads the resource ID. IDs that cannot exist are not found.
+func scimID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		scim.WriteJSON(c.Writer, http.StatusNotFound, scim.NewError(http.StatusNotFound, "", services.ErrSCIMNotFound.Error()))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// scimPage reads startIndex and count, which SCIM clamps rather than rejects.
+func scimPage(c *gin.Context) (int, int) {
+	startIndex, err := strconv.Atoi(c.Query("startIndex"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(services.SCIMDefaultPageSize)))
+	switch {
+	case err != nil:
+		count = services.SCIMDefaultPageSize
+	case count < 0:
+		count = 0
+	case count > services.SCIMMaxPageSize:
+		count = services.SCIMMaxPageSize
+	}
+	return startIndex, count
+}
+
+func withMembers(c *gin.Context) bool {
+	for _, attribute := range strings.Split(c.Query("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attribute), "members") {
+			return false
+		}
+	}
+	return true
+}
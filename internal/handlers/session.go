@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"iq-go/internal/models"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SessionRequest struct {
+	TestID      uint      `json:"test_id" binding:"required"`
+	Name        string    `json:"name" binding:"required"`
+	ScheduledAt time.Time `json:"scheduled_at" binding:"required"`
+	UserIDs     []uint    `json:"user_ids" binding:"required,min=1"`
+}
+
+type ExtendTimeRequest struct {
+	UserID  uint `json:"user_id" binding:"required"`
+	Minutes int  `json:"minutes" binding:"required,min=1,max=600"`
+}
+
+type TerminateAttemptRequest struct {
+	UserID uint   `json:"user_id" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+func (h *TestHandler) CreateSession(c *gin.Context) {
+	var req SessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	session, err := h.testService.CreateSession(c.GetUint("organization_id"), c.GetUint("user_id"), services.SessionRequest{
+		TestID:      req.TestID,
+		Name:        req.Name,
+		ScheduledAt: req.ScheduledAt,
+		UserIDs:     req.UserIDs,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Test not found")
+			return
+		}
+		if sessionErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Session scheduled successfully", session)
+}
+
+func (h *TestHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.testService.ListSessions(c.GetUint("organization_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch sessions")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sessions fetched successfully", sessions)
+}
+
+func (h *TestHandler) GetSession(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	session, err := h.testService.GetSession(c.GetUint("organization_id"), uint(sessionID))
+	if err != nil {
+		if sessionErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch session")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Session fetched successfully", session)
+}
+
+func (h *TestHandler) StartSession(c *gin.Context) {
+	h.changeSession(c, h.testService.StartSession, services.ProctoringSessionStarted, "Session started successfully")
+}
+
+func (h *TestHandler) PauseSession(c *gin.Context) {
+	h.changeSession(c, h.testService.PauseSession, services.ProctoringSessionPaused, "Session paused successfully")
+}
+
+func (h *TestHandler) ResumeSession(c *gin.Context) {
+	h.changeSession(c, h.testService.ResumeSession, services.ProctoringSessionResumed, "Session resumed successfully")
+}
+
+func (h *TestHandler) EndSession(c *gin.Context) {
+	h.changeSession(c, h.testService.EndSession, services.ProctoringSessionEnded, "Session ended successfully")
+}
+
+// changeSession applies a change to the whole session and announces it to the
+// proctors watching the test.
+func (h *TestHandler) changeSession(c *gin.Context, change func(orgID, sessionID uint) (*models.Session, error), event services.ProctoringEventType, message string) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	session, err := change(c.GetUint("organization_id"), uint(sessionID))
+	if err != nil {
+		if sessionErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update session")
+		return
+	}
+
+	h.proctoringService.SessionChanged(c.Request.Context(), session, event)
+	utils.SuccessResponse(c, http.StatusOK, message, session)
+}
+
+func (h *TestHandler) ExtendTime(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	var req ExtendTimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	session, err := h.testService.ExtendTime(c.GetUint("organization_id"), uint(sessionID), req.UserID, req.Minutes)
+	if err != nil {
+		if sessionErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to extend time")
+		return
+	}
+
+	h.proctoringService.CandidateChanged(c.Request.Context(), session, req.UserID, services.ProctoringTimeExtended)
+	utils.SuccessResponse(c, http.StatusOK, "Time extended successfully", session)
+}
+
+func (h *TestHandler) TerminateAttempt(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	var req TerminateAttemptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	session, err := h.testService.TerminateAttempt(c.GetUint("organization_id"), uint(sessionID), req.UserID, c.GetUint("user_id"), req.Reason)
+	if err != nil {
+		if sessionErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to terminate attempt")
+		return
+	}
+
+	h.proctoringService.CandidateChanged(c.Request.Context(), session, req.UserID, services.ProctoringAttemptTerminated)
+	utils.SuccessResponse(c, http.StatusOK, "Attempt terminated successfully", session)
+}
+
+// JoinSession puts the candidate in the session's lobby.
+func (h *TestHandler) JoinSession(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	if !h.checkInvitedSession(c, uint(sessionID)) {
+		return
+	}
+
+	lobby, err := h.testService.JoinSession(c.GetUint("organization_id"), uint(sessionID), c.GetUint("user_id"))
+	if err != nil {
+		if sessionErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to join session")
+		return
+	}
+
+	session, err := h.testService.GetSession(c.GetUint("organization_id"), uint(sessionID))
+	if err == nil {
+		h.proctoringService.CandidateChanged(c.Request.Context(), session, c.GetUint("user_id"), services.ProctoringCandidateJoined)
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Joined session successfully", lobby)
+}
+
+// GetLobby is polled by candidates waiting for the session to start, and while
+// taking it to follow pauses and extensions of their deadline.
+func (h *TestHandler) GetLobby(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	lobby, err := h.testService.GetLobby(c.GetUint("organization_id"), uint(sessionID), c.GetUint("user_id"))
+	if err != nil {
+		if sessionErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch session")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Session fetched successfully", lobby)
+}
+
+// StartSessionAttempt starts or resumes the candidate's attempt in a session the
+// proctor has started.
+func (h *TestHandler) StartSessionAttempt(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	if !h.checkInvitedSession(c, uint(sessionID)) {
+		return
+	}
+
+	attempt, questions, err := h.testService.StartSessionAttempt(c.GetUint("organization_id"), uint(sessionID), c.GetUint("user_id"))
+	if err != nil {
+		if sessionErrorResponse(c, err) || policyErrorResponse(c, err) || attemptErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start attempt")
+		return
+	}
+
+	h.proctoringService.AttemptStarted(c.Request.Context(), attempt)
+
+	// Remove correct answers from response
+	for i := range questions {
+		questions[i].HideAnswer()
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Attempt started successfully", map[string]interface{}{
+		"attempt":   attempt,
+		"questions": questions,
+	})
+}
+
+// checkInvitedSession is checkInvitedTest for the test of a session.
+func (h *TestHandler) checkInvitedSession(c *gin.Context, sessionID uint) bool {
+	if c.GetUint("invitation_id") == 0 {
+		return true
+	}
+	session, err := h.testService.GetSession(c.GetUint("organization_id"), sessionID)
+	if err != nil {
+		if !sessionErrorResponse(c, err) {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch session")
+		}
+		return false
+	}
+	return h.checkInvitedTest(c, session.TestID)
+}
+
+// sessionErrorResponse writes the response for a session error and reports
+// whether err was one.
+func sessionErrorResponse(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrSessionNotFound):
+		utils.CodedErrorResponse(c, http.StatusNotFound, utils.CodeSessionNotFound, "Session not found")
+	case errors.Is(err, services.ErrSessionState):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeSessionState, "Session cannot do that in its current state")
+	case errors.Is(err, services.ErrSessionUntimed):
+		utils.CodedErrorResponse(c, http.StatusUnprocessableEntity, utils.CodeTestUntimed, "Test needs a duration to be scheduled")
+	case errors.Is(err, services.ErrNotCandidate):
+		utils.CodedErrorResponse(c, http.StatusUnprocessableEntity, utils.CodeNotCandidate, "User is not a candidate in this session")
+	case errors.Is(err, services.ErrSessionOver):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeSessionOver, "Session time is over")
+	case errors.Is(err, services.ErrInvalidSession):
+		utils.CodedErrorResponse(c, http.StatusUnprocessableEntity, utils.CodeInvalidSession, err.Error())
+	case errors.Is(err, services.ErrSessionRequired):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeSessionRequired, "Test must be taken in its proctored session")
+	default:
+		return false
+	}
+	return true
+}
@@ -1,28 +1,36 @@
 package handlers
 
 import (
+	"errors"
+	"iq-go/internal/models"
 	"iq-go/internal/services"
 	"iq-go/internal/utils"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type TestHandler struct {
-	testService *services.TestService
+	testService       *services.TestService
+	invitationService *services.InvitationService
+	proctoringService *services.ProctoringService
 }
 
-func NewTestHandler(testService *services.TestService) *TestHandler {
+func NewTestHandler(testService *services.TestService, invitationService *services.InvitationService, proctoringService *services.ProctoringService) *TestHandler {
 	return &TestHandler{
-		testService: testService,
+		testService:       testService,
+		invitationService: invitationService,
+		proctoringService: proctoringService,
 	}
 }
 
 type SubmitTestRequest struct {
 	TestID    uint                  `json:"test_id" binding:"required"`
+	AttemptID uint                  `json:"attempt_id" binding:"required"`
 	Answers   []SubmitAnswerRequest `json:"answers" binding:"required"`
-	TimeTaken int                   `json:"time_taken"`
 }
 
 type SubmitAnswerRequest struct {
@@ -31,15 +39,230 @@ type SubmitAnswerRequest struct {
 	ResponseTime int    `json:"response_time"`
 }
 
+type CreateTestRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Duration    int    `json:"duration" binding:"min=0"`
+}
+
+type UpdatePolicyRequest struct {
+	MaxAttempts     int                  `json:"max_attempts" binding:"min=0"`
+	CooldownMinutes int                  `json:"cooldown_minutes" binding:"min=0"`
+	OpensAt         *time.Time           `json:"opens_at"`
+	ClosesAt        *time.Time           `json:"closes_at"`
+	ScoringPolicy   models.ScoringPolicy `json:"scoring_policy" binding:"required,oneof=first best latest"`
+	AnswerReveal    models.AnswerReveal  `json:"answer_reveal" binding:"omitempty,oneof=immediately after_close never"`
+	PracticeEnabled bool                 `json:"practice_enabled"`
+}
+
+type QuestionRequest struct {
+	TestID        uint                `json:"test_id" binding:"required"`
+	QuestionText  string              `json:"question_text" binding:"required"`
+	QuestionType  models.QuestionType `json:"question_type" binding:"required,oneof=multiple_choice text_input number_input key_sequence"`
+	Category      models.Category     `json:"category" binding:"required"`
+	Options       string              `json:"options"`
+	CorrectAnswer string              `json:"correct_answer" binding:"required"`
+	Explanation   string              `json:"explanation"`
+	Reference     string              `json:"reference"`
+	TimeLimit     int                 `json:"time_limit"`
+	DisplayTime   int                 `json:"display_time"`
+	OrderIndex    int                 `json:"order_index"`
+}
+
+func (r *QuestionRequest) apply(question *models.Question) {
+	question.TestID = r.TestID
+	question.QuestionText = r.QuestionText
+	question.QuestionType = r.QuestionType
+	question.Category = r.Category
+	question.Options = r.Options
+	question.CorrectAnswer = r.CorrectAnswer
+	question.Explanation = r.Explanation
+	question.Reference = r.Reference
+	question.TimeLimit = r.TimeLimit
+	question.DisplayTime = r.DisplayTime
+	question.OrderIndex = r.OrderIndex
+}
+
+func (h *TestHandler) ListTests(c *gin.Context) {
+	tests, err := h.testService.ListTests(c.GetUint("organization_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch tests")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tests fetched successfully", tests)
+}
+
+func (h *TestHandler) CreateTest(c *gin.Context) {
+	var req CreateTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	test := &models.Test{
+		OrganizationID: c.GetUint("organization_id"),
+		Name:           req.Name,
+		Description:    req.Description,
+		Duration:       req.Duration,
+	}
+
+	if err := h.testService.CreateTest(test); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create test")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Test created successfully", test)
+}
+
+func (h *TestHandler) UpdatePolicy(c *gin.Context) {
+	testID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid test ID")
+		return
+	}
+
+	var req UpdatePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+	if req.OpensAt != nil && req.ClosesAt != nil && !req.ClosesAt.After(*req.OpensAt) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "closes_at must be after opens_at")
+		return
+	}
+
+	test, err := h.testService.UpdatePolicy(c.GetUint("organization_id"), uint(testID), &models.Test{
+		MaxAttempts:     req.MaxAttempts,
+		CooldownMinutes: req.CooldownMinutes,
+		OpensAt:         req.OpensAt,
+		ClosesAt:        req.ClosesAt,
+		ScoringPolicy:   req.ScoringPolicy,
+		AnswerReveal:    req.AnswerReveal,
+		PracticeEnabled: req.PracticeEnabled,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Test not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update policy")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Policy updated successfully", test)
+}
+
+func (h *TestHandler) GetEligibility(c *gin.Context) {
+	testID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid test ID")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	eligibility, err := h.testService.GetEligibility(c.GetUint("organization_id"), userID.(uint), uint(testID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Test not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to check eligibility")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Eligibility fetched successfully", eligibility)
+}
+
+func (h *TestHandler) StartAttempt(c *gin.Context) {
+	testID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid test ID")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	if !h.checkInvitedTest(c, uint(testID)) {
+		return
+	}
+
+	attempt, questions, err := h.testService.StartAttempt(c.GetUint("organization_id"), userID.(uint), uint(testID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Test not found")
+			return
+		}
+		if policyErrorResponse(c, err) || attemptErrorResponse(c, err) || sessionErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start attempt")
+		return
+	}
+
+	h.proctoringService.AttemptStarted(c.Request.Context(), attempt)
+
+	// Remove correct answers from response
+	for i := range questions {
+		questions[i].HideAnswer()
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Attempt started successfully", map[string]interface{}{
+		"attempt":   attempt,
+		"questions": questions,
+	})
+}
+
+// checkInvitedTest refuses, with a response, a request for any test but the
+// invited one from a candidate who signed in through an invitation, and reports
+// whether the request may go on.
+func (h *TestHandler) checkInvitedTest(c *gin.Context, testID uint) bool {
+	invitationID := c.GetUint("invitation_id")
+	if invitationID == 0 {
+		return true
+	}
+	invitedTestID, err := h.invitationService.GetInvitationTestID(invitationID)
+	if err != nil || invitedTestID != testID {
+		utils.ErrorResponse(c, http.StatusForbidden, "Invitation is for a different test")
+		return false
+	}
+	return true
+}
+
 func (h *TestHandler) GetQuestions(c *gin.Context) {
 	testIDStr := c.DefaultQuery("test_id", "1")
+
+	// Candidates who came through an invitation may only see the invited test.
+	if invitationID := c.GetUint("invitation_id"); invitationID != 0 {
+		invitedTestID, err := h.invitationService.GetInvitationTestID(invitationID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusNotFound, "Invitation not found")
+			return
+		}
+		if _, given := c.GetQuery("test_id"); !given {
+			testIDStr = strconv.FormatUint(uint64(invitedTestID), 10)
+		} else if testIDStr != strconv.FormatUint(uint64(invitedTestID), 10) {
+			utils.ErrorResponse(c, http.StatusForbidden, "Invitation is for a different test")
+			return
+		}
+	}
+
 	testID, err := strconv.ParseUint(testIDStr, 10, 32)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid test ID")
 		return
 	}
 
-	questions, err := h.testService.GetQuestionsByTestID(uint(testID))
+	questions, err := h.testService.GetCandidateQuestions(c.GetUint("organization_id"), c.GetUint("user_id"), uint(testID))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch questions")
 		return
@@ -47,7 +270,7 @@ func (h *TestHandler) GetQuestions(c *gin.Context) {
 
 	// Remove correct answers from response
 	for i := range questions {
-		questions[i].CorrectAnswer = ""
+		questions[i].HideAnswer()
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Questions fetched successfully", questions)
@@ -56,7 +279,7 @@ func (h *TestHandler) GetQuestions(c *gin.Context) {
 func (h *TestHandler) SubmitTest(c *gin.Context) {
 	var req SubmitTestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		utils.BindingErrorResponse(c, err)
 		return
 	}
 
@@ -76,11 +299,161 @@ func (h *TestHandler) SubmitTest(c *gin.Context) {
 		}
 	}
 
-	result, err := h.testService.SubmitTest(userID.(uint), req.TestID, serviceAnswers, req.TimeTaken)
+	// An invitation can be submitted against exactly once.
+	invitationID := c.GetUint("invitation_id")
+	if invitationID != 0 {
+		if err := h.invitationService.ClaimInvitation(invitationID, userID.(uint), req.TestID); err != nil {
+			invitationErrorResponse(c, err)
+			return
+		}
+	}
+
+	result, err := h.testService.SubmitTest(c.GetUint("organization_id"), userID.(uint), services.Submission{
+		TestID:    req.TestID,
+		AttemptID: req.AttemptID,
+		Answers:   serviceAnswers,
+	})
 	if err != nil {
+		if invitationID != 0 {
+			h.invitationService.ReleaseInvitation(invitationID)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Test not found")
+			return
+		}
+		if policyErrorResponse(c, err) || attemptErrorResponse(c, err) {
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to submit test")
 		return
 	}
 
+	if invitationID != 0 {
+		if err := h.invitationService.CompleteInvitation(invitationID, result.ID); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to complete invitation")
+			return
+		}
+	}
+
+	h.proctoringService.AttemptSubmitted(c.Request.Context(), result)
+
 	utils.SuccessResponse(c, http.StatusOK, "Test submitted successfully", result)
 }
+
+func (h *TestHandler) CreateQuestion(c *gin.Context) {
+	var req QuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	question := &models.Question{}
+	req.apply(question)
+
+	if err := h.testService.CreateQuestion(c.GetUint("organization_id"), question); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Test not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create question")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Question created successfully", question)
+}
+
+func (h *TestHandler) UpdateQuestion(c *gin.Context) {
+	questionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid question ID")
+		return
+	}
+
+	var req QuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	question, err := h.testService.GetQuestionByID(c.GetUint("organization_id"), uint(questionID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Question not found")
+		return
+	}
+
+	req.apply(question)
+
+	if err := h.testService.UpdateQuestion(c.GetUint("organization_id"), question); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Test not found")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update question")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Question updated successfully", question)
+}
+
+func (h *TestHandler) DeleteQuestion(c *gin.Context) {
+	questionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid question ID")
+		return
+	}
+
+	if err := h.testService.DeleteQuestion(c.GetUint("organization_id"), uint(questionID)); err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Question not found")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Question deleted successfully", nil)
+}
+
+// policyErrorResponse writes the response for an attempt policy violation and
+// reports whether err was one.
+func policyErrorResponse(c *gin.Context, err error) bool {
+	var violation *services.PolicyViolation
+	if !errors.As(err, &violation) {
+		return false
+	}
+
+	status, code := http.StatusForbidden, utils.CodeAttemptLimitReached
+	switch {
+	case errors.Is(err, services.ErrAttemptCooldown):
+		status, code = http.StatusTooManyRequests, utils.CodeAttemptCooldown
+	case errors.Is(err, services.ErrTestNotOpen):
+		code = utils.CodeTestNotOpen
+	case errors.Is(err, services.ErrTestClosed):
+		code = utils.CodeTestClosed
+	}
+	if violation.RetryAt != nil {
+		seconds := int(time.Until(*violation.RetryAt).Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(seconds))
+	}
+
+	utils.CodedErrorResponse(c, status, code, violation.Message)
+	return true
+}
+
+// attemptErrorResponse writes the response for an invalid attempt or submission and
+// reports whether err was one.
+func attemptErrorResponse(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrInvalidSubmission):
+		utils.CodedErrorResponse(c, http.StatusUnprocessableEntity, utils.CodeInvalidSubmission, err.Error())
+	case errors.Is(err, services.ErrAttemptNotFound):
+		utils.CodedErrorResponse(c, http.StatusNotFound, utils.CodeAttemptNotFound, "Attempt not found")
+	case errors.Is(err, services.ErrAttemptCompleted):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeAttemptCompleted, "Attempt has already been submitted")
+	case errors.Is(err, services.ErrAttemptExpired):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeAttemptExpired, "Attempt deadline has passed; it was closed without the late answers")
+	case errors.Is(err, services.ErrNoQuestions):
+		utils.CodedErrorResponse(c, http.StatusUnprocessableEntity, utils.CodeNoQuestions, "Test has no questions")
+	case errors.Is(err, services.ErrSessionPaused):
+		utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeSessionPaused, "Session is paused; wait for the proctor to resume it")
+	default:
+		return false
+	}
+	return true
+}
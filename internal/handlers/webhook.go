@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"iq-go/internal/models"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDeliveryPageSize = 50
+	maxDeliveryPageSize     = 200
+)
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+func (h *WebhookHandler) ListEndpoints(c *gin.Context) {
+	endpoints, err := h.webhookService.ListEndpoints(c.GetUint("organization_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch webhooks")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhooks fetched successfully", endpoints)
+}
+
+// CreateEndpoint responds with the signing secret. It is not shown again.
+func (h *WebhookHandler) CreateEndpoint(c *gin.Context) {
+	var req services.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	endpoint, secret, err := h.webhookService.CreateEndpoint(c.GetUint("organization_id"), req)
+	if err != nil {
+		if webhookErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create webhook")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Webhook created successfully", map[string]interface{}{
+		"webhook": endpoint,
+		"secret":  secret,
+	})
+}
+
+func (h *WebhookHandler) UpdateEndpoint(c *gin.Context) {
+	endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	var req services.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BindingErrorResponse(c, err)
+		return
+	}
+
+	endpoint, err := h.webhookService.UpdateEndpoint(c.GetUint("organization_id"), uint(endpointID), req)
+	if err != nil {
+		if webhookErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update webhook")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook updated successfully", endpoint)
+}
+
+func (h *WebhookHandler) DeleteEndpoint(c *gin.Context) {
+	endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	if err := h.webhookService.DeleteEndpoint(c.GetUint("organization_id"), uint(endpointID)); err != nil {
+		if webhookErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete webhook")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook deleted successfully", nil)
+}
+
+// RotateSecret replaces the signing secret. Deliveries sent from now on, including
+// retries of earlier events, are signed with the new secret.
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	endpoint, secret, err := h.webhookService.RotateSecret(c.GetUint("organization_id"), uint(endpointID))
+	if err != nil {
+		if webhookErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to rotate webhook secret")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook secret rotated successfully", map[string]interface{}{
+		"webhook": endpoint,
+		"secret":  secret,
+	})
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	endpointID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	status := models.DeliveryStatus(c.Query("status"))
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryFailed:
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "status must be pending, delivered or failed")
+		return
+	}
+
+	limit := defaultDeliveryPageSize
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxDeliveryPageSize {
+			utils.ErrorResponse(c, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.GetUint("organization_id"), uint(endpointID), status, limit)
+	if err != nil {
+		if webhookErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch deliveries")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Deliveries fetched successfully", deliveries)
+}
+
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	deliveryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delivery ID")
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(c.GetUint("organization_id"), uint(deliveryID))
+	if err != nil {
+		if webhookErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch delivery")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Delivery fetched successfully", delivery)
+}
+
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	deliveryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delivery ID")
+		return
+	}
+
+	delivery, err := h.webhookService.ReplayDelivery(c.GetUint("organization_id"), uint(deliveryID))
+	if err != nil {
+		if webhookErrorResponse(c, err) {
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to replay delivery")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusAccepted, "Delivery queued for replay", delivery)
+}
+
+// webhookErrorResponse maps webhook errors to responses and reports whether it wrote one.
+func webhookErrorResponse(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound):
+		utils.CodedErrorResponse(c, http.StatusNotFound, utils.CodeWebhookNotFound, "Webhook not found")
+	case errors.Is(err, services.ErrDeliveryNotFound):
+		utils.CodedErrorResponse(c, http.StatusNotFound, utils.CodeDeliveryNotFound, "Delivery not found")
+	case errors.Is(err, services.ErrInvalidWebhook):
+		utils.CodedErrorResponse(c, http.StatusBadRequest, utils.CodeInvalidWebhook, err.Error())
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+package lti
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWK is an RSA public key in JSON Web Key form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type KeySet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func PublicJWK(key *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Kid: KeyID(key),
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// KeyID is the RFC 7638 thumbprint of the key, so the same key always gets the
+// same ID.
+func KeyID(key *rsa.PublicKey) string {
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	n := base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+	sum := sha256.Sum256([]byte(`{"e":"` + e + `","kty":"RSA","n":"` + n + `"}`))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Key returns the RSA key with the ID. A set with a single key also answers for
+// tokens that name no key.
+func (s *KeySet) Key(kid string) (*rsa.PublicKey, error) {
+	for _, jwk := range s.Keys {
+		if jwk.Kty != "RSA" || (jwk.Kid != kid && !(kid == "" && len(s.Keys) == 1)) {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: invalid modulus", jwk.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: invalid exponent", jwk.Kid)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	}
+	return nil, fmt.Errorf("no key with ID %q", kid)
+}
+
+// FetchKeySet downloads a JSON Web Key Set.
+func FetchKeySet(ctx context.Context, client *http.Client, url string) (*KeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: status %d", url, resp.StatusCode)
+	}
+
+	var set KeySet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	return &set, nil
+}
+
+// Sign issues an RS256 JWT naming the key it was signed with.
+func Sign(key *rsa.PrivateKey, claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = KeyID(&key.PublicKey)
+	return token.SignedString(key)
+}
+
+// NewJTI returns a random token ID, also used for nonces.
+func NewJTI() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// Tool is this application as an LTI tool: the URLs platforms are configured with
+// and the key its messages are signed with.
+type Tool struct {
+	BaseURL string
+	key     *rsa.PrivateKey
+}
+
+var (
+	ephemeralKey     *rsa.PrivateKey
+	ephemeralKeyOnce sync.Once
+)
+
+// NewTool loads the tool's RSA key from PEM (PKCS #1 or PKCS #8). Without one a
+// key is generated for the life of the process, which is enough for development
+// but breaks deep links and score passback after a restart.
+func NewTool(baseURL, privateKeyPEM string) (*Tool, error) {
+	tool := &Tool{BaseURL: strings.TrimSuffix(baseURL, "/")}
+	if privateKeyPEM == "" {
+		ephemeralKeyOnce.Do(func() {
+			log.Printf("LTI_PRIVATE_KEY is not set; using a temporary LTI signing key")
+			ephemeralKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+		})
+		tool.key = ephemeralKey
+		return tool, nil
+	}
+
+	// Keys passed through the environment often have escaped newlines.
+	block, _ := pem.Decode([]byte(strings.ReplaceAll(privateKeyPEM, `\n`, "\n")))
+	if block == nil {
+		return nil, errors.New("LTI private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		tool.key = key
+		return tool, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing LTI private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("LTI private key is not an RSA key")
+	}
+	tool.key = key
+	return tool, nil
+}
+
+func (t *Tool) LoginURL() string    { return t.BaseURL + "/lti/login" }
+func (t *Tool) LaunchURL() string   { return t.BaseURL + "/lti/launch" }
+func (t *Tool) DeepLinkURL() string { return t.BaseURL + "/lti/deep-link" }
+func (t *Tool) JWKSURL() string     { return t.BaseURL + "/lti/jwks" }
+
+func (t *Tool) KeySet() KeySet {
+	return KeySet{Keys: []JWK{PublicJWK(&t.key.PublicKey)}}
+}
+
+func (t *Tool) Sign(claims jwt.Claims) (string, error) {
+	return Sign(t.key, claims)
+}
+
+// Verify parses a token the tool signed for itself, addressed to audience.
+func (t *Tool) Verify(token string, claims jwt.Claims, audience string) error {
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return &t.key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithIssuer(t.BaseURL), jwt.WithAudience(audience), jwt.WithExpirationRequired())
+	return err
+}
+
+// ClientAssertion is the JWT a tool authenticates with at a platform's token
+// endpoint (the client credentials grant of the LTI Security Framework).
+func (t *Tool) ClientAssertion(clientID, tokenURL string) (string, error) {
+	now := time.Now()
+	return t.Sign(jwt.RegisteredClaims{
+		Issuer:    clientID,
+		Subject:   clientID,
+		Audience:  jwt.ClaimStrings{tokenURL},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		ID:        NewJTI(),
+	})
+}
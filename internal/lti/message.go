@@ -0,0 +1,224 @@
+// Package lti implements the parts of LTI 1.3 (IMS Learning Tools Interoperability)
+// used to embed tests in a learning platform: the OIDC launch, Deep Linking 2.0 and
+// the score service of Assignment and Grade Services 2.0. It knows the protocol but
+// nothing about the application's models.
+package lti
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const Version = "1.3.0"
+
+// Message types
+const (
+	MessageResourceLink        = "LtiResourceLinkRequest"
+	MessageDeepLinking         = "LtiDeepLinkingRequest"
+	MessageDeepLinkingResponse = "LtiDeepLinkingResponse"
+)
+
+// Scopes a tool requests from the platform's token endpoint.
+const (
+	ScopeScore    = "https://purl.imsglobal.org/spec/lti-ags/scope/score"
+	ScopeLineItem = "https://purl.imsglobal.org/spec/lti-ags/scope/lineitem"
+)
+
+const (
+	ContentItemLink = "ltiResourceLink"
+	ScoreMediaType  = "application/vnd.ims.lis.v1.score+json"
+
+	// ClientAssertionType is the client_assertion_type of a token request signed
+	// with the tool's key.
+	ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+// LaunchClaims are the claims of the id_token a platform posts to the tool. Claims
+// the tool does not use are not decoded.
+type LaunchClaims struct {
+	jwt.RegisteredClaims
+	Nonce           string `json:"nonce"`
+	AuthorizedParty string `json:"azp,omitempty"`
+
+	Email      string `json:"email,omitempty"`
+	Name       string `json:"name,omitempty"`
+	GivenName  string `json:"given_name,omitempty"`
+	FamilyName string `json:"family_name,omitempty"`
+
+	MessageType   string                 `json:"https://purl.imsglobal.org/spec/lti/claim/message_type"`
+	Version       string                 `json:"https://purl.imsglobal.org/spec/lti/claim/version"`
+	DeploymentID  string                 `json:"https://purl.imsglobal.org/spec/lti/claim/deployment_id"`
+	TargetLinkURI string                 `json:"https://purl.imsglobal.org/spec/lti/claim/target_link_uri,omitempty"`
+	Roles         []string               `json:"https://purl.imsglobal.org/spec/lti/claim/roles"`
+	ResourceLink  *ResourceLink          `json:"https://purl.imsglobal.org/spec/lti/claim/resource_link,omitempty"`
+	Context       *Context               `json:"https://purl.imsglobal.org/spec/lti/claim/context,omitempty"`
+	Custom        map[string]interface{} `json:"https://purl.imsglobal.org/spec/lti/claim/custom,omitempty"`
+
+	Endpoint            *Endpoint            `json:"https://purl.imsglobal.org/spec/lti-ags/claim/endpoint,omitempty"`
+	DeepLinkingSettings *DeepLinkingSettings `json:"https://purl.imsglobal.org/spec/lti-dl/claim/deep_linking_settings,omitempty"`
+}
+
+type ResourceLink struct {
+	ID          string `json:"id"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type Context struct {
+	ID    string   `json:"id"`
+	Label string   `json:"label,omitempty"`
+	Title string   `json:"title,omitempty"`
+	Type  []string `json:"type,omitempty"`
+}
+
+// Endpoint is the Assignment and Grade Services claim. LineItem is set when the
+// resource link has a single line item, which is where scores are posted.
+type Endpoint struct {
+	Scope     []string `json:"scope"`
+	LineItems string   `json:"lineitems,omitempty"`
+	LineItem  string   `json:"lineitem,omitempty"`
+}
+
+func (e *Endpoint) Allows(scope string) bool {
+	for _, granted := range e.Scope {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type DeepLinkingSettings struct {
+	ReturnURL   string   `json:"deep_link_return_url"`
+	AcceptTypes []string `json:"accept_types"`
+	Targets     []string `json:"accept_presentation_document_targets,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Text        string   `json:"text,omitempty"`
+	Data        string   `json:"data,omitempty"`
+}
+
+func (s *DeepLinkingSettings) Accepts(itemType string) bool {
+	for _, accepted := range s.AcceptTypes {
+		if accepted == itemType {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomString returns a custom parameter as a string. Platforms send them as
+// strings, but some send numbers for numeric values.
+func (c *LaunchClaims) CustomString(name string) string {
+	switch value := c.Custom[name].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+// Validate checks the LTI-specific claims. Signature, issuer, audience and expiry
+// are checked by the JWT parser.
+func (c *LaunchClaims) Validate(clientID string) error {
+	if c.Version != Version {
+		return errors.New("unsupported LTI version " + strconv.Quote(c.Version))
+	}
+	if c.Subject == "" {
+		return errors.New("anonymous launches are not supported")
+	}
+	if len(c.Audience) > 1 && c.AuthorizedParty != clientID {
+		return errors.New("azp does not match the client ID")
+	}
+	switch c.MessageType {
+	case MessageResourceLink:
+		if c.ResourceLink == nil || c.ResourceLink.ID == "" {
+			return errors.New("missing resource link")
+		}
+	case MessageDeepLinking:
+		if c.DeepLinkingSettings == nil || c.DeepLinkingSettings.ReturnURL == "" {
+			return errors.New("missing deep linking settings")
+		}
+	default:
+		return errors.New("unsupported message type " + strconv.Quote(c.MessageType))
+	}
+	return nil
+}
+
+// ContextRole returns the name of a course (membership) role, e.g. Instructor for
+// http://purl.imsglobal.org/vocab/lis/v2/membership#Instructor, and reports whether
+// the role was one. Short role names count as course roles; institution and system
+// roles do not.
+func ContextRole(role string) (string, bool) {
+	const membership = "http://purl.imsglobal.org/vocab/lis/v2/membership"
+	if !strings.Contains(role, "/") {
+		return role, true
+	}
+	if !strings.HasPrefix(role, membership) {
+		return "", false
+	}
+	return role[strings.LastIndexAny(role, "#/")+1:], true
+}
+
+// DeepLinkingResponse is the message a tool posts back to the platform with the
+// content items picked by the user.
+type DeepLinkingResponse struct {
+	jwt.RegisteredClaims
+	Nonce        string        `json:"nonce"`
+	MessageType  string        `json:"https://purl.imsglobal.org/spec/lti/claim/message_type"`
+	Version      string        `json:"https://purl.imsglobal.org/spec/lti/claim/version"`
+	DeploymentID string        `json:"https://purl.imsglobal.org/spec/lti/claim/deployment_id"`
+	Data         string        `json:"https://purl.imsglobal.org/spec/lti-dl/claim/data,omitempty"`
+	ContentItems []ContentItem `json:"https://purl.imsglobal.org/spec/lti-dl/claim/content_items"`
+}
+
+type ContentItem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title,omitempty"`
+	Text     string            `json:"text,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Custom   map[string]string `json:"custom,omitempty"`
+	LineItem *LineItem         `json:"lineItem,omitempty"`
+}
+
+// LineItem asks the platform to create a gradebook column for a content item.
+type LineItem struct {
+	Label        string  `json:"label,omitempty"`
+	ScoreMaximum float64 `json:"scoreMaximum"`
+	ResourceID   string  `json:"resourceId,omitempty"`
+	Tag          string  `json:"tag,omitempty"`
+}
+
+// Score is posted to a line item's scores endpoint.
+type Score struct {
+	UserID           string  `json:"userId"`
+	ScoreGiven       float64 `json:"scoreGiven"`
+	ScoreMaximum     float64 `json:"scoreMaximum"`
+	ActivityProgress string  `json:"activityProgress"`
+	GradingProgress  string  `json:"gradingProgress"`
+	Timestamp        string  `json:"timestamp"`
+}
+
+// ScoresURL returns the scores endpoint of a line item, which is the line item
+// URL with /scores appended to its path.
+func ScoresURL(lineItem string) (string, error) {
+	u, err := url.Parse(lineItem)
+	if err != nil {
+		return "", err
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/scores"
+	return u.String(), nil
+}
+
+// TokenResponse is the platform's answer to a client credentials grant.
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
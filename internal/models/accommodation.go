@@ -0,0 +1,39 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Accommodation is a documented adjustment for a candidate with an accessibility
+// need, granted either to a user for all their tests or to one invitation.
+// TimeMultiplier scales the test's duration and each question's time limit and
+// display time. Accommodations are never edited: granting another to the same user
+// or invitation revokes the current one, so the rows are the audit trail of who
+// granted what and when.
+type Accommodation struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID uint       `json:"organization_id" gorm:"index;not null"`
+	UserID         *uint      `json:"user_id,omitempty" gorm:"index"`
+	InvitationID   *uint      `json:"invitation_id,omitempty" gorm:"index"`
+	TimeMultiplier float64    `json:"time_multiplier" gorm:"not null"`
+	Reason         string     `json:"reason"`
+	GrantedByID    *uint      `json:"granted_by_id,omitempty"`
+	GrantedByKeyID *uint      `json:"granted_by_api_key_id,omitempty"`
+	GrantedAt      time.Time  `json:"granted_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	RevokedByID    *uint      `json:"revoked_by_id,omitempty"`
+	RevokedByKeyID *uint      `json:"revoked_by_api_key_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ScaleTimeLimit stretches a time limit in whole seconds or minutes by the
+// multiplier, rounding up. Zero, meaning no limit, stays zero.
+func ScaleTimeLimit(limit int, multiplier float64) int {
+	if limit <= 0 || multiplier <= 1 {
+		return limit
+	}
+	// the tolerance keeps products such as 10 * 1.1 from rounding up a whole unit
+	return int(math.Ceil(float64(limit)*multiplier - 1e-9))
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// APIKeyPermissions are the permissions an API key may be granted. Keys act for an
+// organization rather than a person, so permissions tied to a user's own tests and
+// results, and those that manage access itself, are left out, except for the
+// provisioning permission an identity provider's SCIM token needs.
+var APIKeyPermissions = []string{
+	PermReadAllResults,
+	PermExportResults,
+	PermManageQuestions,
+	PermManageTests,
+	PermInviteCandidates,
+	PermAccommodate,
+	PermManageWebhooks,
+	PermProvisionUsers,
+}
+
+// APIKey authenticates a server-to-server integration of one organization. Only a
+// hash of the key is stored; Prefix is kept so keys can be told apart in listings.
+type APIKey struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID uint       `json:"organization_id" gorm:"index;not null"`
+	Name           string     `json:"name" gorm:"not null"`
+	Prefix         string     `json:"prefix" gorm:"not null"`
+	KeyHash        string     `json:"-" gorm:"uniqueIndex;not null"`
+	Permissions    []string   `json:"permissions" gorm:"serializer:json;type:text;not null"`
+	CreatedByID    uint       `json:"created_by_id"`
+	RotatedFromID  *uint      `json:"rotated_from_id,omitempty"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP     string     `json:"last_used_ip,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
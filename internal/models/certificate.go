@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// Certificate is a publicly shareable, signed snapshot of a result.
+type Certificate struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID uint       `json:"organization_id" gorm:"index;not null"`
+	UserID         uint       `json:"user_id" gorm:"index;not null"`
+	TestResultID   uint       `json:"test_result_id" gorm:"index;not null"`
+	Slug           string     `json:"slug" gorm:"uniqueIndex;not null"`
+	Payload        string     `json:"payload" gorm:"type:text;not null"` // the exact JSON that was signed
+	Signature      string     `json:"signature" gorm:"not null"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// CertificatePayload is the signed content of a certificate.
+type CertificatePayload struct {
+	Slug           string    `json:"certificate_id"`
+	Issuer         string    `json:"issuer"`
+	Name           string    `json:"name"`
+	TestName       string    `json:"test_name"`
+	Score          int       `json:"score"`
+	TotalQuestions int       `json:"total_questions"`
+	Percentage     float64   `json:"percentage"`
+	CompletedAt    time.Time `json:"completed_at"`
+	IssuedAt       time.Time `json:"issued_at"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type InvitationStatus string
+
+const (
+	InvitationSent      InvitationStatus = "sent"
+	InvitationOpened    InvitationStatus = "opened"
+	InvitationStarted   InvitationStatus = "started"
+	InvitationCompleted InvitationStatus = "completed"
+)
+
+type Invitation struct {
+	ID             uint             `json:"id" gorm:"primaryKey"`
+	OrganizationID uint             `json:"organization_id" gorm:"index;not null"`
+	TestID         uint             `json:"test_id" gorm:"not null"`
+	InvitedByID    uint             `json:"invited_by_id"`
+	InvitedByKeyID *uint            `json:"invited_by_api_key_id,omitempty"`
+	Email          string           `json:"email" gorm:"not null"`
+	Name           string           `json:"name"`
+	TokenHash      string           `json:"-" gorm:"uniqueIndex;not null"`
+	Status         InvitationStatus `json:"status" gorm:"not null;default:sent"`
+	ExpiresAt      time.Time        `json:"expires_at"`
+	OpenedAt       *time.Time       `json:"opened_at,omitempty"`
+	StartedAt      *time.Time       `json:"started_at,omitempty"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
+	UserID         *uint            `json:"user_id,omitempty"`
+	TestResultID   *uint            `json:"test_result_id,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt   `json:"-" gorm:"index"`
+
+	Test       Test        `json:"test,omitempty" gorm:"foreignKey:TestID"`
+	TestResult *TestResult `json:"test_result,omitempty" gorm:"foreignKey:TestResultID"`
+}
+
+// InvitationResumeWindow is how long after starting a candidate may come back to
+// an invitation link to finish, even if the invitation expires meanwhile.
+const InvitationResumeWindow = 24 * time.Hour
+
+// IsExpired reports whether the link no longer works: after ExpiresAt, or for a
+// started invitation after the resume window if that ends later.
+func (i *Invitation) IsExpired() bool {
+	until := i.ExpiresAt
+	if i.Status == InvitationStarted && i.StartedAt != nil && i.StartedAt.Add(InvitationResumeWindow).After(until) {
+		until = i.StartedAt.Add(InvitationResumeWindow)
+	}
+	return time.Now().After(until)
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+type LeaderboardPeriod string
+
+const (
+	PeriodAllTime LeaderboardPeriod = "all"
+	PeriodWeek    LeaderboardPeriod = "week"
+	PeriodMonth   LeaderboardPeriod = "month"
+)
+
+// LeaderboardProfile holds a user's leaderboard consent. Users only appear on
+// leaderboards once they opt in, and then only under their pseudonym.
+type LeaderboardProfile struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"uniqueIndex;not null"`
+	OptedIn   bool      `json:"opted_in" gorm:"not null;default:false"`
+	Pseudonym string    `json:"pseudonym" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LeaderboardEntry is a user's best result on a test, overall or in one category,
+// within a leaderboard period. Entries are kept up to date as results are scored,
+// so leaderboards never have to scan the results table. Category is empty for the
+// whole test and PeriodStart is zero for all time. Score is a percentage, and ties
+// go to the lower TimeTaken unless either entry is Accommodated: a result given
+// extra time only ties on time.
+type LeaderboardEntry struct {
+	ID             uint              `gorm:"primaryKey"`
+	OrganizationID uint              `gorm:"index;not null"`
+	UserID         uint              `gorm:"uniqueIndex:idx_leaderboard_entry;not null"`
+	TestID         uint              `gorm:"uniqueIndex:idx_leaderboard_entry;index:idx_leaderboard_test,priority:1;not null"`
+	Category       Category          `gorm:"uniqueIndex:idx_leaderboard_entry;index:idx_leaderboard_test,priority:2;index:idx_leaderboard_category,priority:1;not null;default:''"`
+	Period         LeaderboardPeriod `gorm:"uniqueIndex:idx_leaderboard_entry;index:idx_leaderboard_test,priority:3;index:idx_leaderboard_category,priority:2;not null"`
+	PeriodStart    time.Time         `gorm:"uniqueIndex:idx_leaderboard_entry;index:idx_leaderboard_test,priority:4;index:idx_leaderboard_category,priority:3;not null"`
+	Score          float64           `gorm:"index:idx_leaderboard_test,priority:5,sort:desc;index:idx_leaderboard_category,priority:4,sort:desc;not null"`
+	TimeTaken      int               `gorm:"not null"`
+	Accommodated   bool              `gorm:"not null;default:false"`
+	TestResultID   uint              `gorm:"not null"`
+	AchievedAt     time.Time         `gorm:"not null"`
+}
+
+// PeriodStart returns the start of the period containing t: Monday 00:00 UTC for
+// weeks, the first of the month for months, and the zero time for all time.
+func PeriodStart(period LeaderboardPeriod, t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case PeriodWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case PeriodMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Time{}
+	}
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LTIPlatform is a learning platform registered with one organization. Launches
+// from it sign in users of that organization as candidates. StaffRoles maps
+// course roles, such as Instructor, to the roles a launch with them also signs in
+// with; those roles last for that sign-in and are never saved on the user.
+type LTIPlatform struct {
+	ID             uint                `json:"id" gorm:"primaryKey"`
+	OrganizationID uint                `json:"organization_id" gorm:"index;not null"`
+	Name           string              `json:"name" gorm:"not null"`
+	Issuer         string              `json:"issuer" gorm:"index;not null"`
+	ClientID       string              `json:"client_id" gorm:"not null"`
+	DeploymentIDs  []string            `json:"deployment_ids" gorm:"serializer:json;type:text;not null"`
+	AuthLoginURL   string              `json:"auth_login_url" gorm:"not null"`
+	AccessTokenURL string              `json:"access_token_url" gorm:"not null"`
+	JWKSURL        string              `json:"jwks_url" gorm:"not null"`
+	Active         bool                `json:"active" gorm:"not null;default:true"`
+	LinkByEmail    bool                `json:"link_by_email" gorm:"not null;default:false"` // a first launch may take over the account with its email
+	StaffRoles     map[string][]string `json:"staff_roles" gorm:"serializer:json;type:text"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt      `json:"-" gorm:"index"`
+}
+
+func (p *LTIPlatform) HasDeployment(deploymentID string) bool {
+	for _, id := range p.DeploymentIDs {
+		if id == deploymentID {
+			return true
+		}
+	}
+	return false
+}
+
+// LTILogin is an OIDC login a platform started, waiting for its launch. The state
+// and nonce can be used once.
+type LTILogin struct {
+	ID         uint      `gorm:"primaryKey"`
+	PlatformID uint      `gorm:"not null"`
+	State      string    `gorm:"uniqueIndex;not null"`
+	Nonce      string    `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"index"`
+	CreatedAt  time.Time
+}
+
+// LTIIdentity links a platform's user ID (the launch's sub claim) to a user.
+type LTIIdentity struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	PlatformID uint      `json:"platform_id" gorm:"uniqueIndex:idx_lti_identity;not null"`
+	Subject    string    `json:"subject" gorm:"uniqueIndex:idx_lti_identity;not null"`
+	UserID     uint      `json:"user_id" gorm:"index;not null"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// LTIResourceLink is a placement of a test in a platform's course. The test is
+// chosen through deep linking and sent back in the launch's custom parameters.
+// Scores are posted to LineItemURL when the platform provides one.
+type LTIResourceLink struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"index;not null"`
+	PlatformID     uint      `json:"platform_id" gorm:"uniqueIndex:idx_lti_resource_link;not null"`
+	LinkID         string    `json:"link_id" gorm:"uniqueIndex:idx_lti_resource_link;not null"`
+	DeploymentID   string    `json:"deployment_id"`
+	ContextID      string    `json:"context_id"`
+	ContextTitle   string    `json:"context_title"`
+	Title          string    `json:"title"`
+	TestID         uint      `json:"test_id" gorm:"index"`
+	LineItemURL    string    `json:"line_item_url"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// LTILaunch records that a user launched a resource link, which is what makes
+// their results for its test go to the platform's gradebook.
+type LTILaunch struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ResourceLinkID uint      `json:"resource_link_id" gorm:"uniqueIndex:idx_lti_launch;not null"`
+	UserID         uint      `json:"user_id" gorm:"uniqueIndex:idx_lti_launch;not null"`
+	Subject        string    `json:"subject" gorm:"not null"`
+	LaunchedAt     time.Time `json:"launched_at"`
+}
+
+// LTIScore is a score queued for a platform's gradebook. Like webhook deliveries,
+// scores are written in the transaction that scored the result and sent by a
+// dispatcher until they succeed or run out of attempts.
+type LTIScore struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	OrganizationID uint           `json:"organization_id" gorm:"index;not null"`
+	ResourceLinkID uint           `json:"resource_link_id" gorm:"index;not null"`
+	TestResultID   uint           `json:"test_result_id" gorm:"not null"`
+	Subject        string         `json:"subject" gorm:"not null"`
+	ScoreGiven     int            `json:"score_given"`
+	ScoreMaximum   int            `json:"score_maximum"`
+	Timestamp      time.Time      `json:"timestamp"`
+	Status         DeliveryStatus `json:"status" gorm:"index:idx_lti_score_due,priority:1;not null;default:pending"`
+	Attempts       int            `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time      `json:"next_attempt_at" gorm:"index:idx_lti_score_due,priority:2"`
+	LastStatusCode int            `json:"last_status_code"`
+	LastError      string         `json:"last_error"`
+	SentAt         *time.Time     `json:"sent_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+
+	ResourceLink LTIResourceLink `json:"-" gorm:"foreignKey:ResourceLinkID"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// PracticeSession is a warm-up run through a test. Practice is kept apart from
+// TestResult so it never counts as an attempt, towards norms or in analytics.
+type PracticeSession struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID uint       `json:"organization_id" gorm:"index;not null"`
+	UserID         uint       `json:"user_id" gorm:"index;not null"`
+	TestID         uint       `json:"test_id" gorm:"not null"`
+	Score          int        `json:"score"`
+	ScoreHidden    bool       `json:"score_hidden,omitempty" gorm:"-"` // the test does not reveal answers yet
+	Answered       int        `json:"answered"`
+	TotalQuestions int        `json:"total_questions"`
+	StartedAt      time.Time  `json:"started_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	Test    Test             `json:"test,omitempty" gorm:"foreignKey:TestID"`
+	Answers []PracticeAnswer `json:"answers,omitempty" gorm:"foreignKey:PracticeSessionID"`
+}
+
+// HideScore clears the score and answers, which would tell the candidate which
+// questions they got right, before a session of a test that does not reveal its
+// answers is shown.
+func (p *PracticeSession) HideScore() {
+	p.Score = 0
+	p.Answers = nil
+	p.ScoreHidden = true
+}
+
+type PracticeAnswer struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	PracticeSessionID uint      `json:"practice_session_id" gorm:"uniqueIndex:idx_practice_answer;not null"`
+	QuestionID        uint      `json:"question_id" gorm:"uniqueIndex:idx_practice_answer;not null"`
+	UserAnswer        string    `json:"user_answer"`
+	IsCorrect         bool      `json:"is_correct"`
+	ResponseTime      int       `json:"response_time"` // in milliseconds
+	CreatedAt         time.Time `json:"created_at"`
+}
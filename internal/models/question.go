@@ -33,6 +33,8 @@ type Question struct {
 	Category      Category       `json:"category" gorm:"not null"`
 	Options       string         `json:"options,omitempty" gorm:"type:text"` // JSON array for multiple choice
 	CorrectAnswer string         `json:"correct_answer" gorm:"not null"`
+	Explanation   string         `json:"explanation,omitempty" gorm:"type:text"`
+	Reference     string         `json:"reference,omitempty" gorm:"type:text"`
 	TimeLimit     int            `json:"time_limit"`   // in seconds
 	DisplayTime   int            `json:"display_time"` // in seconds for memory questions
 	OrderIndex    int            `json:"order_index"`
@@ -42,3 +44,18 @@ type Question struct {
 
 	Test Test `json:"test,omitempty" gorm:"foreignKey:TestID"`
 }
+
+// HideAnswer clears everything that would give the answer away, before a question
+// is shown to a candidate.
+func (q *Question) HideAnswer() {
+	q.CorrectAnswer = ""
+	q.Explanation = ""
+	q.Reference = ""
+}
+
+// ScaleTime gives the question's time limit and display time to a candidate
+// allowed multiplier times as long.
+func (q *Question) ScaleTime(multiplier float64) {
+	q.TimeLimit = ScaleTimeLimit(q.TimeLimit, multiplier)
+	q.DisplayTime = ScaleTimeLimit(q.DisplayTime, multiplier)
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+// ReliabilityReport is one computed snapshot of a test's psychometric properties.
+// Reports are never updated; every run that sees new results adds a version, so
+// the history of a test's reliability is kept.
+type ReliabilityReport struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"index;not null"`
+	TestID         uint      `json:"test_id" gorm:"uniqueIndex:idx_reliability_test_version;not null"`
+	Version        int       `json:"version" gorm:"uniqueIndex:idx_reliability_test_version;not null"`
+	ExcludedCount  int       `json:"excluded_attempts"`
+	Accommodated   int       `json:"accommodated_attempts"` // counted attempts given extra time, left out
+	ComputedAt     time.Time `json:"computed_at"`
+
+	// The counted results seen, to skip runs when none was added, removed or changed.
+	ResultCount      int        `json:"result_count"`
+	ResultsUpdatedAt *time.Time `json:"results_updated_at"`
+
+	// The test's questions seen, to recompute when one was added, removed or edited.
+	QuestionCount      int        `json:"question_count"`
+	QuestionsUpdatedAt *time.Time `json:"questions_updated_at"`
+
+	Overall      ScaleReliability      `json:"overall" gorm:"embedded;embeddedPrefix:overall_"`
+	Subscales    []ScaleReliability    `json:"subscales" gorm:"serializer:json;type:text"`
+	Correlations []CategoryCorrelation `json:"correlations" gorm:"serializer:json;type:text"`
+}
+
+// ScaleReliability describes the whole test or one category subscale. Statistics
+// that cannot be computed, such as alpha for a single item, are null.
+type ScaleReliability struct {
+	Category      Category `json:"category,omitempty"`
+	Items         int      `json:"items"`
+	Attempts      int      `json:"attempts"`
+	Mean          float64  `json:"mean"`
+	SD            float64  `json:"sd"`
+	CronbachAlpha *float64 `json:"cronbach_alpha"`
+	SplitHalf     *float64 `json:"split_half"` // odd-even, Spearman-Brown corrected
+	SEM           *float64 `json:"sem"`        // standard error of measurement, from alpha
+}
+
+// CategoryCorrelation is the Pearson correlation between two subscale scores.
+type CategoryCorrelation struct {
+	A Category `json:"a"`
+	B Category `json:"b"`
+	R *float64 `json:"r"`
+}
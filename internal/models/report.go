@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// ReportTemplate holds an organization's branding for generated PDF reports.
+type ReportTemplate struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID uint      `json:"organization_id" gorm:"uniqueIndex;not null"`
+	Title          string    `json:"title"`
+	Subtitle       string    `json:"subtitle"`
+	PrimaryColor   string    `json:"primary_color"` // hex, e.g. #1f4e79
+	FooterText     string    `json:"footer_text"`
+	ShowReview     bool      `json:"show_review" gorm:"not null;default:true"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func DefaultReportTemplate(orgID uint) ReportTemplate {
+	return ReportTemplate{
+		OrganizationID: orgID,
+		Title:          "Cognitive Assessment Report",
+		PrimaryColor:   "#2c3e50",
+		ShowReview:     true,
+	}
+}
@@ -6,28 +6,58 @@ import (
 	"gorm.io/gorm"
 )
 
+// AttemptEndReason says why an attempt ended other than by the candidate
+// submitting it.
+type AttemptEndReason string
+
+const (
+	AttemptExpired    AttemptEndReason = "expired"    // not submitted by its deadline
+	AttemptTerminated AttemptEndReason = "terminated" // ended by a proctor
+)
+
 type TestResult struct {
-	ID             uint           `json:"id" gorm:"primaryKey"`
-	UserID         uint           `json:"user_id" gorm:"not null"`
-	TestID         uint           `json:"test_id" gorm:"not null"`
-	Score          int            `json:"score"`
-	TotalQuestions int            `json:"total_questions"`
-	TimeTaken      int            `json:"time_taken"` // in seconds
-	StartedAt      time.Time      `json:"started_at"`
-	CompletedAt    *time.Time     `json:"completed_at,omitempty"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
-
-	User    User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	ID              uint             `json:"id" gorm:"primaryKey"`
+	OrganizationID  uint             `json:"organization_id" gorm:"index"`
+	UserID          uint             `json:"user_id" gorm:"not null"`
+	TestID          uint             `json:"test_id" gorm:"not null"`
+	Score           int              `json:"score"`
+	TotalQuestions  int              `json:"total_questions"`
+	TimeTaken       int              `json:"time_taken"` // in seconds
+	Counted         bool             `json:"counted"`    // the attempt selected by the test's scoring policy
+	StartedAt       time.Time        `json:"started_at"`
+	CompletedAt     *time.Time       `json:"completed_at,omitempty"`
+	SessionID       *uint            `json:"session_id,omitempty" gorm:"index"`
+	Deadline        *time.Time       `json:"deadline,omitempty"`  // submissions are refused after it; nil when untimed
+	PausedAt        *time.Time       `json:"paused_at,omitempty"` // its session is paused; the time left is Deadline minus PausedAt
+	EndReason       AttemptEndReason `json:"end_reason,omitempty"`
+	AccommodationID *uint            `json:"accommodation_id,omitempty"`
+	TimeMultiplier  float64          `json:"time_multiplier" gorm:"not null;default:1"` // above 1 when accommodated; keep such results apart in norm comparisons
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt   `json:"-" gorm:"index"`
+
+	User    *User    `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Test    Test     `json:"test,omitempty" gorm:"foreignKey:TestID"`
 	Answers []Answer `json:"answers,omitempty" gorm:"foreignKey:TestResultID"`
+
+	ServedQuestions []AttemptQuestion `json:"-" gorm:"foreignKey:TestResultID"`
+}
+
+// AttemptQuestion records a question served in an attempt, so the attempt is
+// scored against what the user was shown even if the test changes afterwards.
+type AttemptQuestion struct {
+	ID           uint `json:"id" gorm:"primaryKey"`
+	TestResultID uint `json:"test_result_id" gorm:"index;not null"`
+	QuestionID   uint `json:"question_id" gorm:"not null"`
+	Position     int  `json:"position"`
 }
 
+// Answer is the response to one served question; an attempt has at most one per
+// question.
 type Answer struct {
 	ID           uint           `json:"id" gorm:"primaryKey"`
-	TestResultID uint           `json:"test_result_id" gorm:"not null"`
-	QuestionID   uint           `json:"question_id" gorm:"not null"`
+	TestResultID uint           `json:"test_result_id" gorm:"uniqueIndex:idx_answer_question;not null"`
+	QuestionID   uint           `json:"question_id" gorm:"uniqueIndex:idx_answer_question;not null"`
 	UserAnswer   string         `json:"user_answer"`
 	IsCorrect    bool           `json:"is_correct"`
 	ResponseTime int            `json:"response_time"` // in milliseconds
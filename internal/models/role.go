@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RoleCandidate     = "candidate"
+	RoleProctor       = "proctor"
+	RoleRecruiter     = "recruiter"
+	RoleContentAuthor = "content_author"
+	RoleOrgAdmin      = "org_admin"
+	RoleAdmin         = "admin"
+)
+
+const (
+	PermTakeTests        = "tests:take"
+	PermReadOwnResults   = "results:read_own"
+	PermReadAllResults   = "results:read_all"
+	PermExportResults    = "results:export"
+	PermManageQuestions  = "questions:manage"
+	PermManageTests      = "tests:manage"
+	PermInviteCandidates = "invitations:manage"
+	PermProctor          = "sessions:proctor"
+	PermAccommodate      = "accommodations:manage"
+	PermManageUsers      = "users:manage"
+	PermAssignRoles      = "roles:assign"
+	PermManageRoles      = "roles:manage"
+	PermManageOrgs       = "organizations:manage"
+	PermManageWebhooks   = "webhooks:manage"
+	PermManageAPIKeys    = "api_keys:manage"
+	PermManageLTI        = "lti:manage"
+	PermProvisionUsers   = "users:provision"
+)
+
+// GlobalPermissions act across organizations. Organization admins cannot grant
+// roles that include them, and SCIM neither offers such roles as groups nor
+// changes the users holding them.
+var GlobalPermissions = []string{PermManageRoles, PermManageOrgs}
+
+// orgAdminPermissions is everything needed to run a single organization.
+var orgAdminPermissions = []string{
+	PermTakeTests,
+	PermReadOwnResults,
+	PermReadAllResults,
+	PermExportResults,
+	PermManageQuestions,
+	PermManageTests,
+	PermInviteCandidates,
+	PermProctor,
+	PermAccommodate,
+	PermManageUsers,
+	PermAssignRoles,
+	PermManageWebhooks,
+	PermManageAPIKeys,
+	PermManageLTI,
+	PermProvisionUsers,
+}
+
+// DefaultRolePermissions is the permission set each built-in role is seeded with.
+var DefaultRolePermissions = map[string][]string{
+	RoleCandidate:     {PermTakeTests, PermReadOwnResults},
+	RoleProctor:       {PermProctor, PermAccommodate, PermReadAllResults},
+	RoleRecruiter:     {PermReadAllResults, PermInviteCandidates},
+	RoleContentAuthor: {PermManageQuestions, PermManageTests},
+	RoleOrgAdmin:      orgAdminPermissions,
+	RoleAdmin:         append(append([]string{}, orgAdminPermissions...), PermManageRoles, PermManageOrgs),
+}
+
+type Permission struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"uniqueIndex;not null"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type Role struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"uniqueIndex;not null"`
+	Description string         `json:"description"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Permissions []Permission `json:"permissions,omitempty" gorm:"many2many:role_permissions"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// SCIMUser records that a user is managed by the organization's identity provider
+// through SCIM, with the provider's own ID for it. RemovedAt is set when the
+// provider deletes the user: the account stays soft-deleted so its results are
+// kept, but SCIM no longer returns it.
+type SCIMUser struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	OrganizationID uint       `json:"organization_id" gorm:"index;not null"`
+	UserID         uint       `json:"user_id" gorm:"uniqueIndex;not null"`
+	ExternalID     string     `json:"external_id" gorm:"index"`
+	RemovedAt      *time.Time `json:"removed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionStatus string
+
+const (
+	SessionScheduled SessionStatus = "scheduled"
+	SessionRunning   SessionStatus = "running"
+	SessionPaused    SessionStatus = "paused"
+	SessionEnded     SessionStatus = "ended"
+)
+
+// Session is a proctored sitting of a test by a group of candidates. Candidates
+// wait in the lobby until the proctor starts it, then share one deadline: EndsAt,
+// which pauses move back. A candidate's attempt is due at EndsAt plus their
+// ExtraMinutes and AccommodationMinutes.
+type Session struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	OrganizationID uint           `json:"organization_id" gorm:"index;not null"`
+	TestID         uint           `json:"test_id" gorm:"not null"`
+	ProctorID      uint           `json:"proctor_id"` // who scheduled it
+	Name           string         `json:"name"`
+	Status         SessionStatus  `json:"status" gorm:"not null;default:scheduled"`
+	ScheduledAt    time.Time      `json:"scheduled_at"`
+	StartedAt      *time.Time     `json:"started_at,omitempty"`
+	EndsAt         *time.Time     `json:"ends_at,omitempty"`
+	PausedAt       *time.Time     `json:"paused_at,omitempty"`
+	EndedAt        *time.Time     `json:"ended_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Test       Test               `json:"test,omitempty" gorm:"foreignKey:TestID"`
+	Candidates []SessionCandidate `json:"candidates,omitempty" gorm:"foreignKey:SessionID"`
+}
+
+// SessionCandidate is a user scheduled into a session.
+type SessionCandidate struct {
+	ID                   uint       `json:"id" gorm:"primaryKey"`
+	SessionID            uint       `json:"session_id" gorm:"uniqueIndex:idx_session_candidate;not null"`
+	UserID               uint       `json:"user_id" gorm:"uniqueIndex:idx_session_candidate;not null"`
+	ExtraMinutes         int        `json:"extra_minutes"`
+	AccommodationMinutes int        `json:"accommodation_minutes"` // from the candidate's time multiplier
+	JoinedAt             *time.Time `json:"joined_at,omitempty"`   // entered the lobby
+	TestResultID         *uint      `json:"test_result_id,omitempty"`
+	TerminatedAt         *time.Time `json:"terminated_at,omitempty"`
+	TerminatedByID       *uint      `json:"terminated_by_id,omitempty"`
+	TerminationReason    string     `json:"termination_reason,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+
+	User       *User       `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	TestResult *TestResult `json:"test_result,omitempty" gorm:"foreignKey:TestResultID"`
+}
+
+// Candidate returns the user's place in the session, or nil. Candidates must be
+// loaded.
+func (s *Session) Candidate(userID uint) *SessionCandidate {
+	for i := range s.Candidates {
+		if s.Candidates[i].UserID == userID {
+			return &s.Candidates[i]
+		}
+	}
+	return nil
+}
+
+// Deadline is when the candidate's attempt is due, or nil before the session
+// starts and after it ends. While paused it stays where the pause found it, and
+// resuming moves it back by the length of the pause.
+func (s *Session) Deadline(candidate *SessionCandidate) *time.Time {
+	if (s.Status != SessionRunning && s.Status != SessionPaused) || s.EndsAt == nil {
+		return nil
+	}
+	deadline := s.EndsAt.Add(time.Duration(candidate.ExtraMinutes+candidate.AccommodationMinutes) * time.Minute)
+	return &deadline
+}
@@ -6,14 +6,53 @@ import (
 	"gorm.io/gorm"
 )
 
+type ScoringPolicy string
+
+const (
+	ScoreFirstAttempt  ScoringPolicy = "first"
+	ScoreBestAttempt   ScoringPolicy = "best"
+	ScoreLatestAttempt ScoringPolicy = "latest"
+)
+
+// AnswerReveal controls when candidates may see correct answers and explanations
+// for their attempts.
+type AnswerReveal string
+
+const (
+	RevealImmediately AnswerReveal = "immediately"
+	RevealAfterClose  AnswerReveal = "after_close"
+	RevealNever       AnswerReveal = "never"
+)
+
 type Test struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	Name        string         `json:"name" gorm:"not null"`
-	Description string         `json:"description"`
-	Duration    int            `json:"duration"` // in minutes
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	OrganizationID  uint           `json:"organization_id" gorm:"index"`
+	Name            string         `json:"name" gorm:"not null"`
+	Description     string         `json:"description"`
+	Duration        int            `json:"duration"`         // in minutes
+	MaxAttempts     int            `json:"max_attempts"`     // 0 means unlimited
+	CooldownMinutes int            `json:"cooldown_minutes"` // minimum gap between attempts
+	OpensAt         *time.Time     `json:"opens_at,omitempty"`
+	ClosesAt        *time.Time     `json:"closes_at,omitempty"`
+	ScoringPolicy   ScoringPolicy  `json:"scoring_policy" gorm:"not null;default:latest"`
+	AnswerReveal    AnswerReveal   `json:"answer_reveal" gorm:"not null;default:never"`
+	PracticeEnabled bool           `json:"practice_enabled" gorm:"not null;default:false"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 
 	Questions []Question `json:"questions,omitempty" gorm:"foreignKey:TestID"`
 }
+
+// AnswersRevealed reports whether candidates may see the answers at the given time.
+// Tests revealed after closing never reveal if they have no closing date.
+func (t *Test) AnswersRevealed(now time.Time) bool {
+	switch t.AnswerReveal {
+	case RevealImmediately:
+		return true
+	case RevealAfterClose:
+		return t.ClosesAt != nil && now.After(*t.ClosesAt)
+	default:
+		return false
+	}
+}
@@ -7,14 +7,42 @@ import (
 )
 
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
-	Password  string         `json:"-" gorm:"not null"`
-	FirstName string         `json:"first_name" gorm:"not null"`
-	LastName  string         `json:"last_name" gorm:"not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	OrganizationID uint           `json:"organization_id" gorm:"index"`
+	Email          string         `json:"email" gorm:"uniqueIndex;not null"`
+	Password       string         `json:"-" gorm:"not null"`
+	FirstName      string         `json:"first_name" gorm:"not null"`
+	LastName       string         `json:"last_name" gorm:"not null"`
+	TokenVersion   int            `json:"-" gorm:"not null;default:0"` // raised to revoke the tokens issued so far
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
 
-	TestResults []TestResult `json:"test_results,omitempty" gorm:"foreignKey:UserID"`
+	Organization *Organization `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+	Roles        []Role        `json:"roles,omitempty" gorm:"many2many:user_roles"`
+	TestResults  []TestResult  `json:"test_results,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func (u *User) RoleNames() []string {
+	names := make([]string, 0, len(u.Roles))
+	for _, role := range u.Roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+// PermissionNames returns the de-duplicated union of permissions granted by the user's roles.
+// Roles must be preloaded together with their permissions.
+func (u *User) PermissionNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, role := range u.Roles {
+		for _, permission := range role.Permissions {
+			if !seen[permission.Name] {
+				seen[permission.Name] = true
+				names = append(names, permission.Name)
+			}
+		}
+	}
+	return names
 }
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookEvent string
+
+const (
+	EventAttemptStarted   WebhookEvent = "attempt.started"
+	EventAttemptSubmitted WebhookEvent = "attempt.submitted"
+	EventResultScored     WebhookEvent = "result.scored"
+)
+
+var WebhookEvents = []WebhookEvent{EventAttemptStarted, EventAttemptSubmitted, EventResultScored}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// WebhookEndpoint is a URL an organization receives events at. Every request is
+// signed with the endpoint's secret.
+type WebhookEndpoint struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	OrganizationID uint           `json:"organization_id" gorm:"index;not null"`
+	URL            string         `json:"url" gorm:"not null"`
+	Description    string         `json:"description"`
+	Events         []WebhookEvent `json:"events" gorm:"serializer:json;type:text;not null"`
+	Secret         string         `json:"-" gorm:"not null"`
+	Active         bool           `json:"active" gorm:"not null;default:true"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (e *WebhookEndpoint) Subscribes(event WebhookEvent) bool {
+	for _, subscribed := range e.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for one endpoint. Deliveries are written in
+// the same transaction as the change that raised the event (a transactional
+// outbox) and sent by the dispatcher until they succeed or run out of attempts.
+type WebhookDelivery struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	OrganizationID uint           `json:"organization_id" gorm:"index;not null"`
+	EndpointID     uint           `json:"endpoint_id" gorm:"index;not null"`
+	EventID        string         `json:"event_id" gorm:"index;not null"`
+	Event          WebhookEvent   `json:"event" gorm:"not null"`
+	Payload        string         `json:"payload" gorm:"type:text;not null"`
+	Status         DeliveryStatus `json:"status" gorm:"index:idx_webhook_delivery_due,priority:1;not null;default:pending"`
+	Attempts       int            `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time      `json:"next_attempt_at" gorm:"index:idx_webhook_delivery_due,priority:2"`
+	LastStatusCode int            `json:"last_status_code"`
+	LastError      string         `json:"last_error"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+	ReplayOf       *uint          `json:"replay_of,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+
+	Endpoint WebhookEndpoint          `json:"-" gorm:"foreignKey:EndpointID"`
+	Log      []WebhookDeliveryAttempt `json:"log,omitempty" gorm:"foreignKey:DeliveryID"`
+}
+
+// WebhookDeliveryAttempt logs one HTTP request made for a delivery.
+type WebhookDeliveryAttempt struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	DeliveryID  uint      `json:"delivery_id" gorm:"index;not null"`
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  int       `json:"status_code"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+}
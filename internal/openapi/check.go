@@ -0,0 +1,48 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CheckRoutes compares the documented routes with those registered on a Gin
+// engine under prefix and describes every route missing from either side.
+func CheckRoutes(routes []Route, registered gin.RoutesInfo, prefix string) []string {
+	documented := make(map[string]bool, len(routes))
+	seen := make(map[string]bool, len(routes))
+	var problems []string
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		if documented[key] {
+			problems = append(problems, "documented twice: "+key)
+		}
+		documented[key] = true
+		if seen["id:"+route.ID] {
+			problems = append(problems, fmt.Sprintf("operation ID %s is used twice", route.ID))
+		}
+		seen["id:"+route.ID] = true
+	}
+
+	served := make(map[string]bool, len(registered))
+	for _, info := range registered {
+		if !strings.HasPrefix(info.Path, prefix) || info.Method == "HEAD" {
+			continue
+		}
+		key := info.Method + " " + info.Path
+		served[key] = true
+		if !documented[key] {
+			problems = append(problems, "not documented: "+key)
+		}
+	}
+	for key := range documented {
+		if !served[key] {
+			problems = append(problems, "documented but not served: "+key)
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
@@ -0,0 +1,339 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateClient writes the Go client for the spec: a type for every component
+// schema and a method on Client for every route. The package must also contain the
+// hand-written Client with its do, doMultipart and stream helpers.
+func (s *Spec) GenerateClient(pkg string) ([]byte, error) {
+	doc := s.Document()
+	g := &generator{doc: doc, types: make(map[string]bool)}
+
+	g.printf("// Code generated by cmd/openapi from the route table in internal/server. DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", pkg)
+	g.printf("import (\n\"context\"\n\"encoding/json\"\n\"io\"\n\"net/http\"\n\"net/url\"\n\"strconv\"\n\"time\"\n)\n\n")
+	// Not every generated file needs every import.
+	g.printf("var (\n_ = json.RawMessage{}\n_ = io.EOF\n_ = http.MethodGet\n_ = url.PathEscape\n_ = strconv.Itoa\n_ = time.Time{}\n)\n\n")
+
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.namedType(name, doc.Components.Schemas[name])
+	}
+
+	for _, route := range s.Routes {
+		op := doc.Operation(route.Method, OpenAPIPath(route.Path))
+		if err := g.method(route, op); err != nil {
+			return nil, err
+		}
+	}
+
+	// Types for inline schemas are collected while writing methods.
+	g.buf.Write(g.extra.Bytes())
+
+	source, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated client: %w", err)
+	}
+	return source, nil
+}
+
+type generator struct {
+	doc   *Document
+	buf   bytes.Buffer
+	extra bytes.Buffer
+	types map[string]bool
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// namedType declares a Go type for a schema.
+func (g *generator) namedType(name string, schema *Schema) {
+	var out bytes.Buffer
+	g.declare(&out, name, schema)
+	g.buf.Write(out.Bytes())
+}
+
+func (g *generator) declare(out *bytes.Buffer, name string, schema *Schema) {
+	if g.types[name] {
+		return
+	}
+	g.types[name] = true
+
+	switch {
+	case schema.Type == "string" && len(schema.Enum) > 0:
+		fmt.Fprintf(out, "type %s string\n\nconst (\n", name)
+		for _, value := range schema.Enum {
+			fmt.Fprintf(out, "%s%s %s = %q\n", name, goIdentifier(value), name, value)
+		}
+		fmt.Fprintf(out, ")\n\n")
+	case schema.Type == "object" && schema.Properties != nil:
+		fmt.Fprintf(out, "type %s struct {\n", name)
+		for _, property := range propertyNames(schema) {
+			field := schema.Properties[property]
+			fieldName := field.GoName
+			if fieldName == "" {
+				fieldName = goIdentifier(property)
+			}
+			tag := property
+			if !hasRule(strings.Join(schema.Required, ","), property) {
+				tag += ",omitempty"
+			}
+			fmt.Fprintf(out, "%s %s `json:%q`\n", fieldName, g.goType(field, name+fieldName), tag)
+		}
+		fmt.Fprintf(out, "}\n\n")
+	default:
+		fmt.Fprintf(out, "type %s %s\n\n", name, g.goType(schema, name+"Value"))
+	}
+}
+
+// goType returns the Go type for a schema. Inline objects become named types
+// called hint.
+func (g *generator) goType(schema *Schema, hint string) string {
+	if schema.Ref != "" {
+		return schema.RefName()
+	}
+	if len(schema.AllOf) == 1 && schema.Type == "" {
+		inner := g.goType(schema.AllOf[0], hint)
+		if schema.Nullable && !strings.HasPrefix(inner, "[]") && !strings.HasPrefix(inner, "map[") {
+			return "*" + inner
+		}
+		return inner
+	}
+
+	var t string
+	switch schema.Type {
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			t = "time.Time"
+		case "byte", "binary":
+			return "[]byte"
+		default:
+			t = "string"
+		}
+	case "integer":
+		t = "int64"
+		if schema.Format == "int32" {
+			t = "int32"
+		}
+	case "number":
+		t = "float64"
+	case "boolean":
+		t = "bool"
+	case "array":
+		return "[]" + g.goType(schema.Items, hint+"Item")
+	case "object":
+		if schema.Properties == nil {
+			if schema.AdditionalProperties == nil {
+				return "map[string]json.RawMessage"
+			}
+			return "map[string]" + g.goType(schema.AdditionalProperties, hint+"Value")
+		}
+		g.declare(&g.extra, hint, schema)
+		t = hint
+	default:
+		return "json.RawMessage"
+	}
+	if schema.Nullable {
+		return "*" + t
+	}
+	return t
+}
+
+// method writes the client method for a route.
+func (g *generator) method(route Route, op *Operation) error {
+	var params, args []string
+	params = append(params, "ctx context.Context")
+
+	// Path parameters become arguments, in order.
+	pathExpr := strconv.Quote(OpenAPIPath(route.Path))
+	for _, p := range op.Parameters {
+		if p.In != "path" {
+			continue
+		}
+		name := paramName(p.Name)
+		value := "url.PathEscape(" + name + ")"
+		if p.Schema.Type == "integer" {
+			params = append(params, name+" int64")
+			value = "strconv.FormatInt(" + name + ", 10)"
+		} else {
+			params = append(params, name+" string")
+		}
+		pathExpr = strings.Replace(pathExpr, "{"+p.Name+"}", `" + `+value+` + "`, 1)
+	}
+	pathExpr = strings.TrimSuffix(pathExpr, ` + ""`)
+
+	query := "nil"
+	if len(route.Query) > 0 {
+		paramsType := route.ID + "Params"
+		g.queryType(paramsType, route.Query)
+		params = append(params, "params *"+paramsType)
+		query = "params.values()"
+	}
+
+	if op.RequestBody != nil {
+		for mediaType, content := range op.RequestBody.Content {
+			schema := content.Schema
+			if mediaType == "multipart/form-data" {
+				schema = schema.AllOf[0]
+				params = append(params, "body "+g.goType(schema, route.ID+"Request"), "file io.Reader", "filename string")
+			} else {
+				params = append(params, "body "+g.goType(schema, route.ID+"Request"))
+			}
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := op.Responses[strconv.Itoa(status)]
+
+	var result string
+	if content, ok := response.Content["application/json"]; ok && route.Response != nil {
+		data := content.Schema.AllOf[1].Properties["data"]
+		result = g.goType(data, route.ID+"Response")
+		if !strings.HasPrefix(result, "[]") && !strings.HasPrefix(result, "map[") && !strings.HasPrefix(result, "*") {
+			result = "*" + result
+		}
+	}
+
+	method := "http.Method" + methodName(route.Method)
+	var body string
+	body += fmt.Sprintf("// %s calls %s %s.\n", route.ID, route.Method, OpenAPIPath(route.Path))
+	if route.Summary != "" {
+		body += fmt.Sprintf("// %s.\n", strings.TrimSuffix(route.Summary, "."))
+	}
+	if route.Permission != "" {
+		body += fmt.Sprintf("// It requires the %s permission.\n", route.Permission)
+	}
+
+	switch {
+	case len(route.ContentTypes) > 0:
+		body += fmt.Sprintf("// The caller must close the returned body.\nfunc (c *Client) %s(%s) (io.ReadCloser, error) {\n", route.ID, strings.Join(params, ", "))
+		body += fmt.Sprintf("return c.stream(ctx, %s, %s, %s, %s)\n}\n\n", method, pathExpr, query, bodyArgs(op)[0])
+	case result == "":
+		args = bodyArgs(op)
+		body += fmt.Sprintf("func (c *Client) %s(%s) error {\n", route.ID, strings.Join(params, ", "))
+		body += fmt.Sprintf("return c.%s(ctx, %s, %s, %s, %s, nil)\n}\n\n", doFunc(op), method, pathExpr, query, strings.Join(args, ", "))
+	default:
+		args = bodyArgs(op)
+		out := "&out"
+		declaration := "var out " + result
+		if strings.HasPrefix(result, "*") {
+			declaration = "out := new(" + result[1:] + ")"
+			out = "out"
+		}
+		body += fmt.Sprintf("func (c *Client) %s(%s) (%s, error) {\n%s\n", route.ID, strings.Join(params, ", "), result, declaration)
+		body += fmt.Sprintf("if err := c.%s(ctx, %s, %s, %s, %s, %s); err != nil {\nreturn nil, err\n}\nreturn out, nil\n}\n\n",
+			doFunc(op), method, pathExpr, query, strings.Join(args, ", "), out)
+	}
+	g.buf.WriteString(body)
+	return nil
+}
+
+// queryType declares the struct holding a route's query parameters and its values
+// method. Zero values are left out of the query.
+func (g *generator) queryType(name string, query []Param) {
+	var fields, values strings.Builder
+	for _, p := range query {
+		field := goIdentifier(p.Name)
+		t := "string"
+		switch p.Type {
+		case "integer":
+			t = "int64"
+		case "boolean":
+			t = "bool"
+		}
+		if p.Description != "" {
+			fmt.Fprintf(&fields, "// %s\n", p.Description)
+		}
+		fmt.Fprintf(&fields, "%s %s\n", field, t)
+
+		switch t {
+		case "int64":
+			fmt.Fprintf(&values, "if p.%s != 0 {\nvalues.Set(%q, strconv.FormatInt(p.%s, 10))\n}\n", field, p.Name, field)
+		case "bool":
+			fmt.Fprintf(&values, "if p.%s {\nvalues.Set(%q, \"true\")\n}\n", field, p.Name)
+		default:
+			fmt.Fprintf(&values, "if p.%s != \"\" {\nvalues.Set(%q, p.%s)\n}\n", field, p.Name, field)
+		}
+	}
+	fmt.Fprintf(&g.extra, "type %s struct {\n%s}\n\n", name, fields.String())
+	fmt.Fprintf(&g.extra, "func (p *%s) values() url.Values {\nif p == nil {\nreturn nil\n}\nvalues := url.Values{}\n%sreturn values\n}\n\n", name, values.String())
+}
+
+func bodyArgs(op *Operation) []string {
+	if op.RequestBody == nil {
+		return []string{"nil"}
+	}
+	if _, ok := op.RequestBody.Content["multipart/form-data"]; ok {
+		return []string{"body", "file", "filename"}
+	}
+	return []string{"body"}
+}
+
+func doFunc(op *Operation) string {
+	if op.RequestBody != nil {
+		if _, ok := op.RequestBody.Content["multipart/form-data"]; ok {
+			return "doMultipart"
+		}
+	}
+	return "do"
+}
+
+func propertyNames(schema *Schema) []string {
+	if len(schema.order) == len(schema.Properties) {
+		return schema.order
+	}
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func methodName(method string) string {
+	return string(method[0]) + strings.ToLower(method[1:])
+}
+
+// goIdentifier turns a JSON name or enum value such as attempt.started into
+// AttemptStarted, keeping common initialisms upper case.
+func goIdentifier(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, word := range words {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(exportedName(word))
+	}
+	return b.String()
+}
+
+var initialisms = map[string]bool{"API": true, "ID": true, "IP": true, "PDF": true, "URL": true, "JSON": true, "CSV": true}
+
+// paramName turns a parameter name into a Go argument name.
+func paramName(name string) string {
+	first, rest, _ := strings.Cut(name, "_")
+	return strings.ToLower(first) + goIdentifier(rest)
+}
@@ -0,0 +1,193 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaRegistry turns Go types into schemas, collecting named structs and enums
+// as components so each is described once.
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+	enums   map[reflect.Type][]string
+}
+
+func newSchemaRegistry(enums map[reflect.Type][]string) *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+		enums:   enums,
+	}
+}
+
+// schemaOf describes a value of type t as encoding/json would encode it.
+func (r *schemaRegistry) schemaOf(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() != reflect.Pointer && t.Implements(marshalerType):
+		// Custom encodings can produce any JSON value.
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(r.schemaOf(t.Elem()))
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		if values, ok := r.enums[t]; ok {
+			return r.component(t, func() *Schema { return &Schema{Type: "string", Enum: values} })
+		}
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte", Nullable: t.Kind() == reflect.Slice}
+		}
+		// A nil slice or map encodes as null.
+		return &Schema{Type: "array", Items: r.schemaOf(t.Elem()), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaOf(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		return r.component(t, func() *Schema { return r.structSchema(t) })
+	default:
+		// interface{} and anything else can hold any JSON value.
+		return &Schema{}
+	}
+}
+
+// component registers t under its type name, qualified with its package name if
+// another type already took the name, and returns a reference to it.
+func (r *schemaRegistry) component(t reflect.Type, build func() *Schema) *Schema {
+	name, ok := r.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := r.schemas[name]; taken {
+			name = exportedName(path.Base(t.PkgPath())) + name
+		}
+		r.names[t] = name
+		// Reserve the name before building so recursive types terminate.
+		r.schemas[name] = &Schema{}
+		*r.schemas[name] = *build()
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// structSchema lists the fields encoding/json would encode, flattening embedded
+// structs. Fields are required when bound with binding:"required", and in types
+// that are not requests, whenever they are always encoded.
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	isRequest := strings.HasSuffix(t.Name(), "Request")
+	r.addFields(schema, t, isRequest)
+	return schema
+}
+
+func (r *schemaRegistry) addFields(schema *Schema, t reflect.Type, isRequest bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "" {
+			tag = field.Tag.Get("form")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.addFields(schema, embedded, isRequest)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := r.schemaOf(field.Type)
+		binding := field.Tag.Get("binding")
+		if property.Ref == "" {
+			applyBinding(property, binding)
+			property.GoName = field.Name
+		} else {
+			// Keywords next to $ref are ignored, so wrap it to carry the Go name.
+			property = &Schema{AllOf: []*Schema{property}, GoName: field.Name}
+		}
+		if _, ok := schema.Properties[name]; !ok {
+			schema.order = append(schema.order, name)
+		}
+		schema.Properties[name] = property
+
+		// omitempty never omits a struct.
+		omitted := strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Struct
+		always := !isRequest && !omitted && field.Type.Kind() != reflect.Pointer
+		if (hasRule(binding, "required") || always) && !hasRule(strings.Join(schema.Required, ","), name) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// applyBinding carries validation rules the schema can express.
+func applyBinding(schema *Schema, binding string) {
+	for _, rule := range strings.Split(binding, ",") {
+		switch {
+		case rule == "email":
+			schema.Format = "email"
+		case strings.HasPrefix(rule, "oneof="):
+			schema.Enum = strings.Fields(strings.TrimPrefix(rule, "oneof="))
+		}
+	}
+}
+
+func hasRule(binding, rule string) bool {
+	for _, r := range strings.Split(binding, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func nullable(schema *Schema) *Schema {
+	if schema.Ref != "" {
+		return &Schema{AllOf: []*Schema{schema}, Nullable: true}
+	}
+	schema.Nullable = true
+	return schema
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,248 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Route documents one route. Paths use Gin syntax, e.g. /api/tests/:id.
+type Route struct {
+	Method     string
+	Path       string
+	ID         string // operationId, also the name of the Go client method
+	Summary    string
+	Tag        string
+	Permission string
+	Public     bool // served without authentication
+
+	// Params gives the types of path parameters; those not listed are integer IDs
+	// when named id and strings otherwise.
+	Params []Param
+	Query  []Param
+
+	// Request is a value of the JSON body type. With Multipart set it is instead
+	// bound from multipart form fields, alongside an uploaded file named "file".
+	Request   interface{}
+	Multipart bool
+
+	// Response is a value of the type wrapped in the envelope's data field, or nil
+	// when the route returns no data. ContentTypes replace the JSON envelope with a
+	// raw body of one of those media types.
+	Response     interface{}
+	ContentTypes []string
+	Status       int   // success status, 200 when zero
+	OtherStatus  []int // further success statuses with the same response
+}
+
+type Param struct {
+	Name        string
+	Type        string // string (default), integer, number, boolean, date or date-time
+	Description string
+	Required    bool
+	Enum        []string
+}
+
+// Enum lists the values of a named string type.
+type Enum struct {
+	Type   reflect.Type
+	Values []string
+}
+
+func EnumOf[T ~string](values ...T) Enum {
+	enum := Enum{Type: reflect.TypeOf(values[0])}
+	for _, value := range values {
+		enum.Values = append(enum.Values, string(value))
+	}
+	return enum
+}
+
+// security accepts any one of the schemes.
+var security = []map[string][]string{{"bearerAuth": {}}, {"apiKey": {}}, {"cookieAuth": {}}}
+
+// Spec is everything the document is built from.
+type Spec struct {
+	Info Info
+	// Envelope is a value of the type every JSON response is wrapped in. Its data
+	// field carries each route's Response.
+	Envelope     interface{}
+	TenantHeader string
+	Routes       []Route
+	Enums        []Enum
+}
+
+// Document builds the OpenAPI document for the spec.
+func (s *Spec) Document() *Document {
+	enums := make(map[reflect.Type][]string, len(s.Enums))
+	for _, enum := range s.Enums {
+		enums[enum.Type] = enum.Values
+	}
+	registry := newSchemaRegistry(enums)
+	envelope := registry.schemaOf(reflect.TypeOf(s.Envelope))
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    s.Info,
+		Paths:   make(map[string]*PathItem),
+		Components: Components{
+			Schemas: registry.schemas,
+			Parameters: map[string]*Parameter{
+				"Organization": {
+					Name:        s.TenantHeader,
+					In:          "header",
+					Description: "Slug of the organization the request is addressed to; defaults to the subdomain or the default organization",
+					Schema:      &Schema{Type: "string"},
+				},
+			},
+			Responses: map[string]*Response{
+				"Error": {
+					Description: "Error",
+					Content:     jsonContent(envelope),
+				},
+			},
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "A JWT from /api/login, or an API key"},
+				"apiKey":     {Type: "apiKey", In: "header", Name: "X-API-Key"},
+				"cookieAuth": {Type: "apiKey", In: "cookie", Name: "token"},
+			},
+		},
+		Security: security,
+	}
+
+	seenTags := make(map[string]bool)
+	for _, route := range s.Routes {
+		path := OpenAPIPath(route.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(route.Method)] = s.operation(registry, route, envelope)
+
+		if route.Tag != "" && !seenTags[route.Tag] {
+			seenTags[route.Tag] = true
+			doc.Tags = append(doc.Tags, Tag{Name: route.Tag})
+		}
+	}
+	return doc
+}
+
+func (s *Spec) operation(registry *schemaRegistry, route Route, envelope *Schema) *Operation {
+	op := &Operation{
+		OperationID: route.ID,
+		Summary:     route.Summary,
+		Permission:  route.Permission,
+		Parameters:  []*Parameter{{Ref: "#/components/parameters/Organization"}},
+		Responses:   map[string]*Response{"default": {Ref: "#/components/responses/Error"}},
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+	op.Security = security
+	if route.Public {
+		op.Security = []map[string][]string{}
+	}
+
+	for _, name := range PathParams(route.Path) {
+		param := Param{Name: name, Type: "string"}
+		if name == "id" {
+			param.Type = "integer"
+		}
+		for _, override := range route.Params {
+			if override.Name == name {
+				param = override
+			}
+		}
+		param.Required = true
+		op.Parameters = append(op.Parameters, parameter("path", param))
+	}
+	for _, param := range route.Query {
+		op.Parameters = append(op.Parameters, parameter("query", param))
+	}
+
+	if route.Request != nil {
+		schema := registry.schemaOf(reflect.TypeOf(route.Request))
+		mediaType := "application/json"
+		if route.Multipart {
+			mediaType = "multipart/form-data"
+			schema = &Schema{AllOf: []*Schema{schema, {
+				Type:       "object",
+				Properties: map[string]*Schema{"file": {Type: "string", Format: "binary"}},
+				Required:   []string{"file"},
+			}}}
+		}
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{mediaType: {Schema: schema}}}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := &Response{Description: http.StatusText(status)}
+	switch {
+	case len(route.ContentTypes) > 0:
+		response.Content = make(map[string]*MediaType, len(route.ContentTypes))
+		for _, contentType := range route.ContentTypes {
+			response.Content[contentType] = &MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+		}
+	case route.Response != nil:
+		data := registry.schemaOf(reflect.TypeOf(route.Response))
+		response.Content = jsonContent(&Schema{AllOf: []*Schema{envelope, {
+			Type:       "object",
+			Properties: map[string]*Schema{"data": data},
+			Required:   []string{"data"},
+		}}})
+	default:
+		response.Content = jsonContent(envelope)
+	}
+	op.Responses[strconv.Itoa(status)] = response
+	for _, other := range route.OtherStatus {
+		op.Responses[strconv.Itoa(other)] = &Response{Description: http.StatusText(other), Content: response.Content}
+	}
+
+	return op
+}
+
+func parameter(in string, param Param) *Parameter {
+	schema := &Schema{Type: param.Type, Enum: param.Enum}
+	switch param.Type {
+	case "":
+		schema.Type = "string"
+	case "date", "date-time":
+		schema = &Schema{Type: "string", Format: param.Type}
+	}
+	return &Parameter{
+		Name:        param.Name,
+		In:          in,
+		Description: param.Description,
+		Required:    param.Required,
+		Schema:      schema,
+	}
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// OpenAPIPath converts a Gin path to OpenAPI syntax: /tests/:id becomes /tests/{id}.
+func OpenAPIPath(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// PathParams returns the names of the parameters in a Gin path, in order.
+func PathParams(ginPath string) []string {
+	var names []string
+	for _, segment := range strings.Split(ginPath, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			names = append(names, segment[1:])
+		}
+	}
+	return names
+}
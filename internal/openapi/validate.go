@@ -0,0 +1,207 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxValidatedBody is the largest response body ValidateResponses buffers.
+const maxValidatedBody = 4 << 20
+
+// Operation returns the operation for a method and an OpenAPI path, or nil.
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil
+	}
+	return (*item)[strings.ToLower(method)]
+}
+
+// ValidateResponse checks a response against the document: the route and status
+// must be documented, and JSON bodies must match their schema.
+func (d *Document) ValidateResponse(method, ginPath string, status int, contentType string, body []byte) error {
+	op := d.Operation(method, OpenAPIPath(ginPath))
+	if op == nil {
+		return fmt.Errorf("%s %s is not documented", method, ginPath)
+	}
+
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if status < 400 {
+			return fmt.Errorf("%s %s: status %d is not documented", method, ginPath, status)
+		}
+		response = op.Responses["default"]
+	}
+	if response.Ref != "" {
+		response = d.Components.Responses[strings.TrimPrefix(response.Ref, "#/components/responses/")]
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	content, ok := response.Content[mediaType]
+	if !ok {
+		return fmt.Errorf("%s %s: status %d responded with undocumented content type %q", method, ginPath, status, contentType)
+	}
+	if mediaType != "application/json" || body == nil || content.Schema.Format == "binary" {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Errorf("%s %s: invalid JSON: %w", method, ginPath, err)
+	}
+	if err := d.Validate(content.Schema, value); err != nil {
+		return fmt.Errorf("%s %s: status %d: %w", method, ginPath, status, err)
+	}
+	return nil
+}
+
+// Validate checks a value decoded by encoding/json against a schema.
+func (d *Document) Validate(schema *Schema, value interface{}) error {
+	return d.validate(schema, value, "body")
+}
+
+func (d *Document) validate(schema *Schema, value interface{}, at string) error {
+	if schema.Ref != "" {
+		resolved, ok := d.Components.Schemas[schema.RefName()]
+		if !ok {
+			return fmt.Errorf("%s: unknown schema %s", at, schema.Ref)
+		}
+		schema = resolved
+	}
+
+	if value == nil {
+		if schema.Nullable || (schema.Type == "" && len(schema.AllOf) == 0 && schema.Properties == nil) {
+			return nil
+		}
+		return fmt.Errorf("%s: is null", at)
+	}
+	for _, part := range schema.AllOf {
+		if err := d.validate(part, value, at); err != nil {
+			return err
+		}
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object", at)
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s: missing %q", at, name)
+			}
+		}
+		for name, field := range object {
+			property, ok := schema.Properties[name]
+			if !ok {
+				property = schema.AdditionalProperties
+			}
+			if property == nil {
+				continue
+			}
+			if err := d.validate(property, field, at+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an array", at)
+		}
+		for i, item := range array {
+			if err := d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected a string", at)
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, text); err != nil {
+				return fmt.Errorf("%s: expected a date-time", at)
+			}
+		}
+		// Associations that were not loaded encode with zero values, so an empty
+		// string passes any enum.
+		if len(schema.Enum) > 0 && text != "" && !hasRule(strings.Join(schema.Enum, ","), text) {
+			return fmt.Errorf("%s: %q is not one of %s", at, text, strings.Join(schema.Enum, ", "))
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return fmt.Errorf("%s: expected an integer", at)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected a number", at)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean", at)
+		}
+	}
+	return nil
+}
+
+// ValidateResponses checks every response to a documented route prefix against
+// the document and passes mismatches to report. It buffers response bodies, so it
+// is meant for development and CI rather than production.
+func ValidateResponses(doc *Document, prefix string, report func(c *gin.Context, err error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, prefix) {
+			c.Next()
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if c.FullPath() == "" {
+			return // unmatched route
+		}
+		body := recorder.body.Bytes()
+		if recorder.overflow {
+			body = nil
+		}
+		if err := doc.ValidateResponse(c.Request.Method, c.FullPath(), c.Writer.Status(), c.Writer.Header().Get("Content-Type"), body); err != nil {
+			report(c, err)
+		}
+	}
+}
+
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *bodyRecorder) Write(data []byte) (int, error) {
+	r.record(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *bodyRecorder) WriteString(s string) (int, error) {
+	r.record([]byte(s))
+	return r.ResponseWriter.WriteString(s)
+}
+
+func (r *bodyRecorder) record(data []byte) {
+	if r.overflow || r.body.Len()+len(data) > maxValidatedBody {
+		r.overflow = true
+		r.body.Reset()
+		return
+	}
+	r.body.Write(data)
+}
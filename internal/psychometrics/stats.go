@@ -0,0 +1,158 @@
+// Package psychometrics implements the classical test theory statistics used by
+// the analytics reports.
+package psychometrics
+
+import "math"
+
+// Moments accumulates the count, sum and sum of squares of a sample, so
+// statistics can be computed in one pass over streamed rows.
+type Moments struct {
+	N     int
+	Sum   float64
+	SumSq float64
+}
+
+func (m *Moments) Add(x float64) {
+	m.N++
+	m.Sum += x
+	m.SumSq += x * x
+}
+
+func (m Moments) Mean() float64 {
+	if m.N == 0 {
+		return 0
+	}
+	return m.Sum / float64(m.N)
+}
+
+// Variance is the population variance of the sample.
+func (m Moments) Variance() float64 {
+	if m.N == 0 {
+		return 0
+	}
+	mean := m.Mean()
+	variance := m.SumSq/float64(m.N) - mean*mean
+	if variance < 0 {
+		// Rounding can push a zero variance slightly negative.
+		return 0
+	}
+	return variance
+}
+
+// PointBiserial correlates a dichotomous item with a continuous score, given the
+// scores of the respondents who got the item right and of those who got it wrong.
+// It is undefined when either group is empty or the scores do not vary.
+func PointBiserial(correct, incorrect Moments) (float64, bool) {
+	if correct.N == 0 || incorrect.N == 0 {
+		return 0, false
+	}
+
+	all := Moments{
+		N:     correct.N + incorrect.N,
+		Sum:   correct.Sum + incorrect.Sum,
+		SumSq: correct.SumSq + incorrect.SumSq,
+	}
+	sd := math.Sqrt(all.Variance())
+	if sd == 0 {
+		return 0, false
+	}
+
+	p := float64(correct.N) / float64(all.N)
+	return (correct.Mean() - incorrect.Mean()) / sd * math.Sqrt(p*(1-p)), true
+}
+
+// Pearson is the correlation between two paired samples. It is undefined when
+// either sample does not vary.
+func Pearson(x, y []float64) (float64, bool) {
+	if len(x) != len(y) || len(x) < 2 {
+		return 0, false
+	}
+
+	var mx, my Moments
+	for i := range x {
+		mx.Add(x[i])
+		my.Add(y[i])
+	}
+	meanX, meanY := mx.Mean(), my.Mean()
+
+	var covariance float64
+	for i := range x {
+		covariance += (x[i] - meanX) * (y[i] - meanY)
+	}
+	covariance /= float64(len(x))
+
+	denominator := math.Sqrt(mx.Variance() * my.Variance())
+	if denominator == 0 {
+		return 0, false
+	}
+	return covariance / denominator, true
+}
+
+// CronbachAlpha estimates internal consistency from a matrix of item scores with
+// one row per respondent and one column per item.
+func CronbachAlpha(scores [][]float64) (float64, bool) {
+	if len(scores) < 2 || len(scores[0]) < 2 {
+		return 0, false
+	}
+	k := len(scores[0])
+
+	items := make([]Moments, k)
+	var totals Moments
+	for _, row := range scores {
+		total := 0.0
+		for i, score := range row {
+			items[i].Add(score)
+			total += score
+		}
+		totals.Add(total)
+	}
+
+	totalVariance := totals.Variance()
+	if totalVariance == 0 {
+		return 0, false
+	}
+	itemVariance := 0.0
+	for _, item := range items {
+		itemVariance += item.Variance()
+	}
+
+	return float64(k) / float64(k-1) * (1 - itemVariance/totalVariance), true
+}
+
+// SplitHalf correlates the odd and even numbered items and steps the correlation
+// up to the full test length with the Spearman-Brown formula.
+func SplitHalf(scores [][]float64) (float64, bool) {
+	if len(scores) < 2 || len(scores[0]) < 2 {
+		return 0, false
+	}
+
+	odd := make([]float64, len(scores))
+	even := make([]float64, len(scores))
+	for respondent, row := range scores {
+		for i, score := range row {
+			if i%2 == 0 {
+				odd[respondent] += score
+			} else {
+				even[respondent] += score
+			}
+		}
+	}
+
+	r, ok := Pearson(odd, even)
+	if !ok || r <= -1 {
+		return 0, false
+	}
+	return 2 * r / (1 + r), true
+}
+
+// StandardError is the standard error of measurement of a score with the given
+// standard deviation and reliability.
+func StandardError(sd, reliability float64) float64 {
+	if reliability >= 1 {
+		return 0
+	}
+	if reliability < 0 {
+		reliability = 0
+	}
+	return sd * math.Sqrt(1-reliability)
+}
@@ -0,0 +1,177 @@
+package psychometrics
+
+import (
+	"math"
+	"testing"
+)
+
+const tolerance = 1e-9
+
+// guttman is a perfect cumulative pattern: each respondent gets the easier items
+// right and the harder ones wrong.
+var guttman = [][]float64{
+	{1, 1, 1, 1},
+	{1, 1, 1, 0},
+	{1, 1, 0, 0},
+	{1, 0, 0, 0},
+	{0, 0, 0, 0},
+}
+
+func moments(xs ...float64) Moments {
+	var m Moments
+	for _, x := range xs {
+		m.Add(x)
+	}
+	return m
+}
+
+func checkStatistic(t *testing.T, got float64, gotOK bool, want float64, wantOK bool) {
+	t.Helper()
+	if gotOK != wantOK {
+		t.Fatalf("ok = %v, want %v (value %v)", gotOK, wantOK, got)
+	}
+	if wantOK && math.Abs(got-want) > tolerance {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCronbachAlpha(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores [][]float64
+		want   float64
+		ok     bool
+	}{
+		// item variances sum to 0.8 and the total variance is 2: 4/3 * (1 - 0.8/2)
+		{name: "guttman pattern", scores: guttman, want: 0.8, ok: true},
+		{name: "rating scale", scores: [][]float64{{2, 3, 3}, {4, 4, 5}, {3, 5, 4}, {1, 2, 2}}, want: 39.0 / 41, ok: true},
+		// items that cancel out leave every total equal
+		{name: "zero total variance", scores: [][]float64{{1, 0}, {0, 1}, {1, 0}}, ok: false},
+		{name: "everyone the same", scores: [][]float64{{1, 1, 0}, {1, 1, 0}}, ok: false},
+		{name: "one respondent", scores: [][]float64{{1, 0, 1}}, ok: false},
+		{name: "one item", scores: [][]float64{{1}, {0}, {1}}, ok: false},
+		{name: "no respondents", scores: nil, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alpha, ok := CronbachAlpha(tt.scores)
+			checkStatistic(t, alpha, ok, tt.want, tt.ok)
+		})
+	}
+}
+
+func TestSplitHalf(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores [][]float64
+		want   float64
+		ok     bool
+	}{
+		// odd halves 2,2,1,1,0 and even halves 2,1,1,0,0 correlate at 11/14
+		{name: "guttman pattern", scores: guttman, want: 2 * (11.0 / 14) / (1 + 11.0/14), ok: true},
+		{name: "identical halves", scores: [][]float64{{1, 1}, {0, 0}, {1, 1}}, want: 1, ok: true},
+		{name: "opposite halves", scores: [][]float64{{1, 0}, {0, 1}}, ok: false},
+		{name: "constant half", scores: [][]float64{{1, 1}, {1, 0}, {1, 1}}, ok: false},
+		{name: "one respondent", scores: [][]float64{{1, 0, 1, 1}}, ok: false},
+		{name: "one item", scores: [][]float64{{1}, {0}}, ok: false},
+		{name: "no respondents", scores: nil, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := SplitHalf(tt.scores)
+			checkStatistic(t, r, ok, tt.want, tt.ok)
+		})
+	}
+}
+
+func TestPointBiserial(t *testing.T) {
+	tests := []struct {
+		name      string
+		correct   Moments
+		incorrect Moments
+		want      float64
+		ok        bool
+	}{
+		// the Pearson correlation of item 1,1,0,1,0,0 with scores 5,4,4,3,2,1
+		{name: "rest scores", correct: moments(5, 4, 3), incorrect: moments(4, 2, 1), want: 0.6201736729460423, ok: true},
+		{name: "perfect separation", correct: moments(1, 1), incorrect: moments(0, 0), want: 1, ok: true},
+		{name: "wrong answers score higher", correct: moments(0, 0), incorrect: moments(1, 1), want: -1, ok: true},
+		{name: "zero variance", correct: moments(3, 3), incorrect: moments(3), ok: false},
+		{name: "everyone correct", correct: moments(1, 2, 3), ok: false},
+		{name: "no one correct", incorrect: moments(1, 2, 3), ok: false},
+		{name: "no responses", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := PointBiserial(tt.correct, tt.incorrect)
+			checkStatistic(t, r, ok, tt.want, tt.ok)
+		})
+	}
+}
+
+func TestPearson(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y []float64
+		want float64
+		ok   bool
+	}{
+		{name: "positive", x: []float64{1, 2, 3, 4}, y: []float64{2, 4, 5, 9}, want: 0.9647638212377322, ok: true},
+		{name: "negative", x: []float64{1, 2, 3}, y: []float64{3, 2, 1}, want: -1, ok: true},
+		{name: "constant sample", x: []float64{1, 2, 3}, y: []float64{2, 2, 2}, ok: false},
+		{name: "one pair", x: []float64{1}, y: []float64{2}, ok: false},
+		{name: "unequal lengths", x: []float64{1, 2, 3}, y: []float64{1, 2}, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := Pearson(tt.x, tt.y)
+			checkStatistic(t, r, ok, tt.want, tt.ok)
+		})
+	}
+}
+
+func TestMoments(t *testing.T) {
+	var empty Moments
+	if empty.Mean() != 0 || empty.Variance() != 0 {
+		t.Errorf("empty sample: mean %v, variance %v, want 0 and 0", empty.Mean(), empty.Variance())
+	}
+
+	m := moments(2, 4, 4, 4, 5, 5, 7, 9)
+	if m.Mean() != 5 || m.Variance() != 4 {
+		t.Errorf("mean %v, variance %v, want 5 and 4", m.Mean(), m.Variance())
+	}
+
+	// a large constant sample must not report a negative variance through rounding
+	var constant Moments
+	for i := 0; i < 1000; i++ {
+		constant.Add(0.1)
+	}
+	if v := constant.Variance(); v < 0 {
+		t.Errorf("constant sample variance %v, want at least 0", v)
+	}
+}
+
+func TestStandardError(t *testing.T) {
+	tests := []struct {
+		name        string
+		sd          float64
+		reliability float64
+		want        float64
+	}{
+		{name: "typical", sd: 15, reliability: 0.91, want: 4.5},
+		{name: "perfect reliability", sd: 15, reliability: 1, want: 0},
+		{name: "negative reliability treated as zero", sd: 15, reliability: -0.3, want: 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StandardError(tt.sd, tt.reliability); math.Abs(got-tt.want) > tolerance {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Package pubsub delivers messages to the subscribers of a topic. Bus is the
+// extension point for backends; Memory delivers within one process, so every
+// subscriber must be connected to the instance that publishes. A backend shared
+// by several instances, such as Redis or Postgres LISTEN/NOTIFY, only needs to
+// implement Bus.
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// Bus publishes messages to topics. Delivery is best effort: messages published
+// while nobody is subscribed are lost, and a subscriber that falls behind may
+// miss some.
+type Bus interface {
+	Publish(ctx context.Context, topic string, message []byte) error
+
+	// Subscribe returns the messages published to the topic from now on. The
+	// channel is closed once ctx is done.
+	Subscribe(ctx context.Context, topic string) (<-chan []byte, error)
+}
+
+// DefaultBuffer is how many messages Memory holds for a subscriber before it
+// drops new ones.
+const DefaultBuffer = 64
+
+// Memory is an in-process Bus. Publishing never blocks on slow subscribers;
+// messages that do not fit in a subscriber's buffer are dropped for it.
+type Memory struct {
+	mu          sync.Mutex
+	buffer      int
+	subscribers map[string]map[chan []byte]struct{}
+}
+
+func NewMemory(buffer int) *Memory {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &Memory{
+		buffer:      buffer,
+		subscribers: make(map[string]map[chan []byte]struct{}),
+	}
+}
+
+func (m *Memory) Publish(_ context.Context, topic string, message []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for ch := range m.subscribers[topic] {
+		select {
+		case ch <- message:
+		default:
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	ch := make(chan []byte, m.buffer)
+
+	m.mu.Lock()
+	if m.subscribers[topic] == nil {
+		m.subscribers[topic] = make(map[chan []byte]struct{})
+	}
+	m.subscribers[topic][ch] = struct{}{}
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.subscribers[topic], ch)
+		if len(m.subscribers[topic]) == 0 {
+			delete(m.subscribers, topic)
+		}
+		close(ch)
+	}()
+	return ch, nil
+}
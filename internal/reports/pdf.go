@@ -0,0 +1,261 @@
+package reports
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"iq-go/internal/models"
+	"iq-go/internal/services"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	pageMargin = 15.0
+	lineHeight = 6.0
+)
+
+type rgb struct {
+	r, g, b int
+}
+
+// ResultReport is everything that goes into a result PDF. The result's Test and
+// Answers.Question relations must be preloaded.
+type ResultReport struct {
+	Result       *models.TestResult
+	User         *models.User
+	Organization string
+	Template     *models.ReportTemplate
+}
+
+// WriteResultPDF renders the report as a PDF document to w.
+func WriteResultPDF(w io.Writer, report ResultReport) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, 20)
+
+	// Core PDF fonts only cover cp1252; anything outside it is replaced.
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	primary := parseColor(report.Template.PrimaryColor)
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 10, tr(report.Template.FooterText), "", 0, "L", false, 0, "")
+		pdf.SetX(pageMargin)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d/{nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AliasNbPages("")
+	pdf.AddPage()
+
+	writeHeader(pdf, tr, report, primary)
+	writeSummary(pdf, tr, report)
+	writeCategories(pdf, tr, report, primary)
+	if report.Template.ShowReview {
+		writeReview(pdf, tr, report, primary)
+	}
+
+	return pdf.Output(w)
+}
+
+func writeHeader(pdf *gofpdf.Fpdf, tr func(string) string, report ResultReport, primary rgb) {
+	pageWidth, _ := pdf.GetPageSize()
+
+	pdf.SetFillColor(primary.r, primary.g, primary.b)
+	pdf.Rect(0, 0, pageWidth, 32, "F")
+
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetXY(pageMargin, 8)
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 9, tr(report.Template.Title), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	subtitle := report.Template.Subtitle
+	if subtitle == "" {
+		subtitle = report.Organization
+	}
+	pdf.CellFormat(0, 6, tr(subtitle), "", 1, "L", false, 0, "")
+
+	pdf.SetY(40)
+	pdf.SetTextColor(0, 0, 0)
+}
+
+func writeSummary(pdf *gofpdf.Fpdf, tr func(string) string, report ResultReport) {
+	result := report.Result
+
+	rows := [][2]string{
+		{"Candidate", strings.TrimSpace(report.User.FirstName + " " + report.User.LastName)},
+		{"Email", report.User.Email},
+		{"Test", result.Test.Name},
+		{"Completed", formatTime(result.CompletedAt)},
+	}
+	pdf.SetFont("Helvetica", "", 10)
+	for _, row := range rows {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(35, lineHeight, row[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, lineHeight, tr(row[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	percentage := 0.0
+	if result.TotalQuestions > 0 {
+		percentage = float64(result.Score) / float64(result.TotalQuestions) * 100
+	}
+	sectionTitle(pdf, "Overall Score")
+	pdf.SetFont("Helvetica", "B", 24)
+	pdf.CellFormat(0, 12, fmt.Sprintf("%d / %d  (%.0f%%)", result.Score, result.TotalQuestions, percentage), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	sectionTitle(pdf, "Time Summary")
+	pdf.SetFont("Helvetica", "", 10)
+	timeRows := [][2]string{{"Time taken", formatDuration(result.TimeTaken)}}
+	if result.Test.Duration > 0 {
+		timeRows = append(timeRows, [2]string{"Time allowed", formatDuration(result.Test.Duration * 60)})
+	}
+	if answered, total := responseStats(result.Answers); answered > 0 {
+		timeRows = append(timeRows, [2]string{"Average response", fmt.Sprintf("%.1f s", float64(total)/float64(answered)/1000)})
+	}
+	for _, row := range timeRows {
+		pdf.CellFormat(35, lineHeight, row[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, lineHeight, row[1], "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+}
+
+func writeCategories(pdf *gofpdf.Fpdf, tr func(string) string, report ResultReport, primary rgb) {
+	categories := services.CategoryBreakdown(report.Result.Answers)
+	if len(categories) == 0 {
+		return
+	}
+
+	sectionTitle(pdf, "Category Breakdown")
+	barWidth := 80.0
+	for _, category := range categories {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(55, 7, tr(formatCategory(category.Category)), "", 0, "L", false, 0, "")
+
+		x, y := pdf.GetXY()
+		pdf.SetFillColor(230, 230, 230)
+		pdf.Rect(x, y+1.5, barWidth, 4, "F")
+		pdf.SetFillColor(primary.r, primary.g, primary.b)
+		pdf.Rect(x, y+1.5, barWidth*category.Percentage/100, 4, "F")
+		pdf.SetX(x + barWidth + 4)
+
+		pdf.CellFormat(0, 7, fmt.Sprintf("%d/%d  %.0f%%  avg %.1f s", category.Correct, category.Total,
+			category.Percentage, category.AvgResponseTime/1000), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+}
+
+func writeReview(pdf *gofpdf.Fpdf, tr func(string) string, report ResultReport, primary rgb) {
+	sectionTitle(pdf, "Question Review")
+
+	widths := []float64{10, 88, 30, 30, 22}
+	headers := []string{"#", "Question", "Your answer", "Correct answer", "Time"}
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(primary.r, primary.g, primary.b)
+	pdf.SetTextColor(255, 255, 255)
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], 7, header, "", 0, "L", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetTextColor(0, 0, 0)
+
+	pdf.SetFont("Helvetica", "", 8)
+	for i, answer := range report.Result.Answers {
+		lines := pdf.SplitLines([]byte(tr(answer.Question.QuestionText)), widths[1]-2)
+		height := float64(len(lines)) * 4.5
+		if height < 6 {
+			height = 6
+		}
+		if pdf.GetY()+height > 275 {
+			pdf.AddPage()
+		}
+
+		if answer.IsCorrect {
+			pdf.SetFillColor(232, 245, 233)
+		} else {
+			pdf.SetFillColor(253, 236, 234)
+		}
+
+		x, y := pdf.GetXY()
+		pdf.Rect(x, y, widths[0]+widths[1]+widths[2]+widths[3]+widths[4], height, "F")
+		pdf.CellFormat(widths[0], height, strconv.Itoa(i+1), "", 0, "L", false, 0, "")
+		pdf.MultiCell(widths[1], 4.5, tr(answer.Question.QuestionText), "", "L", false)
+		pdf.SetXY(x+widths[0]+widths[1], y)
+
+		userAnswer := answer.UserAnswer
+		if userAnswer == "" {
+			userAnswer = "(no answer)"
+		}
+		pdf.CellFormat(widths[2], height, tr(truncate(userAnswer, 20)), "", 0, "L", false, 0, "")
+		correctAnswer := answer.Question.CorrectAnswer
+		if correctAnswer == "" {
+			// Hidden by the test's answer reveal policy.
+			correctAnswer = "-"
+		}
+		pdf.CellFormat(widths[3], height, tr(truncate(correctAnswer, 20)), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[4], height, fmt.Sprintf("%.1f s", float64(answer.ResponseTime)/1000), "", 0, "L", false, 0, "")
+		pdf.SetXY(x, y+height)
+	}
+}
+
+func sectionTitle(pdf *gofpdf.Fpdf, title string) {
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 8, title, "B", 1, "L", false, 0, "")
+	pdf.Ln(2)
+}
+
+func responseStats(answers []models.Answer) (answered, totalMillis int) {
+	for _, answer := range answers {
+		if answer.UserAnswer != "" {
+			answered++
+			totalMillis += answer.ResponseTime
+		}
+	}
+	return answered, totalMillis
+}
+
+func formatCategory(category models.Category) string {
+	words := strings.Split(string(category), "_")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func formatDuration(seconds int) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "In progress"
+	}
+	return t.Format("2 January 2006 15:04 MST")
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// parseColor reads a #rrggbb color, falling back to a neutral dark blue.
+func parseColor(hex string) rgb {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) == 6 {
+		if value, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			return rgb{int(value >> 16 & 0xff), int(value >> 8 & 0xff), int(value & 0xff)}
+		}
+	}
+	return rgb{44, 62, 80}
+}
@@ -0,0 +1,316 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expr is a parsed filter: a Comparison, And, Or or Not.
+type Expr interface {
+	expr()
+}
+
+// Comparison compares an attribute with a value. Attr is the lower-cased
+// attribute path without its schema URN, e.g. name.givenname. Value is a string,
+// float64, bool or nil; it is nil for the pr (present) operator.
+type Comparison struct {
+	Attr  string
+	Op    string
+	Value interface{}
+}
+
+type And struct{ Left, Right Expr }
+type Or struct{ Left, Right Expr }
+type Not struct{ X Expr }
+
+func (Comparison) expr() {}
+func (And) expr()        {}
+func (Or) expr()         {}
+func (Not) expr()        {}
+
+var operators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true, "pr": true,
+}
+
+// ParseFilter parses a filter of RFC 7644 section 3.4.2.2, e.g.
+// userName eq "ada@example.com" and not (emails[type eq "work"]). Value paths
+// such as emails[type eq "work"] become comparisons of emails.type.
+func ParseFilter(filter string) (Expr, error) {
+	p, err := newParser(filter)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	return expr, nil
+}
+
+// Path is a PATCH operation's target, e.g. emails[type eq "work"].value, which has
+// the attribute emails, a filter over its values and the sub-attribute value.
+type Path struct {
+	Attr   string
+	Filter Expr
+	Sub    string
+}
+
+// Key returns the attribute and sub-attribute joined with a dot, e.g. emails.value.
+func (p Path) Key() string {
+	if p.Sub == "" {
+		return p.Attr
+	}
+	return p.Attr + "." + p.Sub
+}
+
+func ParsePath(path string) (Path, error) {
+	p, err := newParser(path)
+	if err != nil {
+		return Path{}, err
+	}
+	tok := p.next()
+	if tok.kind != tokenWord || operators[strings.ToLower(tok.text)] {
+		return Path{}, errors.New("expected an attribute")
+	}
+	result := Path{Attr: attributeName(tok.text)}
+	if p.peek().kind == tokenOpen && p.peek().text == "[" {
+		p.next()
+		if result.Filter, err = p.parseOr(); err != nil {
+			return Path{}, err
+		}
+		if tok := p.next(); tok.kind != tokenClose || tok.text != "]" {
+			return Path{}, errors.New("expected ]")
+		}
+		if tok := p.peek(); tok.kind == tokenWord && strings.HasPrefix(tok.text, ".") {
+			p.next()
+			result.Sub = strings.ToLower(tok.text[1:])
+		}
+	}
+	if !p.done() {
+		return Path{}, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	return result, nil
+}
+
+// attributeName lower-cases an attribute path and drops its schema URN, so
+// urn:ietf:params:scim:schemas:core:2.0:User:name.givenName becomes name.givenname.
+func attributeName(name string) string {
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.ToLower(name)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func newParser(input string) (*parser, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		switch ch := input[i]; {
+		case ch == ' ' || ch == '\t':
+			i++
+		case ch == '(' || ch == '[':
+			tokens = append(tokens, token{tokenOpen, string(ch)})
+			i++
+		case ch == ')' || ch == ']':
+			tokens = append(tokens, token{tokenClose, string(ch)})
+			i++
+		case ch == '"':
+			end := i + 1
+			for end < len(input) && input[end] != '"' {
+				if input[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(input) {
+				return nil, errors.New("unterminated string")
+			}
+			var value string
+			if err := json.Unmarshal([]byte(input[i:end+1]), &value); err != nil {
+				return nil, fmt.Errorf("invalid string %s", input[i:end+1])
+			}
+			tokens = append(tokens, token{tokenString, value})
+			i = end + 1
+		default:
+			end := i
+			for end < len(input) && !strings.ContainsRune(" \t()[]\"", rune(input[end])) {
+				end++
+			}
+			tokens = append(tokens, token{tokenWord, input[i:end]})
+			i = end
+		}
+	}
+	return &parser{tokens: tokens}, nil
+}
+
+func (p *parser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{kind: tokenEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.peek()
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) keyword(word string) bool {
+	tok := p.peek()
+	if tok.kind == tokenWord && strings.EqualFold(tok.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.keyword("not") {
+		if tok := p.next(); tok.kind != tokenOpen || tok.text != "(" {
+			return nil, errors.New("expected ( after not")
+		}
+		inner, err := p.parseGroup(")")
+		return Not{inner}, err
+	}
+	if p.peek().text == "(" && p.peek().kind == tokenOpen {
+		p.next()
+		return p.parseGroup(")")
+	}
+
+	tok := p.next()
+	if tok.kind != tokenWord {
+		return nil, errors.New("expected an attribute")
+	}
+	attr := attributeName(tok.text)
+
+	if p.peek().kind == tokenOpen && p.peek().text == "[" {
+		p.next()
+		inner, err := p.parseGroup("]")
+		if err != nil {
+			return nil, err
+		}
+		return prefixed(inner, attr), nil
+	}
+
+	opTok := p.next()
+	op := strings.ToLower(opTok.text)
+	if opTok.kind != tokenWord || !operators[op] {
+		return nil, fmt.Errorf("unknown operator %q", opTok.text)
+	}
+	if op == "pr" {
+		return Comparison{Attr: attr, Op: op}, nil
+	}
+
+	valueTok := p.next()
+	switch valueTok.kind {
+	case tokenString:
+		return Comparison{Attr: attr, Op: op, Value: valueTok.text}, nil
+	case tokenWord:
+		switch strings.ToLower(valueTok.text) {
+		case "true":
+			return Comparison{Attr: attr, Op: op, Value: true}, nil
+		case "false":
+			return Comparison{Attr: attr, Op: op, Value: false}, nil
+		case "null":
+			return Comparison{Attr: attr, Op: op}, nil
+		}
+		number, err := strconv.ParseFloat(valueTok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q", valueTok.text)
+		}
+		return Comparison{Attr: attr, Op: op, Value: number}, nil
+	default:
+		return nil, errors.New("expected a value")
+	}
+}
+
+func (p *parser) parseGroup(closing string) (Expr, error) {
+	inner, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.next(); tok.kind != tokenClose || tok.text != closing {
+		return nil, errors.New("expected " + closing)
+	}
+	return inner, nil
+}
+
+// prefixed rewrites the attributes of a value path filter relative to the
+// multi-valued attribute they belong to.
+func prefixed(expr Expr, attr string) Expr {
+	switch e := expr.(type) {
+	case Comparison:
+		e.Attr = attr + "." + e.Attr
+		return e
+	case And:
+		return And{prefixed(e.Left, attr), prefixed(e.Right, attr)}
+	case Or:
+		return Or{prefixed(e.Left, attr), prefixed(e.Right, attr)}
+	case Not:
+		return Not{prefixed(e.X, attr)}
+	}
+	return expr
+}
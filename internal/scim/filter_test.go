@@ -0,0 +1,137 @@
+package scim
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	userName := Comparison{Attr: "username", Op: "eq", Value: "ada@example.com"}
+	active := Comparison{Attr: "active", Op: "eq", Value: true}
+	external := Comparison{Attr: "externalid", Op: "pr"}
+
+	tests := []struct {
+		name   string
+		filter string
+		want   Expr
+	}{
+		{name: "eq", filter: `userName eq "ada@example.com"`, want: userName},
+		{name: "operator and attribute case", filter: `USERNAME EQ "ada@example.com"`, want: userName},
+		{name: "schema urn", filter: `urn:ietf:params:scim:schemas:core:2.0:User:name.givenName sw "Ad"`,
+			want: Comparison{Attr: "name.givenname", Op: "sw", Value: "Ad"}},
+		{name: "present", filter: `externalId pr`, want: external},
+		{name: "boolean", filter: `active eq true`, want: active},
+		{name: "number", filter: `meta.version gt 2.5`, want: Comparison{Attr: "meta.version", Op: "gt", Value: 2.5}},
+		{name: "null", filter: `externalId eq null`, want: Comparison{Attr: "externalid", Op: "eq"}},
+		{name: "escaped quote", filter: `displayName eq "Ada \"Countess\" Lovelace"`,
+			want: Comparison{Attr: "displayname", Op: "eq", Value: `Ada "Countess" Lovelace`}},
+		{name: "keywords inside quotes", filter: `displayName co "black and white or not (grey)"`,
+			want: Comparison{Attr: "displayname", Op: "co", Value: "black and white or not (grey)"}},
+		{name: "non-ascii value", filter: `name.familyName eq "Lovélace"`,
+			want: Comparison{Attr: "name.familyname", Op: "eq", Value: "Lovélace"}},
+		{name: "and", filter: `userName eq "ada@example.com" and active eq true`, want: And{userName, active}},
+		{name: "or", filter: `userName eq "ada@example.com" or active eq true`, want: Or{userName, active}},
+		{name: "and binds tighter than or", filter: `externalId pr or userName eq "ada@example.com" and active eq true`,
+			want: Or{external, And{userName, active}}},
+		{name: "or is left associative", filter: `externalId pr or userName eq "ada@example.com" or active eq true`,
+			want: Or{Or{external, userName}, active}},
+		{name: "parentheses", filter: `(externalId pr or userName eq "ada@example.com") and active eq true`,
+			want: And{Or{external, userName}, active}},
+		{name: "not", filter: `not (active eq true)`, want: Not{active}},
+		{name: "value path", filter: `emails[type eq "work" and value ew "@example.com"]`,
+			want: And{
+				Comparison{Attr: "emails.type", Op: "eq", Value: "work"},
+				Comparison{Attr: "emails.value", Op: "ew", Value: "@example.com"},
+			}},
+		{name: "value path in an expression", filter: `userName eq "ada@example.com" and not (emails[type eq "work"])`,
+			want: And{userName, Not{Comparison{Attr: "emails.type", Op: "eq", Value: "work"}}}},
+		{name: "extra whitespace", filter: "  userName\teq   \"ada@example.com\"  ", want: userName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("ParseFilter(%q): %v", tt.filter, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFilter(%q) = %#v, want %#v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+	}{
+		{name: "empty", filter: ``},
+		{name: "attribute only", filter: `userName`},
+		{name: "missing value", filter: `userName eq`},
+		{name: "unknown operator", filter: `userName like "ada"`},
+		{name: "unquoted string", filter: `userName eq ada`},
+		{name: "unterminated string", filter: `userName eq "ada`},
+		{name: "trailing escape", filter: `userName eq "ada\"`},
+		{name: "invalid escape", filter: `userName eq "ada\q"`},
+		{name: "dangling and", filter: `userName eq "ada" and`},
+		{name: "leading or", filter: `or userName eq "ada"`},
+		{name: "missing connective", filter: `userName eq "ada" active eq true`},
+		{name: "unclosed parenthesis", filter: `(userName eq "ada"`},
+		{name: "unopened parenthesis", filter: `userName eq "ada")`},
+		{name: "quoted closing parenthesis", filter: `(userName eq "ada" ")"`},
+		{name: "not without parentheses", filter: `not active eq true`},
+		{name: "not with a quoted parenthesis", filter: `not "(" active eq true)`},
+		{name: "unclosed value path", filter: `emails[type eq "work"`},
+		{name: "mismatched brackets", filter: `emails[type eq "work")`},
+		{name: "empty group", filter: `()`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if expr, err := ParseFilter(tt.filter); err == nil {
+				t.Errorf("ParseFilter(%q) = %#v, want an error", tt.filter, expr)
+			}
+		})
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want Path
+		key  string
+	}{
+		{name: "attribute", path: `active`, want: Path{Attr: "active"}, key: "active"},
+		{name: "sub-attribute", path: `name.givenName`, want: Path{Attr: "name.givenname"}, key: "name.givenname"},
+		{name: "schema urn", path: `urn:ietf:params:scim:schemas:core:2.0:User:userName`, want: Path{Attr: "username"}, key: "username"},
+		{name: "value filter", path: `emails[type eq "work"]`,
+			want: Path{Attr: "emails", Filter: Comparison{Attr: "type", Op: "eq", Value: "work"}}, key: "emails"},
+		{name: "value filter and sub-attribute", path: `emails[type eq "work"].Value`,
+			want: Path{Attr: "emails", Filter: Comparison{Attr: "type", Op: "eq", Value: "work"}, Sub: "value"}, key: "emails.value"},
+		{name: "member filter", path: `members[value eq "42"]`,
+			want: Path{Attr: "members", Filter: Comparison{Attr: "value", Op: "eq", Value: "42"}}, key: "members"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePath(tt.path)
+			if err != nil {
+				t.Fatalf("ParsePath(%q): %v", tt.path, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePath(%q) = %#v, want %#v", tt.path, got, tt.want)
+			}
+			if got.Key() != tt.key {
+				t.Errorf("ParsePath(%q).Key() = %q, want %q", tt.path, got.Key(), tt.key)
+			}
+		})
+	}
+
+	for _, path := range []string{``, `eq`, `"userName"`, `emails[type eq "work"`, `emails[type eq "work"] extra`, `emails[type zz "work"]`} {
+		if got, err := ParsePath(path); err == nil {
+			t.Errorf("ParsePath(%q) = %#v, want an error", path, got)
+		}
+	}
+}
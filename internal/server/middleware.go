@@ -0,0 +1,75 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// legacyAPIDeprecatedAt is when /api/v1 replaced the unversioned API.
+var legacyAPIDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// validRequestID limits the request IDs accepted from clients and proxies to what
+// is safe to log and echo back.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestID keeps the X-Request-ID of the request, or assigns one, and returns it
+// in the response so errors can be traced in logs.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			buf := make([]byte, 16)
+			rand.Read(buf)
+			id = hex.EncodeToString(buf)
+		}
+		c.Set(utils.RequestIDKey, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+func apiVersion(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(utils.APIVersionKey, version)
+		c.Next()
+	}
+}
+
+// deprecated marks responses of the unversioned API as deprecated (RFC 9745) and
+// points to the versioned route. sunset, when set, announces when it is removed
+// (RFC 8594).
+func deprecated(sunset *time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "@"+strconv.FormatInt(legacyAPIDeprecatedAt.Unix(), 10))
+		if sunset != nil {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		c.Header("Link", `<`+versionedPath(c.Request.URL.Path)+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
+
+// legacyAPIRemoved answers unversioned API requests once they are switched off.
+func legacyAPIRemoved(c *gin.Context) {
+	path := c.Request.URL.Path
+	if !strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/api/v1/") {
+		return // Gin's default 404
+	}
+	c.Header("Link", `<`+versionedPath(path)+`>; rel="successor-version"`)
+	utils.CodedErrorResponse(c, http.StatusGone, utils.CodeAPIVersionGone, "The unversioned API has been removed; use /api/v1")
+}
+
+func versionedPath(path string) string {
+	return "/api/v1/" + strings.TrimPrefix(path, "/api/")
+}
@@ -0,0 +1,293 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"iq-go/internal/auth"
+	"iq-go/internal/config"
+	"iq-go/internal/handlers"
+	"iq-go/internal/lti"
+	"iq-go/internal/models"
+	"iq-go/internal/openapi"
+	"iq-go/internal/pubsub"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// NewRouter wires the services and handlers to the web and API routes. Every
+// route under /api must also be listed in APISpec; cmd/openapi -check enforces it.
+// Templates are loaded relative to the working directory.
+func NewRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
+	userService := services.NewUserService(db)
+	testService := services.NewTestService(db)
+	proctoringService := services.NewProctoringService(db, pubsub.NewMemory(pubsub.DefaultBuffer))
+	resultService := services.NewResultService(db)
+	roleService := services.NewRoleService(db)
+	organizationService := services.NewOrganizationService(db)
+	invitationService := services.NewInvitationService(db)
+	reportService := services.NewReportService(db)
+	certificateService := services.NewCertificateService(db)
+	exportService := services.NewExportService(db)
+	analyticsService := services.NewAnalyticsService(db)
+	dashboardService := services.NewDashboardService(db, cfg.DashboardMinGroupSize, cfg.DashboardCacheTTL)
+	leaderboardService := services.NewLeaderboardService(db)
+	webhookService := services.NewWebhookService(db)
+	apiKeyService := services.NewAPIKeyService(db)
+	accommodationService := services.NewAccommodationService(db)
+
+	ltiTool, err := lti.NewTool(cfg.AppURL, cfg.LTIPrivateKey)
+	if err != nil {
+		log.Fatal("Failed to load LTI key:", err)
+	}
+	ltiService := services.NewLTIService(db, ltiTool, cfg.LTIAllowPrivateHosts)
+	scimService := services.NewSCIMService(db)
+	graphQLService := services.NewGraphQLService(db, cfg.GraphQLMaxDepth, cfg.GraphQLMaxComplexity)
+
+	authHandler := handlers.NewAuthHandler(userService)
+	testHandler := handlers.NewTestHandler(testService, invitationService, proctoringService)
+	resultHandler := handlers.NewResultHandler(resultService, reportService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	organizationHandler := handlers.NewOrganizationHandler(organizationService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService, cfg)
+	certificateHandler := handlers.NewCertificateHandler(certificateService, cfg)
+	exportHandler := handlers.NewExportHandler(exportService)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	dashboardHandler := handlers.NewDashboardHandler(dashboardService)
+	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	accommodationHandler := handlers.NewAccommodationHandler(accommodationService)
+	ltiHandler := handlers.NewLTIHandler(ltiService)
+	scimHandler := handlers.NewSCIMHandler(scimService, cfg.AppURL)
+	graphQLHandler := handlers.NewGraphQLHandler(graphQLService)
+
+	document := APISpec().Document()
+
+	utils.UseJSONFieldNames()
+
+	r := gin.Default()
+	r.Use(requestID())
+
+	r.Use(cors.New(cors.Config{
+		AllowOrigins:     []string{"*"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"*"},
+		ExposeHeaders:    []string{requestIDHeader, "Deprecation", "Sunset", "Link"},
+		AllowCredentials: true,
+	}))
+
+	if cfg.OpenAPIValidateResponses {
+		r.Use(openapi.ValidateResponses(document, "/api/v1/", func(c *gin.Context, err error) {
+			log.Printf("openapi: %v", err)
+		}))
+	}
+
+	r.LoadHTMLGlob("web/templates/*")
+	r.Static("/static", "./web/static")
+
+	// Web routes
+	r.GET("/", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "dashboard.html", nil)
+	})
+	r.GET("/login", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "login.html", nil)
+	})
+	r.GET("/register", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "register.html", nil)
+	})
+	r.GET("/invite/:token", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "invite.html", gin.H{"token": c.Param("token")})
+	})
+	r.GET("/certificates/:slug", certificateHandler.ShowCertificatePage)
+
+	r.GET("/test", auth.RequireAuth(userService), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "test.html", nil)
+	})
+	r.GET("/results", auth.RequireAuth(userService), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "results.html", nil)
+	})
+
+	// LTI 1.3 endpoints called by learning platforms and their users' browsers
+	r.GET("/lti/jwks", ltiHandler.GetJWKS)
+	r.GET("/lti/login", ltiHandler.Login)
+	r.POST("/lti/login", ltiHandler.Login)
+	r.POST("/lti/launch", ltiHandler.Launch)
+	r.POST("/lti/deep-link", ltiHandler.DeepLink)
+
+	// SCIM 2.0 provisioning by identity providers, authenticated with an API key
+	scimAPI := r.Group("/scim/v2", auth.RequireSCIMToken(apiKeyService))
+	{
+		scimAPI.GET("/ServiceProviderConfig", scimHandler.ServiceProviderConfig)
+		scimAPI.GET("/ResourceTypes", scimHandler.ResourceTypes)
+		scimAPI.GET("/Schemas", scimHandler.Schemas)
+
+		scimAPI.GET("/Users", scimHandler.ListUsers)
+		scimAPI.POST("/Users", scimHandler.CreateUser)
+		scimAPI.GET("/Users/:id", scimHandler.GetUser)
+		scimAPI.PUT("/Users/:id", scimHandler.ReplaceUser)
+		scimAPI.PATCH("/Users/:id", scimHandler.PatchUser)
+		scimAPI.DELETE("/Users/:id", scimHandler.DeleteUser)
+
+		scimAPI.GET("/Groups", scimHandler.ListGroups)
+		scimAPI.POST("/Groups", scimHandler.GroupsReadOnly)
+		scimAPI.GET("/Groups/:id", scimHandler.GetGroup)
+		scimAPI.PUT("/Groups/:id", scimHandler.ReplaceGroup)
+		scimAPI.PATCH("/Groups/:id", scimHandler.PatchGroup)
+		scimAPI.DELETE("/Groups/:id", scimHandler.GroupsReadOnly)
+	}
+
+	// API routes, registered once per version
+	registerAPI := func(api *gin.RouterGroup) {
+		api.Use(auth.ResolveTenant(organizationService, cfg))
+
+		api.GET("/openapi.json", func(c *gin.Context) {
+			c.JSON(http.StatusOK, document)
+		})
+
+		// Auth routes
+		api.POST("/register", authHandler.Register)
+		api.POST("/login", authHandler.Login)
+		api.POST("/logout", authHandler.Logout)
+
+		// Invitation links
+		api.GET("/invite/:token", invitationHandler.OpenInvitation)
+		api.POST("/invite/:token/start", invitationHandler.StartInvitation)
+
+		// Public certificates
+		api.GET("/certificates/public/:slug", certificateHandler.GetPublicCertificate)
+		api.POST("/certificates/verify", certificateHandler.VerifyCertificate)
+		api.GET("/certificates/public-key", certificateHandler.GetPublicKey)
+
+		// Protected routes
+		protected := api.Group("/")
+		protected.Use(auth.RequireAuthOrAPIKey(apiKeyService, userService))
+		{
+			protected.GET("/tests", testHandler.ListTests)
+			protected.POST("/tests", auth.RequirePermission(models.PermManageTests), testHandler.CreateTest)
+			protected.PUT("/tests/:id/policy", auth.RequirePermission(models.PermManageTests), testHandler.UpdatePolicy)
+			protected.GET("/tests/:id/eligibility", testHandler.GetEligibility)
+			protected.POST("/tests/:id/start", auth.RequirePermission(models.PermTakeTests), testHandler.StartAttempt)
+			protected.POST("/tests/:id/practice", auth.RequirePermission(models.PermTakeTests), testHandler.StartPractice)
+			protected.GET("/practice", testHandler.ListPracticeSessions)
+			protected.POST("/practice/:id/answers", auth.RequirePermission(models.PermTakeTests), testHandler.AnswerPractice)
+			protected.POST("/practice/:id/finish", auth.RequirePermission(models.PermTakeTests), testHandler.FinishPractice)
+			protected.GET("/questions", auth.RequirePermission(models.PermTakeTests), testHandler.GetQuestions)
+			protected.POST("/submit", auth.RequirePermission(models.PermTakeTests), testHandler.SubmitTest)
+			protected.POST("/attempts/:id/activity", auth.RequirePermission(models.PermTakeTests), testHandler.ReportActivity)
+			protected.GET("/tests/:id/events", auth.RequirePermission(models.PermProctor), testHandler.StreamTestEvents)
+			protected.GET("/results", resultHandler.GetResults)
+			protected.GET("/results/:id", resultHandler.GetResult)
+			protected.GET("/progress", resultHandler.GetProgress)
+			protected.GET("/results/:id/review", resultHandler.GetReview)
+			protected.GET("/results/:id/report.pdf", resultHandler.GetReport)
+			protected.POST("/results/:id/certificate", certificateHandler.PublishResult)
+			protected.GET("/certificates", certificateHandler.ListCertificates)
+			protected.DELETE("/certificates/:id", certificateHandler.RevokeCertificate)
+			protected.GET("/report-template", auth.RequirePermission(models.PermManageTests), resultHandler.GetReportTemplate)
+			protected.PUT("/report-template", auth.RequirePermission(models.PermManageTests), resultHandler.UpdateReportTemplate)
+
+			// Proctored sessions
+			protected.POST("/sessions", auth.RequirePermission(models.PermProctor), testHandler.CreateSession)
+			protected.GET("/sessions", auth.RequirePermission(models.PermProctor), testHandler.ListSessions)
+			protected.GET("/sessions/:id", auth.RequirePermission(models.PermProctor), testHandler.GetSession)
+			protected.POST("/sessions/:id/start", auth.RequirePermission(models.PermProctor), testHandler.StartSession)
+			protected.POST("/sessions/:id/pause", auth.RequirePermission(models.PermProctor), testHandler.PauseSession)
+			protected.POST("/sessions/:id/resume", auth.RequirePermission(models.PermProctor), testHandler.ResumeSession)
+			protected.POST("/sessions/:id/end", auth.RequirePermission(models.PermProctor), testHandler.EndSession)
+			protected.POST("/sessions/:id/extend", auth.RequirePermission(models.PermProctor), testHandler.ExtendTime)
+			protected.POST("/sessions/:id/terminate", auth.RequirePermission(models.PermProctor), testHandler.TerminateAttempt)
+			protected.POST("/sessions/:id/join", auth.RequirePermission(models.PermTakeTests), testHandler.JoinSession)
+			protected.GET("/sessions/:id/lobby", auth.RequirePermission(models.PermTakeTests), testHandler.GetLobby)
+			protected.POST("/sessions/:id/attempt", auth.RequirePermission(models.PermTakeTests), testHandler.StartSessionAttempt)
+
+			// Leaderboards
+			protected.GET("/leaderboards/profile", leaderboardHandler.GetProfile)
+			protected.PUT("/leaderboards/profile", leaderboardHandler.UpdateProfile)
+			protected.GET("/leaderboards/tests/:id", leaderboardHandler.GetTestLeaderboard)
+			protected.GET("/leaderboards/categories/:category", leaderboardHandler.GetCategoryLeaderboard)
+
+			// Question bank
+			protected.POST("/questions", auth.RequirePermission(models.PermManageQuestions), testHandler.CreateQuestion)
+			protected.PUT("/questions/:id", auth.RequirePermission(models.PermManageQuestions), testHandler.UpdateQuestion)
+			protected.DELETE("/questions/:id", auth.RequirePermission(models.PermManageQuestions), testHandler.DeleteQuestion)
+
+			// Staff access to candidates
+			protected.GET("/users/:id/results", auth.RequirePermission(models.PermReadAllResults), resultHandler.GetUserResults)
+			protected.GET("/users/:id/progress", auth.RequirePermission(models.PermReadAllResults), resultHandler.GetUserProgress)
+			protected.PUT("/users/:id/roles", auth.RequirePermission(models.PermAssignRoles), roleHandler.SetUserRoles)
+
+			// Role management
+			protected.GET("/roles", auth.RequirePermission(models.PermManageRoles), roleHandler.ListRoles)
+			protected.POST("/roles", auth.RequirePermission(models.PermManageRoles), roleHandler.CreateRole)
+			protected.PUT("/roles/:id", auth.RequirePermission(models.PermManageRoles), roleHandler.UpdateRole)
+			protected.GET("/permissions", auth.RequirePermission(models.PermManageRoles), roleHandler.ListPermissions)
+
+			// Invitations
+			protected.GET("/invitations", auth.RequirePermission(models.PermInviteCandidates), invitationHandler.ListInvitations)
+			protected.POST("/invitations", auth.RequirePermission(models.PermInviteCandidates), invitationHandler.CreateInvitations)
+			protected.POST("/invitations/bulk", auth.RequirePermission(models.PermInviteCandidates), invitationHandler.BulkCreateInvitations)
+			protected.GET("/invitations/:id", auth.RequirePermission(models.PermInviteCandidates), invitationHandler.GetInvitation)
+
+			// Accommodations
+			protected.GET("/accommodations", auth.RequirePermission(models.PermAccommodate), accommodationHandler.ListAccommodations)
+			protected.POST("/accommodations", auth.RequirePermission(models.PermAccommodate), accommodationHandler.GrantAccommodation)
+			protected.DELETE("/accommodations/:id", auth.RequirePermission(models.PermAccommodate), accommodationHandler.RevokeAccommodation)
+
+			// Analytics
+			protected.GET("/admin/export", auth.RequirePermission(models.PermExportResults), exportHandler.ExportResults)
+			protected.GET("/admin/item-analysis", auth.RequirePermission(models.PermManageQuestions), analyticsHandler.GetItemAnalysis)
+			protected.GET("/admin/tests/:id/reliability", auth.RequirePermission(models.PermManageTests), analyticsHandler.ListReliabilityReports)
+			protected.POST("/admin/tests/:id/reliability", auth.RequirePermission(models.PermManageTests), analyticsHandler.ComputeReliability)
+			protected.GET("/dashboards/organization", auth.RequirePermission(models.PermReadAllResults), dashboardHandler.GetOrganizationDashboard)
+			protected.GET("/dashboards/tests/:id", auth.RequirePermission(models.PermReadAllResults), dashboardHandler.GetTestDashboard)
+
+			// GraphQL over results, answers, questions and users
+			protected.POST("/graphql", graphQLHandler.Query)
+			protected.GET("/graphql/schema", graphQLHandler.GetSchema)
+
+			// Webhooks
+			protected.GET("/admin/webhooks", auth.RequirePermission(models.PermManageWebhooks), webhookHandler.ListEndpoints)
+			protected.POST("/admin/webhooks", auth.RequirePermission(models.PermManageWebhooks), webhookHandler.CreateEndpoint)
+			protected.PUT("/admin/webhooks/:id", auth.RequirePermission(models.PermManageWebhooks), webhookHandler.UpdateEndpoint)
+			protected.DELETE("/admin/webhooks/:id", auth.RequirePermission(models.PermManageWebhooks), webhookHandler.DeleteEndpoint)
+			protected.POST("/admin/webhooks/:id/rotate-secret", auth.RequirePermission(models.PermManageWebhooks), webhookHandler.RotateSecret)
+			protected.GET("/admin/webhooks/:id/deliveries", auth.RequirePermission(models.PermManageWebhooks), webhookHandler.ListDeliveries)
+			protected.GET("/admin/webhook-deliveries/:id", auth.RequirePermission(models.PermManageWebhooks), webhookHandler.GetDelivery)
+			protected.POST("/admin/webhook-deliveries/:id/replay", auth.RequirePermission(models.PermManageWebhooks), webhookHandler.ReplayDelivery)
+
+			// API keys
+			protected.GET("/admin/api-keys", auth.RequirePermission(models.PermManageAPIKeys), apiKeyHandler.ListAPIKeys)
+			protected.POST("/admin/api-keys", auth.RequirePermission(models.PermManageAPIKeys), apiKeyHandler.CreateAPIKey)
+			protected.POST("/admin/api-keys/:id/rotate", auth.RequirePermission(models.PermManageAPIKeys), apiKeyHandler.RotateAPIKey)
+			protected.DELETE("/admin/api-keys/:id", auth.RequirePermission(models.PermManageAPIKeys), apiKeyHandler.RevokeAPIKey)
+
+			// LTI platforms
+			protected.GET("/admin/lti/tool", auth.RequirePermission(models.PermManageLTI), ltiHandler.GetToolConfiguration)
+			protected.GET("/admin/lti/platforms", auth.RequirePermission(models.PermManageLTI), ltiHandler.ListPlatforms)
+			protected.POST("/admin/lti/platforms", auth.RequirePermission(models.PermManageLTI), ltiHandler.CreatePlatform)
+			protected.PUT("/admin/lti/platforms/:id", auth.RequirePermission(models.PermManageLTI), ltiHandler.UpdatePlatform)
+			protected.DELETE("/admin/lti/platforms/:id", auth.RequirePermission(models.PermManageLTI), ltiHandler.DeletePlatform)
+
+			// Organizations
+			protected.GET("/organizations", auth.RequirePermission(models.PermManageOrgs), organizationHandler.ListOrganizations)
+			protected.POST("/organizations", auth.RequirePermission(models.PermManageOrgs), organizationHandler.CreateOrganization)
+		}
+	}
+
+	registerAPI(r.Group("/api/v1", apiVersion("v1")))
+
+	// The unversioned API serves the same handlers with its original error format
+	// until it is switched off. See the README for the deprecation schedule.
+	if cfg.LegacyAPIEnabled {
+		registerAPI(r.Group("/api", deprecated(cfg.LegacyAPISunset)))
+	} else {
+		r.NoRoute(legacyAPIRemoved)
+	}
+
+	return r
+}
@@ -0,0 +1,330 @@
+package server
+
+import (
+	"iq-go/internal/auth"
+	"iq-go/internal/handlers"
+	"iq-go/internal/models"
+	"iq-go/internal/openapi"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+)
+
+// resultFilter are the query parameters read by resultFilterFromQuery.
+var resultFilter = []openapi.Param{
+	{Name: "organization_id", Type: "integer", Description: "Organization to report on; platform admins only"},
+	{Name: "test_id", Type: "integer"},
+	{Name: "from", Description: "Earliest completion, as a date (YYYY-MM-DD) or RFC 3339 timestamp"},
+	{Name: "to", Description: "Latest completion, as a date (YYYY-MM-DD) or RFC 3339 timestamp"},
+}
+
+// leaderboardQuery are the query parameters read by leaderboardQueryFromRequest.
+var leaderboardQuery = []openapi.Param{
+	{Name: "period", Enum: []string{"all", "week", "month"}},
+	{Name: "date", Description: "Day within the period, as a date (YYYY-MM-DD) or RFC 3339 timestamp"},
+	{Name: "limit", Type: "integer", Description: "Number of rows, 1 to 100"},
+}
+
+// Responses of handlers that answer with a map.
+type (
+	AuthResponse struct {
+		User  *models.User `json:"user"`
+		Token string       `json:"token"`
+	}
+	IssuedAPIKey struct {
+		APIKey *models.APIKey `json:"api_key"`
+		Key    string         `json:"key"`
+	}
+	WebhookWithSecret struct {
+		Webhook *models.WebhookEndpoint `json:"webhook"`
+		Secret  string                  `json:"secret"`
+	}
+	StartedAttempt struct {
+		Attempt   *models.TestResult `json:"attempt"`
+		Questions []models.Question  `json:"questions"`
+	}
+	StartedPractice struct {
+		Session   *models.PracticeSession `json:"session"`
+		Questions []models.Question       `json:"questions"`
+	}
+	StartedInvitation struct {
+		Invitation *models.Invitation `json:"invitation"`
+		Token      string             `json:"token"`
+	}
+	SignedCertificate struct {
+		Payload   string `json:"payload"`
+		Signature string `json:"signature"`
+	}
+	CertificatePublicKey struct {
+		Algorithm string `json:"algorithm"`
+		PublicKey string `json:"public_key"`
+	}
+)
+
+// APISpec lists every route under /api/v1 with the types it binds and returns.
+func APISpec() *openapi.Spec {
+	return &openapi.Spec{
+		Info: openapi.Info{
+			Title:       "IQ Test API",
+			Description: "Cognitive testing platform. Every JSON response is wrapped in the Response envelope, and errors carry a stable code.",
+			Version:     "1.0.0",
+		},
+		Envelope:     utils.Response{},
+		TenantHeader: auth.TenantHeader,
+		Enums: []openapi.Enum{
+			openapi.EnumOf(models.AnalyticalReasoning, models.WorkingMemory, models.ProcessingSpeed, models.AttentionFocus, models.EmotionalRegulation),
+			openapi.EnumOf(models.MultipleChoice, models.TextInput, models.NumberInput, models.KeySequence),
+			openapi.EnumOf(models.ScoreFirstAttempt, models.ScoreBestAttempt, models.ScoreLatestAttempt),
+			openapi.EnumOf(models.RevealImmediately, models.RevealAfterClose, models.RevealNever),
+			openapi.EnumOf(models.InvitationSent, models.InvitationOpened, models.InvitationStarted, models.InvitationCompleted),
+			openapi.EnumOf(models.PeriodAllTime, models.PeriodWeek, models.PeriodMonth),
+			openapi.EnumOf(models.WebhookEvents...),
+			openapi.EnumOf(models.DeliveryPending, models.DeliveryDelivered, models.DeliveryFailed),
+			openapi.EnumOf(utils.ErrorCodes...),
+			openapi.EnumOf(services.TrendImproving, services.TrendDeclining, services.TrendStable, services.TrendInsufficientData),
+		},
+		Routes: []openapi.Route{
+			{Method: "GET", Path: "/api/v1/openapi.json", ID: "GetOpenAPIDocument", Tag: "Meta", Public: true,
+				Summary: "The OpenAPI document for this API", ContentTypes: []string{"application/json"}},
+
+			// Auth
+			{Method: "POST", Path: "/api/v1/register", ID: "Register", Tag: "Auth", Public: true,
+				Summary: "Register a user", Request: handlers.RegisterRequest{}, Response: AuthResponse{}, Status: 201},
+			{Method: "POST", Path: "/api/v1/login", ID: "Login", Tag: "Auth", Public: true,
+				Summary: "Log in and receive a token", Request: handlers.LoginRequest{}, Response: AuthResponse{}},
+			{Method: "POST", Path: "/api/v1/logout", ID: "Logout", Tag: "Auth", Public: true,
+				Summary: "Clear the session cookie"},
+
+			// Invitation links
+			{Method: "GET", Path: "/api/v1/invite/:token", ID: "OpenInvitation", Tag: "Invitations", Public: true,
+				Summary: "Open an invitation link", Response: &models.Invitation{}},
+			{Method: "POST", Path: "/api/v1/invite/:token/start", ID: "StartInvitation", Tag: "Invitations", Public: true,
+				Summary: "Start the invited test and receive a candidate token", Response: StartedInvitation{}},
+
+			// Public certificates
+			{Method: "GET", Path: "/api/v1/certificates/public/:slug", ID: "GetPublicCertificate", Tag: "Certificates", Public: true,
+				Summary: "Fetch a published certificate and its signature", Response: SignedCertificate{}},
+			{Method: "POST", Path: "/api/v1/certificates/verify", ID: "VerifyCertificate", Tag: "Certificates", Public: true,
+				Summary: "Verify a certificate signature", Request: handlers.VerifyCertificateRequest{}, Response: &services.CertificateVerification{}},
+			{Method: "GET", Path: "/api/v1/certificates/public-key", ID: "GetCertificatePublicKey", Tag: "Certificates", Public: true,
+				Summary: "The key certificates are signed with", Response: CertificatePublicKey{}},
+
+			// Tests
+			{Method: "GET", Path: "/api/v1/tests", ID: "ListTests", Tag: "Tests",
+				Summary: "List tests", Response: []models.Test{}},
+			{Method: "POST", Path: "/api/v1/tests", ID: "CreateTest", Tag: "Tests", Permission: models.PermManageTests,
+				Summary: "Create a test", Request: handlers.CreateTestRequest{}, Response: &models.Test{}, Status: 201},
+			{Method: "PUT", Path: "/api/v1/tests/:id/policy", ID: "UpdateTestPolicy", Tag: "Tests", Permission: models.PermManageTests,
+				Summary: "Update a test's attempt policy", Request: handlers.UpdatePolicyRequest{}, Response: &models.Test{}},
+			{Method: "GET", Path: "/api/v1/tests/:id/eligibility", ID: "GetEligibility", Tag: "Tests",
+				Summary: "Whether the caller may start another attempt", Response: &services.AttemptEligibility{}},
+			{Method: "POST", Path: "/api/v1/tests/:id/start", ID: "StartAttempt", Tag: "Tests", Permission: models.PermTakeTests,
+				Summary: "Start an attempt", Response: StartedAttempt{}},
+			{Method: "POST", Path: "/api/v1/tests/:id/practice", ID: "StartPractice", Tag: "Practice", Permission: models.PermTakeTests,
+				Summary: "Start a practice session", Response: StartedPractice{}},
+			{Method: "GET", Path: "/api/v1/practice", ID: "ListPracticeSessions", Tag: "Practice",
+				Summary: "List the caller's practice sessions", Response: []models.PracticeSession{}},
+			{Method: "POST", Path: "/api/v1/practice/:id/answers", ID: "AnswerPractice", Tag: "Practice", Permission: models.PermTakeTests,
+				Summary: "Answer a practice question and receive feedback", Request: services.SubmitAnswerRequest{}, Response: &services.PracticeFeedback{}},
+			{Method: "POST", Path: "/api/v1/practice/:id/finish", ID: "FinishPractice", Tag: "Practice", Permission: models.PermTakeTests,
+				Summary: "Finish a practice session", Response: &models.PracticeSession{}},
+			{Method: "GET", Path: "/api/v1/questions", ID: "GetQuestions", Tag: "Tests", Permission: models.PermTakeTests,
+				Query:   []openapi.Param{{Name: "test_id", Type: "integer", Description: "Defaults to the invited test, or test 1"}},
+				Summary: "List a test's questions without answers", Response: []models.Question{}},
+			{Method: "POST", Path: "/api/v1/submit", ID: "SubmitTest", Tag: "Tests", Permission: models.PermTakeTests,
+				Summary: "Submit an attempt for scoring", Request: handlers.SubmitTestRequest{}, Response: &models.TestResult{}},
+			{Method: "POST", Path: "/api/v1/attempts/:id/activity", ID: "ReportActivity", Tag: "Proctoring", Permission: models.PermTakeTests,
+				Summary: "Report what the candidate does in an attempt in progress", Request: handlers.ActivityRequest{}},
+			{Method: "GET", Path: "/api/v1/tests/:id/events", ID: "StreamTestEvents", Tag: "Proctoring", Permission: models.PermProctor,
+				Summary: "Stream the events of every attempt at a test as Server-Sent Events", ContentTypes: []string{"text/event-stream"}},
+
+			// Proctored sessions
+			{Method: "POST", Path: "/api/v1/sessions", ID: "CreateSession", Tag: "Sessions", Permission: models.PermProctor,
+				Summary: "Schedule a test for a group of candidates", Request: handlers.SessionRequest{}, Response: &models.Session{}, Status: 201},
+			{Method: "GET", Path: "/api/v1/sessions", ID: "ListSessions", Tag: "Sessions", Permission: models.PermProctor,
+				Summary: "List the organization's sessions", Response: []models.Session{}},
+			{Method: "GET", Path: "/api/v1/sessions/:id", ID: "GetSession", Tag: "Sessions", Permission: models.PermProctor,
+				Summary: "Fetch a session with its candidates and their attempts", Response: &models.Session{}},
+			{Method: "POST", Path: "/api/v1/sessions/:id/start", ID: "StartSession", Tag: "Sessions", Permission: models.PermProctor,
+				Summary: "Start a session for every candidate", Response: &models.Session{}},
+			{Method: "POST", Path: "/api/v1/sessions/:id/pause", ID: "PauseSession", Tag: "Sessions", Permission: models.PermProctor,
+				Summary: "Pause a session's clock", Response: &models.Session{}},
+			{Method: "POST", Path: "/api/v1/sessions/:id/resume", ID: "ResumeSession", Tag: "Sessions", Permission: models.PermProctor,
+				Summary: "Resume a paused session", Response: &models.Session{}},
+			{Method: "POST", Path: "/api/v1/sessions/:id/end", ID: "EndSession", Tag: "Sessions", Permission: models.PermProctor,
+				Summary: "End a session, terminating attempts in progress", Response: &models.Session{}},
+			{Method: "POST", Path: "/api/v1/sessions/:id/extend", ID: "ExtendSessionTime", Tag: "Sessions", Permission: models.PermProctor,
+				Summary: "Give one candidate extra time", Request: handlers.ExtendTimeRequest{}, Response: &models.Session{}},
+			{Method: "POST", Path: "/api/v1/sessions/:id/terminate", ID: "TerminateSessionAttempt", Tag: "Sessions", Permission: models.PermProctor,
+				Summary: "Terminate one candidate's attempt", Request: handlers.TerminateAttemptRequest{}, Response: &models.Session{}},
+			{Method: "POST", Path: "/api/v1/sessions/:id/join", ID: "JoinSession", Tag: "Sessions", Permission: models.PermTakeTests,
+				Summary: "Enter a session's lobby", Response: &services.SessionLobby{}},
+			{Method: "GET", Path: "/api/v1/sessions/:id/lobby", ID: "GetSessionLobby", Tag: "Sessions", Permission: models.PermTakeTests,
+				Summary: "The candidate's view of a session", Response: &services.SessionLobby{}},
+			{Method: "POST", Path: "/api/v1/sessions/:id/attempt", ID: "StartSessionAttempt", Tag: "Sessions", Permission: models.PermTakeTests,
+				Summary: "Start or resume the candidate's attempt in a started session", Response: StartedAttempt{}},
+
+			// Results
+			{Method: "GET", Path: "/api/v1/results", ID: "ListResults", Tag: "Results",
+				Summary: "List the caller's results", Response: []models.TestResult{}},
+			{Method: "GET", Path: "/api/v1/results/:id", ID: "GetResult", Tag: "Results",
+				Summary: "Fetch a result", Response: &models.TestResult{}},
+			{Method: "GET", Path: "/api/v1/progress", ID: "GetProgress", Tag: "Results",
+				Query:   []openapi.Param{{Name: "test_id", Type: "integer"}},
+				Summary: "The caller's score trends", Response: &services.ProgressReport{}},
+			{Method: "GET", Path: "/api/v1/results/:id/review", ID: "GetReview", Tag: "Results",
+				Summary: "Review a result's answers as far as the reveal policy allows", Response: &services.ResultReview{}},
+			{Method: "GET", Path: "/api/v1/results/:id/report.pdf", ID: "GetReport", Tag: "Results",
+				Summary: "Download a result as a PDF report", ContentTypes: []string{"application/pdf"}},
+			{Method: "POST", Path: "/api/v1/results/:id/certificate", ID: "PublishResult", Tag: "Certificates",
+				Summary: "Publish a signed certificate for a result", Response: handlers.PublishedCertificate{}, Status: 201},
+			{Method: "GET", Path: "/api/v1/certificates", ID: "ListCertificates", Tag: "Certificates",
+				Summary: "List the caller's certificates", Response: []handlers.PublishedCertificate{}},
+			{Method: "DELETE", Path: "/api/v1/certificates/:id", ID: "RevokeCertificate", Tag: "Certificates",
+				Summary: "Revoke a certificate"},
+			{Method: "GET", Path: "/api/v1/report-template", ID: "GetReportTemplate", Tag: "Results", Permission: models.PermManageTests,
+				Summary: "Fetch the organization's report branding", Response: &models.ReportTemplate{}},
+			{Method: "PUT", Path: "/api/v1/report-template", ID: "UpdateReportTemplate", Tag: "Results", Permission: models.PermManageTests,
+				Summary: "Save the organization's report branding", Request: handlers.ReportTemplateRequest{}, Response: &models.ReportTemplate{}},
+
+			// Leaderboards
+			{Method: "GET", Path: "/api/v1/leaderboards/profile", ID: "GetLeaderboardProfile", Tag: "Leaderboards",
+				Summary: "The caller's leaderboard profile, if they opted in", Response: &models.LeaderboardProfile{}},
+			{Method: "PUT", Path: "/api/v1/leaderboards/profile", ID: "UpdateLeaderboardProfile", Tag: "Leaderboards",
+				Summary: "Opt in to or out of leaderboards", Request: handlers.LeaderboardProfileRequest{}, Response: &models.LeaderboardProfile{}},
+			{Method: "GET", Path: "/api/v1/leaderboards/tests/:id", ID: "GetTestLeaderboard", Tag: "Leaderboards",
+				Query:   append([]openapi.Param{{Name: "category", Description: "Rank on one category instead of the overall score"}}, leaderboardQuery...),
+				Summary: "Rank users on a test", Response: &services.Leaderboard{}},
+			{Method: "GET", Path: "/api/v1/leaderboards/categories/:category", ID: "GetCategoryLeaderboard", Tag: "Leaderboards",
+				Params:  []openapi.Param{{Name: "category", Enum: stringValues(models.AnalyticalReasoning, models.WorkingMemory, models.ProcessingSpeed, models.AttentionFocus, models.EmotionalRegulation)}},
+				Query:   append([]openapi.Param{{Name: "scope", Enum: []string{"organization", "global"}}}, leaderboardQuery...),
+				Summary: "Rank users on a category across tests", Response: &services.Leaderboard{}},
+
+			// Question bank
+			{Method: "POST", Path: "/api/v1/questions", ID: "CreateQuestion", Tag: "Questions", Permission: models.PermManageQuestions,
+				Summary: "Add a question to a test", Request: handlers.QuestionRequest{}, Response: &models.Question{}, Status: 201},
+			{Method: "PUT", Path: "/api/v1/questions/:id", ID: "UpdateQuestion", Tag: "Questions", Permission: models.PermManageQuestions,
+				Summary: "Update a question", Request: handlers.QuestionRequest{}, Response: &models.Question{}},
+			{Method: "DELETE", Path: "/api/v1/questions/:id", ID: "DeleteQuestion", Tag: "Questions", Permission: models.PermManageQuestions,
+				Summary: "Delete a question"},
+
+			// Staff access to candidates
+			{Method: "GET", Path: "/api/v1/users/:id/results", ID: "ListUserResults", Tag: "Results", Permission: models.PermReadAllResults,
+				Summary: "List a user's results", Response: []models.TestResult{}},
+			{Method: "GET", Path: "/api/v1/users/:id/progress", ID: "GetUserProgress", Tag: "Results", Permission: models.PermReadAllResults,
+				Query:   []openapi.Param{{Name: "test_id", Type: "integer"}},
+				Summary: "A user's score trends", Response: &services.ProgressReport{}},
+			{Method: "PUT", Path: "/api/v1/users/:id/roles", ID: "SetUserRoles", Tag: "Roles", Permission: models.PermAssignRoles,
+				Summary: "Replace a user's roles", Request: handlers.SetUserRolesRequest{}, Response: &models.User{}},
+
+			// Roles
+			{Method: "GET", Path: "/api/v1/roles", ID: "ListRoles", Tag: "Roles", Permission: models.PermManageRoles,
+				Summary: "List roles", Response: []models.Role{}},
+			{Method: "POST", Path: "/api/v1/roles", ID: "CreateRole", Tag: "Roles", Permission: models.PermManageRoles,
+				Summary: "Create a role", Request: handlers.CreateRoleRequest{}, Response: &models.Role{}, Status: 201},
+			{Method: "PUT", Path: "/api/v1/roles/:id", ID: "UpdateRole", Tag: "Roles", Permission: models.PermManageRoles,
+				Summary: "Update a role", Request: handlers.UpdateRoleRequest{}, Response: &models.Role{}},
+			{Method: "GET", Path: "/api/v1/permissions", ID: "ListPermissions", Tag: "Roles", Permission: models.PermManageRoles,
+				Summary: "List permissions", Response: []models.Permission{}},
+
+			// Invitations
+			{Method: "GET", Path: "/api/v1/invitations", ID: "ListInvitations", Tag: "Invitations", Permission: models.PermInviteCandidates,
+				Query:   []openapi.Param{{Name: "test_id", Type: "integer"}},
+				Summary: "List invitations", Response: []models.Invitation{}},
+			{Method: "POST", Path: "/api/v1/invitations", ID: "CreateInvitations", Tag: "Invitations", Permission: models.PermInviteCandidates,
+				Summary: "Invite candidates to a test", Request: handlers.CreateInvitationsRequest{}, Response: []handlers.InvitationLink{}, Status: 201},
+			{Method: "POST", Path: "/api/v1/invitations/bulk", ID: "BulkCreateInvitations", Tag: "Invitations", Permission: models.PermInviteCandidates,
+				Summary: "Invite the candidates in an uploaded CSV", Request: handlers.BulkInvitationsRequest{}, Multipart: true, Response: []handlers.InvitationLink{}, Status: 201},
+			{Method: "GET", Path: "/api/v1/invitations/:id", ID: "GetInvitation", Tag: "Invitations", Permission: models.PermInviteCandidates,
+				Summary: "Fetch an invitation", Response: &models.Invitation{}},
+
+			// Accommodations
+			{Method: "GET", Path: "/api/v1/accommodations", ID: "ListAccommodations", Tag: "Accommodations", Permission: models.PermAccommodate,
+				Query:   []openapi.Param{{Name: "user_id", Type: "integer"}, {Name: "invitation_id", Type: "integer"}},
+				Summary: "List accommodations, revoked ones included", Response: []models.Accommodation{}},
+			{Method: "POST", Path: "/api/v1/accommodations", ID: "GrantAccommodation", Tag: "Accommodations", Permission: models.PermAccommodate,
+				Summary: "Grant a user or an invitation a time multiplier", Request: services.AccommodationRequest{}, Response: &models.Accommodation{}, Status: 201},
+			{Method: "DELETE", Path: "/api/v1/accommodations/:id", ID: "RevokeAccommodation", Tag: "Accommodations", Permission: models.PermAccommodate,
+				Summary: "Revoke an accommodation", Response: &models.Accommodation{}},
+
+			// Analytics
+			{Method: "GET", Path: "/api/v1/admin/export", ID: "ExportResults", Tag: "Analytics", Permission: models.PermExportResults,
+				Query:        append([]openapi.Param{{Name: "format", Enum: []string{"csv", "jsonl"}}}, resultFilter...),
+				Summary:      "Stream answer-level results as CSV or JSON lines",
+				ContentTypes: []string{"text/csv", "application/x-ndjson"}},
+			{Method: "GET", Path: "/api/v1/admin/item-analysis", ID: "GetItemAnalysis", Tag: "Analytics", Permission: models.PermManageQuestions,
+				Query:   append([]openapi.Param{{Name: "min_responses", Type: "integer", Description: "Responses below which items are not flagged"}}, resultFilter...),
+				Summary: "Item statistics for the question bank", Response: &services.ItemAnalysisReport{}},
+			{Method: "GET", Path: "/api/v1/admin/tests/:id/reliability", ID: "ListReliabilityReports", Tag: "Analytics", Permission: models.PermManageTests,
+				Summary: "List a test's reliability reports", Response: []models.ReliabilityReport{}},
+			{Method: "POST", Path: "/api/v1/admin/tests/:id/reliability", ID: "ComputeReliability", Tag: "Analytics", Permission: models.PermManageTests,
+				Summary: "Compute a reliability report, unless no results arrived since the latest", Response: &models.ReliabilityReport{}, Status: 201, OtherStatus: []int{200}},
+			{Method: "GET", Path: "/api/v1/dashboards/organization", ID: "GetOrganizationDashboard", Tag: "Analytics", Permission: models.PermReadAllResults,
+				Query:   resultFilter,
+				Summary: "Organization-wide aggregates", Response: &services.OrganizationDashboard{}},
+			{Method: "GET", Path: "/api/v1/dashboards/tests/:id", ID: "GetTestDashboard", Tag: "Analytics", Permission: models.PermReadAllResults,
+				Query:   append([]openapi.Param{{Name: "bins", Type: "integer", Description: "Histogram bins, 1 to 50"}}, resultFilter...),
+				Summary: "Aggregates for one test", Response: &services.TestDashboard{}},
+
+			// GraphQL
+			{Method: "POST", Path: "/api/v1/graphql", ID: "QueryGraphQL", Tag: "GraphQL",
+				Summary: "Run a GraphQL query; the response is a GraphQL response, not the envelope", Request: handlers.GraphQLRequest{}, ContentTypes: []string{"application/json"}},
+			{Method: "GET", Path: "/api/v1/graphql/schema", ID: "GetGraphQLSchema", Tag: "GraphQL",
+				Summary: "The GraphQL schema in the schema definition language", ContentTypes: []string{"text/plain"}},
+
+			// Webhooks
+			{Method: "GET", Path: "/api/v1/admin/webhooks", ID: "ListWebhooks", Tag: "Webhooks", Permission: models.PermManageWebhooks,
+				Summary: "List webhook endpoints", Response: []models.WebhookEndpoint{}},
+			{Method: "POST", Path: "/api/v1/admin/webhooks", ID: "CreateWebhook", Tag: "Webhooks", Permission: models.PermManageWebhooks,
+				Summary: "Add a webhook endpoint; the signing secret is only returned here", Request: services.WebhookRequest{}, Response: WebhookWithSecret{}, Status: 201},
+			{Method: "PUT", Path: "/api/v1/admin/webhooks/:id", ID: "UpdateWebhook", Tag: "Webhooks", Permission: models.PermManageWebhooks,
+				Summary: "Update a webhook endpoint", Request: services.WebhookRequest{}, Response: &models.WebhookEndpoint{}},
+			{Method: "DELETE", Path: "/api/v1/admin/webhooks/:id", ID: "DeleteWebhook", Tag: "Webhooks", Permission: models.PermManageWebhooks,
+				Summary: "Delete a webhook endpoint"},
+			{Method: "POST", Path: "/api/v1/admin/webhooks/:id/rotate-secret", ID: "RotateWebhookSecret", Tag: "Webhooks", Permission: models.PermManageWebhooks,
+				Summary: "Replace a webhook endpoint's signing secret", Response: WebhookWithSecret{}},
+			{Method: "GET", Path: "/api/v1/admin/webhooks/:id/deliveries", ID: "ListWebhookDeliveries", Tag: "Webhooks", Permission: models.PermManageWebhooks,
+				Query: []openapi.Param{
+					{Name: "status", Enum: stringValues(models.DeliveryPending, models.DeliveryDelivered, models.DeliveryFailed)},
+					{Name: "limit", Type: "integer", Description: "Number of deliveries, 1 to 200"},
+				},
+				Summary: "List an endpoint's deliveries, newest first", Response: []models.WebhookDelivery{}},
+			{Method: "GET", Path: "/api/v1/admin/webhook-deliveries/:id", ID: "GetWebhookDelivery", Tag: "Webhooks", Permission: models.PermManageWebhooks,
+				Summary: "Fetch a delivery with its attempt log", Response: &models.WebhookDelivery{}},
+			{Method: "POST", Path: "/api/v1/admin/webhook-deliveries/:id/replay", ID: "ReplayWebhookDelivery", Tag: "Webhooks", Permission: models.PermManageWebhooks,
+				Summary: "Queue a delivery to be sent again", Response: &models.WebhookDelivery{}, Status: 202},
+
+			// API keys
+			{Method: "GET", Path: "/api/v1/admin/api-keys", ID: "ListAPIKeys", Tag: "API keys", Permission: models.PermManageAPIKeys,
+				Summary: "List API keys", Response: []models.APIKey{}},
+			{Method: "POST", Path: "/api/v1/admin/api-keys", ID: "CreateAPIKey", Tag: "API keys", Permission: models.PermManageAPIKeys,
+				Summary: "Issue an API key; the key is only returned here", Request: services.APIKeyRequest{}, Response: IssuedAPIKey{}, Status: 201},
+			{Method: "POST", Path: "/api/v1/admin/api-keys/:id/rotate", ID: "RotateAPIKey", Tag: "API keys", Permission: models.PermManageAPIKeys,
+				Query:   []openapi.Param{{Name: "grace", Description: "How long the old key keeps working, e.g. 24h; it is revoked at once when omitted"}},
+				Summary: "Replace an API key", Response: IssuedAPIKey{}, Status: 201},
+			{Method: "DELETE", Path: "/api/v1/admin/api-keys/:id", ID: "RevokeAPIKey", Tag: "API keys", Permission: models.PermManageAPIKeys,
+				Summary: "Revoke an API key", Response: &models.APIKey{}},
+
+			// LTI platforms
+			{Method: "GET", Path: "/api/v1/admin/lti/tool", ID: "GetLTIToolConfiguration", Tag: "LTI", Permission: models.PermManageLTI,
+				Summary: "Fetch the URLs to register the tool with on a learning platform", Response: services.LTIToolConfiguration{}},
+			{Method: "GET", Path: "/api/v1/admin/lti/platforms", ID: "ListLTIPlatforms", Tag: "LTI", Permission: models.PermManageLTI,
+				Summary: "List registered learning platforms", Response: []models.LTIPlatform{}},
+			{Method: "POST", Path: "/api/v1/admin/lti/platforms", ID: "CreateLTIPlatform", Tag: "LTI", Permission: models.PermManageLTI,
+				Summary: "Register a learning platform", Request: services.LTIPlatformRequest{}, Response: &models.LTIPlatform{}, Status: 201},
+			{Method: "PUT", Path: "/api/v1/admin/lti/platforms/:id", ID: "UpdateLTIPlatform", Tag: "LTI", Permission: models.PermManageLTI,
+				Summary: "Update a learning platform", Request: services.LTIPlatformRequest{}, Response: &models.LTIPlatform{}},
+			{Method: "DELETE", Path: "/api/v1/admin/lti/platforms/:id", ID: "DeleteLTIPlatform", Tag: "LTI", Permission: models.PermManageLTI,
+				Summary: "Remove a learning platform"},
+
+			// Organizations
+			{Method: "GET", Path: "/api/v1/organizations", ID: "ListOrganizations", Tag: "Organizations", Permission: models.PermManageOrgs,
+				Summary: "List organizations", Response: []models.Organization{}},
+			{Method: "POST", Path: "/api/v1/organizations", ID: "CreateOrganization", Tag: "Organizations", Permission: models.PermManageOrgs,
+				Summary: "Create an organization", Request: handlers.CreateOrganizationRequest{}, Response: &models.Organization{}, Status: 201},
+		},
+	}
+}
+
+func stringValues[T ~string](values ...T) []string {
+	return openapi.EnumOf(values...).Values
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"iq-go/internal/config"
+	"iq-go/internal/models"
+	"iq-go/internal/openapi"
+	"iq-go/internal/services"
+	"iq-go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestMain runs the tests from the repository root, where the router finds its
+// templates and the generated client lives.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestRoutesMatchSpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Handlers are only registered, never called, so no database is needed.
+	router := NewRouter(nil, config.Load())
+	for _, problem := range openapi.CheckRoutes(APISpec().Routes, router.Routes(), "/api/v1/") {
+		t.Error(problem)
+	}
+}
+
+func TestClientIsGenerated(t *testing.T) {
+	generated, err := APISpec().GenerateClient("client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := os.ReadFile("client/client.gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(committed, generated) {
+		t.Error("client/client.gen.go is out of date; run go run ./cmd/openapi -client")
+	}
+}
+
+func TestResponsesMatchSpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doc := APISpec().Document()
+
+	now := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	deadline := now.Add(45 * time.Minute)
+	attemptID := uint(42)
+	attempt := &models.TestResult{
+		ID:             attemptID,
+		OrganizationID: 1,
+		UserID:         7,
+		TestID:         3,
+		TotalQuestions: 2,
+		StartedAt:      now,
+		Deadline:       &deadline,
+		TimeMultiplier: 1.5,
+	}
+	question := models.Question{
+		ID:           11,
+		TestID:       3,
+		QuestionText: "Which number comes next: 2, 4, 8, ...?",
+		QuestionType: models.MultipleChoice,
+		Category:     models.AnalyticalReasoning,
+		Options:      `["10","12","16","18"]`,
+		TimeLimit:    60,
+		OrderIndex:   1,
+	}
+	completed := now.Add(20 * time.Minute)
+	result := *attempt
+	result.Score = 1
+	result.TimeTaken = 1200
+	result.Counted = true
+	result.CompletedAt = &completed
+	result.Answers = []models.Answer{{ID: 1, TestResultID: attemptID, QuestionID: 11, UserAnswer: "16", IsCorrect: true, ResponseTime: 5400}}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		respond func(c *gin.Context)
+		invalid bool
+	}{
+		{
+			name: "login", method: "POST", path: "/api/v1/login",
+			respond: func(c *gin.Context) {
+				user := &models.User{ID: 7, OrganizationID: 1, Email: "ada@example.com", FirstName: "Ada", LastName: "Lovelace",
+					Roles: []models.Role{{ID: 1, Name: models.RoleCandidate}}}
+				utils.SuccessResponse(c, http.StatusOK, "Login successful", AuthResponse{User: user, Token: "header.payload.signature"})
+			},
+		},
+		{
+			name: "list tests", method: "GET", path: "/api/v1/tests",
+			respond: func(c *gin.Context) {
+				utils.SuccessResponse(c, http.StatusOK, "Tests fetched successfully", []models.Test{
+					{ID: 3, OrganizationID: 1, Name: "Reasoning", Duration: 30, ScoringPolicy: models.ScoreBestAttempt, AnswerReveal: models.RevealNever},
+				})
+			},
+		},
+		{
+			name: "start attempt", method: "POST", path: "/api/v1/tests/:id/start",
+			respond: func(c *gin.Context) {
+				utils.SuccessResponse(c, http.StatusOK, "Attempt started", StartedAttempt{Attempt: attempt, Questions: []models.Question{question}})
+			},
+		},
+		{
+			name: "submit", method: "POST", path: "/api/v1/submit",
+			respond: func(c *gin.Context) {
+				utils.SuccessResponse(c, http.StatusOK, "Test submitted successfully", &result)
+			},
+		},
+		{
+			name: "eligibility", method: "GET", path: "/api/v1/tests/:id/eligibility",
+			respond: func(c *gin.Context) {
+				utils.SuccessResponse(c, http.StatusOK, "Eligibility fetched successfully", &services.AttemptEligibility{
+					TestID: 3, AttemptsUsed: 2, MaxAttempts: 2, Reason: "Maximum number of attempts (2) reached",
+				})
+			},
+		},
+		{
+			name: "session lobby", method: "GET", path: "/api/v1/sessions/:id/lobby",
+			respond: func(c *gin.Context) {
+				utils.SuccessResponse(c, http.StatusOK, "Lobby fetched successfully", &services.SessionLobby{
+					SessionID: 5, TestID: 3, Name: "Morning group", Status: models.SessionPaused,
+					ScheduledAt: now, StartedAt: &now, Deadline: &deadline, AttemptID: &attemptID,
+				})
+			},
+		},
+		{
+			name: "coded error", method: "POST", path: "/api/v1/submit",
+			respond: func(c *gin.Context) {
+				utils.CodedErrorResponse(c, http.StatusConflict, utils.CodeSessionPaused, "Session is paused; wait for the proctor to resume it")
+			},
+		},
+		{
+			name: "generic error", method: "GET", path: "/api/v1/tests",
+			respond: func(c *gin.Context) {
+				utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch tests")
+			},
+		},
+		{
+			name: "wrong data type", method: "GET", path: "/api/v1/tests", invalid: true,
+			respond: func(c *gin.Context) {
+				utils.SuccessResponse(c, http.StatusOK, "Tests fetched successfully", map[string]string{"name": "Reasoning"})
+			},
+		},
+		{
+			name: "undocumented status", method: "GET", path: "/api/v1/tests", invalid: true,
+			respond: func(c *gin.Context) {
+				utils.SuccessResponse(c, http.StatusAccepted, "Tests fetched successfully", []models.Test{})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Set(utils.APIVersionKey, "v1")
+			tt.respond(c)
+
+			err := doc.ValidateResponse(tt.method, tt.path, recorder.Code, recorder.Header().Get("Content-Type"), recorder.Body.Bytes())
+			switch {
+			case tt.invalid && err == nil:
+				t.Errorf("%s %s: expected a mismatch with the spec, body %s", tt.method, tt.path, recorder.Body)
+			case !tt.invalid && err != nil:
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"iq-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAccommodationNotFound = errors.New("accommodation not found")
+	ErrInvalidAccommodation  = errors.New("invalid accommodation")
+)
+
+type AccommodationService struct {
+	db *gorm.DB
+}
+
+func NewAccommodationService(db *gorm.DB) *AccommodationService {
+	return &AccommodationService{db: db}
+}
+
+// AccommodationRequest grants an accommodation to either a user or an invitation.
+type AccommodationRequest struct {
+	UserID         *uint   `json:"user_id"`
+	InvitationID   *uint   `json:"invitation_id"`
+	TimeMultiplier float64 `json:"time_multiplier" binding:"required,gt=1,max=4"`
+	Reason         string  `json:"reason" binding:"required"`
+}
+
+// AccommodationFilter selects accommodations by the user or invitation they were
+// granted to; zero fields match everything.
+type AccommodationFilter struct {
+	UserID       uint
+	InvitationID uint
+}
+
+// ListAccommodations returns the organization's accommodations newest first,
+// including revoked ones, as the audit trail of what was granted.
+func (s *AccommodationService) ListAccommodations(orgID uint, filter AccommodationFilter) ([]models.Accommodation, error) {
+	query := s.db.Scopes(inOrganization(orgID))
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.InvitationID != 0 {
+		query = query.Where("invitation_id = ?", filter.InvitationID)
+	}
+	var accommodations []models.Accommodation
+	err := query.Order("id DESC").Find(&accommodations).Error
+	return accommodations, err
+}
+
+// GrantAccommodation records an accommodation on behalf of a user or, when
+// apiKeyID is set, an API key. The user's or invitation's current accommodation,
+// if any, is revoked by the same grantor. Attempts already started keep the time
+// they were given.
+func (s *AccommodationService) GrantAccommodation(orgID, grantedByID, apiKeyID uint, req AccommodationRequest) (*models.Accommodation, error) {
+	if (req.UserID == nil) == (req.InvitationID == nil) {
+		return nil, fmt.Errorf("%w: give either user_id or invitation_id", ErrInvalidAccommodation)
+	}
+
+	accommodation := &models.Accommodation{
+		OrganizationID: orgID,
+		UserID:         req.UserID,
+		InvitationID:   req.InvitationID,
+		TimeMultiplier: req.TimeMultiplier,
+		Reason:         req.Reason,
+		GrantedAt:      time.Now(),
+	}
+	accommodation.GrantedByID, accommodation.GrantedByKeyID = actor(grantedByID, apiKeyID)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		current := tx.Model(&models.Accommodation{}).Scopes(inOrganization(orgID)).Where("revoked_at IS NULL")
+		if req.UserID != nil {
+			var found int64
+			if err := tx.Model(&models.User{}).Scopes(inOrganization(orgID)).Where("id = ?", *req.UserID).Count(&found).Error; err != nil {
+				return err
+			}
+			if found == 0 {
+				return fmt.Errorf("%w: user is not in the organization", ErrInvalidAccommodation)
+			}
+			current = current.Where("user_id = ?", *req.UserID)
+		} else {
+			var invitation models.Invitation
+			err := tx.Scopes(inOrganization(orgID)).First(&invitation, *req.InvitationID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: invitation not found", ErrInvalidAccommodation)
+			}
+			if err != nil {
+				return err
+			}
+			if invitation.Status == models.InvitationCompleted {
+				return fmt.Errorf("%w: invitation is already completed", ErrInvalidAccommodation)
+			}
+			current = current.Where("invitation_id = ?", *req.InvitationID)
+		}
+
+		err := current.Updates(map[string]interface{}{
+			"revoked_at":        accommodation.GrantedAt,
+			"revoked_by_id":     accommodation.GrantedByID,
+			"revoked_by_key_id": accommodation.GrantedByKeyID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(accommodation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return accommodation, nil
+}
+
+// RevokeAccommodation ends an accommodation for attempts started from now on.
+// Revoking one that is already revoked changes nothing.
+func (s *AccommodationService) RevokeAccommodation(orgID, accommodationID, revokedByID, apiKeyID uint) (*models.Accommodation, error) {
+	var accommodation models.Accommodation
+	err := s.db.Scopes(inOrganization(orgID)).First(&accommodation, accommodationID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAccommodationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if accommodation.RevokedAt == nil {
+		now := time.Now()
+		accommodation.RevokedAt = &now
+		accommodation.RevokedByID, accommodation.RevokedByKeyID = actor(revokedByID, apiKeyID)
+		err := s.db.Model(&accommodation).Updates(map[string]interface{}{
+			"revoked_at":        accommodation.RevokedAt,
+			"revoked_by_id":     accommodation.RevokedByID,
+			"revoked_by_key_id": accommodation.RevokedByKeyID,
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &accommodation, nil
+}
+
+// actor returns who made a change: the API key when the request used one,
+// otherwise the signed-in user.
+func actor(userID, apiKeyID uint) (*uint, *uint) {
+	if apiKeyID != 0 {
+		return nil, &apiKeyID
+	}
+	return &userID, nil
+}
+
+// activeAccommodation returns the accommodation that applies to the user's
+// attempts at the test, or nil. One granted to the invitation the user is taking
+// the test through, which has no result yet, comes before one granted to the user.
+func activeAccommodation(db *gorm.DB, orgID, testID, userID uint) (*models.Accommodation, error) {
+	var accommodation models.Accommodation
+	err := db.Joins("JOIN invitations ON invitations.id = accommodations.invitation_id AND invitations.deleted_at IS NULL").
+		Where("accommodations.revoked_at IS NULL AND invitations.user_id = ? AND invitations.test_id = ? AND invitations.test_result_id IS NULL",
+			userID, testID).
+		Order("accommodations.id DESC").
+		Limit(1).
+		Find(&accommodation).Error
+	if err != nil {
+		return nil, err
+	}
+	if accommodation.ID != 0 {
+		return &accommodation, nil
+	}
+
+	err = db.Where("organization_id = ? AND user_id = ? AND revoked_at IS NULL", orgID, userID).
+		Order("id DESC").
+		Limit(1).
+		Find(&accommodation).Error
+	if err != nil || accommodation.ID == 0 {
+		return nil, err
+	}
+	return &accommodation, nil
+}
+
+// unaccommodated restricts test_results to attempts taken in the standard time.
+// Attempts given extra time are not comparable with them, so score and time
+// statistics leave them out and report how many they left out.
+func unaccommodated(db *gorm.DB) *gorm.DB {
+	return db.Where("test_results.time_multiplier <= 1")
+}
+
+// countAccommodated counts the completed attempts in a scope that were given
+// extra time.
+func countAccommodated(db *gorm.DB, scope func(*gorm.DB) *gorm.DB) (int, error) {
+	var count int64
+	err := db.Model(&models.TestResult{}).
+		Scopes(scope).
+		Where("test_results.completed_at IS NOT NULL AND test_results.time_multiplier > 1").
+		Count(&count).Error
+	return int(count), err
+}
+
+// timeMultiplier is how many times as long an accommodated candidate is given.
+func timeMultiplier(accommodation *models.Accommodation) float64 {
+	if accommodation == nil {
+		return 1
+	}
+	return accommodation.TimeMultiplier
+}
+
+// accommodatedMinutes is the extra time a multiplier adds to a test of the given
+// duration in minutes.
+func accommodatedMinutes(duration int, multiplier float64) int {
+	return models.ScaleTimeLimit(duration, multiplier) - duration
+}
+
+// scaleQuestionTimes gives the served questions' time limits and display times
+// to a candidate allowed multiplier times as long.
+func scaleQuestionTimes(questions []models.Question, multiplier float64) {
+	for i := range questions {
+		questions[i].ScaleTime(multiplier)
+	}
+}
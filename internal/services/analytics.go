@@ -0,0 +1,369 @@
+package services
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"iq-go/internal/models"
+	"iq-go/internal/psychometrics"
+
+	"gorm.io/gorm"
+)
+
+// Item analysis thresholds. Items outside them are flagged for review.
+const (
+	DefaultMinItemResponses = 30
+
+	minPValue         = 0.20
+	maxPValue         = 0.95
+	minDiscrimination = 0.20
+	maxOmissionRate   = 0.10
+
+	// free-text answers beyond this many distinct values are grouped as "(other)"
+	maxTrackedAnswers = 20
+)
+
+const (
+	FlagFewResponses      = "few_responses"
+	FlagTooHard           = "too_hard"
+	FlagTooEasy           = "too_easy"
+	FlagLowDiscrimination = "low_discrimination"
+	FlagNegativeDiscrim   = "negative_discrimination"
+	FlagHighOmission      = "high_omission"
+	FlagKeyNotAnOption    = "key_not_an_option"
+	FlagDistractorOverKey = "distractor_outperforms_key"
+	FlagPossibleMiskey    = "possible_miskey"
+)
+
+const otherAnswersPlaceholder = "(other)"
+
+type AnalyticsService struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsService(db *gorm.DB) *AnalyticsService {
+	return &AnalyticsService{db: db}
+}
+
+// ItemAnalysisReport holds classical test theory statistics for every question
+// answered in the filtered results. Accommodated attempts are left out and only
+// counted.
+type ItemAnalysisReport struct {
+	GeneratedAt          time.Time        `json:"generated_at"`
+	Attempts             int              `json:"attempts"`
+	AccommodatedAttempts int              `json:"accommodated_attempts"`
+	MinResponses         int              `json:"min_responses"`
+	Items                []ItemStatistics `json:"items"`
+}
+
+type ItemStatistics struct {
+	QuestionID    uint                `json:"question_id"`
+	TestID        uint                `json:"test_id"`
+	OrderIndex    int                 `json:"order_index"`
+	QuestionText  string              `json:"question_text"`
+	QuestionType  models.QuestionType `json:"question_type"`
+	Category      models.Category     `json:"category"`
+	CorrectAnswer string              `json:"correct_answer"`
+	Responses     int                 `json:"responses"`
+	PValue        float64             `json:"p_value"` // proportion correct
+	// Discrimination is the point-biserial correlation between the item and the
+	// rest of the attempt's score. It is null when everyone or no one got the item right.
+	Discrimination     *float64           `json:"discrimination"`
+	OmissionRate       float64            `json:"omission_rate"`
+	MeanResponseTimeMs float64            `json:"mean_response_time_ms"` // answered responses only
+	Answers            []AnswerStatistics `json:"answers"`
+	Flags              []string           `json:"flags"`
+}
+
+// AnswerStatistics describes how often an answer was given and how well the people
+// who gave it did on the rest of the attempt. For multiple choice questions these
+// are the options, which makes it a distractor analysis.
+type AnswerStatistics struct {
+	Answer        string  `json:"answer"`
+	Label         string  `json:"label,omitempty"` // option text for multiple choice
+	Count         int     `json:"count"`
+	Proportion    float64 `json:"proportion"`
+	MeanRestScore float64 `json:"mean_rest_score"`
+	IsKey         bool    `json:"is_key"`
+}
+
+type answerAccumulator struct {
+	count   int
+	correct int
+	rest    float64
+}
+
+type itemAccumulator struct {
+	correct      psychometrics.Moments // rest scores of respondents who got the item right
+	incorrect    psychometrics.Moments
+	omitted      int
+	responseTime psychometrics.Moments
+	answers      map[string]*answerAccumulator
+}
+
+func (a *itemAccumulator) add(answer itemResponse, restScore float64) {
+	if answer.IsCorrect {
+		a.correct.Add(restScore)
+	} else {
+		a.incorrect.Add(restScore)
+	}
+
+	value := strings.ToLower(strings.TrimSpace(answer.UserAnswer))
+	if value == "" {
+		a.omitted++
+		return
+	}
+	a.responseTime.Add(float64(answer.ResponseTime))
+
+	tracked, ok := a.answers[value]
+	if !ok {
+		if len(a.answers) >= maxTrackedAnswers {
+			value = otherAnswersPlaceholder
+		}
+		if tracked, ok = a.answers[value]; !ok {
+			tracked = &answerAccumulator{}
+			a.answers[value] = tracked
+		}
+	}
+	tracked.count++
+	tracked.rest += restScore
+	if answer.IsCorrect {
+		tracked.correct++
+	}
+}
+
+type itemResponse struct {
+	TestResultID uint
+	Score        int
+	QuestionID   uint
+	UserAnswer   string
+	IsCorrect    bool
+	ResponseTime int
+}
+
+// ItemAnalysis computes item statistics from the answers of completed results. The
+// answers are streamed one attempt at a time, so the cost is one pass over the rows.
+func (s *AnalyticsService) ItemAnalysis(filter ResultFilter, minResponses int) (*ItemAnalysisReport, error) {
+	if minResponses <= 0 {
+		minResponses = DefaultMinItemResponses
+	}
+
+	rows, err := s.db.Table("answers").
+		Select("answers.test_result_id, test_results.score, answers.question_id, answers.user_answer, answers.is_correct, answers.response_time").
+		Joins("JOIN test_results ON test_results.id = answers.test_result_id AND test_results.deleted_at IS NULL").
+		Where("answers.deleted_at IS NULL AND test_results.completed_at IS NOT NULL").
+		Scopes(filter.scope, unaccommodated).
+		Order("answers.test_result_id").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make(map[uint]*itemAccumulator)
+	attempts := 0
+	var attempt []itemResponse
+
+	flush := func() {
+		if len(attempt) == 0 {
+			return
+		}
+		attempts++
+		for _, answer := range attempt {
+			item, ok := items[answer.QuestionID]
+			if !ok {
+				item = &itemAccumulator{answers: make(map[string]*answerAccumulator)}
+				items[answer.QuestionID] = item
+			}
+			restScore := float64(answer.Score)
+			if answer.IsCorrect {
+				restScore--
+			}
+			item.add(answer, restScore)
+		}
+		attempt = attempt[:0]
+	}
+
+	for rows.Next() {
+		var response itemResponse
+		if err := s.db.ScanRows(rows, &response); err != nil {
+			return nil, err
+		}
+		if len(attempt) > 0 && attempt[0].TestResultID != response.TestResultID {
+			flush()
+		}
+		attempt = append(attempt, response)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	questionIDs := make([]uint, 0, len(items))
+	for id := range items {
+		questionIDs = append(questionIDs, id)
+	}
+	var questions []models.Question
+	if len(questionIDs) > 0 {
+		// Include deleted questions: they may still have answers worth reviewing.
+		if err := s.db.Unscoped().Where("id IN ?", questionIDs).Find(&questions).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	accommodated, err := countAccommodated(s.db, filter.scope)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ItemAnalysisReport{
+		GeneratedAt:          time.Now(),
+		Attempts:             attempts,
+		AccommodatedAttempts: accommodated,
+		MinResponses:         minResponses,
+		Items:                make([]ItemStatistics, 0, len(questions)),
+	}
+	for i := range questions {
+		report.Items = append(report.Items, itemStatistics(&questions[i], items[questions[i].ID], minResponses))
+	}
+	sort.Slice(report.Items, func(i, j int) bool {
+		a, b := report.Items[i], report.Items[j]
+		if a.TestID != b.TestID {
+			return a.TestID < b.TestID
+		}
+		if a.OrderIndex != b.OrderIndex {
+			return a.OrderIndex < b.OrderIndex
+		}
+		return a.QuestionID < b.QuestionID
+	})
+
+	return report, nil
+}
+
+func itemStatistics(question *models.Question, item *itemAccumulator, minResponses int) ItemStatistics {
+	responses := item.correct.N + item.incorrect.N
+	stats := ItemStatistics{
+		QuestionID:         question.ID,
+		TestID:             question.TestID,
+		OrderIndex:         question.OrderIndex,
+		QuestionText:       question.QuestionText,
+		QuestionType:       question.QuestionType,
+		Category:           question.Category,
+		CorrectAnswer:      question.CorrectAnswer,
+		Responses:          responses,
+		PValue:             float64(item.correct.N) / float64(responses),
+		OmissionRate:       float64(item.omitted) / float64(responses),
+		MeanResponseTimeMs: item.responseTime.Mean(),
+		Flags:              []string{},
+	}
+	if r, ok := psychometrics.PointBiserial(item.correct, item.incorrect); ok {
+		stats.Discrimination = &r
+	}
+
+	var options []string
+	if question.QuestionType == models.MultipleChoice {
+		// Malformed options are reported through the key_not_an_option flag below.
+		_ = json.Unmarshal([]byte(question.Options), &options)
+	}
+	stats.Answers = answerStatistics(item, options, responses)
+
+	if question.QuestionType == models.MultipleChoice {
+		key := strings.ToLower(strings.TrimSpace(question.CorrectAnswer))
+		if len(key) != 1 || key[0] < 'a' || int(key[0]-'a') >= len(options) {
+			stats.Flags = append(stats.Flags, FlagKeyNotAnOption)
+		}
+	}
+
+	if responses < minResponses {
+		stats.Flags = append(stats.Flags, FlagFewResponses)
+		return stats
+	}
+
+	switch {
+	case stats.PValue < minPValue:
+		stats.Flags = append(stats.Flags, FlagTooHard)
+	case stats.PValue > maxPValue:
+		stats.Flags = append(stats.Flags, FlagTooEasy)
+	}
+	if stats.Discrimination != nil {
+		switch {
+		case *stats.Discrimination < 0:
+			stats.Flags = append(stats.Flags, FlagNegativeDiscrim)
+		case *stats.Discrimination < minDiscrimination:
+			stats.Flags = append(stats.Flags, FlagLowDiscrimination)
+		}
+	}
+	if stats.OmissionRate > maxOmissionRate {
+		stats.Flags = append(stats.Flags, FlagHighOmission)
+	}
+	stats.Flags = append(stats.Flags, answerFlags(stats.Answers, item.correct)...)
+
+	return stats
+}
+
+// answerStatistics lists multiple choice options in order, followed by any other
+// answers given, most frequent first.
+func answerStatistics(item *itemAccumulator, options []string, responses int) []AnswerStatistics {
+	stats := make([]AnswerStatistics, 0, len(options)+len(item.answers))
+	seen := make(map[string]bool, len(options))
+
+	add := func(value, label string, answer *answerAccumulator) {
+		entry := AnswerStatistics{Answer: value, Label: label}
+		if answer != nil {
+			entry.Count = answer.count
+			entry.Proportion = float64(answer.count) / float64(responses)
+			entry.MeanRestScore = answer.rest / float64(answer.count)
+			entry.IsKey = answer.correct > 0
+		}
+		stats = append(stats, entry)
+		seen[value] = true
+	}
+
+	for i, option := range options {
+		letter := string(rune('a' + i))
+		add(letter, option, item.answers[letter])
+	}
+
+	var others []string
+	for value := range item.answers {
+		if !seen[value] {
+			others = append(others, value)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool {
+		a, b := item.answers[others[i]], item.answers[others[j]]
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		return others[i] < others[j]
+	})
+	for _, value := range others {
+		add(value, "", item.answers[value])
+	}
+
+	return stats
+}
+
+// answerFlags looks for wrong answers that attract the stronger candidates. A wrong
+// answer whose respondents score higher on the rest of the attempt than those who
+// got the item right points at an ambiguous item; if it is also more popular than
+// the key, the key itself is probably wrong.
+func answerFlags(answers []AnswerStatistics, correct psychometrics.Moments) []string {
+	var flags []string
+	keyMean := correct.Mean()
+	for _, answer := range answers {
+		if answer.IsKey || answer.Count == 0 || answer.Answer == otherAnswersPlaceholder {
+			continue
+		}
+		outperforms := correct.N == 0 || answer.MeanRestScore > keyMean
+		if outperforms && answer.Count > correct.N {
+			return []string{FlagPossibleMiskey}
+		}
+		if outperforms && flags == nil {
+			flags = append(flags, FlagDistractorOverKey)
+		}
+	}
+	return flags
+}
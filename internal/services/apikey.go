@@ -0,0 +1,204 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"iq-go/internal/models"
+	"iq-go/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrAPIKeyInvalid  = errors.New("API key is invalid, expired or revoked")
+	ErrAPIKeyScope    = errors.New("invalid API key permissions")
+)
+
+// APIKeyPrefix starts every API key, so keys can be told apart from JWTs.
+const APIKeyPrefix = "iqk_"
+
+const (
+	apiKeyDisplayLength = 12              // characters of the key kept in clear as its prefix
+	apiKeyUsageInterval = 1 * time.Minute // last-used tracking is written at most this often
+)
+
+type APIKeyService struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{db: db}
+}
+
+type APIKeyRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	Permissions []string   `json:"permissions" binding:"required"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+func (s *APIKeyService) ListAPIKeys(orgID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := s.db.Scopes(inOrganization(orgID)).Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+// CreateAPIKey issues a key with a subset of the creator's own permissions. The
+// plaintext key is returned once and never stored.
+func (s *APIKeyService) CreateAPIKey(orgID, createdByID uint, granted []string, req APIKeyRequest) (*models.APIKey, string, error) {
+	if err := validateAPIKeyPermissions(req.Permissions, granted); err != nil {
+		return nil, "", err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, "", fmt.Errorf("%w: expiry must be in the future", ErrAPIKeyScope)
+	}
+
+	key := &models.APIKey{
+		OrganizationID: orgID,
+		Name:           req.Name,
+		Permissions:    req.Permissions,
+		CreatedByID:    createdByID,
+		ExpiresAt:      req.ExpiresAt,
+	}
+	plaintext, err := s.issue(s.db, key)
+	return key, plaintext, err
+}
+
+// RotateAPIKey replaces a key with a new one carrying the same name and
+// permissions, which the rotator must hold just as when creating a key. The old
+// key keeps working for the grace period so integrations can switch over, and is
+// revoked immediately when grace is zero.
+func (s *APIKeyService) RotateAPIKey(orgID, keyID, rotatedByID uint, granted []string, grace time.Duration) (*models.APIKey, string, error) {
+	var rotated *models.APIKey
+	var plaintext string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		old, err := s.findAPIKey(tx, orgID, keyID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if !old.IsActive(now) {
+			return ErrAPIKeyInvalid
+		}
+		if err := validateAPIKeyPermissions(old.Permissions, granted); err != nil {
+			return err
+		}
+
+		rotated = &models.APIKey{
+			OrganizationID: orgID,
+			Name:           old.Name,
+			Permissions:    old.Permissions,
+			CreatedByID:    rotatedByID,
+			RotatedFromID:  &old.ID,
+			ExpiresAt:      old.ExpiresAt,
+		}
+		if plaintext, err = s.issue(tx, rotated); err != nil {
+			return err
+		}
+
+		if grace <= 0 {
+			return tx.Model(old).Update("revoked_at", now).Error
+		}
+		if expiresAt := now.Add(grace); old.ExpiresAt == nil || expiresAt.Before(*old.ExpiresAt) {
+			return tx.Model(old).Update("expires_at", expiresAt).Error
+		}
+		return nil
+	})
+	return rotated, plaintext, err
+}
+
+func (s *APIKeyService) RevokeAPIKey(orgID, keyID uint) (*models.APIKey, error) {
+	key, err := s.findAPIKey(s.db, orgID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+		if err := s.db.Model(key).Update("revoked_at", now).Error; err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// Authenticate resolves a plaintext key to an active API key and records its use.
+func (s *APIKeyService) Authenticate(plaintext, clientIP string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := s.db.Where("key_hash = ?", utils.HashToken(plaintext)).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !key.IsActive(now) {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	// Busy integrations would otherwise write to the row on every request.
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyUsageInterval || key.LastUsedIP != clientIP {
+		err := s.db.Model(&key).Updates(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": clientIP,
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &key, nil
+}
+
+func (s *APIKeyService) issue(db *gorm.DB, key *models.APIKey) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	plaintext := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	key.Prefix = plaintext[:apiKeyDisplayLength]
+	key.KeyHash = utils.HashToken(plaintext)
+	if err := db.Create(key).Error; err != nil {
+		return "", err
+	}
+	return plaintext, nil
+}
+
+func (s *APIKeyService) findAPIKey(db *gorm.DB, orgID, keyID uint) (*models.APIKey, error) {
+	var key models.APIKey
+	err := db.Scopes(inOrganization(orgID)).First(&key, keyID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	return &key, err
+}
+
+// validateAPIKeyPermissions checks that every requested permission may be given to
+// a key and is held by the person creating it, so keys never escalate privileges.
+func validateAPIKeyPermissions(requested, granted []string) error {
+	if len(requested) == 0 {
+		return fmt.Errorf("%w: grant at least one permission", ErrAPIKeyScope)
+	}
+	for _, permission := range requested {
+		if !containsString(models.APIKeyPermissions, permission) {
+			return fmt.Errorf("%w: %q cannot be granted to an API key", ErrAPIKeyScope, permission)
+		}
+		if !containsString(granted, permission) {
+			return fmt.Errorf("%w: you do not hold %q", ErrAPIKeyScope, permission)
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"iq-go/internal/models"
+	"iq-go/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrResultIncomplete   = errors.New("result has not been completed")
+	ErrCertificateRevoked = errors.New("certificate has been revoked")
+)
+
+type CertificateService struct {
+	db *gorm.DB
+}
+
+func NewCertificateService(db *gorm.DB) *CertificateService {
+	return &CertificateService{db: db}
+}
+
+// CertificateVerification is the outcome of checking a payload and signature.
+type CertificateVerification struct {
+	Valid       bool                       `json:"valid"`
+	Revoked     bool                       `json:"revoked"`
+	Certificate *models.CertificatePayload `json:"certificate,omitempty"`
+}
+
+// PublishResult signs a snapshot of the user's completed result and publishes it
+// under a new unguessable slug.
+func (s *CertificateService) PublishResult(orgID, userID, resultID uint) (*models.Certificate, error) {
+	var result models.TestResult
+	err := s.db.Scopes(inOrganization(orgID)).
+		Preload("Test").
+		Preload("User.Organization").
+		Where("id = ? AND user_id = ?", resultID, userID).
+		First(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	if result.CompletedAt == nil {
+		return nil, ErrResultIncomplete
+	}
+
+	slug, err := newSlug()
+	if err != nil {
+		return nil, err
+	}
+
+	payload := models.CertificatePayload{
+		Slug:           slug,
+		Name:           strings.TrimSpace(result.User.FirstName + " " + result.User.LastName),
+		TestName:       result.Test.Name,
+		Score:          result.Score,
+		TotalQuestions: result.TotalQuestions,
+		CompletedAt:    result.CompletedAt.UTC(),
+		IssuedAt:       time.Now().UTC().Truncate(time.Second),
+	}
+	if result.User.Organization != nil {
+		payload.Issuer = result.User.Organization.Name
+	}
+	if result.TotalQuestions > 0 {
+		payload.Percentage = float64(result.Score) / float64(result.TotalQuestions) * 100
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	certificate := &models.Certificate{
+		OrganizationID: orgID,
+		UserID:         userID,
+		TestResultID:   result.ID,
+		Slug:           slug,
+		Payload:        string(encoded),
+		Signature:      utils.SignPayload(encoded),
+	}
+	if err := s.db.Create(certificate).Error; err != nil {
+		return nil, err
+	}
+	return certificate, nil
+}
+
+func (s *CertificateService) ListCertificates(orgID, userID uint) ([]models.Certificate, error) {
+	var certificates []models.Certificate
+	err := s.db.Scopes(inOrganization(orgID)).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&certificates).Error
+	return certificates, err
+}
+
+func (s *CertificateService) RevokeCertificate(orgID, userID, certificateID uint) error {
+	result := s.db.Model(&models.Certificate{}).
+		Scopes(inOrganization(orgID)).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", certificateID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetPublicCertificate looks up a certificate by slug for anonymous viewers.
+func (s *CertificateService) GetPublicCertificate(slug string) (*models.Certificate, error) {
+	var certificate models.Certificate
+	if err := s.db.Where("slug = ?", slug).First(&certificate).Error; err != nil {
+		return nil, err
+	}
+	if certificate.RevokedAt != nil {
+		return nil, ErrCertificateRevoked
+	}
+	return &certificate, nil
+}
+
+// Verify checks that payload was signed by this server and that the certificate
+// it describes has not been revoked since.
+func (s *CertificateService) Verify(payload, signature string) (*CertificateVerification, error) {
+	verification := &CertificateVerification{}
+	if !utils.VerifyPayload([]byte(payload), signature) {
+		return verification, nil
+	}
+
+	var decoded models.CertificatePayload
+	if err := json.Unmarshal([]byte(payload), &decoded); err != nil {
+		return verification, nil
+	}
+
+	var certificate models.Certificate
+	err := s.db.Where("slug = ?", decoded.Slug).First(&certificate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return verification, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	verification.Valid = certificate.RevokedAt == nil
+	verification.Revoked = certificate.RevokedAt != nil
+	verification.Certificate = &decoded
+	return verification, nil
+}
+
+func newSlug() (string, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"iq-go/internal/cache"
+	"iq-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultHistogramBins = 10
+	MaxHistogramBins     = 50
+
+	dashboardCacheSize = 1000
+)
+
+// DashboardService aggregates results for dashboards. Any statistic computed from
+// fewer than minGroupSize attempts is withheld so individuals cannot be singled out.
+type DashboardService struct {
+	db           *gorm.DB
+	minGroupSize int
+	cache        *cache.TTL[string, any]
+}
+
+func NewDashboardService(db *gorm.DB, minGroupSize int, cacheTTL time.Duration) *DashboardService {
+	if minGroupSize < 1 {
+		minGroupSize = 1
+	}
+	return &DashboardService{
+		db:           db,
+		minGroupSize: minGroupSize,
+		cache:        cache.NewTTL[string, any](cacheTTL, dashboardCacheSize),
+	}
+}
+
+// TestDashboard describes the cohort of attempts at a test started in a date range.
+// Pointer fields are null when the group they describe is below the minimum size.
+// Score and time statistics leave out accommodated attempts, which are counted in
+// Started, Completed and Accommodated.
+type TestDashboard struct {
+	TestID                 uint           `json:"test_id"`
+	TestName               string         `json:"test_name"`
+	From                   *time.Time     `json:"from"`
+	To                     *time.Time     `json:"to"`
+	MinGroupSize           int            `json:"min_group_size"`
+	GeneratedAt            time.Time      `json:"generated_at"`
+	Started                int            `json:"started"`
+	Completed              int            `json:"completed"`
+	CompletionRate         float64        `json:"completion_rate"`
+	Accommodated           *int           `json:"accommodated"` // completed attempts given extra time
+	Suppressed             bool           `json:"suppressed"`   // too few completed attempts for score statistics
+	MeanPercentage         *float64       `json:"mean_percentage"`
+	MedianTimeTakenSeconds *float64       `json:"median_time_taken_seconds"`
+	ScoreDistribution      []HistogramBin `json:"score_distribution"`
+	CategoryMeans          []CategoryMean `json:"category_means"`
+	DropOff                []DropOffPoint `json:"drop_off"`
+}
+
+// HistogramBin counts completed attempts with a percentage score in [Min, Max),
+// the last bin including 100.
+type HistogramBin struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count *int    `json:"count"`
+}
+
+type CategoryMean struct {
+	Category       models.Category `json:"category"`
+	MeanPercentage *float64        `json:"mean_percentage"`
+	Attempts       int             `json:"attempts"`
+}
+
+// DropOffPoint is the share of started attempts that answered the question at
+// Position or a later one. Abandoned attempts answered none.
+type DropOffPoint struct {
+	Position int     `json:"position"`
+	Reached  int     `json:"reached"`
+	Rate     float64 `json:"rate"`
+}
+
+// OrganizationDashboard summarises every test of an organization over a date range.
+type OrganizationDashboard struct {
+	From         *time.Time    `json:"from"`
+	To           *time.Time    `json:"to"`
+	MinGroupSize int           `json:"min_group_size"`
+	GeneratedAt  time.Time     `json:"generated_at"`
+	Tests        []TestSummary `json:"tests"`
+}
+
+type TestSummary struct {
+	TestID                 uint     `json:"test_id"`
+	TestName               string   `json:"test_name"`
+	Started                int      `json:"started"`
+	Completed              int      `json:"completed"`
+	CompletionRate         float64  `json:"completion_rate"`
+	Accommodated           *int     `json:"accommodated"` // completed attempts given extra time, left out of the statistics below
+	MeanPercentage         *float64 `json:"mean_percentage"`
+	MedianTimeTakenSeconds *float64 `json:"median_time_taken_seconds"`
+
+	scored int // completed attempts the statistics are computed from
+}
+
+// cohort restricts test_results to the filter, by when attempts were started so
+// that abandoned attempts are part of the cohort.
+func cohort(filter ResultFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("test_results.organization_id = ? AND test_results.deleted_at IS NULL", filter.OrganizationID)
+		if filter.TestID != 0 {
+			db = db.Where("test_results.test_id = ?", filter.TestID)
+		}
+		if filter.From != nil {
+			db = db.Where("test_results.started_at >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			db = db.Where("test_results.started_at < ?", *filter.To)
+		}
+		return db
+	}
+}
+
+func (s *DashboardService) GetTestDashboard(filter ResultFilter, bins int) (*TestDashboard, error) {
+	if bins <= 0 {
+		bins = DefaultHistogramBins
+	}
+	key := fmt.Sprintf("test:%d:%d:%s:%s:%d", filter.OrganizationID, filter.TestID, formatBound(filter.From), formatBound(filter.To), bins)
+	if cached, ok := s.cache.Get(key); ok {
+		return cached.(*TestDashboard), nil
+	}
+
+	var test models.Test
+	if err := s.db.Scopes(inOrganization(filter.OrganizationID)).First(&test, filter.TestID).Error; err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.testSummaries(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &TestDashboard{
+		TestID:            test.ID,
+		TestName:          test.Name,
+		From:              filter.From,
+		To:                filter.To,
+		MinGroupSize:      s.minGroupSize,
+		GeneratedAt:       time.Now(),
+		ScoreDistribution: []HistogramBin{},
+		CategoryMeans:     []CategoryMean{},
+		DropOff:           []DropOffPoint{},
+	}
+	if len(summaries) > 0 {
+		summary := summaries[0]
+		dashboard.Started = summary.Started
+		dashboard.Completed = summary.Completed
+		dashboard.CompletionRate = summary.CompletionRate
+		dashboard.Accommodated = summary.Accommodated
+		dashboard.MeanPercentage = summary.MeanPercentage
+		dashboard.MedianTimeTakenSeconds = summary.MedianTimeTakenSeconds
+	}
+
+	if dashboard.Started >= s.minGroupSize {
+		if dashboard.DropOff, err = s.dropOff(filter, dashboard.Started); err != nil {
+			return nil, err
+		}
+	}
+
+	dashboard.Suppressed = len(summaries) == 0 || summaries[0].scored < s.minGroupSize
+	if !dashboard.Suppressed {
+		if dashboard.ScoreDistribution, err = s.scoreDistribution(filter, bins); err != nil {
+			return nil, err
+		}
+		if dashboard.CategoryMeans, err = s.categoryMeans(filter); err != nil {
+			return nil, err
+		}
+	}
+
+	s.cache.Set(key, dashboard)
+	return dashboard, nil
+}
+
+func (s *DashboardService) GetOrganizationDashboard(filter ResultFilter) (*OrganizationDashboard, error) {
+	filter.TestID = 0
+	key := fmt.Sprintf("org:%d:%s:%s", filter.OrganizationID, formatBound(filter.From), formatBound(filter.To))
+	if cached, ok := s.cache.Get(key); ok {
+		return cached.(*OrganizationDashboard), nil
+	}
+
+	summaries, err := s.testSummaries(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &OrganizationDashboard{
+		From:         filter.From,
+		To:           filter.To,
+		MinGroupSize: s.minGroupSize,
+		GeneratedAt:  time.Now(),
+		Tests:        summaries,
+	}
+	s.cache.Set(key, dashboard)
+	return dashboard, nil
+}
+
+// testSummaries aggregates the cohort per test, withholding score statistics for
+// tests with too few completed, unaccommodated attempts.
+func (s *DashboardService) testSummaries(filter ResultFilter) ([]TestSummary, error) {
+	var rows []struct {
+		TestID       uint
+		TestName     string
+		Started      int
+		Completed    int
+		Accommodated int
+		Mean         *float64
+		MedianTime   *float64
+	}
+	err := s.db.Table("test_results").
+		Select(`test_results.test_id, tests.name AS test_name, COUNT(*) AS started,
+			COUNT(test_results.completed_at) AS completed,
+			COUNT(test_results.completed_at) FILTER (WHERE test_results.time_multiplier > 1) AS accommodated,
+			AVG(test_results.score * 100.0 / NULLIF(test_results.total_questions, 0))
+				FILTER (WHERE test_results.completed_at IS NOT NULL AND test_results.time_multiplier <= 1) AS mean,
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY test_results.time_taken)
+				FILTER (WHERE test_results.completed_at IS NOT NULL AND test_results.time_multiplier <= 1) AS median_time`).
+		Joins("JOIN tests ON tests.id = test_results.test_id").
+		Scopes(cohort(filter)).
+		Group("test_results.test_id, tests.name").
+		Order("tests.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]TestSummary, 0, len(rows))
+	for _, row := range rows {
+		summary := TestSummary{
+			TestID:       row.TestID,
+			TestName:     row.TestName,
+			Started:      row.Started,
+			Completed:    row.Completed,
+			Accommodated: s.suppressCount(row.Accommodated),
+			scored:       row.Completed - row.Accommodated,
+		}
+		if row.Started > 0 {
+			summary.CompletionRate = float64(row.Completed) / float64(row.Started)
+		}
+		if summary.scored >= s.minGroupSize {
+			summary.MeanPercentage = row.Mean
+			summary.MedianTimeTakenSeconds = row.MedianTime
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func (s *DashboardService) scoreDistribution(filter ResultFilter, bins int) ([]HistogramBin, error) {
+	var rows []struct {
+		Bin   int
+		Count int
+	}
+	err := s.db.Table("test_results").
+		Select("CAST(LEAST(FLOOR(test_results.score * ? / test_results.total_questions), ?) AS INTEGER) AS bin, COUNT(*) AS count", float64(bins), bins-1).
+		Scopes(cohort(filter), unaccommodated).
+		Where("test_results.completed_at IS NOT NULL AND test_results.total_questions > 0").
+		Group("bin").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int, bins)
+	for _, row := range rows {
+		if row.Bin >= 0 && row.Bin < bins {
+			counts[row.Bin] = row.Count
+		}
+	}
+
+	width := 100.0 / float64(bins)
+	histogram := make([]HistogramBin, bins)
+	for i, count := range counts {
+		histogram[i] = HistogramBin{
+			Min:   math.Round(float64(i)*width*100) / 100,
+			Max:   math.Round(float64(i+1)*width*100) / 100,
+			Count: s.suppressCount(count),
+		}
+	}
+	return histogram, nil
+}
+
+func (s *DashboardService) categoryMeans(filter ResultFilter) ([]CategoryMean, error) {
+	var rows []struct {
+		Category models.Category
+		Mean     float64
+		Attempts int
+	}
+	err := s.db.Table("answers").
+		Select(`questions.category, AVG(CASE WHEN answers.is_correct THEN 100.0 ELSE 0 END) AS mean,
+			COUNT(DISTINCT answers.test_result_id) AS attempts`).
+		Joins("JOIN test_results ON test_results.id = answers.test_result_id").
+		Joins("JOIN questions ON questions.id = answers.question_id").
+		Scopes(cohort(filter), unaccommodated).
+		Where("answers.deleted_at IS NULL AND test_results.completed_at IS NOT NULL").
+		Group("questions.category").
+		Order("questions.category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	means := make([]CategoryMean, 0, len(rows))
+	for _, row := range rows {
+		mean := CategoryMean{Category: row.Category, Attempts: row.Attempts}
+		if row.Attempts >= s.minGroupSize {
+			value := row.Mean
+			mean.MeanPercentage = &value
+		}
+		means = append(means, mean)
+	}
+	return means, nil
+}
+
+// dropOff finds the last question position each started attempt answered and
+// turns the counts into a survival curve.
+func (s *DashboardService) dropOff(filter ResultFilter, started int) ([]DropOffPoint, error) {
+	lastAnswered := s.db.Table("test_results").
+		Select(`test_results.id, COALESCE(MAX(attempt_questions.position)
+			FILTER (WHERE answers.user_answer <> ''), 0) AS last_position`).
+		Joins("LEFT JOIN answers ON answers.test_result_id = test_results.id AND answers.deleted_at IS NULL").
+		Joins("LEFT JOIN attempt_questions ON attempt_questions.test_result_id = answers.test_result_id AND attempt_questions.question_id = answers.question_id").
+		Scopes(cohort(filter)).
+		Group("test_results.id")
+
+	var rows []struct {
+		LastPosition int
+		Count        int
+	}
+	err := s.db.Table("(?) AS attempts", lastAnswered).
+		Select("last_position, COUNT(*) AS count").
+		Group("last_position").
+		Order("last_position DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || rows[0].LastPosition == 0 {
+		return []DropOffPoint{}, nil
+	}
+
+	reached := make([]int, rows[0].LastPosition+1)
+	for _, row := range rows {
+		reached[row.LastPosition] = row.Count
+	}
+	for position := len(reached) - 2; position >= 1; position-- {
+		reached[position] += reached[position+1]
+	}
+
+	points := make([]DropOffPoint, 0, len(reached)-1)
+	for position := 1; position < len(reached); position++ {
+		points = append(points, DropOffPoint{
+			Position: position,
+			Reached:  reached[position],
+			Rate:     float64(reached[position]) / float64(started),
+		})
+	}
+	return points, nil
+}
+
+// suppressCount hides counts small enough to point at individuals. Zero reveals
+// nothing about anyone and is kept.
+func (s *DashboardService) suppressCount(count int) *int {
+	if count > 0 && count < s.minGroupSize {
+		return nil
+	}
+	return &count
+}
+
+func formatBound(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"time"
+
+	"iq-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ExportService struct {
+	db *gorm.DB
+}
+
+func NewExportService(db *gorm.DB) *ExportService {
+	return &ExportService{db: db}
+}
+
+// ResultFilter selects completed results for exports and analytics. Zero values mean
+// no restriction, except that OrganizationID zero covers every organization and is
+// reserved for platform admins.
+type ResultFilter struct {
+	OrganizationID uint
+	TestID         uint
+	From           *time.Time
+	To             *time.Time
+}
+
+// scope applies the filter to a query that joins test_results.
+func (f ResultFilter) scope(db *gorm.DB) *gorm.DB {
+	if f.OrganizationID != 0 {
+		db = db.Where("test_results.organization_id = ?", f.OrganizationID)
+	}
+	if f.TestID != 0 {
+		db = db.Where("test_results.test_id = ?", f.TestID)
+	}
+	if f.From != nil {
+		db = db.Where("test_results.completed_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		db = db.Where("test_results.completed_at < ?", *f.To)
+	}
+	return db
+}
+
+// ExportRow is one answer joined with its attempt, question and user. Columns are
+// flat and consistently typed so the output loads directly into dataframes.
+type ExportRow struct {
+	ResultID         uint            `json:"result_id"`
+	OrganizationID   uint            `json:"organization_id"`
+	UserID           uint            `json:"user_id"`
+	UserEmail        string          `json:"user_email"`
+	UserFirstName    string          `json:"user_first_name"`
+	UserLastName     string          `json:"user_last_name"`
+	TestID           uint            `json:"test_id"`
+	TestName         string          `json:"test_name"`
+	StartedAt        time.Time       `json:"started_at"`
+	CompletedAt      time.Time       `json:"completed_at"`
+	Score            int             `json:"score"`
+	TotalQuestions   int             `json:"total_questions"`
+	TimeTakenSeconds int             `json:"time_taken_seconds"`
+	Counted          bool            `json:"counted"`
+	TimeMultiplier   float64         `json:"time_multiplier"` // above 1 for accommodated attempts
+	AnswerID         uint            `json:"answer_id"`
+	QuestionID       uint            `json:"question_id"`
+	QuestionOrder    int             `json:"question_order"`
+	Category         models.Category `json:"category"`
+	QuestionType     string          `json:"question_type"`
+	UserAnswer       string          `json:"user_answer"`
+	IsCorrect        bool            `json:"is_correct"`
+	ResponseTimeMs   int             `json:"response_time_ms"`
+}
+
+// StreamAnswers calls fn for every exported row, reading them from a database
+// cursor so memory use does not grow with the size of the export.
+func (s *ExportService) StreamAnswers(filter ResultFilter, fn func(*ExportRow) error) error {
+	query := s.db.Table("answers").
+		Select(`test_results.id AS result_id, test_results.organization_id, test_results.user_id,
+			users.email AS user_email, users.first_name AS user_first_name, users.last_name AS user_last_name,
+			test_results.test_id, tests.name AS test_name, test_results.started_at, test_results.completed_at,
+			test_results.score, test_results.total_questions, test_results.time_taken AS time_taken_seconds,
+			test_results.counted, test_results.time_multiplier, answers.id AS answer_id, answers.question_id,
+			questions.order_index AS question_order, questions.category, questions.question_type,
+			answers.user_answer, answers.is_correct, answers.response_time AS response_time_ms`).
+		Joins("JOIN test_results ON test_results.id = answers.test_result_id AND test_results.deleted_at IS NULL").
+		Joins("JOIN tests ON tests.id = test_results.test_id").
+		Joins("JOIN users ON users.id = test_results.user_id").
+		Joins("JOIN questions ON questions.id = answers.question_id").
+		Where("answers.deleted_at IS NULL AND test_results.completed_at IS NOT NULL")
+
+	rows, err := query.Scopes(filter.scope).Order("test_results.id, questions.order_index").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row ExportRow
+		if err := s.db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,503 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"iq-go/internal/graphql"
+	"iq-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	GraphQLDefaultPageSize = 20
+	GraphQLMaxPageSize     = 100
+)
+
+var errGraphQLForbidden = errors.New("insufficient permissions")
+
+// GraphQLViewer is who a query runs for. It sees the results GetResultByID would
+// give it, or every result in the organization with ReadAllResults, and correct
+// answers only as the result handlers show them.
+type GraphQLViewer struct {
+	OrganizationID uint
+	UserID         uint // zero for API keys
+	ReadAllResults bool
+}
+
+type graphQLViewerKey struct{}
+
+func viewerFrom(ctx context.Context) GraphQLViewer {
+	viewer, _ := ctx.Value(graphQLViewerKey{}).(GraphQLViewer)
+	return viewer
+}
+
+// GraphQLService answers read-only queries over results, their answers and
+// questions, and users. Every field loads its rows for all the objects it is
+// selected on in one query.
+type GraphQLService struct {
+	db     *gorm.DB
+	schema *graphql.Schema
+	limits graphql.Limits
+}
+
+func NewGraphQLService(db *gorm.DB, maxDepth, maxComplexity int) *GraphQLService {
+	s := &GraphQLService{
+		db:     db,
+		limits: graphql.Limits{MaxDepth: maxDepth, MaxComplexity: maxComplexity},
+	}
+	schema, err := graphql.NewSchema(s.queryType())
+	if err != nil {
+		// The schema is fixed, so this is a programming error.
+		panic(err)
+	}
+	s.schema = schema
+	return s
+}
+
+func (s *GraphQLService) Execute(ctx context.Context, viewer GraphQLViewer, req graphql.Request) *graphql.Response {
+	return s.schema.Execute(context.WithValue(ctx, graphQLViewerKey{}, viewer), req, s.limits)
+}
+
+// Schema returns the schema in the GraphQL schema definition language.
+func (s *GraphQLService) Schema() string {
+	return s.schema.SDL()
+}
+
+// graphQLAnswer is an answer with whether its question's correct answer may be shown.
+type graphQLAnswer struct {
+	*models.Answer
+	revealed bool
+}
+
+type graphQLQuestion struct {
+	*models.Question
+	revealed bool
+}
+
+func pageArgs() []*graphql.ArgumentDefinition {
+	return []*graphql.ArgumentDefinition{
+		{Name: "first", Type: graphql.Int, Default: GraphQLDefaultPageSize, Description: "At most 100"},
+		{Name: "offset", Type: graphql.Int, Default: 0},
+	}
+}
+
+// page reads the first and offset arguments, clamped to the allowed range.
+func page(args map[string]interface{}) (int, int) {
+	first, _ := args["first"].(int)
+	offset, _ := args["offset"].(int)
+	if first < 0 {
+		first = 0
+	}
+	if first > GraphQLMaxPageSize {
+		first = GraphQLMaxPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return first, offset
+}
+
+func pageSize(args map[string]interface{}) int {
+	first, _ := page(args)
+	return first
+}
+
+func (s *GraphQLService) queryType() *graphql.Object {
+	test := &graphql.Object{
+		Name: "Test",
+		Fields: []*graphql.FieldDefinition{
+			{Name: "id", Type: graphql.NonNull{Of: graphql.ID}, Resolve: testProperty(func(t *models.Test) interface{} { return t.ID })},
+			{Name: "name", Type: graphql.NonNull{Of: graphql.String}, Resolve: testProperty(func(t *models.Test) interface{} { return t.Name })},
+			{Name: "description", Type: graphql.NonNull{Of: graphql.String}, Resolve: testProperty(func(t *models.Test) interface{} { return t.Description })},
+			{Name: "duration", Type: graphql.NonNull{Of: graphql.Int}, Description: "In minutes", Resolve: testProperty(func(t *models.Test) interface{} { return t.Duration })},
+			{Name: "scoringPolicy", Type: graphql.NonNull{Of: graphql.String}, Resolve: testProperty(func(t *models.Test) interface{} { return string(t.ScoringPolicy) })},
+			{Name: "answerReveal", Type: graphql.NonNull{Of: graphql.String}, Resolve: testProperty(func(t *models.Test) interface{} { return string(t.AnswerReveal) })},
+		},
+	}
+
+	question := &graphql.Object{
+		Name:        "Question",
+		Description: "A question as served. Correct answers, explanations and references are null until the test reveals them.",
+		Fields: []*graphql.FieldDefinition{
+			{Name: "id", Type: graphql.NonNull{Of: graphql.ID}, Resolve: questionProperty(func(q graphQLQuestion) interface{} { return q.ID })},
+			{Name: "text", Type: graphql.NonNull{Of: graphql.String}, Resolve: questionProperty(func(q graphQLQuestion) interface{} { return q.QuestionText })},
+			{Name: "type", Type: graphql.NonNull{Of: graphql.String}, Resolve: questionProperty(func(q graphQLQuestion) interface{} { return string(q.QuestionType) })},
+			{Name: "category", Type: graphql.NonNull{Of: graphql.String}, Resolve: questionProperty(func(q graphQLQuestion) interface{} { return string(q.Category) })},
+			{Name: "options", Type: graphql.List{Of: graphql.NonNull{Of: graphql.String}}, Resolve: questionProperty(func(q graphQLQuestion) interface{} {
+				var options []string
+				if json.Unmarshal([]byte(q.Options), &options) != nil {
+					return nil
+				}
+				return options
+			})},
+			{Name: "timeLimit", Type: graphql.NonNull{Of: graphql.Int}, Description: "In seconds", Resolve: questionProperty(func(q graphQLQuestion) interface{} { return q.TimeLimit })},
+			{Name: "displayTime", Type: graphql.NonNull{Of: graphql.Int}, Description: "In seconds", Resolve: questionProperty(func(q graphQLQuestion) interface{} { return q.DisplayTime })},
+			{Name: "correctAnswer", Type: graphql.String, Resolve: questionProperty(func(q graphQLQuestion) interface{} { return revealed(q, q.CorrectAnswer) })},
+			{Name: "explanation", Type: graphql.String, Resolve: questionProperty(func(q graphQLQuestion) interface{} { return revealed(q, q.Explanation) })},
+			{Name: "reference", Type: graphql.String, Resolve: questionProperty(func(q graphQLQuestion) interface{} { return revealed(q, q.Reference) })},
+		},
+	}
+
+	answer := &graphql.Object{
+		Name: "Answer",
+		Fields: []*graphql.FieldDefinition{
+			{Name: "id", Type: graphql.NonNull{Of: graphql.ID}, Resolve: answerProperty(func(a graphQLAnswer) interface{} { return a.ID })},
+			{Name: "userAnswer", Type: graphql.NonNull{Of: graphql.String}, Resolve: answerProperty(func(a graphQLAnswer) interface{} { return a.UserAnswer })},
+			{Name: "isCorrect", Type: graphql.NonNull{Of: graphql.Boolean}, Resolve: answerProperty(func(a graphQLAnswer) interface{} { return a.IsCorrect })},
+			{Name: "responseTime", Type: graphql.NonNull{Of: graphql.Int}, Description: "In milliseconds", Resolve: answerProperty(func(a graphQLAnswer) interface{} { return a.ResponseTime })},
+			{Name: "question", Type: graphql.NonNull{Of: question}, Resolve: s.answerQuestions},
+		},
+	}
+
+	user := &graphql.Object{Name: "User"}
+	result := &graphql.Object{
+		Name:        "Result",
+		Description: "An attempt at a test",
+		Fields: []*graphql.FieldDefinition{
+			{Name: "id", Type: graphql.NonNull{Of: graphql.ID}, Resolve: resultProperty(func(r *models.TestResult) interface{} { return r.ID })},
+			{Name: "score", Type: graphql.NonNull{Of: graphql.Int}, Resolve: resultProperty(func(r *models.TestResult) interface{} { return r.Score })},
+			{Name: "totalQuestions", Type: graphql.NonNull{Of: graphql.Int}, Resolve: resultProperty(func(r *models.TestResult) interface{} { return r.TotalQuestions })},
+			{Name: "timeTaken", Type: graphql.NonNull{Of: graphql.Int}, Description: "In seconds", Resolve: resultProperty(func(r *models.TestResult) interface{} { return r.TimeTaken })},
+			{Name: "counted", Type: graphql.NonNull{Of: graphql.Boolean}, Description: "Whether this is the attempt the test's scoring policy counts", Resolve: resultProperty(func(r *models.TestResult) interface{} { return r.Counted })},
+			{Name: "timeMultiplier", Type: graphql.NonNull{Of: graphql.Float}, Description: "Above 1 when the candidate had an accommodation; keep such results apart in norm comparisons", Resolve: resultProperty(func(r *models.TestResult) interface{} { return r.TimeMultiplier })},
+			{Name: "startedAt", Type: graphql.NonNull{Of: graphql.Time}, Resolve: resultProperty(func(r *models.TestResult) interface{} { return r.StartedAt })},
+			{Name: "completedAt", Type: graphql.Time, Resolve: resultProperty(func(r *models.TestResult) interface{} { return r.CompletedAt })},
+			{Name: "user", Type: user, Resolve: s.resultUsers},
+			{Name: "test", Type: graphql.NonNull{Of: test}, Resolve: s.resultTests},
+			{Name: "answers", Type: graphql.NonNull{Of: graphql.List{Of: graphql.NonNull{Of: answer}}}, Resolve: s.resultAnswers,
+				Size: func(map[string]interface{}) int { return 50 }},
+		},
+	}
+
+	resultFilters := []*graphql.ArgumentDefinition{
+		{Name: "testId", Type: graphql.ID},
+		{Name: "counted", Type: graphql.Boolean, Description: "Only attempts the scoring policy counts, or only those it does not"},
+		{Name: "accommodated", Type: graphql.Boolean, Description: "Only attempts with an accommodation, or only those without"},
+	}
+	user.Fields = []*graphql.FieldDefinition{
+		{Name: "id", Type: graphql.NonNull{Of: graphql.ID}, Resolve: userProperty(func(u *models.User) interface{} { return u.ID })},
+		{Name: "email", Type: graphql.NonNull{Of: graphql.String}, Resolve: userProperty(func(u *models.User) interface{} { return u.Email })},
+		{Name: "firstName", Type: graphql.NonNull{Of: graphql.String}, Resolve: userProperty(func(u *models.User) interface{} { return u.FirstName })},
+		{Name: "lastName", Type: graphql.NonNull{Of: graphql.String}, Resolve: userProperty(func(u *models.User) interface{} { return u.LastName })},
+		{Name: "createdAt", Type: graphql.NonNull{Of: graphql.Time}, Resolve: userProperty(func(u *models.User) interface{} { return u.CreatedAt })},
+		{Name: "results", Type: graphql.NonNull{Of: graphql.List{Of: graphql.NonNull{Of: result}}}, Description: "Newest first",
+			Args: append(resultFilters, pageArgs()...), Resolve: s.userResults, Size: pageSize},
+	}
+
+	return &graphql.Object{
+		Name: "Query",
+		Fields: []*graphql.FieldDefinition{
+			{Name: "me", Type: user, Description: "The signed-in user; null for API keys", Resolve: s.me},
+			{Name: "user", Type: user, Description: "A user of the organization; others than yourself need results:read_all",
+				Args: []*graphql.ArgumentDefinition{{Name: "id", Type: graphql.NonNull{Of: graphql.ID}}}, Resolve: s.user},
+			{Name: "users", Type: graphql.NonNull{Of: graphql.List{Of: graphql.NonNull{Of: user}}}, Description: "The organization's users; needs results:read_all",
+				Args: pageArgs(), Resolve: s.users, Size: pageSize},
+			{Name: "result", Type: result, Description: "A result you may read",
+				Args: []*graphql.ArgumentDefinition{{Name: "id", Type: graphql.NonNull{Of: graphql.ID}}}, Resolve: s.result},
+			{Name: "results", Type: graphql.NonNull{Of: graphql.List{Of: graphql.NonNull{Of: result}}}, Description: "Results you may read, newest first",
+				Args:    append(append([]*graphql.ArgumentDefinition{{Name: "userId", Type: graphql.ID}}, resultFilters...), pageArgs()...),
+				Resolve: s.results, Size: pageSize},
+		},
+	}
+}
+
+func testProperty(get func(*models.Test) interface{}) graphql.Resolver {
+	return graphql.Property(func(source interface{}) interface{} { return get(source.(*models.Test)) })
+}
+
+func questionProperty(get func(graphQLQuestion) interface{}) graphql.Resolver {
+	return graphql.Property(func(source interface{}) interface{} { return get(source.(graphQLQuestion)) })
+}
+
+func answerProperty(get func(graphQLAnswer) interface{}) graphql.Resolver {
+	return graphql.Property(func(source interface{}) interface{} { return get(source.(graphQLAnswer)) })
+}
+
+func resultProperty(get func(*models.TestResult) interface{}) graphql.Resolver {
+	return graphql.Property(func(source interface{}) interface{} { return get(source.(*models.TestResult)) })
+}
+
+func userProperty(get func(*models.User) interface{}) graphql.Resolver {
+	return graphql.Property(func(source interface{}) interface{} { return get(source.(*models.User)) })
+}
+
+func revealed(q graphQLQuestion, value string) interface{} {
+	if !q.revealed {
+		return nil
+	}
+	return value
+}
+
+// visibleResults restricts a query on test_results to what the viewer may read.
+func visibleResults(viewer GraphQLViewer) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("test_results.organization_id = ?", viewer.OrganizationID)
+		if !viewer.ReadAllResults {
+			db = db.Where("test_results.user_id = ?", viewer.UserID)
+		}
+		return db
+	}
+}
+
+// filterResults applies the testId, counted and accommodated arguments.
+func filterResults(args map[string]interface{}) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if testID, ok := args["testId"].(uint); ok {
+			db = db.Where("test_results.test_id = ?", testID)
+		}
+		if counted, ok := args["counted"].(bool); ok {
+			db = db.Where("test_results.counted = ?", counted)
+		}
+		if accommodated, ok := args["accommodated"].(bool); ok {
+			if accommodated {
+				db = db.Where("test_results.accommodation_id IS NOT NULL")
+			} else {
+				db = db.Where("test_results.accommodation_id IS NULL")
+			}
+		}
+		return db
+	}
+}
+
+func (s *GraphQLService) me(ctx context.Context, sources []interface{}, _ map[string]interface{}) ([]interface{}, error) {
+	viewer := viewerFrom(ctx)
+	if viewer.UserID == 0 {
+		return []interface{}{nil}, nil
+	}
+	user, err := s.findUser(viewer, viewer.UserID)
+	return []interface{}{user}, err
+}
+
+func (s *GraphQLService) user(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	viewer := viewerFrom(ctx)
+	id := args["id"].(uint)
+	if !viewer.ReadAllResults && id != viewer.UserID {
+		return []interface{}{nil}, nil
+	}
+	user, err := s.findUser(viewer, id)
+	return []interface{}{user}, err
+}
+
+func (s *GraphQLService) findUser(viewer GraphQLViewer, id uint) (*models.User, error) {
+	var user models.User
+	err := s.db.Scopes(inOrganization(viewer.OrganizationID)).First(&user, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *GraphQLService) users(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	viewer := viewerFrom(ctx)
+	if !viewer.ReadAllResults {
+		return nil, errGraphQLForbidden
+	}
+	first, offset := page(args)
+	users := []*models.User{}
+	err := s.db.Scopes(inOrganization(viewer.OrganizationID)).
+		Order("id").
+		Offset(offset).
+		Limit(first).
+		Find(&users).Error
+	return []interface{}{users}, err
+}
+
+func (s *GraphQLService) result(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	var result models.TestResult
+	err := s.db.Scopes(visibleResults(viewerFrom(ctx))).First(&result, args["id"].(uint)).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return []interface{}{nil}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{&result}, nil
+}
+
+func (s *GraphQLService) results(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	query := s.db.Scopes(visibleResults(viewerFrom(ctx)), filterResults(args))
+	if userID, ok := args["userId"].(uint); ok {
+		query = query.Where("test_results.user_id = ?", userID)
+	}
+
+	first, offset := page(args)
+	results := []*models.TestResult{}
+	err := query.Order("test_results.created_at DESC, test_results.id DESC").
+		Offset(offset).
+		Limit(first).
+		Find(&results).Error
+	return []interface{}{results}, err
+}
+
+// userResults loads a page of results for every user at once, numbering each
+// user's results so the page applies per user.
+func (s *GraphQLService) userResults(ctx context.Context, sources []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	ids := make([]uint, len(sources))
+	for i, source := range sources {
+		ids[i] = source.(*models.User).ID
+	}
+
+	first, offset := page(args)
+	ranked := s.db.Model(&models.TestResult{}).
+		Select("test_results.*, ROW_NUMBER() OVER (PARTITION BY test_results.user_id ORDER BY test_results.created_at DESC, test_results.id DESC) AS result_rank").
+		Scopes(visibleResults(viewerFrom(ctx)), filterResults(args)).
+		Where("test_results.user_id IN ?", ids)
+	var results []*models.TestResult
+	err := s.db.Table("(?) AS test_results", ranked).
+		Where("result_rank > ? AND result_rank <= ?", offset, offset+first).
+		Order("result_rank").
+		Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[uint][]*models.TestResult, len(ids))
+	for _, result := range results {
+		byUser[result.UserID] = append(byUser[result.UserID], result)
+	}
+	values := make([]interface{}, len(sources))
+	for i, id := range ids {
+		values[i] = append([]*models.TestResult{}, byUser[id]...)
+	}
+	return values, nil
+}
+
+// resultUsers loads the users of results. A viewer without ReadAllResults only
+// reads its own results, so it only ever sees itself.
+func (s *GraphQLService) resultUsers(ctx context.Context, sources []interface{}, _ map[string]interface{}) ([]interface{}, error) {
+	ids := make([]uint, len(sources))
+	for i, source := range sources {
+		ids[i] = source.(*models.TestResult).UserID
+	}
+
+	var users []*models.User
+	err := s.db.Scopes(inOrganization(viewerFrom(ctx).OrganizationID)).Where("id IN ?", ids).Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+	values := make([]interface{}, len(sources))
+	for i, id := range ids {
+		if user, ok := byID[id]; ok {
+			values[i] = user
+		}
+	}
+	return values, nil
+}
+
+// resultTests loads the tests of results, including deleted ones, which their
+// results still refer to.
+func (s *GraphQLService) resultTests(ctx context.Context, sources []interface{}, _ map[string]interface{}) ([]interface{}, error) {
+	ids := make([]uint, len(sources))
+	for i, source := range sources {
+		ids[i] = source.(*models.TestResult).TestID
+	}
+
+	tests, err := s.loadTests(ids)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(sources))
+	for i, id := range ids {
+		if test, ok := tests[id]; ok {
+			values[i] = test
+		}
+	}
+	return values, nil
+}
+
+func (s *GraphQLService) loadTests(ids []uint) (map[uint]*models.Test, error) {
+	var tests []*models.Test
+	if err := s.db.Unscoped().Where("id IN ?", ids).Find(&tests).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.Test, len(tests))
+	for _, test := range tests {
+		byID[test.ID] = test
+	}
+	return byID, nil
+}
+
+// resultAnswers loads the answers of results, in the order they were given, and
+// works out whether each result's correct answers may be shown.
+func (s *GraphQLService) resultAnswers(ctx context.Context, sources []interface{}, _ map[string]interface{}) ([]interface{}, error) {
+	viewer := viewerFrom(ctx)
+	ids := make([]uint, len(sources))
+	testIDs := make([]uint, len(sources))
+	for i, source := range sources {
+		ids[i] = source.(*models.TestResult).ID
+		testIDs[i] = source.(*models.TestResult).TestID
+	}
+
+	var answers []*models.Answer
+	if err := s.db.Where("test_result_id IN ?", ids).Order("id").Find(&answers).Error; err != nil {
+		return nil, err
+	}
+
+	var tests map[uint]*models.Test
+	if !viewer.ReadAllResults {
+		var err error
+		if tests, err = s.loadTests(testIDs); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	reveal := make(map[uint]bool, len(sources))
+	for i, id := range ids {
+		reveal[id] = answersRevealed(viewer, tests[testIDs[i]], now)
+	}
+
+	byResult := make(map[uint][]graphQLAnswer, len(ids))
+	for _, answer := range answers {
+		byResult[answer.TestResultID] = append(byResult[answer.TestResultID], graphQLAnswer{Answer: answer, revealed: reveal[answer.TestResultID]})
+	}
+	values := make([]interface{}, len(sources))
+	for i, id := range ids {
+		values[i] = append([]graphQLAnswer{}, byResult[id]...)
+	}
+	return values, nil
+}
+
+// answersRevealed reports whether the viewer may see the correct answers of a
+// result at the test: always with ReadAllResults, otherwise when the test's answer
+// reveal allows it.
+func answersRevealed(viewer GraphQLViewer, test *models.Test, now time.Time) bool {
+	if viewer.ReadAllResults {
+		return true
+	}
+	return test != nil && test.AnswersRevealed(now)
+}
+
+// answerQuestions loads the questions of answers, including deleted ones.
+func (s *GraphQLService) answerQuestions(ctx context.Context, sources []interface{}, _ map[string]interface{}) ([]interface{}, error) {
+	ids := make([]uint, len(sources))
+	for i, source := range sources {
+		ids[i] = source.(graphQLAnswer).QuestionID
+	}
+
+	var questions []*models.Question
+	if err := s.db.Unscoped().Where("id IN ?", ids).Find(&questions).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.Question, len(questions))
+	for _, question := range questions {
+		byID[question.ID] = question
+	}
+	values := make([]interface{}, len(sources))
+	for i, source := range sources {
+		if question, ok := byID[ids[i]]; ok {
+			values[i] = graphQLQuestion{Question: question, revealed: source.(graphQLAnswer).revealed}
+		}
+	}
+	return values, nil
+}
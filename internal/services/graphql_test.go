@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"iq-go/internal/graphql"
+	"iq-go/internal/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunDB builds queries without running them, and records each one with its
+// values inlined. No database is needed, and every query finds nothing.
+func dryRunDB(t *testing.T) (*gorm.DB, *[]string) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queries []string
+	err = db.Callback().Query().After("gorm:query").Register("test:record", func(tx *gorm.DB) {
+		queries = append(queries, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, &queries
+}
+
+func TestGraphQLVisibility(t *testing.T) {
+	candidate := GraphQLViewer{OrganizationID: 1, UserID: 7}
+	reader := GraphQLViewer{OrganizationID: 1, UserID: 8, ReadAllResults: true}
+	apiKey := GraphQLViewer{OrganizationID: 2, ReadAllResults: true}
+
+	tests := []struct {
+		name    string
+		viewer  GraphQLViewer
+		query   string
+		results []string // in every query on test_results
+		users   []string // in every query on users
+		data    string   // checked when set
+		errors  []string
+	}{
+		{
+			name:    "own results",
+			viewer:  candidate,
+			query:   `{ results { id } }`,
+			results: []string{"test_results.organization_id = 1", "test_results.user_id = 7"},
+		},
+		{
+			name:    "another user's results",
+			viewer:  candidate,
+			query:   `{ results(userId: 9) { id } }`,
+			results: []string{"test_results.organization_id = 1", "test_results.user_id = 7", "test_results.user_id = 9"},
+		},
+		{
+			name:    "a result by id",
+			viewer:  candidate,
+			query:   `{ result(id: 5) { id } }`,
+			results: []string{`"test_results"."id" = 5`, "test_results.organization_id = 1", "test_results.user_id = 7"},
+		},
+		{
+			name:    "nested results",
+			viewer:  candidate,
+			query:   `{ me { results(testId: 3, accommodated: false) { id } } }`,
+			results: []string{"test_results.organization_id = 1", "test_results.user_id = 7", "test_results.test_id = 3", "test_results.accommodation_id IS NULL"},
+			users:   []string{`"users"."id" = 7`, "organization_id = 1"},
+		},
+		{
+			name:    "all results of the organization",
+			viewer:  reader,
+			query:   `{ results(counted: true) { id } }`,
+			results: []string{"test_results.organization_id = 1", "test_results.counted = true"},
+		},
+		{
+			name:    "an API key reads its own organization",
+			viewer:  apiKey,
+			query:   `{ user(id: 9) { email } results { id } }`,
+			results: []string{"test_results.organization_id = 2"},
+			users:   []string{`"users"."id" = 9`, "organization_id = 2"},
+		},
+		{
+			name:   "me for an API key",
+			viewer: apiKey,
+			query:  `{ me { id } }`,
+			data:   `{"me":null}`,
+		},
+		{
+			name:   "another user",
+			viewer: candidate,
+			query:  `{ user(id: 9) { email } }`,
+			data:   `{"user":null}`,
+		},
+		{
+			name:   "the user list",
+			viewer: candidate,
+			query:  `{ users { id } }`,
+			data:   `null`, // users is non-null, so its error nulls the whole response
+			errors: []string{"insufficient permissions"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, queries := dryRunDB(t)
+			resp := NewGraphQLService(db, 10, 100000).Execute(context.Background(), tt.viewer, graphql.Request{Query: tt.query})
+
+			var messages []string
+			for _, err := range resp.Errors {
+				messages = append(messages, err.Message)
+			}
+			if !reflect.DeepEqual(messages, tt.errors) {
+				t.Errorf("errors %q, want %q", messages, tt.errors)
+			}
+			if tt.data != "" {
+				data, _ := json.Marshal(resp.Data)
+				if string(data) != tt.data {
+					t.Errorf("data %s, want %s", data, tt.data)
+				}
+			}
+
+			var sawResults, sawUsers bool
+			for _, query := range *queries {
+				want := tt.users
+				if strings.Contains(query, "test_results") {
+					want, sawResults = tt.results, true
+				} else {
+					sawUsers = true
+				}
+				for _, condition := range want {
+					if !strings.Contains(query, condition) {
+						t.Errorf("query lacks %s: %s", condition, query)
+					}
+				}
+			}
+			if sawResults != (tt.results != nil) || sawUsers != (tt.users != nil) {
+				t.Errorf("queried results %v and users %v, want %v and %v: %q", sawResults, sawUsers, tt.results != nil, tt.users != nil, *queries)
+			}
+		})
+	}
+}
+
+func TestGraphQLAnswersRevealed(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	closed := now.Add(-time.Hour)
+	open := now.Add(time.Hour)
+
+	candidate := GraphQLViewer{OrganizationID: 1, UserID: 7}
+	reader := GraphQLViewer{OrganizationID: 1, UserID: 8, ReadAllResults: true}
+
+	tests := []struct {
+		name   string
+		viewer GraphQLViewer
+		test   *models.Test
+		want   bool
+	}{
+		{name: "revealed immediately", viewer: candidate, test: &models.Test{AnswerReveal: models.RevealImmediately}, want: true},
+		{name: "never revealed", viewer: candidate, test: &models.Test{AnswerReveal: models.RevealNever}},
+		{name: "after close, closed", viewer: candidate, test: &models.Test{AnswerReveal: models.RevealAfterClose, ClosesAt: &closed}, want: true},
+		{name: "after close, still open", viewer: candidate, test: &models.Test{AnswerReveal: models.RevealAfterClose, ClosesAt: &open}},
+		{name: "after close, no closing date", viewer: candidate, test: &models.Test{AnswerReveal: models.RevealAfterClose}},
+		{name: "test not found", viewer: candidate},
+		{name: "reader, never revealed", viewer: reader, test: &models.Test{AnswerReveal: models.RevealNever}, want: true},
+		{name: "reader, test not found", viewer: reader, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := answersRevealed(tt.viewer, tt.test, now); got != tt.want {
+				t.Errorf("answersRevealed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphQLQuestionHidesAnswers(t *testing.T) {
+	s := NewGraphQLService(nil, 10, 1000)
+	question := s.queryType()
+	for _, name := range []string{"result", "answers", "question"} {
+		question = objectType(t, question.Field(name))
+	}
+
+	source := &models.Question{CorrectAnswer: "b", Explanation: "Each term doubles", Reference: "Series, p. 4"}
+	fields := map[string]string{"correctAnswer": "b", "explanation": "Each term doubles", "reference": "Series, p. 4"}
+	for name, value := range fields {
+		resolve := question.Field(name).Resolve
+		got, err := resolve(context.Background(), []interface{}{
+			graphQLQuestion{Question: source},
+			graphQLQuestion{Question: source, revealed: true},
+		}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []interface{}{nil, value}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %#v, want %#v", name, got, want)
+		}
+	}
+}
+
+// objectType returns the object a field's type wraps.
+func objectType(t *testing.T, field *graphql.FieldDefinition) *graphql.Object {
+	t.Helper()
+	if field == nil {
+		t.Fatal("no such field")
+	}
+	typ := field.Type
+	for {
+		switch wrapper := typ.(type) {
+		case graphql.NonNull:
+			typ = wrapper.Of
+		case graphql.List:
+			typ = wrapper.Of
+		case *graphql.Object:
+			return wrapper
+		default:
+			t.Fatalf("field %s is a %s, not an object", field.Name, typ)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"iq-go/internal/models"
+	"iq-go/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvitationInvalid   = errors.New("invitation is invalid")
+	ErrInvitationExpired   = errors.New("invitation has expired")
+	ErrInvitationUsed      = errors.New("invitation has already been used")
+	ErrInvitationEmailUsed = errors.New("email belongs to an account in another organization")
+	ErrInvitationStaff     = errors.New("email belongs to an account with more than the candidate role")
+)
+
+type InvitationService struct {
+	db *gorm.DB
+}
+
+func NewInvitationService(db *gorm.DB) *InvitationService {
+	return &InvitationService{db: db}
+}
+
+type InvitationRecipient struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// IssuedInvitation pairs a new invitation with its plaintext token, which is only
+// available at creation time.
+type IssuedInvitation struct {
+	models.Invitation
+	Token string `json:"token"`
+}
+
+// CreateInvitations issues one invitation per recipient for a test owned by the
+// organization, on behalf of a user or, when apiKeyID is set, an API key.
+func (s *InvitationService) CreateInvitations(orgID, invitedByID, apiKeyID, testID uint, recipients []InvitationRecipient, expiresAt time.Time) ([]IssuedInvitation, error) {
+	var test models.Test
+	if err := s.db.Scopes(inOrganization(orgID)).First(&test, testID).Error; err != nil {
+		return nil, err
+	}
+
+	issued := make([]IssuedInvitation, 0, len(recipients))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, recipient := range recipients {
+			token, err := utils.GenerateSignedToken()
+			if err != nil {
+				return err
+			}
+
+			invitation := models.Invitation{
+				OrganizationID: orgID,
+				TestID:         testID,
+				InvitedByID:    invitedByID,
+				Email:          strings.ToLower(strings.TrimSpace(recipient.Email)),
+				Name:           strings.TrimSpace(recipient.Name),
+				TokenHash:      utils.HashToken(token),
+				Status:         models.InvitationSent,
+				ExpiresAt:      expiresAt,
+			}
+			if apiKeyID != 0 {
+				invitation.InvitedByKeyID = &apiKeyID
+			}
+			if err := tx.Create(&invitation).Error; err != nil {
+				return err
+			}
+
+			invitation.Test = test
+			issued = append(issued, IssuedInvitation{Invitation: invitation, Token: token})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issued, nil
+}
+
+func (s *InvitationService) ListInvitations(orgID, testID uint) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	query := s.db.Scopes(inOrganization(orgID)).Preload("Test").Preload("TestResult")
+	if testID != 0 {
+		query = query.Where("test_id = ?", testID)
+	}
+	err := query.Order("created_at DESC").Find(&invitations).Error
+	return invitations, err
+}
+
+func (s *InvitationService) GetInvitationByID(orgID, invitationID uint) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := s.db.Scopes(inOrganization(orgID)).
+		Preload("Test").
+		Preload("TestResult").
+		First(&invitation, invitationID).Error
+	return &invitation, err
+}
+
+// OpenInvitation resolves a link token and records that the candidate opened it.
+func (s *InvitationService) OpenInvitation(token string) (*models.Invitation, error) {
+	invitation, err := s.findUsable(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.Status == models.InvitationSent {
+		now := time.Now()
+		invitation.Status = models.InvitationOpened
+		invitation.OpenedAt = &now
+		if err := s.db.Save(invitation).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return invitation, nil
+}
+
+// StartInvitation marks the invitation as started and returns the candidate account
+// the test is taken under, creating a password-less one on first use. Links to the
+// email of an account with any other role are refused, since whoever holds the link
+// would otherwise act as that account.
+func (s *InvitationService) StartInvitation(token string) (*models.Invitation, *models.User, error) {
+	invitation, err := s.findUsable(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var user models.User
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("email = ?", invitation.Email).First(&user).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := s.createCandidate(tx, invitation, &user); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case user.OrganizationID != invitation.OrganizationID:
+			return ErrInvitationEmailUsed
+		default:
+			var staffRoles int64
+			err := tx.Table("user_roles").
+				Joins("JOIN roles ON roles.id = user_roles.role_id").
+				Where("user_roles.user_id = ? AND roles.name <> ?", user.ID, models.RoleCandidate).
+				Count(&staffRoles).Error
+			if err != nil {
+				return err
+			}
+			if staffRoles > 0 {
+				return ErrInvitationStaff
+			}
+		}
+
+		now := time.Now()
+		if invitation.StartedAt == nil {
+			invitation.StartedAt = &now
+		}
+		if invitation.OpenedAt == nil {
+			invitation.OpenedAt = &now
+		}
+		invitation.Status = models.InvitationStarted
+		invitation.UserID = &user.ID
+		return tx.Save(invitation).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.db.Preload("Roles.Permissions").First(&user, user.ID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return invitation, &user, nil
+}
+
+// ClaimInvitation reserves a started invitation for a submission. Only one caller
+// can claim it, which is what makes the link single-use.
+func (s *InvitationService) ClaimInvitation(invitationID, userID, testID uint) error {
+	result := s.db.Model(&models.Invitation{}).
+		Where("id = ? AND user_id = ? AND test_id = ? AND status = ?", invitationID, userID, testID, models.InvitationStarted).
+		Update("status", models.InvitationCompleted)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvitationUsed
+	}
+	return nil
+}
+
+// ReleaseInvitation undoes a claim when the submission it was reserved for failed.
+func (s *InvitationService) ReleaseInvitation(invitationID uint) error {
+	return s.db.Model(&models.Invitation{}).
+		Where("id = ? AND status = ? AND test_result_id IS NULL", invitationID, models.InvitationCompleted).
+		Update("status", models.InvitationStarted).Error
+}
+
+func (s *InvitationService) CompleteInvitation(invitationID, testResultID uint) error {
+	return s.db.Model(&models.Invitation{}).
+		Where("id = ?", invitationID).
+		Updates(map[string]interface{}{
+			"status":         models.InvitationCompleted,
+			"completed_at":   time.Now(),
+			"test_result_id": testResultID,
+		}).Error
+}
+
+func (s *InvitationService) GetInvitationTestID(invitationID uint) (uint, error) {
+	var invitation models.Invitation
+	err := s.db.Select("test_id").First(&invitation, invitationID).Error
+	return invitation.TestID, err
+}
+
+func (s *InvitationService) findUsable(token string) (*models.Invitation, error) {
+	if !utils.VerifySignedToken(token) {
+		return nil, ErrInvitationInvalid
+	}
+
+	var invitation models.Invitation
+	err := s.db.Preload("Test").Where("token_hash = ?", utils.HashToken(token)).First(&invitation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvitationInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.Status == models.InvitationCompleted {
+		return nil, ErrInvitationUsed
+	}
+	// A candidate who already started may come back to the link for a while.
+	if invitation.IsExpired() {
+		return nil, ErrInvitationExpired
+	}
+
+	return &invitation, nil
+}
+
+func (s *InvitationService) createCandidate(tx *gorm.DB, invitation *models.Invitation, user *models.User) error {
+	// Invited candidates never sign in with a password.
+	password, err := unusablePassword()
+	if err != nil {
+		return err
+	}
+
+	firstName, lastName, _ := strings.Cut(invitation.Name, " ")
+	if firstName == "" {
+		firstName = invitation.Email
+	}
+
+	var role models.Role
+	if err := tx.Where("name = ?", models.RoleCandidate).First(&role).Error; err != nil {
+		return err
+	}
+
+	*user = models.User{
+		OrganizationID: invitation.OrganizationID,
+		Email:          invitation.Email,
+		Password:       password,
+		FirstName:      firstName,
+		LastName:       lastName,
+		Roles:          []models.Role{role},
+	}
+	return tx.Omit("Roles.*").Create(user).Error
+}
@@ -0,0 +1,380 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"iq-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultLeaderboardSize = 25
+	MaxLeaderboardSize     = 100
+)
+
+var leaderboardPeriods = []models.LeaderboardPeriod{models.PeriodAllTime, models.PeriodWeek, models.PeriodMonth}
+
+type LeaderboardService struct {
+	db *gorm.DB
+}
+
+func NewLeaderboardService(db *gorm.DB) *LeaderboardService {
+	return &LeaderboardService{db: db}
+}
+
+// LeaderboardQuery selects a leaderboard. A test leaderboard ranks one test, overall
+// or in a category; with TestID zero, a category leaderboard ranks each user's best
+// category score across tests, in the organization or, if Global, everywhere.
+type LeaderboardQuery struct {
+	OrganizationID uint
+	TestID         uint
+	Category       models.Category
+	Global         bool
+	Period         models.LeaderboardPeriod
+	Date           time.Time // any time within the period
+	Limit          int
+	ViewerID       uint
+}
+
+type Leaderboard struct {
+	TestID      uint                     `json:"test_id,omitempty"`
+	Category    models.Category          `json:"category,omitempty"`
+	Scope       string                   `json:"scope"`
+	Period      models.LeaderboardPeriod `json:"period"`
+	PeriodStart *time.Time               `json:"period_start,omitempty"`
+	PeriodEnd   *time.Time               `json:"period_end,omitempty"`
+	Entries     []LeaderboardRow         `json:"entries"`
+	You         *LeaderboardRow          `json:"you"` // the viewer's own position, if they opted in and placed
+}
+
+type LeaderboardRow struct {
+	Rank       int       `json:"rank"`
+	Pseudonym  string    `json:"pseudonym"`
+	Score      float64   `json:"score"`
+	TimeTaken  int       `json:"time_taken"`
+	AchievedAt time.Time `json:"achieved_at"`
+	IsYou      bool      `json:"is_you,omitempty"`
+}
+
+type leaderboardRow struct {
+	UserID       uint
+	Pseudonym    string
+	Score        float64
+	TimeTaken    int
+	Accommodated bool
+	AchievedAt   time.Time
+}
+
+func (s *LeaderboardService) GetLeaderboard(query LeaderboardQuery) (*Leaderboard, error) {
+	if query.Limit <= 0 {
+		query.Limit = DefaultLeaderboardSize
+	}
+	if query.TestID != 0 {
+		var test models.Test
+		if err := s.db.Scopes(inOrganization(query.OrganizationID)).Select("id").First(&test, query.TestID).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	periodStart := models.PeriodStart(query.Period, query.Date)
+	board := &Leaderboard{
+		TestID:   query.TestID,
+		Category: query.Category,
+		Scope:    "organization",
+		Period:   query.Period,
+		Entries:  []LeaderboardRow{},
+	}
+	if query.Global {
+		board.Scope = "global"
+	}
+	if query.Period != models.PeriodAllTime {
+		end := periodStart.AddDate(0, 0, 7)
+		if query.Period == models.PeriodMonth {
+			end = periodStart.AddDate(0, 1, 0)
+		}
+		board.PeriodStart = &periodStart
+		board.PeriodEnd = &end
+	}
+
+	var rows []leaderboardRow
+	err := s.db.Table("(?) AS board", s.boardQuery(query, periodStart)).
+		Order("score DESC, accommodated DESC, time_taken, achieved_at, user_id").
+		Limit(query.Limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ranks := rankRows(rows)
+	for i, row := range rows {
+		entry := row.public()
+		entry.Rank = ranks[i]
+		if row.UserID == query.ViewerID {
+			entry.IsYou = true
+			board.You = &entry
+		}
+		board.Entries = append(board.Entries, entry)
+	}
+
+	if board.You == nil && query.ViewerID != 0 {
+		you, err := s.viewerRow(query, periodStart)
+		if err != nil {
+			return nil, err
+		}
+		board.You = you
+	}
+
+	return board, nil
+}
+
+// rankRows gives each row, in board order, one more than the number of rows ahead
+// of it: on score, or on time between unaccommodated rows with the same score.
+// Accommodated rows had extra time, so they tie with every row on their score and
+// are listed first among them.
+func rankRows(rows []leaderboardRow) []int {
+	ranks := make([]int, len(rows))
+	group, timed, sameTime := 0, 0, 0
+	for i, row := range rows {
+		if row.Score != rows[group].Score {
+			group, timed, sameTime = i, 0, 0
+		}
+		ranks[i] = group + 1
+		if row.Accommodated {
+			continue
+		}
+		if timed > 0 && row.TimeTaken == rows[i-1].TimeTaken {
+			sameTime++
+		} else {
+			sameTime = 0
+		}
+		ranks[i] += timed - sameTime
+		timed++
+	}
+	return ranks
+}
+
+// boardQuery selects one row per opted-in user. It is built fresh for every use
+// because it is embedded as a subquery.
+func (s *LeaderboardService) boardQuery(query LeaderboardQuery, periodStart time.Time) *gorm.DB {
+	columns := `leaderboard_entries.user_id, leaderboard_profiles.pseudonym, leaderboard_entries.score,
+		leaderboard_entries.time_taken, leaderboard_entries.accommodated, leaderboard_entries.achieved_at`
+
+	db := s.db.Table("leaderboard_entries").
+		Joins("JOIN leaderboard_profiles ON leaderboard_profiles.user_id = leaderboard_entries.user_id AND leaderboard_profiles.opted_in").
+		Where("leaderboard_entries.category = ? AND leaderboard_entries.period = ? AND leaderboard_entries.period_start = ?",
+			query.Category, query.Period, periodStart)
+
+	if query.TestID != 0 {
+		return db.Select(columns).Where("leaderboard_entries.test_id = ?", query.TestID)
+	}
+
+	if !query.Global {
+		db = db.Where("leaderboard_entries.organization_id = ?", query.OrganizationID)
+	}
+	// Keep each user's best entry across tests.
+	return db.Select("DISTINCT ON (leaderboard_entries.user_id) " + columns).
+		Order("leaderboard_entries.user_id, leaderboard_entries.score DESC, leaderboard_entries.time_taken, leaderboard_entries.achieved_at")
+}
+
+// viewerRow finds the viewer's position when they are outside the requested page.
+func (s *LeaderboardService) viewerRow(query LeaderboardQuery, periodStart time.Time) (*LeaderboardRow, error) {
+	var mine leaderboardRow
+	err := s.db.Table("(?) AS board", s.boardQuery(query, periodStart)).
+		Where("user_id = ?", query.ViewerID).
+		Limit(1).
+		Scan(&mine).Error
+	if err != nil || mine.UserID == 0 {
+		return nil, err
+	}
+
+	ahead := s.db.Where("score > ?", mine.Score)
+	if !mine.Accommodated {
+		ahead = ahead.Or("score = ? AND NOT accommodated AND time_taken < ?", mine.Score, mine.TimeTaken)
+	}
+	var better int64
+	err = s.db.Table("(?) AS board", s.boardQuery(query, periodStart)).
+		Where(ahead).
+		Count(&better).Error
+	if err != nil {
+		return nil, err
+	}
+
+	row := mine.public()
+	row.Rank = int(better) + 1
+	row.IsYou = true
+	return &row, nil
+}
+
+func (r leaderboardRow) public() LeaderboardRow {
+	return LeaderboardRow{
+		Pseudonym:  r.Pseudonym,
+		Score:      r.Score,
+		TimeTaken:  r.TimeTaken,
+		AchievedAt: r.AchievedAt,
+	}
+}
+
+// GetProfile returns the user's leaderboard profile, creating an opted-out one
+// with a fresh pseudonym on first use.
+func (s *LeaderboardService) GetProfile(userID uint) (*models.LeaderboardProfile, error) {
+	var profile models.LeaderboardProfile
+	err := s.db.Where("user_id = ?", userID).First(&profile).Error
+	if err == nil {
+		return &profile, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	pseudonym, err := generatePseudonym()
+	if err != nil {
+		return nil, err
+	}
+	profile = models.LeaderboardProfile{UserID: userID, Pseudonym: pseudonym}
+	err = s.db.Where("user_id = ?", userID).FirstOrCreate(&profile).Error
+	return &profile, err
+}
+
+// UpdateProfile records the user's consent and optionally draws a new pseudonym.
+func (s *LeaderboardService) UpdateProfile(userID uint, optedIn, newPseudonym bool) (*models.LeaderboardProfile, error) {
+	profile, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.OptedIn = optedIn
+	if newPseudonym {
+		if profile.Pseudonym, err = generatePseudonym(); err != nil {
+			return nil, err
+		}
+	}
+	return profile, s.db.Save(profile).Error
+}
+
+// RebuildEntries recomputes the leaderboard entries of an organization, or of all
+// organizations when orgID is zero, from its completed results.
+func (s *LeaderboardService) RebuildEntries(orgID uint) (int, error) {
+	deleteQuery := s.db.Where("1 = 1")
+	resultsQuery := s.db.Where("completed_at IS NOT NULL")
+	if orgID != 0 {
+		deleteQuery = s.db.Scopes(inOrganization(orgID))
+		resultsQuery = resultsQuery.Scopes(inOrganization(orgID))
+	}
+	if err := deleteQuery.Delete(&models.LeaderboardEntry{}).Error; err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	var batch []models.TestResult
+	err := resultsQuery.Preload("Answers.Question").FindInBatches(&batch, 200, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			result := &batch[i]
+			questions := make([]models.Question, len(result.Answers))
+			for j, answer := range result.Answers {
+				questions[j] = answer.Question
+			}
+			if err := recordLeaderboardEntries(s.db, result, questions, result.Answers); err != nil {
+				return err
+			}
+			processed++
+		}
+		return nil
+	}).Error
+	return processed, err
+}
+
+// recordLeaderboardEntries updates the leaderboard entries for a newly scored
+// result, overall and per category, for every period that contains it. An entry is
+// only replaced by a better score, or an equal score in less time when neither
+// result was accommodated.
+func recordLeaderboardEntries(tx *gorm.DB, result *models.TestResult, questions []models.Question, answers []models.Answer) error {
+	categories := make(map[uint]models.Category, len(questions))
+	for _, question := range questions {
+		categories[question.ID] = question.Category
+	}
+	correct := map[models.Category]int{"": result.Score}
+	total := map[models.Category]int{"": result.TotalQuestions}
+	for _, answer := range answers {
+		category, ok := categories[answer.QuestionID]
+		if !ok || category == "" {
+			continue
+		}
+		total[category]++
+		if answer.IsCorrect {
+			correct[category]++
+		}
+	}
+
+	var entries []models.LeaderboardEntry
+	for _, period := range leaderboardPeriods {
+		for category, count := range total {
+			if count == 0 {
+				continue
+			}
+			entries = append(entries, models.LeaderboardEntry{
+				OrganizationID: result.OrganizationID,
+				UserID:         result.UserID,
+				TestID:         result.TestID,
+				Category:       category,
+				Period:         period,
+				PeriodStart:    models.PeriodStart(period, *result.CompletedAt),
+				Score:          float64(correct[category]) / float64(count) * 100,
+				TimeTaken:      result.TimeTaken,
+				Accommodated:   result.TimeMultiplier > 1,
+				TestResultID:   result.ID,
+				AchievedAt:     *result.CompletedAt,
+			})
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "test_id"}, {Name: "category"}, {Name: "period"}, {Name: "period_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score", "time_taken", "accommodated", "test_result_id", "achieved_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{clause.Expr{
+			SQL: `excluded.score > leaderboard_entries.score OR (excluded.score = leaderboard_entries.score
+				AND NOT excluded.accommodated AND NOT leaderboard_entries.accommodated AND excluded.time_taken < leaderboard_entries.time_taken)`,
+		}}},
+	}).Create(&entries).Error
+}
+
+var (
+	pseudonymAdjectives = []string{
+		"Agile", "Bold", "Bright", "Calm", "Clever", "Curious", "Eager", "Keen",
+		"Lively", "Lucid", "Nimble", "Quick", "Sharp", "Steady", "Swift", "Witty",
+	}
+	pseudonymAnimals = []string{
+		"Badger", "Crane", "Dolphin", "Falcon", "Fox", "Heron", "Lynx", "Marten",
+		"Octopus", "Otter", "Owl", "Panda", "Raven", "Seal", "Tiger", "Wolf",
+	}
+)
+
+// generatePseudonym draws a name like "Swift Otter 42" that reveals nothing about the user.
+func generatePseudonym() (string, error) {
+	pick := func(n int) (int, error) {
+		value, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+		return int(value.Int64()), err
+	}
+
+	adjective, err := pick(len(pseudonymAdjectives))
+	if err != nil {
+		return "", err
+	}
+	animal, err := pick(len(pseudonymAnimals))
+	if err != nil {
+		return "", err
+	}
+	number, err := pick(90)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %s %d", pseudonymAdjectives[adjective], pseudonymAnimals[animal], number+10), nil
+}
@@ -0,0 +1,881 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"iq-go/internal/cache"
+	"iq-go/internal/lti"
+	"iq-go/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrLTIPlatformNotFound = errors.New("LTI platform not found")
+	ErrLTIPlatformExists   = errors.New("an LTI platform with this issuer and client ID is already registered")
+	ErrLTILoginInvalid     = errors.New("LTI login is invalid or has expired")
+	ErrLTILaunchInvalid    = errors.New("invalid LTI launch")
+	ErrLTINoTest           = errors.New("no test is linked to this LTI resource link")
+	ErrLTIDeepLinkDenied   = errors.New("only users who manage tests can add tests to a course")
+	ErrLTIEmailUsed        = errors.New("email belongs to an account in another organization")
+	ErrLTIAccountExists    = errors.New("email belongs to an existing account and the platform does not link by email")
+	ErrLTIStaffRole        = errors.New("staff roles must name existing roles that act within one organization")
+)
+
+const (
+	ltiLoginTTL         = 10 * time.Minute
+	ltiDeepLinkTTL      = time.Hour // how long a deep linking session can take
+	ltiKeySetTTL        = time.Hour
+	ltiTimeout          = 10 * time.Second
+	ltiScoreMaxAttempts = 8
+	ltiScoreBatchSize   = 20
+	ltiScoreLease       = 2 * time.Minute
+
+	// LTITestParameter is the custom parameter deep linking stores the test ID in.
+	LTITestParameter = "test_id"
+)
+
+type LTIService struct {
+	db      *gorm.DB
+	tool    *lti.Tool
+	client  *http.Client
+	keySets *cache.TTL[string, *lti.KeySet]
+	tokens  *cache.TTL[uint, ltiAccessToken]
+}
+
+type ltiAccessToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// NewLTIService returns the service. Platforms are only reached at public addresses
+// unless allowPrivateHosts is set, which is meant for trying the tool locally.
+func NewLTIService(db *gorm.DB, tool *lti.Tool, allowPrivateHosts bool) *LTIService {
+	client := publicClient(ltiTimeout)
+	if allowPrivateHosts {
+		client.Transport = nil
+	}
+	return &LTIService{
+		db:      db,
+		tool:    tool,
+		client:  client,
+		keySets: cache.NewTTL[string, *lti.KeySet](ltiKeySetTTL, 100),
+		tokens:  cache.NewTTL[uint, ltiAccessToken](ltiKeySetTTL, 100),
+	}
+}
+
+type LTIPlatformRequest struct {
+	Name           string   `json:"name" binding:"required"`
+	Issuer         string   `json:"issuer" binding:"required,url"`
+	ClientID       string   `json:"client_id" binding:"required"`
+	DeploymentIDs  []string `json:"deployment_ids" binding:"required,min=1"`
+	AuthLoginURL   string   `json:"auth_login_url" binding:"required,url"`
+	AccessTokenURL string   `json:"access_token_url" binding:"required,url"`
+	JWKSURL        string   `json:"jwks_url" binding:"required,url"`
+	Active         *bool    `json:"active"`
+	LinkByEmail    bool     `json:"link_by_email"`
+	// StaffRoles maps course roles such as Instructor to roles a launch with them
+	// signs in with. Without a mapping every launch signs in as a candidate.
+	StaffRoles map[string][]string `json:"staff_roles"`
+}
+
+// LTIToolConfiguration is what a platform administrator enters when registering
+// the tool.
+type LTIToolConfiguration struct {
+	LoginURL       string   `json:"login_url"`
+	LaunchURL      string   `json:"launch_url"`
+	DeepLinkingURL string   `json:"deep_linking_url"`
+	RedirectURIs   []string `json:"redirect_uris"`
+	JWKSURL        string   `json:"jwks_url"`
+}
+
+// LTILoginRequest is the third-party initiated login a platform starts a launch with.
+type LTILoginRequest struct {
+	Issuer         string `form:"iss" binding:"required"`
+	LoginHint      string `form:"login_hint" binding:"required"`
+	TargetLinkURI  string `form:"target_link_uri"`
+	LTIMessageHint string `form:"lti_message_hint"`
+	ClientID       string `form:"client_id"`
+	DeploymentID   string `form:"lti_deployment_id"`
+}
+
+// LTILaunchResult is a verified launch. User carries, besides its own roles, the
+// platform's staff roles for the launch, which are not saved. For deep linking
+// requests Token carries the deep linking session to the test picker.
+type LTILaunchResult struct {
+	User        *models.User
+	MessageType string
+	TestID      uint
+	Token       string
+}
+
+// LTIDeepLinkClaims carry a deep linking request from the launch to the picker and
+// the response, signed by the tool.
+type LTIDeepLinkClaims struct {
+	jwt.RegisteredClaims
+	PlatformID   uint   `json:"platform_id"`
+	UserID       uint   `json:"user_id"`
+	DeploymentID string `json:"deployment_id"`
+	ReturnURL    string `json:"return_url"`
+	Data         string `json:"data,omitempty"`
+}
+
+func (s *LTIService) ToolConfiguration() LTIToolConfiguration {
+	return LTIToolConfiguration{
+		LoginURL:       s.tool.LoginURL(),
+		LaunchURL:      s.tool.LaunchURL(),
+		DeepLinkingURL: s.tool.LaunchURL(),
+		RedirectURIs:   []string{s.tool.LaunchURL()},
+		JWKSURL:        s.tool.JWKSURL(),
+	}
+}
+
+func (s *LTIService) KeySet() lti.KeySet {
+	return s.tool.KeySet()
+}
+
+func (s *LTIService) ListPlatforms(orgID uint) ([]models.LTIPlatform, error) {
+	var platforms []models.LTIPlatform
+	err := s.db.Scopes(inOrganization(orgID)).Order("id").Find(&platforms).Error
+	return platforms, err
+}
+
+func (s *LTIService) CreatePlatform(orgID uint, req LTIPlatformRequest) (*models.LTIPlatform, error) {
+	platform := &models.LTIPlatform{OrganizationID: orgID, Active: true}
+	applyLTIPlatformRequest(platform, req)
+	if err := s.checkStaffRoles(platform.StaffRoles); err != nil {
+		return nil, err
+	}
+	if err := s.checkUnique(platform); err != nil {
+		return nil, err
+	}
+	return platform, s.db.Create(platform).Error
+}
+
+func (s *LTIService) UpdatePlatform(orgID, platformID uint, req LTIPlatformRequest) (*models.LTIPlatform, error) {
+	platform, err := s.findPlatform(orgID, platformID)
+	if err != nil {
+		return nil, err
+	}
+	applyLTIPlatformRequest(platform, req)
+	if err := s.checkStaffRoles(platform.StaffRoles); err != nil {
+		return nil, err
+	}
+	if err := s.checkUnique(platform); err != nil {
+		return nil, err
+	}
+	return platform, s.db.Save(platform).Error
+}
+
+// DeletePlatform removes a platform. Its users keep their accounts and results, but
+// launches from it stop working and its queued scores are abandoned.
+func (s *LTIService) DeletePlatform(orgID, platformID uint) error {
+	platform, err := s.findPlatform(orgID, platformID)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(platform).Error
+}
+
+// StartLogin records the state and nonce of a login and returns the platform's
+// authorization URL the browser is sent to, and the state, which the caller binds
+// to the browser.
+func (s *LTIService) StartLogin(req LTILoginRequest) (string, string, error) {
+	query := s.db.Where("issuer = ? AND active", req.Issuer)
+	if req.ClientID != "" {
+		query = query.Where("client_id = ?", req.ClientID)
+	}
+	var platforms []models.LTIPlatform
+	if err := query.Find(&platforms).Error; err != nil {
+		return "", "", err
+	}
+	// An issuer registered with several client IDs must say which one it means.
+	if len(platforms) != 1 {
+		return "", "", ErrLTIPlatformNotFound
+	}
+	platform := platforms[0]
+	if req.DeploymentID != "" && !platform.HasDeployment(req.DeploymentID) {
+		return "", "", fmt.Errorf("%w: unknown deployment %q", ErrLTILaunchInvalid, req.DeploymentID)
+	}
+
+	now := time.Now()
+	login := models.LTILogin{
+		PlatformID: platform.ID,
+		State:      lti.NewJTI(),
+		Nonce:      lti.NewJTI(),
+		ExpiresAt:  now.Add(ltiLoginTTL),
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", now).Delete(&models.LTILogin{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&login).Error
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := url.Parse(platform.AuthLoginURL)
+	if err != nil {
+		return "", "", err
+	}
+	params := authURL.Query()
+	params.Set("scope", "openid")
+	params.Set("response_type", "id_token")
+	params.Set("response_mode", "form_post")
+	params.Set("prompt", "none")
+	params.Set("client_id", platform.ClientID)
+	params.Set("redirect_uri", s.tool.LaunchURL())
+	params.Set("login_hint", req.LoginHint)
+	if req.LTIMessageHint != "" {
+		params.Set("lti_message_hint", req.LTIMessageHint)
+	}
+	params.Set("state", login.State)
+	params.Set("nonce", login.Nonce)
+	authURL.RawQuery = params.Encode()
+	return authURL.String(), login.State, nil
+}
+
+// Launch verifies the id_token a platform posted for a login and signs in its user,
+// creating or linking an account in the platform's organization.
+func (s *LTIService) Launch(ctx context.Context, state, idToken string) (*LTILaunchResult, error) {
+	var login models.LTILogin
+	result := s.db.Clauses(clause.Returning{}).Where("state = ?", state).Delete(&login)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(login.ExpiresAt) {
+		return nil, ErrLTILoginInvalid
+	}
+
+	var platform models.LTIPlatform
+	err := s.db.Where("active").First(&platform, login.PlatformID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLTIPlatformNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var claims lti.LaunchClaims
+	_, err = jwt.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.platformKey(ctx, &platform, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(platform.Issuer),
+		jwt.WithAudience(platform.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLTILaunchInvalid, err)
+	}
+	if claims.Nonce != login.Nonce {
+		return nil, fmt.Errorf("%w: nonce does not match the login", ErrLTILaunchInvalid)
+	}
+	if err := claims.Validate(platform.ClientID); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLTILaunchInvalid, err)
+	}
+	if !platform.HasDeployment(claims.DeploymentID) {
+		return nil, fmt.Errorf("%w: unknown deployment %q", ErrLTILaunchInvalid, claims.DeploymentID)
+	}
+
+	launch := &LTILaunchResult{MessageType: claims.MessageType}
+	var user models.User
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.resolveUser(tx, &platform, &claims, &user); err != nil {
+			return err
+		}
+		if err := grantCandidateRole(tx, &user); err != nil {
+			return err
+		}
+		if claims.MessageType == lti.MessageResourceLink {
+			testID, err := s.recordResourceLink(tx, &platform, &claims, user.ID)
+			launch.TestID = testID
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Preload("Roles.Permissions").First(&user, user.ID).Error; err != nil {
+		return nil, err
+	}
+	staffRoles, err := s.staffRoles(&platform, claims.Roles)
+	if err != nil {
+		return nil, err
+	}
+	user.Roles = append(user.Roles, staffRoles...)
+	launch.User = &user
+
+	if claims.MessageType == lti.MessageDeepLinking {
+		canManage := false
+		for _, permission := range user.PermissionNames() {
+			canManage = canManage || permission == models.PermManageTests
+		}
+		if !canManage {
+			return nil, ErrLTIDeepLinkDenied
+		}
+		if !claims.DeepLinkingSettings.Accepts(lti.ContentItemLink) {
+			return nil, fmt.Errorf("%w: the platform does not accept resource links", ErrLTILaunchInvalid)
+		}
+		now := time.Now()
+		launch.Token, err = s.tool.Sign(LTIDeepLinkClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    s.tool.BaseURL,
+				Audience:  jwt.ClaimStrings{s.tool.DeepLinkURL()},
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(ltiDeepLinkTTL)),
+			},
+			PlatformID:   platform.ID,
+			UserID:       user.ID,
+			DeploymentID: claims.DeploymentID,
+			ReturnURL:    claims.DeepLinkingSettings.ReturnURL,
+			Data:         claims.DeepLinkingSettings.Data,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return launch, nil
+}
+
+// DeepLinkTests returns the tests a deep linking session can pick from.
+func (s *LTIService) DeepLinkTests(token string) ([]models.Test, error) {
+	_, platform, err := s.deepLinkSession(token)
+	if err != nil {
+		return nil, err
+	}
+	var tests []models.Test
+	err = s.db.Scopes(inOrganization(platform.OrganizationID)).Order("name").Find(&tests).Error
+	return tests, err
+}
+
+// DeepLinkResponse builds the signed message that places the test in the
+// platform's course, and returns it with the URL it is posted to.
+func (s *LTIService) DeepLinkResponse(token string, testID uint) (string, string, error) {
+	session, platform, err := s.deepLinkSession(token)
+	if err != nil {
+		return "", "", err
+	}
+	var test models.Test
+	if err := s.db.Scopes(inOrganization(platform.OrganizationID)).First(&test, testID).Error; err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	response, err := s.tool.Sign(lti.DeepLinkingResponse{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    platform.ClientID,
+			Audience:  jwt.ClaimStrings{platform.Issuer},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+			ID:        lti.NewJTI(),
+		},
+		Nonce:        lti.NewJTI(),
+		MessageType:  lti.MessageDeepLinkingResponse,
+		Version:      lti.Version,
+		DeploymentID: session.DeploymentID,
+		Data:         session.Data,
+		ContentItems: []lti.ContentItem{{
+			Type:   lti.ContentItemLink,
+			Title:  test.Name,
+			Text:   test.Description,
+			URL:    s.tool.LaunchURL(),
+			Custom: map[string]string{LTITestParameter: strconv.FormatUint(uint64(test.ID), 10)},
+			LineItem: &lti.LineItem{
+				Label:        test.Name,
+				ScoreMaximum: 100,
+				ResourceID:   "test-" + strconv.FormatUint(uint64(test.ID), 10),
+			},
+		}},
+	})
+	return session.ReturnURL, response, err
+}
+
+// RunScoreDispatcher sends due scores every interval until the context is
+// cancelled. Like the webhook dispatcher, several may run at once.
+func (s *LTIService) RunScoreDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			sent, err := s.DeliverDueScores(ctx)
+			if err != nil {
+				log.Printf("LTI score dispatch failed: %v", err)
+			}
+			if err != nil || sent < ltiScoreBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDueScores claims a batch of due scores, posts them and returns how many
+// were attempted.
+func (s *LTIService) DeliverDueScores(ctx context.Context) (int, error) {
+	now := time.Now()
+	var due []models.LTIScore
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(ltiScoreBatchSize).
+			Find(&due).Error
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(due))
+		for i, score := range due {
+			ids[i] = score.ID
+		}
+		return tx.Model(&models.LTIScore{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(ltiScoreLease)).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range due {
+		if err := s.deliverScore(ctx, &due[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(due), nil
+}
+
+func (s *LTIService) deliverScore(ctx context.Context, score *models.LTIScore) error {
+	var link models.LTIResourceLink
+	if err := s.db.First(&link, score.ResourceLinkID).Error; err != nil {
+		return err
+	}
+	var platform models.LTIPlatform
+	err := s.db.First(&platform, link.PlatformID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !platform.Active) {
+		return s.db.Model(score).Updates(map[string]interface{}{
+			"status":     models.DeliveryFailed,
+			"last_error": "platform deleted or disabled",
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	statusCode, sendErr := s.postScore(ctx, &platform, &link, score)
+	if sendErr == nil && (statusCode < 200 || statusCode > 299) {
+		sendErr = fmt.Errorf("unexpected status %d", statusCode)
+	}
+
+	attempts := score.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":         attempts,
+		"last_status_code": statusCode,
+		"last_error":       "",
+	}
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.DeliveryDelivered
+		updates["sent_at"] = time.Now()
+	case attempts >= ltiScoreMaxAttempts:
+		updates["status"] = models.DeliveryFailed
+		updates["last_error"] = sendErr.Error()
+	default:
+		updates["last_error"] = sendErr.Error()
+		updates["next_attempt_at"] = time.Now().Add(webhookBackoff(attempts))
+	}
+	return s.db.Model(score).Updates(updates).Error
+}
+
+func (s *LTIService) postScore(ctx context.Context, platform *models.LTIPlatform, link *models.LTIResourceLink, score *models.LTIScore) (int, error) {
+	token, err := s.accessToken(ctx, platform)
+	if err != nil {
+		return 0, err
+	}
+	scoresURL, err := lti.ScoresURL(link.LineItemURL)
+	if err != nil {
+		return 0, err
+	}
+	body, err := json.Marshal(lti.Score{
+		UserID:           score.Subject,
+		ScoreGiven:       float64(score.ScoreGiven),
+		ScoreMaximum:     float64(score.ScoreMaximum),
+		ActivityProgress: "Completed",
+		GradingProgress:  "FullyGraded",
+		Timestamp:        score.Timestamp.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, scoresURL, strings.NewReader(string(body)))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", lti.ScoreMediaType)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The token may have been revoked early; fetch a new one next time.
+		s.tokens.Set(platform.ID, ltiAccessToken{})
+	}
+	return resp.StatusCode, nil
+}
+
+// accessToken returns a token for the score service, requesting one with the
+// client credentials grant when the cached one is missing or about to expire.
+func (s *LTIService) accessToken(ctx context.Context, platform *models.LTIPlatform) (string, error) {
+	if cached, ok := s.tokens.Get(platform.ID); ok && time.Now().Before(cached.expiresAt) {
+		return cached.token, nil
+	}
+
+	assertion, err := s.tool.ClientAssertion(platform.ClientID, platform.AccessTokenURL)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_assertion_type": {lti.ClientAssertionType},
+		"client_assertion":      {assertion},
+		"scope":                 {lti.ScopeScore},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, platform.AccessTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request: status %d", resp.StatusCode)
+	}
+
+	var token lti.TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("token request: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("token request: no access token in response")
+	}
+	lifetime := time.Duration(token.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = time.Hour
+	}
+	// Renew a little early so a token does not expire on its way to the platform.
+	s.tokens.Set(platform.ID, ltiAccessToken{token: token.AccessToken, expiresAt: time.Now().Add(lifetime * 9 / 10)})
+	return token.AccessToken, nil
+}
+
+// platformKey returns the platform's signing key with the ID, downloading its key
+// set again when the key is unknown, as platforms rotate keys.
+func (s *LTIService) platformKey(ctx context.Context, platform *models.LTIPlatform, kid string) (interface{}, error) {
+	if set, ok := s.keySets.Get(platform.JWKSURL); ok {
+		if key, err := set.Key(kid); err == nil {
+			return key, nil
+		}
+	}
+	set, err := lti.FetchKeySet(ctx, s.client, platform.JWKSURL)
+	if err != nil {
+		return nil, err
+	}
+	s.keySets.Set(platform.JWKSURL, set)
+	return set.Key(kid)
+}
+
+// resolveUser finds the user linked to the launch's subject. On the first launch
+// the subject is linked to a new password-less account, or, when the platform is
+// trusted to link by email, to the account with the launch's email in the
+// platform's organization.
+func (s *LTIService) resolveUser(tx *gorm.DB, platform *models.LTIPlatform, claims *lti.LaunchClaims, user *models.User) error {
+	var identity models.LTIIdentity
+	err := tx.Where("platform_id = ? AND subject = ?", platform.ID, claims.Subject).First(&identity).Error
+	if err == nil {
+		return tx.First(user, identity.UserID).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" {
+		// Platforms may withhold personal data; the account is only reachable
+		// through the platform then.
+		sum := sha256.Sum256([]byte(claims.Subject))
+		email = fmt.Sprintf("lti-%d-%s@lti.invalid", platform.ID, hex.EncodeToString(sum[:8]))
+	}
+
+	err = tx.Where("email = ?", email).First(user).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if err := createLTIUser(tx, platform.OrganizationID, email, claims, user); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case user.OrganizationID != platform.OrganizationID:
+		return ErrLTIEmailUsed
+	case !platform.LinkByEmail:
+		return ErrLTIAccountExists
+	}
+
+	return tx.Create(&models.LTIIdentity{PlatformID: platform.ID, Subject: claims.Subject, UserID: user.ID}).Error
+}
+
+func createLTIUser(tx *gorm.DB, orgID uint, email string, claims *lti.LaunchClaims, user *models.User) error {
+	password, err := unusablePassword()
+	if err != nil {
+		return err
+	}
+
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(claims.Name, " ")
+	}
+	if firstName == "" {
+		firstName = email
+	}
+
+	*user = models.User{
+		OrganizationID: orgID,
+		Email:          email,
+		Password:       password,
+		FirstName:      firstName,
+		LastName:       lastName,
+	}
+	return tx.Create(user).Error
+}
+
+// grantCandidateRole gives the user the candidate role if they do not have it yet.
+// A launch never saves any other role on the user.
+func grantCandidateRole(tx *gorm.DB, user *models.User) error {
+	var missing []models.Role
+	err := tx.Where("name = ?", models.RoleCandidate).
+		Where("id NOT IN (?)", tx.Table("user_roles").Select("role_id").Where("user_id = ?", user.ID)).
+		Find(&missing).Error
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+	return tx.Model(user).Omit("Roles.*").Association("Roles").Append(missing)
+}
+
+// staffRoles returns, with their permissions, the roles the platform maps the
+// launch's course roles to. Institution and system roles map to nothing.
+func (s *LTIService) staffRoles(platform *models.LTIPlatform, ltiRoles []string) ([]models.Role, error) {
+	var names []string
+	for _, ltiRole := range ltiRoles {
+		if name, ok := lti.ContextRole(ltiRole); ok {
+			names = append(names, platform.StaffRoles[name]...)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	var roles []models.Role
+	err := s.db.Preload("Permissions").
+		Where("name IN ?", names).
+		Where("id NOT IN (?)", globalRoles(s.db)).
+		Find(&roles).Error
+	return roles, err
+}
+
+// checkStaffRoles makes sure every mapped role exists and grants no permission
+// that acts across organizations.
+func (s *LTIService) checkStaffRoles(staffRoles map[string][]string) error {
+	var names []string
+	for _, roles := range staffRoles {
+		names = append(names, roles...)
+	}
+	names = uniqueStrings(names)
+	if len(names) == 0 {
+		return nil
+	}
+
+	var count int64
+	err := s.db.Model(&models.Role{}).
+		Where("name IN ?", names).
+		Where("id NOT IN (?)", globalRoles(s.db)).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if int(count) != len(names) {
+		return ErrLTIStaffRole
+	}
+	return nil
+}
+
+// recordResourceLink stores the resource link and the user's launch of it, and
+// returns the linked test.
+func (s *LTIService) recordResourceLink(tx *gorm.DB, platform *models.LTIPlatform, claims *lti.LaunchClaims, userID uint) (uint, error) {
+	var link models.LTIResourceLink
+	err := tx.Where("platform_id = ? AND link_id = ?", platform.ID, claims.ResourceLink.ID).First(&link).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	link.OrganizationID = platform.OrganizationID
+	link.PlatformID = platform.ID
+	link.LinkID = claims.ResourceLink.ID
+	link.DeploymentID = claims.DeploymentID
+	link.Title = claims.ResourceLink.Title
+	if claims.Context != nil {
+		link.ContextID = claims.Context.ID
+		link.ContextTitle = claims.Context.Title
+	}
+	if raw := claims.CustomString(LTITestParameter); raw != "" {
+		testID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid %s %q", ErrLTILaunchInvalid, LTITestParameter, raw)
+		}
+		var test models.Test
+		if err := tx.Scopes(inOrganization(platform.OrganizationID)).First(&test, testID).Error; err != nil {
+			return 0, ErrLTINoTest
+		}
+		link.TestID = test.ID
+	}
+	link.LineItemURL = ""
+	if claims.Endpoint != nil && claims.Endpoint.Allows(lti.ScopeScore) {
+		link.LineItemURL = claims.Endpoint.LineItem
+	}
+	if err := tx.Save(&link).Error; err != nil {
+		return 0, err
+	}
+	if link.TestID == 0 {
+		return 0, ErrLTINoTest
+	}
+
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "resource_link_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"subject", "launched_at"}),
+	}).Create(&models.LTILaunch{
+		ResourceLinkID: link.ID,
+		UserID:         userID,
+		Subject:        claims.Subject,
+		LaunchedAt:     time.Now(),
+	}).Error
+	return link.TestID, err
+}
+
+func (s *LTIService) deepLinkSession(token string) (*LTIDeepLinkClaims, *models.LTIPlatform, error) {
+	var session LTIDeepLinkClaims
+	if err := s.tool.Verify(token, &session, s.tool.DeepLinkURL()); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrLTILaunchInvalid, err)
+	}
+	var platform models.LTIPlatform
+	err := s.db.Where("active").First(&platform, session.PlatformID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrLTIPlatformNotFound
+	}
+	return &session, &platform, err
+}
+
+func (s *LTIService) findPlatform(orgID, platformID uint) (*models.LTIPlatform, error) {
+	var platform models.LTIPlatform
+	err := s.db.Scopes(inOrganization(orgID)).First(&platform, platformID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLTIPlatformNotFound
+	}
+	return &platform, err
+}
+
+func (s *LTIService) checkUnique(platform *models.LTIPlatform) error {
+	var count int64
+	err := s.db.Model(&models.LTIPlatform{}).
+		Where("issuer = ? AND client_id = ? AND id <> ?", platform.Issuer, platform.ClientID, platform.ID).
+		Count(&count).Error
+	if err == nil && count > 0 {
+		return ErrLTIPlatformExists
+	}
+	return err
+}
+
+func applyLTIPlatformRequest(platform *models.LTIPlatform, req LTIPlatformRequest) {
+	platform.Name = req.Name
+	platform.Issuer = strings.TrimSuffix(req.Issuer, "/")
+	platform.ClientID = req.ClientID
+	platform.DeploymentIDs = req.DeploymentIDs
+	platform.AuthLoginURL = req.AuthLoginURL
+	platform.AccessTokenURL = req.AccessTokenURL
+	platform.JWKSURL = req.JWKSURL
+	if req.Active != nil {
+		platform.Active = *req.Active
+	}
+	platform.LinkByEmail = req.LinkByEmail
+	platform.StaffRoles = req.StaffRoles
+}
+
+// enqueueLTIScores queues the user's counted result for the gradebooks of the
+// platforms the user launched the test from, inside the submission's transaction.
+func enqueueLTIScores(tx *gorm.DB, test *models.Test, userID uint) error {
+	var launches []struct {
+		ResourceLinkID uint
+		OrganizationID uint
+		Subject        string
+	}
+	err := tx.Table("lti_launches").
+		Select("lti_launches.resource_link_id, lti_resource_links.organization_id, lti_launches.subject").
+		Joins("JOIN lti_resource_links ON lti_resource_links.id = lti_launches.resource_link_id").
+		Where("lti_resource_links.test_id = ? AND lti_resource_links.line_item_url <> '' AND lti_launches.user_id = ?", test.ID, userID).
+		Scan(&launches).Error
+	if err != nil || len(launches) == 0 {
+		return err
+	}
+
+	var counted models.TestResult
+	err = tx.Where("test_id = ? AND user_id = ? AND counted", test.ID, userID).Limit(1).Find(&counted).Error
+	if err != nil || counted.ID == 0 {
+		return err
+	}
+
+	now := time.Now()
+	scores := make([]models.LTIScore, len(launches))
+	for i, launch := range launches {
+		scores[i] = models.LTIScore{
+			OrganizationID: launch.OrganizationID,
+			ResourceLinkID: launch.ResourceLinkID,
+			TestResultID:   counted.ID,
+			Subject:        launch.Subject,
+			ScoreGiven:     counted.Score,
+			ScoreMaximum:   counted.TotalQuestions,
+			Timestamp:      now,
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  now,
+		}
+	}
+	return tx.Create(&scores).Error
+}
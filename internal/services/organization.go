@@ -0,0 +1,46 @@
+package services
+
+import (
+	"iq-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type OrganizationService struct {
+	db *gorm.DB
+}
+
+func NewOrganizationService(db *gorm.DB) *OrganizationService {
+	return &OrganizationService{db: db}
+}
+
+func (s *OrganizationService) CreateOrganization(organization *models.Organization) error {
+	return s.db.Create(organization).Error
+}
+
+func (s *OrganizationService) ListOrganizations() ([]models.Organization, error) {
+	var organizations []models.Organization
+	err := s.db.Order("name").Find(&organizations).Error
+	return organizations, err
+}
+
+func (s *OrganizationService) GetOrganizationBySlug(slug string) (*models.Organization, error) {
+	var organization models.Organization
+	err := s.db.Where("slug = ?", slug).First(&organization).Error
+	return &organization, err
+}
+
+// inOrganization restricts a single-table query to rows owned by the organization.
+func inOrganization(orgID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("organization_id = ?", orgID)
+	}
+}
+
+// testInOrganization restricts a query on a table with a test_id column to tests owned by the organization.
+func testInOrganization(orgID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("test_id IN (?)", db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.Test{}).Select("id").Where("organization_id = ?", orgID))
+	}
+}
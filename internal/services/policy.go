@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"iq-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTestNotOpen         = errors.New("test is not open yet")
+	ErrTestClosed          = errors.New("test is closed")
+	ErrAttemptLimitReached = errors.New("attempt limit reached")
+	ErrAttemptCooldown     = errors.New("attempt cooldown in effect")
+)
+
+// PolicyViolation explains why a test's attempt policy blocks a new attempt.
+// RetryAt is set when waiting will lift the block.
+type PolicyViolation struct {
+	Reason  error
+	Message string
+	RetryAt *time.Time
+}
+
+func (e *PolicyViolation) Error() string {
+	return e.Message
+}
+
+func (e *PolicyViolation) Unwrap() error {
+	return e.Reason
+}
+
+// AttemptEligibility summarises a user's standing against a test's attempt policy.
+type AttemptEligibility struct {
+	TestID        uint       `json:"test_id"`
+	AttemptsUsed  int64      `json:"attempts_used"`
+	MaxAttempts   int        `json:"max_attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	Allowed       bool       `json:"allowed"`
+	Reason        string     `json:"reason,omitempty"`
+}
+
+func (s *TestService) UpdatePolicy(orgID, testID uint, policy *models.Test) (*models.Test, error) {
+	test, err := s.GetTestByID(orgID, testID)
+	if err != nil {
+		return nil, err
+	}
+
+	test.MaxAttempts = policy.MaxAttempts
+	test.CooldownMinutes = policy.CooldownMinutes
+	test.OpensAt = policy.OpensAt
+	test.ClosesAt = policy.ClosesAt
+	test.ScoringPolicy = policy.ScoringPolicy
+	test.PracticeEnabled = policy.PracticeEnabled
+	if policy.AnswerReveal != "" {
+		test.AnswerReveal = policy.AnswerReveal
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(test).Error; err != nil {
+			return err
+		}
+		// A new scoring policy changes which attempt counts for everyone who took the test.
+		var userIDs []uint
+		if err := tx.Model(&models.TestResult{}).Where("test_id = ?", test.ID).Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+			return err
+		}
+		for _, userID := range userIDs {
+			if err := markCountedAttempt(tx, test, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return test, err
+}
+
+func (s *TestService) GetEligibility(orgID, userID, testID uint) (*AttemptEligibility, error) {
+	test, err := s.GetTestByID(orgID, testID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := countAttempts(s.db, test.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	eligibility := &AttemptEligibility{
+		TestID:       test.ID,
+		AttemptsUsed: attempts,
+		MaxAttempts:  test.MaxAttempts,
+		Allowed:      true,
+	}
+
+	if err := checkAttemptPolicy(s.db, test, userID, time.Now()); err != nil {
+		var violation *PolicyViolation
+		if !errors.As(err, &violation) {
+			return nil, err
+		}
+		eligibility.Allowed = false
+		eligibility.Reason = violation.Message
+		eligibility.NextAttemptAt = violation.RetryAt
+	}
+
+	return eligibility, nil
+}
+
+// checkAttemptPolicy returns a *PolicyViolation when the test's availability window,
+// attempt limit or cooldown rules out a new attempt by the user at the given time.
+// Callers about to start the attempt hold lockAttempts, so concurrent starts cannot
+// both pass the limit.
+func checkAttemptPolicy(db *gorm.DB, test *models.Test, userID uint, now time.Time) error {
+	if test.OpensAt != nil && now.Before(*test.OpensAt) {
+		return &PolicyViolation{
+			Reason:  ErrTestNotOpen,
+			Message: fmt.Sprintf("Test opens at %s", test.OpensAt.Format(time.RFC3339)),
+			RetryAt: test.OpensAt,
+		}
+	}
+	if test.ClosesAt != nil && !now.Before(*test.ClosesAt) {
+		return &PolicyViolation{
+			Reason:  ErrTestClosed,
+			Message: fmt.Sprintf("Test closed at %s", test.ClosesAt.Format(time.RFC3339)),
+		}
+	}
+
+	if test.MaxAttempts > 0 {
+		attempts, err := countAttempts(db, test.ID, userID)
+		if err != nil {
+			return err
+		}
+		if attempts >= int64(test.MaxAttempts) {
+			return &PolicyViolation{
+				Reason:  ErrAttemptLimitReached,
+				Message: fmt.Sprintf("Maximum number of attempts (%d) reached", test.MaxAttempts),
+			}
+		}
+	}
+
+	if test.CooldownMinutes > 0 {
+		var last models.TestResult
+		err := db.Where("test_id = ? AND user_id = ? AND completed_at IS NOT NULL", test.ID, userID).
+			Order("completed_at DESC").
+			Limit(1).
+			Find(&last).Error
+		if err != nil {
+			return err
+		}
+		if last.ID != 0 {
+			retryAt := last.CompletedAt.Add(time.Duration(test.CooldownMinutes) * time.Minute)
+			if now.Before(retryAt) {
+				return &PolicyViolation{
+					Reason:  ErrAttemptCooldown,
+					Message: fmt.Sprintf("Next attempt allowed at %s", retryAt.Format(time.RFC3339)),
+					RetryAt: &retryAt,
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// lockAttempts locks the user's row until the transaction ends, serializing the
+// policy check and creation of the user's attempts.
+func lockAttempts(tx *gorm.DB, userID uint) error {
+	var user models.User
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, userID).Error
+}
+
+// countAttempts counts the user's attempts at the test, including those still in
+// progress, which already use up one of the allowed attempts.
+func countAttempts(db *gorm.DB, testID, userID uint) (int64, error) {
+	var count int64
+	err := db.Model(&models.TestResult{}).
+		Where("test_id = ? AND user_id = ?", testID, userID).
+		Count(&count).Error
+	return count, err
+}
+
+// markCountedAttempt flags the one attempt of the user that counts under the
+// test's scoring policy and clears the flag on all others.
+func markCountedAttempt(tx *gorm.DB, test *models.Test, userID uint) error {
+	order := "completed_at DESC, id DESC"
+	switch test.ScoringPolicy {
+	case models.ScoreFirstAttempt:
+		order = "completed_at ASC, id ASC"
+	case models.ScoreBestAttempt:
+		order = "score DESC, time_taken ASC, completed_at ASC"
+	}
+
+	var counted models.TestResult
+	err := tx.Where("test_id = ? AND user_id = ? AND completed_at IS NOT NULL", test.ID, userID).
+		Order(order).
+		Limit(1).
+		Find(&counted).Error
+	if err != nil || counted.ID == 0 {
+		return err
+	}
+
+	err = tx.Model(&models.TestResult{}).
+		Where("test_id = ? AND user_id = ? AND id <> ?", test.ID, userID, counted.ID).
+		Update("counted", false).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.TestResult{}).Where("id = ?", counted.ID).Update("counted", true).Error
+}
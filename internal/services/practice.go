@@ -0,0 +1,236 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"iq-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPracticeDisabled     = errors.New("practice is not enabled for this test")
+	ErrPracticeNotFound     = errors.New("practice session not found")
+	ErrPracticeFinished     = errors.New("practice session has already finished")
+	ErrPracticeAnswered     = errors.New("question has already been answered in this session")
+	ErrQuestionNotInSession = errors.New("question is not part of this practice session")
+	ErrPracticeAttemptOpen  = errors.New("practice is unavailable while an attempt at this test is open")
+)
+
+// PracticeFeedback is the immediate result of a practice answer. Whether it was
+// right, the score, the correct answer, explanation and reference are only filled
+// in once the test's answer_reveal allows it.
+type PracticeFeedback struct {
+	QuestionID    uint   `json:"question_id"`
+	IsCorrect     *bool  `json:"is_correct"`
+	CorrectAnswer string `json:"correct_answer,omitempty"`
+	Explanation   string `json:"explanation,omitempty"`
+	Reference     string `json:"reference,omitempty"`
+	Score         *int   `json:"score"`
+	Answered      int    `json:"answered"`
+}
+
+// StartPractice opens a practice session at a test with practice enabled. Practice
+// ignores the attempt policy, since it never counts as an attempt, but is refused
+// while the user has an attempt at the test open.
+func (s *TestService) StartPractice(orgID, userID, testID uint) (*models.PracticeSession, []models.Question, error) {
+	test, err := s.GetTestByID(orgID, testID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !test.PracticeEnabled {
+		return nil, nil, ErrPracticeDisabled
+	}
+	if err := checkNoOpenAttempt(s.db, userID, testID, time.Now()); err != nil {
+		return nil, nil, err
+	}
+
+	questions, err := s.GetCandidateQuestions(orgID, userID, testID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(questions) == 0 {
+		return nil, nil, ErrNoQuestions
+	}
+
+	session := &models.PracticeSession{
+		OrganizationID: orgID,
+		UserID:         userID,
+		TestID:         testID,
+		TotalQuestions: len(questions),
+		StartedAt:      time.Now(),
+	}
+	if err := s.db.Create(session).Error; err != nil {
+		return nil, nil, err
+	}
+	return session, questions, nil
+}
+
+// AnswerPractice grades one answer right away with the same rules as a real
+// attempt and returns the outcome when the test reveals answers.
+func (s *TestService) AnswerPractice(orgID, userID, sessionID uint, answer SubmitAnswerRequest) (*PracticeFeedback, error) {
+	var feedback *PracticeFeedback
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		session, err := s.findPracticeSession(tx, orgID, userID, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.CompletedAt != nil {
+			return ErrPracticeFinished
+		}
+
+		var test models.Test
+		if err := tx.First(&test, session.TestID).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := checkNoOpenAttempt(tx, userID, test.ID, now); err != nil {
+			return err
+		}
+
+		var question models.Question
+		err = tx.Where("id = ? AND test_id = ?", answer.QuestionID, session.TestID).First(&question).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrQuestionNotInSession
+		}
+		if err != nil {
+			return err
+		}
+
+		var answered int64
+		err = tx.Model(&models.PracticeAnswer{}).
+			Where("practice_session_id = ? AND question_id = ?", session.ID, question.ID).
+			Count(&answered).Error
+		if err != nil {
+			return err
+		}
+		if answered > 0 {
+			return ErrPracticeAnswered
+		}
+
+		isCorrect := s.evaluateAnswer(&question, answer.UserAnswer)
+		if err := tx.Create(&models.PracticeAnswer{
+			PracticeSessionID: session.ID,
+			QuestionID:        question.ID,
+			UserAnswer:        answer.UserAnswer,
+			IsCorrect:         isCorrect,
+			ResponseTime:      answer.ResponseTime,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Increment in SQL so concurrent answers in the same session are not lost.
+		correct := 0
+		if isCorrect {
+			correct = 1
+		}
+		err = tx.Model(session).Updates(map[string]interface{}{
+			"answered": gorm.Expr("answered + 1"),
+			"score":    gorm.Expr("score + ?", correct),
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.First(session, session.ID).Error; err != nil {
+			return err
+		}
+
+		feedback = &PracticeFeedback{QuestionID: question.ID, Answered: session.Answered}
+		if test.AnswersRevealed(now) {
+			feedback.IsCorrect = &isCorrect
+			feedback.CorrectAnswer = question.CorrectAnswer
+			feedback.Explanation = question.Explanation
+			feedback.Reference = question.Reference
+			feedback.Score = &session.Score
+		}
+		return nil
+	})
+	return feedback, err
+}
+
+// FinishPractice closes a practice session. Unanswered questions are left out of
+// the score rather than counted as wrong.
+func (s *TestService) FinishPractice(orgID, userID, sessionID uint) (*models.PracticeSession, error) {
+	session, err := s.findPracticeSession(s.db, orgID, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.CompletedAt != nil {
+		return nil, ErrPracticeFinished
+	}
+
+	result := s.db.Model(session).Where("completed_at IS NULL").Update("completed_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrPracticeFinished
+	}
+
+	if err := s.db.Preload("Test").Preload("Answers").First(session, session.ID).Error; err != nil {
+		return nil, err
+	}
+	if !session.Test.AnswersRevealed(time.Now()) {
+		session.HideScore()
+	}
+	return session, nil
+}
+
+func (s *TestService) ListPracticeSessions(orgID, userID uint) ([]models.PracticeSession, error) {
+	var sessions []models.PracticeSession
+	err := s.db.Scopes(inOrganization(orgID)).
+		Where("user_id = ?", userID).
+		Preload("Test").
+		Order("started_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range sessions {
+		if !sessions[i].Test.AnswersRevealed(now) {
+			sessions[i].HideScore()
+		}
+	}
+	return sessions, nil
+}
+
+func (s *TestService) findPracticeSession(db *gorm.DB, orgID, userID, sessionID uint) (*models.PracticeSession, error) {
+	var session models.PracticeSession
+	err := db.Scopes(inOrganization(orgID)).
+		Where("id = ? AND user_id = ?", sessionID, userID).
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPracticeNotFound
+	}
+	return &session, err
+}
+
+// checkNoOpenAttempt refuses practice while the user has an attempt at the test in
+// progress or is due to sit it in a proctored session, since practice feedback
+// would otherwise check answers for the scored attempt.
+func checkNoOpenAttempt(db *gorm.DB, userID, testID uint, now time.Time) error {
+	var open int64
+	err := db.Model(&models.TestResult{}).
+		Where("user_id = ? AND test_id = ? AND completed_at IS NULL", userID, testID).
+		Where("deadline IS NULL OR deadline > ? OR paused_at IS NOT NULL", now).
+		Count(&open).Error
+	if err != nil {
+		return err
+	}
+	if open == 0 {
+		err = db.Model(&models.SessionCandidate{}).
+			Joins("JOIN sessions ON sessions.id = session_candidates.session_id AND sessions.deleted_at IS NULL").
+			Where("session_candidates.user_id = ? AND sessions.test_id = ?", userID, testID).
+			Where("sessions.status <> ? AND session_candidates.terminated_at IS NULL", models.SessionEnded).
+			Count(&open).Error
+		if err != nil {
+			return err
+		}
+	}
+	if open > 0 {
+		return ErrPracticeAttemptOpen
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"iq-go/internal/models"
+	"iq-go/internal/pubsub"
+
+	"gorm.io/gorm"
+)
+
+type ProctoringEventType string
+
+const (
+	ProctoringCandidateJoined  ProctoringEventType = "candidate_joined"
+	ProctoringQuestionAdvanced ProctoringEventType = "question_advanced"
+	ProctoringAnswerSaved      ProctoringEventType = "answer_saved"
+	ProctoringFocusLost        ProctoringEventType = "focus_lost"
+	ProctoringSubmitted        ProctoringEventType = "submitted"
+
+	// Proctors' changes to a session
+	ProctoringSessionStarted    ProctoringEventType = "session_started"
+	ProctoringSessionPaused     ProctoringEventType = "session_paused"
+	ProctoringSessionResumed    ProctoringEventType = "session_resumed"
+	ProctoringSessionEnded      ProctoringEventType = "session_ended"
+	ProctoringTimeExtended      ProctoringEventType = "time_extended"
+	ProctoringAttemptTerminated ProctoringEventType = "attempt_terminated"
+)
+
+// candidateActivities are the events candidates report themselves while taking a
+// test; the others are raised by the server.
+var candidateActivities = map[ProctoringEventType]bool{
+	ProctoringQuestionAdvanced: true,
+	ProctoringAnswerSaved:      true,
+	ProctoringFocusLost:        true,
+}
+
+var ErrInvalidActivity = errors.New("invalid activity")
+
+// ProctoringEvent is something that happened in an attempt or a session, as
+// streamed to the proctors watching its test. Session events have no attempt or
+// user. QuestionIndex is the zero-based position of the question in the attempt.
+// Deadline is when the attempt, or for session events the session, is due.
+// Answers themselves are never included.
+type ProctoringEvent struct {
+	Type          ProctoringEventType `json:"type"`
+	SessionID     *uint               `json:"session_id,omitempty"`
+	AttemptID     uint                `json:"attempt_id,omitempty"`
+	TestID        uint                `json:"test_id"`
+	UserID        uint                `json:"user_id,omitempty"`
+	UserEmail     string              `json:"user_email,omitempty"`
+	QuestionIndex *int                `json:"question_index,omitempty"`
+	Score         *int                `json:"score,omitempty"`
+	Deadline      *time.Time          `json:"deadline,omitempty"`
+	At            time.Time           `json:"at"`
+}
+
+// Activity is an event reported by the candidate taking an attempt.
+type Activity struct {
+	Type          ProctoringEventType
+	QuestionIndex *int
+}
+
+// ProctoringService publishes what happens in attempts to the proctors watching
+// them live. Events are published on a Bus after the change they describe is
+// committed; a failure to publish is logged and never fails the test flow.
+type ProctoringService struct {
+	db  *gorm.DB
+	bus pubsub.Bus
+}
+
+func NewProctoringService(db *gorm.DB, bus pubsub.Bus) *ProctoringService {
+	return &ProctoringService{db: db, bus: bus}
+}
+
+// Subscribe streams the events of every attempt at the test, encoded as JSON,
+// until ctx is done.
+func (s *ProctoringService) Subscribe(ctx context.Context, orgID, testID uint) (<-chan []byte, error) {
+	var test models.Test
+	if err := s.db.Scopes(inOrganization(orgID)).Select("id").First(&test, testID).Error; err != nil {
+		return nil, err
+	}
+	return s.bus.Subscribe(ctx, proctoringTopic(orgID, testID))
+}
+
+// AttemptStarted announces a candidate joining the test, including when an
+// attempt in progress is resumed.
+func (s *ProctoringService) AttemptStarted(ctx context.Context, attempt *models.TestResult) {
+	s.publish(ctx, attempt, ProctoringEvent{Type: ProctoringCandidateJoined})
+}
+
+// AttemptSubmitted announces a scored attempt.
+func (s *ProctoringService) AttemptSubmitted(ctx context.Context, result *models.TestResult) {
+	score := result.Score
+	s.publish(ctx, result, ProctoringEvent{Type: ProctoringSubmitted, Score: &score})
+}
+
+// RecordActivity publishes an event the candidate reports from an attempt in
+// progress.
+func (s *ProctoringService) RecordActivity(ctx context.Context, orgID, userID, attemptID uint, activity Activity) error {
+	if !candidateActivities[activity.Type] {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidActivity, activity.Type)
+	}
+
+	var attempt models.TestResult
+	err := s.db.Scopes(inOrganization(orgID)).
+		Where("id = ? AND user_id = ?", attemptID, userID).
+		First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAttemptNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if attempt.CompletedAt != nil {
+		return ErrAttemptCompleted
+	}
+	if attempt.PausedAt != nil {
+		return ErrSessionPaused
+	}
+
+	event := ProctoringEvent{Type: activity.Type}
+	if activity.QuestionIndex != nil {
+		if *activity.QuestionIndex < 0 || *activity.QuestionIndex >= attempt.TotalQuestions {
+			return fmt.Errorf("%w: question_index must be between 0 and %d", ErrInvalidActivity, attempt.TotalQuestions-1)
+		}
+		index := *activity.QuestionIndex
+		event.QuestionIndex = &index
+	} else if activity.Type != ProctoringFocusLost {
+		return fmt.Errorf("%w: question_index is required for %s", ErrInvalidActivity, activity.Type)
+	}
+
+	s.publish(ctx, &attempt, event)
+	return nil
+}
+
+// SessionChanged announces that a proctor started, paused, resumed or ended a
+// session.
+func (s *ProctoringService) SessionChanged(ctx context.Context, session *models.Session, eventType ProctoringEventType) {
+	event := ProctoringEvent{Type: eventType, SessionID: &session.ID, TestID: session.TestID}
+	if session.Status == models.SessionRunning {
+		event.Deadline = session.EndsAt
+	}
+	s.send(ctx, session.OrganizationID, event)
+}
+
+// CandidateChanged announces that a proctor extended a candidate's time or
+// terminated their attempt.
+func (s *ProctoringService) CandidateChanged(ctx context.Context, session *models.Session, userID uint, eventType ProctoringEventType) {
+	candidate := session.Candidate(userID)
+	if candidate == nil {
+		return
+	}
+	event := ProctoringEvent{
+		Type:      eventType,
+		SessionID: &session.ID,
+		TestID:    session.TestID,
+		UserID:    userID,
+		Deadline:  session.Deadline(candidate),
+	}
+	if candidate.TestResultID != nil {
+		event.AttemptID = *candidate.TestResultID
+	}
+	s.send(ctx, session.OrganizationID, event)
+}
+
+func (s *ProctoringService) publish(ctx context.Context, attempt *models.TestResult, event ProctoringEvent) {
+	event.SessionID = attempt.SessionID
+	event.AttemptID = attempt.ID
+	event.TestID = attempt.TestID
+	event.UserID = attempt.UserID
+	event.Deadline = attempt.Deadline
+	s.send(ctx, attempt.OrganizationID, event)
+}
+
+func (s *ProctoringService) send(ctx context.Context, orgID uint, event ProctoringEvent) {
+	event.At = time.Now()
+
+	var err error
+	if event.UserID != 0 {
+		event.UserEmail, err = userEmail(s.db, event.UserID)
+	}
+	if err == nil {
+		var message []byte
+		if message, err = json.Marshal(event); err == nil {
+			err = s.bus.Publish(ctx, proctoringTopic(orgID, event.TestID), message)
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to publish %s for test %d: %v", event.Type, event.TestID, err)
+	}
+}
+
+func proctoringTopic(orgID, testID uint) string {
+	return fmt.Sprintf("proctoring:%d:%d", orgID, testID)
+}
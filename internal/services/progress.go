@@ -0,0 +1,329 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"iq-go/internal/models"
+)
+
+type Trend string
+
+const (
+	TrendImproving        Trend = "improving"
+	TrendDeclining        Trend = "declining"
+	TrendStable           Trend = "stable"
+	TrendInsufficientData Trend = "insufficient_data"
+)
+
+const (
+	// Expected gain in percentage points from the first retake when there are too few
+	// retakes to estimate it; each later retake is expected to gain half as much.
+	defaultPracticeEffect = 3.0
+	// retake pairs needed before the practice effect is estimated from the population
+	minPracticePairs = 20
+	// |RCI| beyond this is a change unlikely to be measurement error (p < .05)
+	reliableChangeThreshold = 1.96
+	// change in percentage points treated as real when no reliability report exists
+	fallbackChangeThreshold = 5.0
+)
+
+// ProgressReport is a user's scores across attempts, one entry per test taken.
+type ProgressReport struct {
+	UserID uint           `json:"user_id"`
+	Tests  []TestProgress `json:"tests"`
+}
+
+type TestProgress struct {
+	TestID     uint              `json:"test_id"`
+	TestName   string            `json:"test_name"`
+	Attempts   []AttemptProgress `json:"attempts"`
+	Overall    ScoreTrend        `json:"overall"`
+	Categories []ScoreTrend      `json:"categories"`
+}
+
+type AttemptProgress struct {
+	ResultID       uint            `json:"result_id"`
+	Attempt        int             `json:"attempt"`
+	CompletedAt    time.Time       `json:"completed_at"`
+	Score          int             `json:"score"`
+	TotalQuestions int             `json:"total_questions"`
+	Percentage     float64         `json:"percentage"`
+	Categories     []CategoryScore `json:"categories"`
+}
+
+// ScoreTrend summarises a series of percentage scores, for the whole test or one
+// category. Changes are in percentage points. AdjustedChange is the change since
+// the first attempt minus the gain expected from practice alone.
+type ScoreTrend struct {
+	Category            models.Category `json:"category,omitempty"`
+	Points              []TrendPoint    `json:"points"`
+	Latest              float64         `json:"latest"`
+	DeltaSinceLast      *float64        `json:"delta_since_last"`
+	ChangeSinceFirst    *float64        `json:"change_since_first"`
+	ExpectedPractice    float64         `json:"expected_practice_effect"`
+	AdjustedChange      *float64        `json:"adjusted_change"`
+	ReliableChangeIndex *float64        `json:"reliable_change_index"`
+	Trend               Trend           `json:"trend"`
+}
+
+type TrendPoint struct {
+	Attempt    int       `json:"attempt"`
+	Date       time.Time `json:"date"`
+	Percentage float64   `json:"percentage"`
+}
+
+// GetProgress builds the user's progress across completed attempts, for one test
+// or for every test when testID is zero.
+func (s *ResultService) GetProgress(orgID, userID, testID uint) (*ProgressReport, error) {
+	query := s.db.Scopes(inOrganization(orgID)).
+		Where("user_id = ? AND completed_at IS NOT NULL", userID).
+		Preload("Test").
+		Order("test_id, completed_at")
+	if testID != 0 {
+		query = query.Where("test_id = ?", testID)
+	}
+	var results []models.TestResult
+	if err := query.Find(&results).Error; err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categoryScores(results)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ProgressReport{UserID: userID, Tests: []TestProgress{}}
+	for start := 0; start < len(results); {
+		end := start
+		for end < len(results) && results[end].TestID == results[start].TestID {
+			end++
+		}
+		progress, err := s.testProgress(results[start:end], categories)
+		if err != nil {
+			return nil, err
+		}
+		report.Tests = append(report.Tests, *progress)
+		start = end
+	}
+
+	return report, nil
+}
+
+func (s *ResultService) testProgress(results []models.TestResult, categories map[uint][]CategoryScore) (*TestProgress, error) {
+	test := results[0].Test
+	progress := &TestProgress{
+		TestID:     test.ID,
+		TestName:   test.Name,
+		Attempts:   make([]AttemptProgress, 0, len(results)),
+		Categories: []ScoreTrend{},
+	}
+
+	overall := make([]TrendPoint, 0, len(results))
+	byCategory := make(map[models.Category][]TrendPoint)
+	for i, result := range results {
+		attempt := AttemptProgress{
+			ResultID:       result.ID,
+			Attempt:        i + 1,
+			CompletedAt:    *result.CompletedAt,
+			Score:          result.Score,
+			TotalQuestions: result.TotalQuestions,
+			Percentage:     percentage(result.Score, result.TotalQuestions),
+			Categories:     categories[result.ID],
+		}
+		if attempt.Categories == nil {
+			attempt.Categories = []CategoryScore{}
+		}
+		progress.Attempts = append(progress.Attempts, attempt)
+
+		overall = append(overall, TrendPoint{Attempt: attempt.Attempt, Date: attempt.CompletedAt, Percentage: attempt.Percentage})
+		for _, category := range attempt.Categories {
+			byCategory[category.Category] = append(byCategory[category.Category], TrendPoint{
+				Attempt:    attempt.Attempt,
+				Date:       attempt.CompletedAt,
+				Percentage: category.Percentage,
+			})
+		}
+	}
+
+	practice, err := s.practiceEffects(test.OrganizationID, test.ID, len(results))
+	if err != nil {
+		return nil, err
+	}
+	sem, err := s.percentageSEMs(test.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress.Overall = scoreTrend("", overall, practice, sem[""])
+	names := make([]models.Category, 0, len(byCategory))
+	for category := range byCategory {
+		names = append(names, category)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	for _, category := range names {
+		// The practice effect is only estimated for the whole test and applied to
+		// every category as an approximation.
+		progress.Categories = append(progress.Categories, scoreTrend(category, byCategory[category], practice, sem[category]))
+	}
+
+	return progress, nil
+}
+
+// scoreTrend classifies a series using the reliable change index when the
+// standard error of measurement is known, and a fixed threshold otherwise.
+func scoreTrend(category models.Category, points []TrendPoint, practice []float64, sem float64) ScoreTrend {
+	trend := ScoreTrend{Category: category, Points: points, Trend: TrendInsufficientData}
+	if len(points) == 0 {
+		return trend
+	}
+	last := points[len(points)-1]
+	trend.Latest = last.Percentage
+	if len(points) < 2 {
+		return trend
+	}
+
+	delta := last.Percentage - points[len(points)-2].Percentage
+	change := last.Percentage - points[0].Percentage
+	// Attempt numbers of a category series can skip attempts that did not include it.
+	for k := points[0].Attempt; k < last.Attempt && k-1 < len(practice); k++ {
+		trend.ExpectedPractice += practice[k-1]
+	}
+	adjusted := change - trend.ExpectedPractice
+	trend.DeltaSinceLast = &delta
+	trend.ChangeSinceFirst = &change
+	trend.AdjustedChange = &adjusted
+
+	threshold := fallbackChangeThreshold
+	if sem > 0 {
+		rci := adjusted / (math.Sqrt2 * sem)
+		trend.ReliableChangeIndex = &rci
+		adjusted = rci
+		threshold = reliableChangeThreshold
+	}
+	switch {
+	case adjusted >= threshold:
+		trend.Trend = TrendImproving
+	case adjusted <= -threshold:
+		trend.Trend = TrendDeclining
+	default:
+		trend.Trend = TrendStable
+	}
+	return trend
+}
+
+// practiceEffects returns the expected gain in percentage points for each retake,
+// from attempt k+1 to k+2 at index k. Each gain is the average across everyone in
+// the organization who retook the test, or the default when too few have.
+func (s *ResultService) practiceEffects(orgID, testID uint, attempts int) ([]float64, error) {
+	effects := make([]float64, 0, attempts)
+	if attempts < 2 {
+		return effects, nil
+	}
+
+	var results []models.TestResult
+	err := s.db.Scopes(inOrganization(orgID)).
+		Select("user_id, score, total_questions").
+		Where("test_id = ? AND completed_at IS NOT NULL", testID).
+		Order("user_id, completed_at").
+		Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	gains := make([]float64, attempts-1)
+	pairs := make([]int, attempts-1)
+	attempt := 0
+	for i := 1; i < len(results); i++ {
+		if results[i].UserID != results[i-1].UserID {
+			attempt = 0
+			continue
+		}
+		if attempt < len(gains) {
+			gains[attempt] += percentage(results[i].Score, results[i].TotalQuestions) -
+				percentage(results[i-1].Score, results[i-1].TotalQuestions)
+			pairs[attempt]++
+		}
+		attempt++
+	}
+
+	for k := range gains {
+		if pairs[k] >= minPracticePairs {
+			effects = append(effects, gains[k]/float64(pairs[k]))
+		} else {
+			effects = append(effects, defaultPracticeEffect/math.Pow(2, float64(k)))
+		}
+	}
+	return effects, nil
+}
+
+// percentageSEMs reads the standard errors of measurement from the test's latest
+// reliability report, converted to percentage points. The whole test is keyed by
+// the empty category.
+func (s *ResultService) percentageSEMs(testID uint) (map[models.Category]float64, error) {
+	sems := make(map[models.Category]float64)
+
+	var report models.ReliabilityReport
+	err := s.db.Where("test_id = ?", testID).Order("version DESC").Limit(1).Find(&report).Error
+	if err != nil || report.ID == 0 {
+		return sems, err
+	}
+
+	for _, scale := range append([]models.ScaleReliability{report.Overall}, report.Subscales...) {
+		if scale.SEM != nil && scale.Items > 0 {
+			sems[scale.Category] = *scale.SEM / float64(scale.Items) * 100
+		}
+	}
+	return sems, nil
+}
+
+// categoryScores loads the per-category breakdown of each result in one query.
+func (s *ResultService) categoryScores(results []models.TestResult) (map[uint][]CategoryScore, error) {
+	scores := make(map[uint][]CategoryScore, len(results))
+	if len(results) == 0 {
+		return scores, nil
+	}
+	ids := make([]uint, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+
+	var rows []struct {
+		TestResultID      uint
+		Category          models.Category
+		Total             int
+		Correct           int
+		TotalResponseTime int
+	}
+	err := s.db.Table("answers").
+		Select(`answers.test_result_id, questions.category, COUNT(*) AS total,
+			SUM(CASE WHEN answers.is_correct THEN 1 ELSE 0 END) AS correct,
+			SUM(answers.response_time) AS total_response_time`).
+		Joins("JOIN questions ON questions.id = answers.question_id").
+		Where("answers.deleted_at IS NULL AND answers.test_result_id IN ?", ids).
+		Group("answers.test_result_id, questions.category").
+		Order("questions.category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		scores[row.TestResultID] = append(scores[row.TestResultID], CategoryScore{
+			Category:        row.Category,
+			Correct:         row.Correct,
+			Total:           row.Total,
+			Percentage:      percentage(row.Correct, row.Total),
+			AvgResponseTime: float64(row.TotalResponseTime) / float64(row.Total),
+		})
+	}
+	return scores, nil
+}
+
+func percentage(score, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(score) / float64(total) * 100
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"iq-go/internal/models"
+	"iq-go/internal/psychometrics"
+
+	"gorm.io/gorm"
+)
+
+// ComputeReliability adds a new version of the test's reliability report. If the
+// test's counted results are unchanged since the latest version, that version is
+// returned instead and created is false.
+func (s *AnalyticsService) ComputeReliability(orgID, testID uint) (report *models.ReliabilityReport, created bool, err error) {
+	var test models.Test
+	if err := s.db.Scopes(inOrganization(orgID)).First(&test, testID).Error; err != nil {
+		return nil, false, err
+	}
+	return s.computeReliability(&test)
+}
+
+// ComputeAllReliability runs ComputeReliability for every test of an organization,
+// or of every organization when orgID is zero, and returns the reports it created.
+func (s *AnalyticsService) ComputeAllReliability(orgID uint) ([]models.ReliabilityReport, error) {
+	var tests []models.Test
+	query := s.db.Order("id")
+	if orgID != 0 {
+		query = query.Scopes(inOrganization(orgID))
+	}
+	if err := query.Find(&tests).Error; err != nil {
+		return nil, err
+	}
+
+	var reports []models.ReliabilityReport
+	for i := range tests {
+		report, created, err := s.computeReliability(&tests[i])
+		if err != nil {
+			return reports, err
+		}
+		if created {
+			reports = append(reports, *report)
+		}
+	}
+	return reports, nil
+}
+
+// ListReliabilityReports returns every version of a test's report, newest first.
+func (s *AnalyticsService) ListReliabilityReports(orgID, testID uint) ([]models.ReliabilityReport, error) {
+	var reports []models.ReliabilityReport
+	err := s.db.Scopes(inOrganization(orgID)).
+		Where("test_id = ?", testID).
+		Order("version DESC").
+		Find(&reports).Error
+	return reports, err
+}
+
+// computeReliability scores each counted attempt on the test's current questions.
+// Only counted attempts are used so every candidate contributes once, and attempts
+// that were not served every current question are excluded (listwise deletion).
+// Accommodated attempts are left out too, since extra time changes the scores.
+func (s *AnalyticsService) computeReliability(test *models.Test) (*models.ReliabilityReport, bool, error) {
+	var latest models.ReliabilityReport
+	err := s.db.Where("test_id = ?", test.ID).Order("version DESC").Limit(1).Find(&latest).Error
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Deleting, recounting or updating a result changes the count or touches
+	// updated_at, so comparing both catches changes a new highest id would miss.
+	var seen struct {
+		Count     int
+		UpdatedAt *time.Time
+	}
+	err = s.db.Model(&models.TestResult{}).
+		Where("test_id = ? AND counted AND completed_at IS NOT NULL", test.ID).
+		Select("COUNT(*) AS count, MAX(updated_at) AS updated_at").
+		Scan(&seen).Error
+	if err != nil {
+		return nil, false, err
+	}
+	// The report scores attempts on the current questions, so a changed question
+	// set changes the report even when no result did.
+	var questionSet struct {
+		Count     int
+		UpdatedAt *time.Time
+	}
+	err = s.db.Model(&models.Question{}).
+		Where("test_id = ?", test.ID).
+		Select("COUNT(*) AS count, MAX(updated_at) AS updated_at").
+		Scan(&questionSet).Error
+	if err != nil {
+		return nil, false, err
+	}
+	if latest.ID != 0 && latest.ResultCount == seen.Count && sameTime(latest.ResultsUpdatedAt, seen.UpdatedAt) &&
+		latest.QuestionCount == questionSet.Count && sameTime(latest.QuestionsUpdatedAt, questionSet.UpdatedAt) {
+		return &latest, false, nil
+	}
+
+	var questions []models.Question
+	if err := s.db.Where("test_id = ?", test.ID).Order("order_index").Find(&questions).Error; err != nil {
+		return nil, false, err
+	}
+
+	scores, excluded, err := s.itemScores(test.ID, questions)
+	if err != nil {
+		return nil, false, err
+	}
+	accommodated, err := countAccommodated(s.db, func(db *gorm.DB) *gorm.DB {
+		return db.Where("test_results.test_id = ? AND test_results.counted", test.ID)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	report := &models.ReliabilityReport{
+		OrganizationID:     test.OrganizationID,
+		TestID:             test.ID,
+		Version:            latest.Version + 1,
+		ExcludedCount:      excluded,
+		Accommodated:       accommodated,
+		ComputedAt:         time.Now(),
+		ResultCount:        seen.Count,
+		ResultsUpdatedAt:   seen.UpdatedAt,
+		QuestionCount:      questionSet.Count,
+		QuestionsUpdatedAt: questionSet.UpdatedAt,
+		Overall:            scaleReliability("", len(questions), scores),
+		Subscales:          []models.ScaleReliability{},
+		Correlations:       []models.CategoryCorrelation{},
+	}
+
+	// Split the item matrix into one matrix per category, keeping question order.
+	columns := make(map[models.Category][]int)
+	var categories []models.Category
+	for i, question := range questions {
+		if _, ok := columns[question.Category]; !ok {
+			categories = append(categories, question.Category)
+		}
+		columns[question.Category] = append(columns[question.Category], i)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i] < categories[j] })
+
+	totals := make(map[models.Category][]float64, len(categories))
+	for _, category := range categories {
+		subscale := make([][]float64, len(scores))
+		totals[category] = make([]float64, len(scores))
+		for respondent, row := range scores {
+			for _, column := range columns[category] {
+				subscale[respondent] = append(subscale[respondent], row[column])
+				totals[category][respondent] += row[column]
+			}
+		}
+		report.Subscales = append(report.Subscales, scaleReliability(category, len(columns[category]), subscale))
+	}
+
+	for i, a := range categories {
+		for _, b := range categories[i+1:] {
+			correlation := models.CategoryCorrelation{A: a, B: b}
+			if r, ok := psychometrics.Pearson(totals[a], totals[b]); ok {
+				correlation.R = &r
+			}
+			report.Correlations = append(report.Correlations, correlation)
+		}
+	}
+
+	if err := s.db.Create(report).Error; err != nil {
+		return nil, false, err
+	}
+	return report, true, nil
+}
+
+// itemScores builds the respondent by item matrix of 0/1 scores for the counted,
+// unaccommodated attempts at a test, with columns in the order of questions.
+func (s *AnalyticsService) itemScores(testID uint, questions []models.Question) ([][]float64, int, error) {
+	column := make(map[uint]int, len(questions))
+	for i, question := range questions {
+		column[question.ID] = i
+	}
+
+	rows, err := s.db.Table("answers").
+		Select("answers.test_result_id, answers.question_id, answers.is_correct").
+		Joins("JOIN test_results ON test_results.id = answers.test_result_id AND test_results.deleted_at IS NULL").
+		Where("answers.deleted_at IS NULL AND test_results.completed_at IS NOT NULL AND test_results.counted").
+		Where("test_results.test_id = ?", testID).
+		Scopes(unaccommodated).
+		Order("answers.test_result_id").
+		Rows()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var (
+		scores   [][]float64
+		excluded int
+		current  uint
+		row      []float64
+		answered int
+	)
+	flush := func() {
+		if current == 0 {
+			return
+		}
+		if answered == len(questions) {
+			scores = append(scores, row)
+		} else {
+			excluded++
+		}
+	}
+
+	for rows.Next() {
+		var answer struct {
+			TestResultID uint
+			QuestionID   uint
+			IsCorrect    bool
+		}
+		if err := s.db.ScanRows(rows, &answer); err != nil {
+			return nil, 0, err
+		}
+		if answer.TestResultID != current {
+			flush()
+			current = answer.TestResultID
+			row = make([]float64, len(questions))
+			answered = 0
+		}
+		i, ok := column[answer.QuestionID]
+		if !ok {
+			continue
+		}
+		answered++
+		if answer.IsCorrect {
+			row[i] = 1
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	flush()
+
+	return scores, excluded, nil
+}
+
+// sameTime reports whether two optional times are both nil or the same instant.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func scaleReliability(category models.Category, items int, scores [][]float64) models.ScaleReliability {
+	scale := models.ScaleReliability{Category: category, Items: items, Attempts: len(scores)}
+	if len(scores) == 0 {
+		return scale
+	}
+
+	var totals psychometrics.Moments
+	for _, row := range scores {
+		total := 0.0
+		for _, score := range row {
+			total += score
+		}
+		totals.Add(total)
+	}
+	scale.Mean = totals.Mean()
+	scale.SD = math.Sqrt(totals.Variance())
+
+	if alpha, ok := psychometrics.CronbachAlpha(scores); ok {
+		sem := psychometrics.StandardError(scale.SD, alpha)
+		scale.CronbachAlpha = &alpha
+		scale.SEM = &sem
+	}
+	if splitHalf, ok := psychometrics.SplitHalf(scores); ok {
+		scale.SplitHalf = &splitHalf
+	}
+	return scale
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"sort"
+
+	"iq-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ReportService struct {
+	db *gorm.DB
+}
+
+func NewReportService(db *gorm.DB) *ReportService {
+	return &ReportService{db: db}
+}
+
+// CategoryScore is a user's performance in one category of an attempt.
+type CategoryScore struct {
+	Category        models.Category `json:"category"`
+	Correct         int             `json:"correct"`
+	Total           int             `json:"total"`
+	Percentage      float64         `json:"percentage"`
+	AvgResponseTime float64         `json:"avg_response_time"` // in milliseconds
+}
+
+// CategoryBreakdown groups an attempt's answers by question category. The answers'
+// questions must be preloaded.
+func CategoryBreakdown(answers []models.Answer) []CategoryScore {
+	byCategory := make(map[models.Category]*CategoryScore)
+	responseTimes := make(map[models.Category]int)
+	for _, answer := range answers {
+		category := answer.Question.Category
+		score, ok := byCategory[category]
+		if !ok {
+			score = &CategoryScore{Category: category}
+			byCategory[category] = score
+		}
+		score.Total++
+		if answer.IsCorrect {
+			score.Correct++
+		}
+		responseTimes[category] += answer.ResponseTime
+	}
+
+	scores := make([]CategoryScore, 0, len(byCategory))
+	for category, score := range byCategory {
+		score.Percentage = float64(score.Correct) / float64(score.Total) * 100
+		score.AvgResponseTime = float64(responseTimes[category]) / float64(score.Total)
+		scores = append(scores, *score)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].Category < scores[j].Category
+	})
+	return scores
+}
+
+// GetReportTemplate returns the organization's report branding, or the default
+// branding when none has been configured.
+func (s *ReportService) GetReportTemplate(orgID uint) (*models.ReportTemplate, error) {
+	var template models.ReportTemplate
+	err := s.db.Where("organization_id = ?", orgID).First(&template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		template = models.DefaultReportTemplate(orgID)
+		return &template, nil
+	}
+	return &template, err
+}
+
+func (s *ReportService) SaveReportTemplate(template *models.ReportTemplate) error {
+	var existing models.ReportTemplate
+	err := s.db.Where("organization_id = ?", template.OrganizationID).First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	template.ID = existing.ID
+	template.CreatedAt = existing.CreatedAt
+	return s.db.Save(template).Error
+}
@@ -14,19 +14,35 @@ func NewResultService(db *gorm.DB) *ResultService {
 	return &ResultService{db: db}
 }
 
-func (s *ResultService) GetResultsByUserID(userID uint) ([]models.TestResult, error) {
+func (s *ResultService) GetResultsByUserID(orgID, userID uint) ([]models.TestResult, error) {
 	var results []models.TestResult
-	err := s.db.Where("user_id = ?", userID).
+	err := s.db.Scopes(inOrganization(orgID)).
+		Where("user_id = ?", userID).
 		Preload("Test").
 		Order("created_at DESC").
 		Find(&results).Error
 	return results, err
 }
 
-func (s *ResultService) GetResultByID(resultID, userID uint) (*models.TestResult, error) {
+func (s *ResultService) GetResultByID(orgID, resultID, userID uint) (*models.TestResult, error) {
 	var result models.TestResult
-	err := s.db.Where("id = ? AND user_id = ?", resultID, userID).
+	err := s.db.Scopes(inOrganization(orgID)).
+		Where("id = ? AND user_id = ?", resultID, userID).
 		Preload("Test").
+		Preload("User.Organization").
+		Preload("Answers").
+		Preload("Answers.Question").
+		First(&result).Error
+	return &result, err
+}
+
+// GetAnyResultByID loads a result regardless of its owner, for staff with access to all results.
+func (s *ResultService) GetAnyResultByID(orgID, resultID uint) (*models.TestResult, error) {
+	var result models.TestResult
+	err := s.db.Scopes(inOrganization(orgID)).
+		Where("id = ?", resultID).
+		Preload("Test").
+		Preload("User.Organization").
 		Preload("Answers").
 		Preload("Answers.Question").
 		First(&result).Error
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"iq-go/internal/models"
+)
+
+// ResultReview walks through an attempt question by question. Correct answers,
+// explanations and references are only filled in when the answers are revealed.
+type ResultReview struct {
+	ResultID        uint                `json:"result_id"`
+	TestID          uint                `json:"test_id"`
+	TestName        string              `json:"test_name"`
+	Score           int                 `json:"score"`
+	TotalQuestions  int                 `json:"total_questions"`
+	CompletedAt     *time.Time          `json:"completed_at"`
+	AnswerReveal    models.AnswerReveal `json:"answer_reveal"`
+	AnswersRevealed bool                `json:"answers_revealed"`
+	RevealsAt       *time.Time          `json:"reveals_at,omitempty"` // when answers will be revealed, if known
+	Items           []ReviewItem        `json:"items"`
+}
+
+type ReviewItem struct {
+	Position      int                 `json:"position"`
+	QuestionID    uint                `json:"question_id"`
+	QuestionText  string              `json:"question_text"`
+	QuestionType  models.QuestionType `json:"question_type"`
+	Category      models.Category     `json:"category"`
+	Options       string              `json:"options,omitempty"`
+	UserAnswer    string              `json:"user_answer"`
+	IsCorrect     bool                `json:"is_correct"`
+	ResponseTime  int                 `json:"response_time"` // in milliseconds
+	CorrectAnswer string              `json:"correct_answer,omitempty"`
+	Explanation   string              `json:"explanation,omitempty"`
+	Reference     string              `json:"reference,omitempty"`
+}
+
+// BuildReview lists a result's answers in the order they were served. The result's
+// Test and Answers.Question relations must be preloaded.
+func BuildReview(result *models.TestResult, reveal bool) *ResultReview {
+	review := &ResultReview{
+		ResultID:        result.ID,
+		TestID:          result.TestID,
+		TestName:        result.Test.Name,
+		Score:           result.Score,
+		TotalQuestions:  result.TotalQuestions,
+		CompletedAt:     result.CompletedAt,
+		AnswerReveal:    result.Test.AnswerReveal,
+		AnswersRevealed: reveal,
+		Items:           make([]ReviewItem, 0, len(result.Answers)),
+	}
+	if !reveal && result.Test.AnswerReveal == models.RevealAfterClose {
+		review.RevealsAt = result.Test.ClosesAt
+	}
+
+	// Answers are stored in the order their questions were served.
+	answers := append([]models.Answer(nil), result.Answers...)
+	sort.Slice(answers, func(i, j int) bool { return answers[i].ID < answers[j].ID })
+
+	for i, answer := range answers {
+		item := ReviewItem{
+			Position:     i + 1,
+			QuestionID:   answer.QuestionID,
+			QuestionText: answer.Question.QuestionText,
+			QuestionType: answer.Question.QuestionType,
+			Category:     answer.Question.Category,
+			Options:      answer.Question.Options,
+			UserAnswer:   answer.UserAnswer,
+			IsCorrect:    answer.IsCorrect,
+			ResponseTime: answer.ResponseTime,
+		}
+		if reveal {
+			item.CorrectAnswer = answer.Question.CorrectAnswer
+			item.Explanation = answer.Question.Explanation
+			item.Reference = answer.Question.Reference
+		}
+		review.Items = append(review.Items, item)
+	}
+	return review
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+
+	"iq-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrUnknownPermission = errors.New("unknown permission")
+var ErrUnknownRole = errors.New("unknown role")
+var ErrGlobalRole = errors.New("role grants permissions across organizations")
+
+type RoleService struct {
+	db *gorm.DB
+}
+
+func NewRoleService(db *gorm.DB) *RoleService {
+	return &RoleService{db: db}
+}
+
+func (s *RoleService) ListRoles() ([]models.Role, error) {
+	var roles []models.Role
+	err := s.db.Preload("Permissions").Order("name").Find(&roles).Error
+	return roles, err
+}
+
+func (s *RoleService) ListPermissions() ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := s.db.Order("name").Find(&permissions).Error
+	return permissions, err
+}
+
+func (s *RoleService) CreateRole(role *models.Role, permissionNames []string) error {
+	permissions, err := s.findPermissions(permissionNames)
+	if err != nil {
+		return err
+	}
+	role.Permissions = permissions
+	return s.db.Create(role).Error
+}
+
+func (s *RoleService) UpdateRole(roleID uint, description string, permissionNames []string) (*models.Role, error) {
+	var role models.Role
+	if err := s.db.First(&role, roleID).Error; err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.findPermissions(permissionNames)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		role.Description = description
+		if err := tx.Save(&role).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+			return err
+		}
+		return revokeTokens(tx, "id IN (?)", tx.Table("user_roles").Select("user_id").Where("role_id = ?", role.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	role.Permissions = permissions
+	return &role, nil
+}
+
+// SetUserRoles replaces the roles of a user in the organization with the named roles
+// and revokes the user's tokens.
+// Unless allowGlobal is set, neither the new roles nor the user's current ones may
+// grant any of models.GlobalPermissions.
+func (s *RoleService) SetUserRoles(orgID, userID uint, roleNames []string, allowGlobal bool) (*models.User, error) {
+	var user models.User
+	if err := s.db.Scopes(inOrganization(orgID)).Preload("Roles.Permissions").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	var roles []models.Role
+	if err := s.db.Preload("Permissions").Where("name IN ?", roleNames).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	if len(roles) != len(uniqueStrings(roleNames)) {
+		return nil, ErrUnknownRole
+	}
+	if !allowGlobal && (grantsGlobal(roles) || grantsGlobal(user.Roles)) {
+		return nil, ErrGlobalRole
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Association("Roles").Replace(roles); err != nil {
+			return err
+		}
+		return revokeTokens(tx, "id = ?", user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Preload("Roles.Permissions").First(&user, userID).Error
+	return &user, err
+}
+
+// grantsGlobal reports whether any of the roles, with their permissions loaded,
+// grants a permission that acts across organizations.
+func grantsGlobal(roles []models.Role) bool {
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			for _, global := range models.GlobalPermissions {
+				if permission.Name == global {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (s *RoleService) findPermissions(names []string) ([]models.Permission, error) {
+	var permissions []models.Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+	if err := s.db.Where("name IN ?", names).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	if len(permissions) != len(uniqueStrings(names)) {
+		return nil, ErrUnknownPermission
+	}
+	return permissions, nil
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,1012 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"iq-go/internal/models"
+	"iq-go/internal/scim"
+	"iq-go/internal/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSCIMNotFound   = errors.New("SCIM resource not found")
+	ErrSCIMUniqueness = errors.New("userName is already taken")
+	ErrSCIMInvalid    = errors.New("invalid SCIM value")
+	ErrSCIMFilter     = errors.New("invalid SCIM filter")
+	ErrSCIMPath       = errors.New("invalid SCIM path")
+	ErrSCIMMutability = errors.New("SCIM attribute cannot be changed")
+)
+
+const (
+	SCIMDefaultPageSize = 100
+	SCIMMaxPageSize     = 200
+)
+
+type SCIMService struct {
+	db *gorm.DB
+}
+
+func NewSCIMService(db *gorm.DB) *SCIMService {
+	return &SCIMService{db: db}
+}
+
+// ProvisionedUser is a user as the identity provider sees it. Groups are the
+// user's roles that are offered as SCIM groups.
+type ProvisionedUser struct {
+	User       models.User
+	ExternalID string
+	Groups     []models.Role
+}
+
+// ProvisionedGroup is a role with its members in one organization.
+type ProvisionedGroup struct {
+	Role    models.Role
+	Members []models.User
+}
+
+// scimUserState holds the attributes of a user that SCIM can change, so a PATCH
+// can apply all of its operations before anything is saved.
+type scimUserState struct {
+	Email      string
+	GivenName  string
+	FamilyName string
+	ExternalID string
+	Active     bool
+	Password   string
+}
+
+// ListUsers returns a page of the organization's users matching the filter, and
+// the number of matches. Deactivated users are included; deleted ones are not.
+func (s *SCIMService) ListUsers(orgID uint, filter string, startIndex, count int) ([]ProvisionedUser, int64, error) {
+	query := func() *gorm.DB { return s.userQuery(orgID) }
+	if filter != "" {
+		condition, args, err := scimCondition(filter, scimUserColumns)
+		if err != nil {
+			return nil, 0, err
+		}
+		base := query
+		query = func() *gorm.DB { return base().Where(condition, args...) }
+	}
+
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if count == 0 {
+		return nil, total, nil
+	}
+
+	var ids []uint
+	err := query().Order("users.id").Offset(startIndex-1).Limit(count).Pluck("users.id", &ids).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	users, err := s.loadUsers(ids)
+	return users, total, err
+}
+
+func (s *SCIMService) GetUser(orgID, userID uint) (*ProvisionedUser, error) {
+	var count int64
+	if err := s.userQuery(orgID).Where("users.id = ?", userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrSCIMNotFound
+	}
+	users, err := s.loadUsers([]uint{userID})
+	if err != nil {
+		return nil, err
+	}
+	return &users[0], nil
+}
+
+// CreateUser provisions an account. New accounts are candidates until the provider
+// adds them to other groups. A user the provider deleted earlier is restored with
+// its results rather than created again.
+func (s *SCIMService) CreateUser(orgID uint, resource scim.User) (*ProvisionedUser, error) {
+	state := scimUserStateOf(resource)
+
+	var userID uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		var link models.SCIMUser
+		err := tx.Unscoped().Where("email = ?", strings.ToLower(strings.TrimSpace(state.Email))).First(&user).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			var role models.Role
+			if err := tx.Where("name = ?", models.RoleCandidate).First(&role).Error; err != nil {
+				return err
+			}
+			user = models.User{OrganizationID: orgID, Roles: []models.Role{role}}
+			if state.Password == "" {
+				if user.Password, err = unusablePassword(); err != nil {
+					return err
+				}
+			}
+		case err != nil:
+			return err
+		default:
+			err := tx.Where("user_id = ? AND removed_at IS NOT NULL", user.ID).First(&link).Error
+			if user.OrganizationID != orgID || errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSCIMUniqueness
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		link.RemovedAt = nil
+		if err := saveSCIMUser(tx, orgID, &user, &link, state); err != nil {
+			return err
+		}
+		userID = user.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetUser(orgID, userID)
+}
+
+// ReplaceUser sets every attribute of a user from the resource; attributes left
+// out are cleared, and a user without active is active.
+func (s *SCIMService) ReplaceUser(orgID, userID uint, resource scim.User) (*ProvisionedUser, error) {
+	err := s.updateUser(orgID, userID, func(state *scimUserState) error {
+		*state = scimUserStateOf(resource)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetUser(orgID, userID)
+}
+
+// PatchUser applies the operations of a PATCH request in order. Attributes the
+// application does not store are ignored.
+func (s *SCIMService) PatchUser(orgID, userID uint, operations []scim.PatchOperation) (*ProvisionedUser, error) {
+	err := s.updateUser(orgID, userID, func(state *scimUserState) error {
+		for _, operation := range operations {
+			if err := applySCIMUserOperation(state, operation); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetUser(orgID, userID)
+}
+
+// DeleteUser deprovisions a user. The account is soft-deleted so its results are
+// kept, and SCIM stops returning it.
+func (s *SCIMService) DeleteUser(orgID, userID uint) error {
+	user, link, err := s.findUser(s.db, orgID, userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if !user.DeletedAt.Valid {
+			if err := tx.Delete(user).Error; err != nil {
+				return err
+			}
+		}
+		if err := revokeTokens(tx, "id = ?", user.ID); err != nil {
+			return err
+		}
+		link.OrganizationID = orgID
+		link.UserID = user.ID
+		link.RemovedAt = &now
+		return tx.Save(link).Error
+	})
+}
+
+// ListGroups returns a page of the roles offered as groups, with their members in
+// the organization when withMembers is set.
+func (s *SCIMService) ListGroups(orgID uint, filter string, startIndex, count int, withMembers bool) ([]ProvisionedGroup, int64, error) {
+	query := s.groupQuery
+	if filter != "" {
+		condition, args, err := scimCondition(filter, scimGroupColumns(orgID))
+		if err != nil {
+			return nil, 0, err
+		}
+		query = func() *gorm.DB { return s.groupQuery().Where(condition, args...) }
+	}
+
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if count == 0 {
+		return nil, total, nil
+	}
+
+	var roles []models.Role
+	if err := query().Order("roles.id").Offset(startIndex - 1).Limit(count).Find(&roles).Error; err != nil {
+		return nil, 0, err
+	}
+	groups, err := s.withMembers(orgID, roles, withMembers)
+	return groups, total, err
+}
+
+func (s *SCIMService) GetGroup(orgID, roleID uint, withMembers bool) (*ProvisionedGroup, error) {
+	var role models.Role
+	err := s.groupQuery().First(&role, roleID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSCIMNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	groups, err := s.withMembers(orgID, []models.Role{role}, withMembers)
+	if err != nil {
+		return nil, err
+	}
+	return &groups[0], nil
+}
+
+// ReplaceGroup sets the organization's members of a group. Groups are the
+// application's roles, shared by every organization, so they cannot be renamed.
+func (s *SCIMService) ReplaceGroup(orgID, roleID uint, resource scim.Group) (*ProvisionedGroup, error) {
+	group, err := s.GetGroup(orgID, roleID, false)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(resource.DisplayName, group.Role.Name) {
+		return nil, fmt.Errorf("%w: groups cannot be renamed", ErrSCIMMutability)
+	}
+
+	members, err := referenceIDs(resource.Members)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setMembers(orgID, roleID, func(map[uint]bool) (map[uint]bool, error) { return members, nil }); err != nil {
+		return nil, err
+	}
+	return s.GetGroup(orgID, roleID, true)
+}
+
+// PatchGroup adds and removes members of a group in the organization.
+func (s *SCIMService) PatchGroup(orgID, roleID uint, operations []scim.PatchOperation) (*ProvisionedGroup, error) {
+	group, err := s.GetGroup(orgID, roleID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.setMembers(orgID, roleID, func(members map[uint]bool) (map[uint]bool, error) {
+		for _, operation := range operations {
+			if err := applySCIMGroupOperation(&group.Role, members, operation); err != nil {
+				return nil, err
+			}
+		}
+		return members, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetGroup(orgID, roleID, true)
+}
+
+// userQuery selects the organization's users that have not been deleted through
+// SCIM, including deactivated ones. Holders of a global permission are left out.
+func (s *SCIMService) userQuery(orgID uint) *gorm.DB {
+	return provisionedUsers(s.db, orgID)
+}
+
+func provisionedUsers(tx *gorm.DB, orgID uint) *gorm.DB {
+	return tx.Unscoped().Model(&models.User{}).
+		Joins("LEFT JOIN scim_users ON scim_users.user_id = users.id").
+		Where("users.organization_id = ? AND scim_users.removed_at IS NULL", orgID).
+		Where("users.id NOT IN (?)", globalUsers(tx))
+}
+
+// groupQuery selects the roles that grant no global permission.
+func (s *SCIMService) groupQuery() *gorm.DB {
+	return s.db.Model(&models.Role{}).Where("roles.id NOT IN (?)", globalRoles(s.db))
+}
+
+// globalRoles selects the IDs of the roles that grant a global permission.
+func globalRoles(tx *gorm.DB) *gorm.DB {
+	return tx.Table("role_permissions").
+		Select("role_permissions.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("permissions.name IN ?", models.GlobalPermissions)
+}
+
+// globalUsers selects the IDs of the users holding a role that grants a global
+// permission. SCIM neither returns nor changes them, so an organization's
+// identity provider cannot take over a platform admin's account.
+func globalUsers(tx *gorm.DB) *gorm.DB {
+	return tx.Table("user_roles").
+		Select("user_roles.user_id").
+		Where("user_roles.role_id IN (?)", globalRoles(tx))
+}
+
+// isStaff reports whether any of the user's roles grants more than a candidate's
+// permissions.
+func isStaff(tx *gorm.DB, userID uint) (bool, error) {
+	var count int64
+	err := tx.Table("user_roles").
+		Joins("JOIN role_permissions ON role_permissions.role_id = user_roles.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("user_roles.user_id = ? AND permissions.name NOT IN ?", userID, models.DefaultRolePermissions[models.RoleCandidate]).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (s *SCIMService) loadUsers(ids []uint) ([]ProvisionedUser, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var users []models.User
+	err := s.db.Unscoped().
+		Preload("Roles", "roles.deleted_at IS NULL").
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var links []models.SCIMUser
+	if err := s.db.Where("user_id IN ?", ids).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	externalIDs := make(map[uint]string, len(links))
+	for _, link := range links {
+		externalIDs[link.UserID] = link.ExternalID
+	}
+
+	var groupIDs []uint
+	if err := s.groupQuery().Pluck("roles.id", &groupIDs).Error; err != nil {
+		return nil, err
+	}
+	isGroup := make(map[uint]bool, len(groupIDs))
+	for _, id := range groupIDs {
+		isGroup[id] = true
+	}
+
+	provisioned := make([]ProvisionedUser, len(users))
+	for i, user := range users {
+		provisioned[i] = ProvisionedUser{User: user, ExternalID: externalIDs[user.ID]}
+		for _, role := range user.Roles {
+			if isGroup[role.ID] {
+				provisioned[i].Groups = append(provisioned[i].Groups, role)
+			}
+		}
+	}
+	return provisioned, nil
+}
+
+func (s *SCIMService) findUser(tx *gorm.DB, orgID, userID uint) (*models.User, *models.SCIMUser, error) {
+	var user models.User
+	err := tx.Unscoped().Scopes(inOrganization(orgID)).
+		Where("users.id NOT IN (?)", globalUsers(tx)).
+		First(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrSCIMNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var link models.SCIMUser
+	if err := tx.Where("user_id = ?", userID).Limit(1).Find(&link).Error; err != nil {
+		return nil, nil, err
+	}
+	if link.RemovedAt != nil {
+		return nil, nil, ErrSCIMNotFound
+	}
+	return &user, &link, nil
+}
+
+func (s *SCIMService) updateUser(orgID, userID uint, change func(*scimUserState) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		user, link, err := s.findUser(tx, orgID, userID)
+		if err != nil {
+			return err
+		}
+
+		state := scimUserState{
+			Email:      user.Email,
+			GivenName:  user.FirstName,
+			FamilyName: user.LastName,
+			ExternalID: link.ExternalID,
+			Active:     !user.DeletedAt.Valid,
+		}
+		if err := change(&state); err != nil {
+			return err
+		}
+		return saveSCIMUser(tx, orgID, user, link, state)
+	})
+}
+
+// saveSCIMUser writes the state to the user and its SCIM link, creating either when
+// it is new. Deactivation soft-deletes the account, which blocks sign-in, and
+// revokes its tokens. The password of a staff account cannot be set, so the
+// provider cannot sign in as someone with access to other candidates' data.
+func saveSCIMUser(tx *gorm.DB, orgID uint, user *models.User, link *models.SCIMUser, state scimUserState) error {
+	email := strings.ToLower(strings.TrimSpace(state.Email))
+	if !strings.Contains(email, "@") {
+		return fmt.Errorf("%w: userName must be an email address", ErrSCIMInvalid)
+	}
+	if email != user.Email {
+		var taken int64
+		err := tx.Unscoped().Model(&models.User{}).Where("email = ? AND id <> ?", email, user.ID).Count(&taken).Error
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrSCIMUniqueness
+		}
+	}
+
+	user.Email = email
+	user.FirstName = state.GivenName
+	user.LastName = state.FamilyName
+	if user.FirstName == "" && user.LastName == "" {
+		user.FirstName = email
+	}
+	if state.Password != "" && user.ID != 0 {
+		staff, err := isStaff(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		if staff {
+			return fmt.Errorf("%w: password cannot be set for staff accounts", ErrSCIMMutability)
+		}
+	}
+	if state.Password != "" {
+		hashed, err := utils.HashPassword(state.Password)
+		if err != nil {
+			return err
+		}
+		user.Password = hashed
+	}
+	switch {
+	case state.Active:
+		user.DeletedAt = gorm.DeletedAt{}
+	case !user.DeletedAt.Valid:
+		user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		user.TokenVersion++
+	}
+
+	if user.ID == 0 {
+		if err := tx.Omit("Roles.*").Create(user).Error; err != nil {
+			return err
+		}
+	} else if err := tx.Unscoped().Omit(clause.Associations).Save(user).Error; err != nil {
+		return err
+	}
+
+	link.OrganizationID = orgID
+	link.UserID = user.ID
+	link.ExternalID = state.ExternalID
+	return tx.Save(link).Error
+}
+
+func scimUserStateOf(resource scim.User) scimUserState {
+	state := scimUserState{
+		Email:      resource.UserName,
+		ExternalID: resource.ExternalID,
+		Active:     resource.Active == nil || *resource.Active,
+		Password:   resource.Password,
+	}
+	if resource.Name != nil {
+		state.GivenName = resource.Name.GivenName
+		state.FamilyName = resource.Name.FamilyName
+		if state.GivenName == "" && state.FamilyName == "" {
+			state.GivenName, state.FamilyName, _ = strings.Cut(resource.Name.Formatted, " ")
+		}
+	}
+	if state.GivenName == "" && state.FamilyName == "" {
+		state.GivenName, state.FamilyName, _ = strings.Cut(resource.DisplayName, " ")
+	}
+	return state
+}
+
+func applySCIMUserOperation(state *scimUserState, operation scim.PatchOperation) error {
+	op := strings.ToLower(operation.Op)
+	if op != "add" && op != "replace" && op != "remove" {
+		return fmt.Errorf("%w: unknown operation %q", ErrSCIMInvalid, operation.Op)
+	}
+
+	if operation.Path == "" {
+		if op == "remove" {
+			return fmt.Errorf("%w: remove needs a path", ErrSCIMPath)
+		}
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(operation.Value, &attributes); err != nil {
+			return fmt.Errorf("%w: value must be an object when there is no path", ErrSCIMInvalid)
+		}
+		for name, value := range attributes {
+			path, err := scim.ParsePath(name)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrSCIMPath, err)
+			}
+			if err := setSCIMUserAttribute(state, path.Key(), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path, err := scim.ParsePath(operation.Path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSCIMPath, err)
+	}
+	if op == "remove" {
+		switch path.Key() {
+		case "username", "emails", "emails.value", "active":
+			return fmt.Errorf("%w: %s cannot be removed", ErrSCIMMutability, operation.Path)
+		case "name":
+			state.GivenName, state.FamilyName = "", ""
+		case "name.givenname":
+			state.GivenName = ""
+		case "name.familyname":
+			state.FamilyName = ""
+		case "externalid":
+			state.ExternalID = ""
+		}
+		return nil
+	}
+	return setSCIMUserAttribute(state, path.Key(), operation.Value)
+}
+
+func setSCIMUserAttribute(state *scimUserState, key string, value json.RawMessage) error {
+	invalid := fmt.Errorf("%w: invalid value for %s", ErrSCIMInvalid, key)
+	var ok bool
+	switch key {
+	case "username", "emails.value":
+		state.Email, ok = scim.String(value)
+	case "emails":
+		var emails []scim.Email
+		ok = json.Unmarshal(value, &emails) == nil && len(emails) > 0
+		for i, email := range emails {
+			if email.Primary || i == 0 {
+				state.Email = email.Value
+			}
+		}
+	case "name":
+		var name scim.Name
+		ok = json.Unmarshal(value, &name) == nil
+		state.GivenName, state.FamilyName = name.GivenName, name.FamilyName
+	case "name.givenname":
+		state.GivenName, ok = scim.String(value)
+	case "name.familyname":
+		state.FamilyName, ok = scim.String(value)
+	case "externalid":
+		state.ExternalID, ok = scim.String(value)
+	case "active":
+		state.Active, ok = scim.Bool(value)
+	case "password":
+		state.Password, ok = scim.String(value)
+	case "id", "groups", "meta", "schemas":
+		return fmt.Errorf("%w: %s is read-only", ErrSCIMMutability, key)
+	default:
+		// Attributes the application does not store, such as phone numbers.
+		return nil
+	}
+	if !ok {
+		return invalid
+	}
+	return nil
+}
+
+func applySCIMGroupOperation(role *models.Role, members map[uint]bool, operation scim.PatchOperation) error {
+	op := strings.ToLower(operation.Op)
+	if op != "add" && op != "replace" && op != "remove" {
+		return fmt.Errorf("%w: unknown operation %q", ErrSCIMInvalid, operation.Op)
+	}
+
+	if operation.Path == "" {
+		if op == "remove" {
+			return fmt.Errorf("%w: remove needs a path", ErrSCIMPath)
+		}
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(operation.Value, &attributes); err != nil {
+			return fmt.Errorf("%w: value must be an object when there is no path", ErrSCIMInvalid)
+		}
+		for name, value := range attributes {
+			if err := applySCIMGroupOperation(role, members, scim.PatchOperation{Op: op, Path: name, Value: value}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path, err := scim.ParsePath(operation.Path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSCIMPath, err)
+	}
+	switch path.Attr {
+	case "displayname":
+		name, ok := scim.String(operation.Value)
+		if op == "remove" || !ok || !strings.EqualFold(name, role.Name) {
+			return fmt.Errorf("%w: groups cannot be renamed", ErrSCIMMutability)
+		}
+		return nil
+	case "members":
+	default:
+		return nil
+	}
+
+	if path.Filter != nil {
+		// members[value eq "12"] names the members to remove.
+		if op != "remove" {
+			return fmt.Errorf("%w: filtered member paths can only be removed", ErrSCIMPath)
+		}
+		ids, err := memberFilterIDs(path.Filter)
+		if err != nil {
+			return err
+		}
+		for id := range ids {
+			delete(members, id)
+		}
+		return nil
+	}
+
+	var ids map[uint]bool
+	if len(operation.Value) > 0 && string(operation.Value) != "null" {
+		var references []scim.Reference
+		if err := json.Unmarshal(operation.Value, &references); err != nil {
+			return fmt.Errorf("%w: members must be a list of references", ErrSCIMInvalid)
+		}
+		if ids, err = referenceIDs(references); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case op == "replace" || (op == "remove" && ids == nil):
+		for id := range members {
+			delete(members, id)
+		}
+		if op == "remove" {
+			return nil
+		}
+		fallthrough
+	case op == "add":
+		for id := range ids {
+			members[id] = true
+		}
+	default: // remove the listed members
+		for id := range ids {
+			delete(members, id)
+		}
+	}
+	return nil
+}
+
+// memberFilterIDs reads the member IDs of a filter like value eq "1" or value eq "2".
+func memberFilterIDs(expr scim.Expr) (map[uint]bool, error) {
+	switch e := expr.(type) {
+	case scim.Comparison:
+		value, ok := e.Value.(string)
+		if e.Attr != "value" || e.Op != "eq" || !ok {
+			break
+		}
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid member %q", ErrSCIMInvalid, value)
+		}
+		return map[uint]bool{uint(id): true}, nil
+	case scim.Or:
+		left, err := memberFilterIDs(e.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := memberFilterIDs(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		for id := range right {
+			left[id] = true
+		}
+		return left, nil
+	}
+	return nil, fmt.Errorf("%w: only members[value eq \"id\"] is supported", ErrSCIMPath)
+}
+
+func referenceIDs(references []scim.Reference) (map[uint]bool, error) {
+	ids := make(map[uint]bool, len(references))
+	for _, reference := range references {
+		id, err := strconv.ParseUint(reference.Value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid member %q", ErrSCIMInvalid, reference.Value)
+		}
+		ids[uint(id)] = true
+	}
+	return ids, nil
+}
+
+func (s *SCIMService) withMembers(orgID uint, roles []models.Role, withMembers bool) ([]ProvisionedGroup, error) {
+	groups := make([]ProvisionedGroup, len(roles))
+	index := make(map[uint]int, len(roles))
+	ids := make([]uint, len(roles))
+	for i, role := range roles {
+		groups[i] = ProvisionedGroup{Role: role}
+		index[role.ID] = i
+		ids[i] = role.ID
+	}
+	if !withMembers || len(roles) == 0 {
+		return groups, nil
+	}
+
+	var members []struct {
+		models.User
+		RoleID uint
+	}
+	err := s.userQuery(orgID).
+		Select("users.*, user_roles.role_id").
+		Joins("JOIN user_roles ON user_roles.user_id = users.id").
+		Where("user_roles.role_id IN ?", ids).
+		Order("users.id").
+		Scan(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		i := index[member.RoleID]
+		groups[i].Members = append(groups[i].Members, member.User)
+	}
+	return groups, nil
+}
+
+// setMembers replaces the organization's members of a role with the set change
+// returns. Members of other organizations and holders of a global permission are
+// never touched.
+func (s *SCIMService) setMembers(orgID, roleID uint, change func(map[uint]bool) (map[uint]bool, error)) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var current []uint
+		err := tx.Table("user_roles").
+			Joins("JOIN users ON users.id = user_roles.user_id").
+			Where("user_roles.role_id = ? AND users.organization_id = ?", roleID, orgID).
+			Where("users.id NOT IN (?)", globalUsers(tx)).
+			Pluck("user_roles.user_id", &current).Error
+		if err != nil {
+			return err
+		}
+		existing := make(map[uint]bool, len(current))
+		members := make(map[uint]bool, len(current))
+		for _, id := range current {
+			existing[id] = true
+			members[id] = true
+		}
+
+		target, err := change(members)
+		if err != nil {
+			return err
+		}
+
+		var added, removed []uint
+		for id := range target {
+			if !existing[id] {
+				added = append(added, id)
+			}
+		}
+		for _, id := range current {
+			if !target[id] {
+				removed = append(removed, id)
+			}
+		}
+
+		if len(added) > 0 {
+			var known int64
+			if err := provisionedUsers(tx, orgID).Where("users.id IN ?", added).Count(&known).Error; err != nil {
+				return err
+			}
+			if int(known) != len(added) {
+				return fmt.Errorf("%w: members must be users of the organization", ErrSCIMInvalid)
+			}
+			rows := make([]map[string]interface{}, len(added))
+			for i, id := range added {
+				rows[i] = map[string]interface{}{"user_id": id, "role_id": roleID}
+			}
+			if err := tx.Table("user_roles").Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+				return err
+			}
+		}
+		if len(removed) > 0 {
+			if err := tx.Exec("DELETE FROM user_roles WHERE role_id = ? AND user_id IN ?", roleID, removed).Error; err != nil {
+				return err
+			}
+			return revokeTokens(tx, "id IN ?", removed)
+		}
+		return nil
+	})
+}
+
+type scimKind int
+
+const (
+	scimString scimKind = iota
+	scimNumber
+	scimBool
+	scimTime
+	scimMembers
+)
+
+// scimColumn is the SQL a filter attribute is compared against.
+type scimColumn struct {
+	sql  string
+	kind scimKind
+	args []interface{}
+}
+
+var scimUserColumns = map[string]scimColumn{
+	"id":                {sql: "users.id", kind: scimNumber},
+	"externalid":        {sql: "scim_users.external_id"},
+	"username":          {sql: "users.email"},
+	"emails":            {sql: "users.email"},
+	"emails.value":      {sql: "users.email"},
+	"name.givenname":    {sql: "users.first_name"},
+	"name.familyname":   {sql: "users.last_name"},
+	"displayname":       {sql: "users.first_name || ' ' || users.last_name"},
+	"active":            {sql: "(users.deleted_at IS NULL)", kind: scimBool},
+	"meta.created":      {sql: "users.created_at", kind: scimTime},
+	"meta.lastmodified": {sql: "users.updated_at", kind: scimTime},
+}
+
+func scimGroupColumns(orgID uint) map[string]scimColumn {
+	members := scimColumn{
+		sql: `EXISTS (SELECT 1 FROM user_roles JOIN users ON users.id = user_roles.user_id
+			WHERE user_roles.role_id = roles.id AND users.organization_id = ?`,
+		kind: scimMembers,
+		args: []interface{}{orgID},
+	}
+	return map[string]scimColumn{
+		"id":                {sql: "roles.id", kind: scimNumber},
+		"displayname":       {sql: "roles.name"},
+		"members":           members,
+		"members.value":     members,
+		"meta.created":      {sql: "roles.created_at", kind: scimTime},
+		"meta.lastmodified": {sql: "roles.updated_at", kind: scimTime},
+	}
+}
+
+// scimCondition translates a filter into a SQL condition over the columns.
+// Strings are compared case-insensitively, as the attributes offered are not
+// case-exact.
+func scimCondition(filter string, columns map[string]scimColumn) (string, []interface{}, error) {
+	expr, err := scim.ParseFilter(filter)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrSCIMFilter, err)
+	}
+	return scimExpr(expr, columns)
+}
+
+func scimExpr(expr scim.Expr, columns map[string]scimColumn) (string, []interface{}, error) {
+	switch e := expr.(type) {
+	case scim.And, scim.Or:
+		var left, right scim.Expr
+		operator := "AND"
+		if and, ok := e.(scim.And); ok {
+			left, right = and.Left, and.Right
+		} else {
+			or := e.(scim.Or)
+			left, right, operator = or.Left, or.Right, "OR"
+		}
+		leftSQL, leftArgs, err := scimExpr(left, columns)
+		if err != nil {
+			return "", nil, err
+		}
+		rightSQL, rightArgs, err := scimExpr(right, columns)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(" + leftSQL + ") " + operator + " (" + rightSQL + ")", append(leftArgs, rightArgs...), nil
+	case scim.Not:
+		sql, args, err := scimExpr(e.X, columns)
+		return "NOT (" + sql + ")", args, err
+	case scim.Comparison:
+		return scimComparison(e, columns)
+	}
+	return "", nil, fmt.Errorf("%w: unsupported expression", ErrSCIMFilter)
+}
+
+func scimComparison(c scim.Comparison, columns map[string]scimColumn) (string, []interface{}, error) {
+	column, ok := columns[c.Attr]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: filtering on %s is not supported", ErrSCIMFilter, c.Attr)
+	}
+	args := append([]interface{}{}, column.args...)
+	unsupported := fmt.Errorf("%w: %s cannot be compared with %s", ErrSCIMFilter, c.Attr, c.Op)
+
+	if column.kind == scimMembers {
+		switch {
+		case c.Op == "pr":
+			return column.sql + ")", args, nil
+		case c.Op == "eq":
+			id, err := scimID(c.Value)
+			if err != nil {
+				return "", nil, err
+			}
+			return column.sql + " AND users.id = ?)", append(args, id), nil
+		}
+		return "", nil, unsupported
+	}
+
+	if c.Op == "pr" {
+		if column.kind == scimString {
+			return "(" + column.sql + " IS NOT NULL AND " + column.sql + " <> '')", args, nil
+		}
+		return column.sql + " IS NOT NULL", args, nil
+	}
+	if c.Value == nil {
+		switch c.Op {
+		case "eq":
+			return column.sql + " IS NULL", args, nil
+		case "ne":
+			return column.sql + " IS NOT NULL", args, nil
+		}
+		return "", nil, unsupported
+	}
+
+	comparisons := map[string]string{"eq": "=", "ne": "<>", "gt": ">", "ge": ">=", "lt": "<", "le": "<="}
+	switch column.kind {
+	case scimString:
+		value, ok := c.Value.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("%w: %s must be compared with a string", ErrSCIMFilter, c.Attr)
+		}
+		value = strings.ToLower(value)
+		pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+		switch c.Op {
+		case "co":
+			return "LOWER(" + column.sql + ") LIKE ?", append(args, "%"+pattern+"%"), nil
+		case "sw":
+			return "LOWER(" + column.sql + ") LIKE ?", append(args, pattern+"%"), nil
+		case "ew":
+			return "LOWER(" + column.sql + ") LIKE ?", append(args, "%"+pattern), nil
+		}
+		return "LOWER(" + column.sql + ") " + comparisons[c.Op] + " ?", append(args, value), nil
+	case scimNumber:
+		id, err := scimID(c.Value)
+		if err != nil || comparisons[c.Op] == "" {
+			return "", nil, unsupported
+		}
+		return column.sql + " " + comparisons[c.Op] + " ?", append(args, id), nil
+	case scimBool:
+		value, ok := c.Value.(bool)
+		if !ok || (c.Op != "eq" && c.Op != "ne") {
+			return "", nil, unsupported
+		}
+		return column.sql + " " + comparisons[c.Op] + " ?", append(args, value), nil
+	case scimTime:
+		raw, _ := c.Value.(string)
+		value, err := time.Parse(time.RFC3339, raw)
+		if err != nil || comparisons[c.Op] == "" {
+			return "", nil, unsupported
+		}
+		return column.sql + " " + comparisons[c.Op] + " ?", append(args, value), nil
+	}
+	return "", nil, unsupported
+}
+
+// scimID reads a resource ID, which SCIM sends as a string.
+func scimID(value interface{}) (uint, error) {
+	switch v := value.(type) {
+	case string:
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err == nil {
+			return uint(id), nil
+		}
+	case float64:
+		if v >= 0 && v == float64(uint(v)) {
+			return uint(v), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: invalid id %v", ErrSCIMFilter, value)
+}
@@ -0,0 +1,442 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"iq-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionState    = errors.New("session cannot do that in its current state")
+	ErrSessionUntimed  = errors.New("test has no duration")
+	ErrNotCandidate    = errors.New("user is not a candidate in this session")
+	ErrSessionOver     = errors.New("session time is over")
+	ErrInvalidSession  = errors.New("invalid session")
+	ErrSessionRequired = errors.New("test is taken in a proctored session")
+	ErrSessionPaused   = errors.New("session is paused")
+)
+
+// SessionRequest schedules a test for a group of candidates.
+type SessionRequest struct {
+	TestID      uint
+	Name        string
+	ScheduledAt time.Time
+	UserIDs     []uint
+}
+
+// SessionLobby is what a candidate sees of a session: whether it has started and
+// when their attempt is due.
+type SessionLobby struct {
+	SessionID   uint                 `json:"session_id"`
+	TestID      uint                 `json:"test_id"`
+	Name        string               `json:"name"`
+	Status      models.SessionStatus `json:"status"`
+	ScheduledAt time.Time            `json:"scheduled_at"`
+	StartedAt   *time.Time           `json:"started_at,omitempty"`
+	Deadline    *time.Time           `json:"deadline,omitempty"`
+	AttemptID   *uint                `json:"attempt_id,omitempty"`
+	Terminated  bool                 `json:"terminated"`
+}
+
+// CreateSession schedules a session for users of the organization. The test must
+// have a duration, which becomes the session's common time limit; accommodated
+// candidates get the extra time their multiplier gives on top of it.
+func (s *TestService) CreateSession(orgID, proctorID uint, req SessionRequest) (*models.Session, error) {
+	test, err := s.GetTestByID(orgID, req.TestID)
+	if err != nil {
+		return nil, err
+	}
+	if test.Duration <= 0 {
+		return nil, ErrSessionUntimed
+	}
+
+	userIDs := make([]uint, 0, len(req.UserIDs))
+	seen := make(map[uint]bool, len(req.UserIDs))
+	for _, id := range req.UserIDs {
+		if !seen[id] {
+			seen[id] = true
+			userIDs = append(userIDs, id)
+		}
+	}
+	var found int64
+	if err := s.db.Model(&models.User{}).Scopes(inOrganization(orgID)).Where("id IN ?", userIDs).Count(&found).Error; err != nil {
+		return nil, err
+	}
+	if int(found) != len(userIDs) {
+		return nil, fmt.Errorf("%w: every candidate must be a user of the organization", ErrInvalidSession)
+	}
+
+	session := &models.Session{
+		OrganizationID: orgID,
+		TestID:         test.ID,
+		ProctorID:      proctorID,
+		Name:           req.Name,
+		Status:         models.SessionScheduled,
+		ScheduledAt:    req.ScheduledAt,
+	}
+	for _, id := range userIDs {
+		accommodation, err := activeAccommodation(s.db, orgID, test.ID, id)
+		if err != nil {
+			return nil, err
+		}
+		session.Candidates = append(session.Candidates, models.SessionCandidate{
+			UserID:               id,
+			AccommodationMinutes: accommodatedMinutes(test.Duration, timeMultiplier(accommodation)),
+		})
+	}
+	if err := s.db.Create(session).Error; err != nil {
+		return nil, err
+	}
+	return s.GetSession(orgID, session.ID)
+}
+
+func (s *TestService) ListSessions(orgID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := s.db.Scopes(inOrganization(orgID)).Order("scheduled_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// GetSession returns a session with its candidates and their attempts.
+func (s *TestService) GetSession(orgID, sessionID uint) (*models.Session, error) {
+	var session models.Session
+	err := s.db.Scopes(inOrganization(orgID)).
+		Preload("Test").
+		Preload("Candidates", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Candidates.User").
+		Preload("Candidates.TestResult").
+		First(&session, sessionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	return &session, err
+}
+
+// StartSession starts a scheduled session for everyone at once. The common
+// deadline is the test's duration from now.
+func (s *TestService) StartSession(orgID, sessionID uint) (*models.Session, error) {
+	return s.changeSession(orgID, sessionID, func(tx *gorm.DB, session *models.Session, now time.Time) error {
+		if session.Status != models.SessionScheduled {
+			return ErrSessionState
+		}
+		endsAt := now.Add(time.Duration(session.Test.Duration) * time.Minute)
+		session.Status = models.SessionRunning
+		session.StartedAt = &now
+		session.EndsAt = &endsAt
+		return nil
+	})
+}
+
+// PauseSession stops the clock: attempts keep the time they have left, and no
+// attempt can be started, submitted or reported on until the session is resumed.
+func (s *TestService) PauseSession(orgID, sessionID uint) (*models.Session, error) {
+	return s.changeSession(orgID, sessionID, func(tx *gorm.DB, session *models.Session, now time.Time) error {
+		if session.Status != models.SessionRunning {
+			return ErrSessionState
+		}
+		session.Status = models.SessionPaused
+		session.PausedAt = &now
+		return nil
+	})
+}
+
+// ResumeSession restarts the clock, moving the deadline back by the length of the
+// pause.
+func (s *TestService) ResumeSession(orgID, sessionID uint) (*models.Session, error) {
+	return s.changeSession(orgID, sessionID, func(tx *gorm.DB, session *models.Session, now time.Time) error {
+		if session.Status != models.SessionPaused {
+			return ErrSessionState
+		}
+		endsAt := session.EndsAt.Add(now.Sub(*session.PausedAt))
+		session.Status = models.SessionRunning
+		session.EndsAt = &endsAt
+		session.PausedAt = nil
+		return nil
+	})
+}
+
+// EndSession closes the session. Attempts still in progress are terminated.
+func (s *TestService) EndSession(orgID, sessionID uint) (*models.Session, error) {
+	return s.changeSession(orgID, sessionID, func(tx *gorm.DB, session *models.Session, now time.Time) error {
+		if session.Status == models.SessionEnded {
+			return ErrSessionState
+		}
+		session.Status = models.SessionEnded
+		session.EndedAt = &now
+		for i := range session.Candidates {
+			candidate := &session.Candidates[i]
+			if candidate.TestResultID == nil || candidate.TerminatedAt != nil {
+				continue
+			}
+			var open int64
+			err := tx.Model(&models.TestResult{}).Where("id = ? AND completed_at IS NULL", *candidate.TestResultID).Count(&open).Error
+			if err != nil {
+				return err
+			}
+			if open > 0 {
+				if err := s.terminateCandidate(tx, session, candidate, nil, "Session ended", now); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// ExtendTime gives one candidate extra minutes on top of the common deadline and
+// any accommodation, for example after a technical problem.
+func (s *TestService) ExtendTime(orgID, sessionID, userID uint, minutes int) (*models.Session, error) {
+	return s.changeSession(orgID, sessionID, func(tx *gorm.DB, session *models.Session, now time.Time) error {
+		if session.Status == models.SessionEnded {
+			return ErrSessionState
+		}
+		candidate := session.Candidate(userID)
+		if candidate == nil {
+			return ErrNotCandidate
+		}
+		candidate.ExtraMinutes += minutes
+		return tx.Model(candidate).Update("extra_minutes", candidate.ExtraMinutes).Error
+	})
+}
+
+// TerminateAttempt ends one candidate's part in the session. An attempt in
+// progress is closed unanswered, since answers only arrive with a submission, and
+// the candidate cannot start another.
+func (s *TestService) TerminateAttempt(orgID, sessionID, userID, proctorID uint, reason string) (*models.Session, error) {
+	return s.changeSession(orgID, sessionID, func(tx *gorm.DB, session *models.Session, now time.Time) error {
+		if session.Status == models.SessionEnded {
+			return ErrSessionState
+		}
+		candidate := session.Candidate(userID)
+		if candidate == nil {
+			return ErrNotCandidate
+		}
+		if candidate.TerminatedAt != nil {
+			return ErrSessionState
+		}
+		return s.terminateCandidate(tx, session, candidate, &proctorID, reason, now)
+	})
+}
+
+// changeSession applies a proctor's change to a session in a transaction, then
+// brings the deadlines and pauses of attempts in progress in line with it.
+func (s *TestService) changeSession(orgID, sessionID uint, change func(tx *gorm.DB, session *models.Session, now time.Time) error) (*models.Session, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var session models.Session
+		err := tx.Scopes(inOrganization(orgID)).
+			Preload("Test").
+			Preload("Candidates").
+			First(&session, sessionID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := change(tx, &session, time.Now()); err != nil {
+			return err
+		}
+		if err := tx.Omit("Test", "Candidates").Save(&session).Error; err != nil {
+			return err
+		}
+		for i := range session.Candidates {
+			candidate := &session.Candidates[i]
+			if candidate.TestResultID == nil {
+				continue
+			}
+			err := tx.Model(&models.TestResult{}).
+				Where("id = ? AND completed_at IS NULL", *candidate.TestResultID).
+				Updates(map[string]interface{}{
+					"deadline":  session.Deadline(candidate),
+					"paused_at": session.PausedAt,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSession(orgID, sessionID)
+}
+
+// terminateCandidate completes the candidate's attempt in progress, if any, and
+// keeps them from starting another. proctorID is nil when the session ended with
+// the attempt in progress.
+func (s *TestService) terminateCandidate(tx *gorm.DB, session *models.Session, candidate *models.SessionCandidate, proctorID *uint, reason string, now time.Time) error {
+	if candidate.TestResultID != nil {
+		var attempt models.TestResult
+		if err := tx.First(&attempt, *candidate.TestResultID).Error; err != nil {
+			return err
+		}
+		if attempt.CompletedAt == nil {
+			if err := s.endAttempt(tx, &session.Test, &attempt, models.AttemptTerminated, now); err != nil {
+				return err
+			}
+		}
+	}
+	candidate.TerminatedAt = &now
+	candidate.TerminatedByID = proctorID
+	candidate.TerminationReason = reason
+	return tx.Model(candidate).Updates(map[string]interface{}{
+		"terminated_at":      candidate.TerminatedAt,
+		"terminated_by_id":   candidate.TerminatedByID,
+		"termination_reason": candidate.TerminationReason,
+	}).Error
+}
+
+// JoinSession puts a candidate in the session's lobby.
+func (s *TestService) JoinSession(orgID, sessionID, userID uint) (*SessionLobby, error) {
+	session, candidate, err := s.sessionCandidate(s.db, orgID, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if candidate.JoinedAt == nil {
+		now := time.Now()
+		candidate.JoinedAt = &now
+		if err := s.db.Model(candidate).Update("joined_at", now).Error; err != nil {
+			return nil, err
+		}
+	}
+	return sessionLobby(session, candidate), nil
+}
+
+// GetLobby returns the candidate's view of the session, for polling while waiting
+// for it to start and while taking it.
+func (s *TestService) GetLobby(orgID, sessionID, userID uint) (*SessionLobby, error) {
+	session, candidate, err := s.sessionCandidate(s.db, orgID, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return sessionLobby(session, candidate), nil
+}
+
+// StartSessionAttempt starts the candidate's attempt once the proctor has started
+// the session, or resumes it, except while the session is paused. The attempt is
+// due at the candidate's deadline in the session.
+func (s *TestService) StartSessionAttempt(orgID, sessionID, userID uint) (*models.TestResult, []models.Question, error) {
+	var attempt *models.TestResult
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		session, candidate, err := s.sessionCandidate(tx, orgID, sessionID, userID)
+		if err != nil {
+			return err
+		}
+		// Read again under a lock, so a concurrent start waits for this one and
+		// then finds its attempt instead of starting a second one.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(candidate, candidate.ID).Error; err != nil {
+			return err
+		}
+		if candidate.TerminatedAt != nil {
+			return ErrAttemptCompleted
+		}
+		if session.Status == models.SessionPaused {
+			return ErrSessionPaused
+		}
+		if candidate.TestResultID != nil {
+			attempt = &models.TestResult{}
+			if err := tx.First(attempt, *candidate.TestResultID).Error; err != nil {
+				return err
+			}
+			if attempt.CompletedAt != nil {
+				return ErrAttemptCompleted
+			}
+			return nil
+		}
+		if session.Status != models.SessionRunning {
+			return ErrSessionState
+		}
+
+		// The accommodation may have changed since the session was scheduled.
+		accommodation, err := activeAccommodation(tx, orgID, session.TestID, userID)
+		if err != nil {
+			return err
+		}
+		candidate.AccommodationMinutes = accommodatedMinutes(session.Test.Duration, timeMultiplier(accommodation))
+
+		now := time.Now()
+		deadline := session.Deadline(candidate)
+		if now.After(*deadline) {
+			return ErrSessionOver
+		}
+		attempt, err = s.startAttempt(tx, &session.Test, userID, now, deadline, &session.ID, accommodation)
+		if err != nil {
+			return err
+		}
+		updates := map[string]interface{}{
+			"test_result_id":        attempt.ID,
+			"accommodation_minutes": candidate.AccommodationMinutes,
+		}
+		if candidate.JoinedAt == nil {
+			updates["joined_at"] = now
+		}
+		return tx.Model(candidate).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	questions, err := s.servedQuestions(s.db, attempt.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	scaleQuestionTimes(questions, attempt.TimeMultiplier)
+	return attempt, questions, nil
+}
+
+// checkStandaloneStart refuses an attempt outside a session while the test has a
+// running or paused session, or when the user is a candidate in one of its
+// sessions that has not ended.
+func checkStandaloneStart(tx *gorm.DB, test *models.Test, userID uint) error {
+	var sessions int64
+	err := tx.Model(&models.Session{}).
+		Where("test_id = ? AND status <> ?", test.ID, models.SessionEnded).
+		Where("status IN ? OR EXISTS (SELECT 1 FROM session_candidates WHERE session_candidates.session_id = sessions.id AND session_candidates.user_id = ?)",
+			[]models.SessionStatus{models.SessionRunning, models.SessionPaused}, userID).
+		Count(&sessions).Error
+	if err != nil {
+		return err
+	}
+	if sessions > 0 {
+		return ErrSessionRequired
+	}
+	return nil
+}
+
+// sessionCandidate loads a session and the user's place in it. Sessions the user
+// is not a candidate in are reported as not found.
+func (s *TestService) sessionCandidate(db *gorm.DB, orgID, sessionID, userID uint) (*models.Session, *models.SessionCandidate, error) {
+	var session models.Session
+	err := db.Scopes(inOrganization(orgID)).
+		Preload("Test").
+		Preload("Candidates", "user_id = ?", userID).
+		First(&session, sessionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && len(session.Candidates) == 0 {
+		return nil, nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return &session, &session.Candidates[0], nil
+}
+
+func sessionLobby(session *models.Session, candidate *models.SessionCandidate) *SessionLobby {
+	return &SessionLobby{
+		SessionID:   session.ID,
+		TestID:      session.TestID,
+		Name:        session.Name,
+		Status:      session.Status,
+		ScheduledAt: session.ScheduledAt,
+		StartedAt:   session.StartedAt,
+		Deadline:    session.Deadline(candidate),
+		AttemptID:   candidate.TestResultID,
+		Terminated:  candidate.TerminatedAt != nil,
+	}
+}
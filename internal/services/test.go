@@ -1,12 +1,15 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"iq-go/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TestService struct {
@@ -17,90 +20,415 @@ func NewTestService(db *gorm.DB) *TestService {
 	return &TestService{db: db}
 }
 
+var (
+	ErrNoQuestions       = errors.New("test has no questions")
+	ErrAttemptNotFound   = errors.New("attempt not found")
+	ErrAttemptCompleted  = errors.New("attempt has already been submitted")
+	ErrAttemptExpired    = errors.New("attempt deadline has passed")
+	ErrInvalidSubmission = errors.New("invalid submission")
+)
+
+// deadlineGrace is how late a submission may arrive after its attempt's deadline,
+// to allow for network latency and clients that submit when time runs out.
+const deadlineGrace = 30 * time.Second
+
 type SubmitAnswerRequest struct {
 	QuestionID   uint   `json:"question_id" binding:"required"`
 	UserAnswer   string `json:"user_answer"`
 	ResponseTime int    `json:"response_time"`
 }
 
-func (s *TestService) GetQuestionsByTestID(testID uint) ([]models.Question, error) {
+// Submission is a user's answers to an attempt they started.
+type Submission struct {
+	TestID    uint
+	AttemptID uint
+	Answers   []SubmitAnswerRequest
+}
+
+func (s *TestService) ListTests(orgID uint) ([]models.Test, error) {
+	var tests []models.Test
+	err := s.db.Scopes(inOrganization(orgID)).Order("name").Find(&tests).Error
+	return tests, err
+}
+
+func (s *TestService) GetTestByID(orgID, testID uint) (*models.Test, error) {
+	var test models.Test
+	err := s.db.Scopes(inOrganization(orgID)).First(&test, testID).Error
+	return &test, err
+}
+
+func (s *TestService) CreateTest(test *models.Test) error {
+	return s.db.Create(test).Error
+}
+
+func (s *TestService) GetQuestionsByTestID(orgID, testID uint) ([]models.Question, error) {
 	var questions []models.Question
-	err := s.db.Where("test_id = ?", testID).Order("order_index").Find(&questions).Error
+	err := s.db.Scopes(testInOrganization(orgID)).
+		Where("test_id = ?", testID).
+		Order("order_index").
+		Find(&questions).Error
 	return questions, err
 }
 
-func (s *TestService) SubmitTest(userID, testID uint, answers []SubmitAnswerRequest, timeTaken int) (*models.TestResult, error) {
-	// Create test result
-	testResult := &models.TestResult{
+// GetCandidateQuestions returns the test's questions as the user is to be shown
+// them, with time limits and display times scaled by their accommodation.
+func (s *TestService) GetCandidateQuestions(orgID, userID, testID uint) ([]models.Question, error) {
+	questions, err := s.GetQuestionsByTestID(orgID, testID)
+	if err != nil {
+		return nil, err
+	}
+	accommodation, err := activeAccommodation(s.db, orgID, testID, userID)
+	if err != nil {
+		return nil, err
+	}
+	scaleQuestionTimes(questions, timeMultiplier(accommodation))
+	return questions, nil
+}
+
+// CreateQuestion adds a question to one of the organization's tests.
+func (s *TestService) CreateQuestion(orgID uint, question *models.Question) error {
+	if _, err := s.GetTestByID(orgID, question.TestID); err != nil {
+		return err
+	}
+	return s.db.Create(question).Error
+}
+
+func (s *TestService) GetQuestionByID(orgID, questionID uint) (*models.Question, error) {
+	var question models.Question
+	err := s.db.Scopes(testInOrganization(orgID)).First(&question, questionID).Error
+	return &question, err
+}
+
+// UpdateQuestion saves the question, which may only be moved between the organization's tests.
+func (s *TestService) UpdateQuestion(orgID uint, question *models.Question) error {
+	if _, err := s.GetTestByID(orgID, question.TestID); err != nil {
+		return err
+	}
+	return s.db.Save(question).Error
+}
+
+func (s *TestService) DeleteQuestion(orgID, questionID uint) error {
+	result := s.db.Scopes(testInOrganization(orgID)).Delete(&models.Question{}, questionID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// StartAttempt opens an attempt at the test and fixes the set of questions served for
+// it. An attempt that is already in progress is resumed instead of starting another;
+// one whose deadline has passed is closed first. Tests in a proctored session are
+// started through the session instead. The candidate's accommodation, if any,
+// scales the deadline and the questions' time limits.
+func (s *TestService) StartAttempt(orgID, userID, testID uint) (*models.TestResult, []models.Question, error) {
+	test, err := s.GetTestByID(orgID, testID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.expireAttempts(test, userID); err != nil {
+		return nil, nil, err
+	}
+
+	var attempt *models.TestResult
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var open models.TestResult
+		err := tx.Where("test_id = ? AND user_id = ? AND completed_at IS NULL", test.ID, userID).
+			Order("started_at DESC").
+			Limit(1).
+			Find(&open).Error
+		if err != nil {
+			return err
+		}
+		if open.PausedAt != nil {
+			return ErrSessionPaused
+		}
+		if open.ID != 0 {
+			attempt = &open
+			return nil
+		}
+
+		if err := checkStandaloneStart(tx, test, userID); err != nil {
+			return err
+		}
+		accommodation, err := activeAccommodation(tx, test.OrganizationID, test.ID, userID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		attempt, err = s.startAttempt(tx, test, userID, now, attemptDeadline(test, now, timeMultiplier(accommodation)), nil, accommodation)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	questions, err := s.servedQuestions(s.db, attempt.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	scaleQuestionTimes(questions, attempt.TimeMultiplier)
+
+	return attempt, questions, nil
+}
+
+// startAttempt creates an attempt due at deadline, which is nil for untimed
+// attempts, within a session when sessionID is set. An accommodated attempt
+// records the accommodation and its time multiplier; the caller has already
+// scaled the deadline. The user's attempts stay locked until tx ends.
+func (s *TestService) startAttempt(tx *gorm.DB, test *models.Test, userID uint, startedAt time.Time, deadline *time.Time, sessionID *uint, accommodation *models.Accommodation) (*models.TestResult, error) {
+	if err := lockAttempts(tx, userID); err != nil {
+		return nil, err
+	}
+	if err := checkAttemptPolicy(tx, test, userID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var questions []models.Question
+	if err := tx.Where("test_id = ?", test.ID).Order("order_index").Find(&questions).Error; err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, ErrNoQuestions
+	}
+
+	attempt := &models.TestResult{
+		OrganizationID: test.OrganizationID,
 		UserID:         userID,
-		TestID:         testID,
-		TimeTaken:      timeTaken,
-		StartedAt:      time.Now().Add(-time.Duration(timeTaken) * time.Second),
-		CompletedAt:    &[]time.Time{time.Now()}[0],
-		TotalQuestions: len(answers),
+		TestID:         test.ID,
+		StartedAt:      startedAt,
+		TotalQuestions: len(questions),
+		SessionID:      sessionID,
+		Deadline:       deadline,
+		TimeMultiplier: timeMultiplier(accommodation),
+	}
+	if accommodation != nil {
+		attempt.AccommodationID = &accommodation.ID
+	}
+	for i, question := range questions {
+		attempt.ServedQuestions = append(attempt.ServedQuestions, models.AttemptQuestion{
+			QuestionID: question.ID,
+			Position:   i + 1,
+		})
 	}
 
-	err := s.db.Create(testResult).Error
+	if err := tx.Create(attempt).Error; err != nil {
+		return nil, err
+	}
+
+	err := enqueueWebhookEvent(tx, attempt.OrganizationID, models.EventAttemptStarted, func() (interface{}, error) {
+		email, err := userEmail(tx, userID)
+		return AttemptEventData{
+			AttemptID: attempt.ID,
+			TestID:    attempt.TestID,
+			UserID:    userID,
+			UserEmail: email,
+			StartedAt: attempt.StartedAt,
+		}, err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return attempt, nil
+}
 
-	// Get all questions for this test
+// servedQuestions returns the questions served for an attempt, in the order they were served.
+func (s *TestService) servedQuestions(db *gorm.DB, attemptID uint) ([]models.Question, error) {
 	var questions []models.Question
-	err = s.db.Where("test_id = ?", testID).Find(&questions).Error
+	err := db.Joins("JOIN attempt_questions ON attempt_questions.question_id = questions.id").
+		Where("attempt_questions.test_result_id = ?", attemptID).
+		Order("attempt_questions.position").
+		Find(&questions).Error
+	return questions, err
+}
+
+// SubmitTest scores a submission against the questions served for its attempt and
+// completes the attempt. Every submission needs an attempt started beforehand, so
+// its time is measured by the server and its deadline enforced. Unanswered
+// questions count as wrong. A submission arriving after the attempt's deadline is
+// refused and the attempt is closed unanswered.
+func (s *TestService) SubmitTest(orgID, userID uint, submission Submission) (*models.TestResult, error) {
+	test, err := s.GetTestByID(orgID, submission.TestID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create a map for quick question lookup
-	questionMap := make(map[uint]*models.Question)
-	for i := range questions {
-		questionMap[questions[i].ID] = &questions[i]
+	var testResult *models.TestResult
+	expired := false
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// Locked so a concurrent submission waits and then finds it completed.
+		var attempt models.TestResult
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(inOrganization(orgID)).
+			Where("id = ? AND user_id = ?", submission.AttemptID, userID).
+			First(&attempt).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAttemptNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if attempt.TestID != test.ID {
+			return fmt.Errorf("%w: attempt belongs to a different test", ErrInvalidSubmission)
+		}
+		if attempt.CompletedAt != nil {
+			return ErrAttemptCompleted
+		}
+		if attempt.PausedAt != nil {
+			return ErrSessionPaused
+		}
+		testResult = &attempt
+		if attemptExpired(&attempt, now) {
+			expired = true
+			return s.endAttempt(tx, test, &attempt, models.AttemptExpired, now)
+		}
+		attempt.TimeTaken = int(now.Sub(attempt.StartedAt).Seconds())
+
+		return s.completeAttempt(tx, test, testResult, submission.Answers, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return nil, ErrAttemptExpired
 	}
 
-	score := 0
-	var answerModels []models.Answer
+	// Load the complete result with relationships
+	err = s.db.Preload("Answers").Preload("Test").First(testResult, testResult.ID).Error
+	return testResult, err
+}
+
+// completeAttempt scores the answers against the attempt's served questions,
+// completes it and records everything that follows from a finished attempt.
+func (s *TestService) completeAttempt(tx *gorm.DB, test *models.Test, testResult *models.TestResult, answers []SubmitAnswerRequest, completedAt time.Time) error {
+	questions, err := s.servedQuestions(tx, testResult.ID)
+	if err != nil {
+		return err
+	}
+
+	answerModels, score, err := s.scoreAnswers(testResult.ID, questions, answers)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Create(&answerModels).Error; err != nil {
+		return err
+	}
+
+	testResult.Score = score
+	testResult.TotalQuestions = len(questions)
+	testResult.CompletedAt = &completedAt
+	if err := tx.Omit("ServedQuestions").Save(testResult).Error; err != nil {
+		return err
+	}
+
+	if err := markCountedAttempt(tx, test, testResult.UserID); err != nil {
+		return err
+	}
+	if err := recordLeaderboardEntries(tx, testResult, questions, answerModels); err != nil {
+		return err
+	}
+	if err := enqueueLTIScores(tx, test, testResult.UserID); err != nil {
+		return err
+	}
+	return enqueueSubmissionEvents(tx, testResult, len(answers))
+}
+
+// endAttempt completes an attempt the candidate did not submit, with every
+// question unanswered. Expired attempts are timed up to their deadline.
+func (s *TestService) endAttempt(tx *gorm.DB, test *models.Test, attempt *models.TestResult, reason models.AttemptEndReason, now time.Time) error {
+	end := now
+	if attempt.Deadline != nil && attempt.Deadline.Before(now) {
+		end = *attempt.Deadline
+	}
+	attempt.TimeTaken = int(end.Sub(attempt.StartedAt).Seconds())
+	attempt.EndReason = reason
+	return s.completeAttempt(tx, test, attempt, nil, now)
+}
+
+// expireAttempts ends the user's attempts at the test whose deadline has passed.
+func (s *TestService) expireAttempts(test *models.Test, userID uint) error {
+	now := time.Now()
+	var attempts []models.TestResult
+	err := s.db.Where("test_id = ? AND user_id = ? AND completed_at IS NULL AND paused_at IS NULL AND deadline < ?", test.ID, userID, now.Add(-deadlineGrace)).
+		Find(&attempts).Error
+	if err != nil {
+		return err
+	}
+	for i := range attempts {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			// The attempt may have been submitted since it was listed.
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&attempts[i], attempts[i].ID).Error
+			if err != nil || attempts[i].CompletedAt != nil {
+				return err
+			}
+			return s.endAttempt(tx, test, &attempts[i], models.AttemptExpired, now)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attemptDeadline is when an attempt at the test started at startedAt is due, or
+// nil for untimed tests. Accommodated candidates get multiplier times the test's
+// duration.
+func attemptDeadline(test *models.Test, startedAt time.Time, multiplier float64) *time.Time {
+	if test.Duration <= 0 {
+		return nil
+	}
+	deadline := startedAt.Add(time.Duration(models.ScaleTimeLimit(test.Duration, multiplier)) * time.Minute)
+	return &deadline
+}
 
-	// Process each answer
+func attemptExpired(attempt *models.TestResult, now time.Time) bool {
+	return attempt.Deadline != nil && attempt.PausedAt == nil && now.After(attempt.Deadline.Add(deadlineGrace))
+}
+
+// scoreAnswers validates the submitted answers against the served questions and
+// grades them. Every served question gets an answer row; unanswered ones are
+// stored empty and marked wrong.
+func (s *TestService) scoreAnswers(testResultID uint, questions []models.Question, answers []SubmitAnswerRequest) ([]models.Answer, int, error) {
+	submitted := make(map[uint]SubmitAnswerRequest, len(answers))
 	for _, answer := range answers {
-		question, exists := questionMap[answer.QuestionID]
-		if !exists {
-			continue
+		if _, duplicate := submitted[answer.QuestionID]; duplicate {
+			return nil, 0, fmt.Errorf("%w: question %d answered more than once", ErrInvalidSubmission, answer.QuestionID)
 		}
+		submitted[answer.QuestionID] = answer
+	}
 
-		isCorrect := s.evaluateAnswer(question, answer.UserAnswer)
+	score := 0
+	answerModels := make([]models.Answer, 0, len(questions))
+	for i := range questions {
+		question := &questions[i]
+		answer, answered := submitted[question.ID]
+		delete(submitted, question.ID)
+
+		isCorrect := answered && s.evaluateAnswer(question, answer.UserAnswer)
 		if isCorrect {
 			score++
 		}
 
-		answerModel := models.Answer{
-			TestResultID: testResult.ID,
-			QuestionID:   answer.QuestionID,
+		answerModels = append(answerModels, models.Answer{
+			TestResultID: testResultID,
+			QuestionID:   question.ID,
 			UserAnswer:   answer.UserAnswer,
 			IsCorrect:    isCorrect,
 			ResponseTime: answer.ResponseTime,
-		}
-		answerModels = append(answerModels, answerModel)
+		})
 	}
 
-	// Bulk create answers
-	if len(answerModels) > 0 {
-		err = s.db.Create(&answerModels).Error
-		if err != nil {
-			return nil, err
-		}
+	for questionID := range submitted {
+		return nil, 0, fmt.Errorf("%w: question %d was not served in this attempt", ErrInvalidSubmission, questionID)
 	}
 
-	// Update test result with score
-	testResult.Score = score
-	err = s.db.Save(testResult).Error
-	if err != nil {
-		return nil, err
-	}
-
-	// Load the complete result with relationships
-	err = s.db.Preload("Answers").Preload("Test").First(testResult, testResult.ID).Error
-	return testResult, err
+	return answerModels, score, nil
 }
 
 func (s *TestService) evaluateAnswer(question *models.Question, userAnswer string) bool {
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"iq-go/internal/models"
+)
+
+func TestScoreAnswers(t *testing.T) {
+	served := []models.Question{
+		{ID: 1, QuestionType: models.MultipleChoice, CorrectAnswer: "B"},
+		{ID: 2, QuestionType: models.TextInput, CorrectAnswer: "grey, gray"},
+		{ID: 3, QuestionType: models.NumberInput, CorrectAnswer: "42"},
+	}
+
+	tests := []struct {
+		name    string
+		answers []SubmitAnswerRequest
+		score   int
+		stored  []models.Answer // in served order
+		invalid string          // in the error when the submission is refused
+	}{
+		{
+			name: "all correct, case and spaces ignored",
+			answers: []SubmitAnswerRequest{
+				{QuestionID: 3, UserAnswer: "42", ResponseTime: 900},
+				{QuestionID: 1, UserAnswer: " b ", ResponseTime: 1200},
+				{QuestionID: 2, UserAnswer: "Gray"},
+			},
+			score: 3,
+			stored: []models.Answer{
+				{TestResultID: 9, QuestionID: 1, UserAnswer: " b ", IsCorrect: true, ResponseTime: 1200},
+				{TestResultID: 9, QuestionID: 2, UserAnswer: "Gray", IsCorrect: true},
+				{TestResultID: 9, QuestionID: 3, UserAnswer: "42", IsCorrect: true, ResponseTime: 900},
+			},
+		},
+		{
+			name:    "wrong answers",
+			answers: []SubmitAnswerRequest{{QuestionID: 1, UserAnswer: "c"}, {QuestionID: 2, UserAnswer: "grey, gray"}, {QuestionID: 3, UserAnswer: "42.0"}},
+			score:   0,
+			stored: []models.Answer{
+				{TestResultID: 9, QuestionID: 1, UserAnswer: "c"},
+				{TestResultID: 9, QuestionID: 2, UserAnswer: "grey, gray"},
+				{TestResultID: 9, QuestionID: 3, UserAnswer: "42.0"},
+			},
+		},
+		{
+			name:    "unanswered questions are stored empty and wrong",
+			answers: []SubmitAnswerRequest{{QuestionID: 2, UserAnswer: "grey"}},
+			score:   1,
+			stored: []models.Answer{
+				{TestResultID: 9, QuestionID: 1},
+				{TestResultID: 9, QuestionID: 2, UserAnswer: "grey", IsCorrect: true},
+				{TestResultID: 9, QuestionID: 3},
+			},
+		},
+		{
+			name:  "nothing submitted",
+			score: 0,
+			stored: []models.Answer{
+				{TestResultID: 9, QuestionID: 1},
+				{TestResultID: 9, QuestionID: 2},
+				{TestResultID: 9, QuestionID: 3},
+			},
+		},
+		{
+			name:    "a question answered twice",
+			answers: []SubmitAnswerRequest{{QuestionID: 1, UserAnswer: "a"}, {QuestionID: 1, UserAnswer: "b"}},
+			invalid: "question 1 answered more than once",
+		},
+		{
+			name:    "a question of another test",
+			answers: []SubmitAnswerRequest{{QuestionID: 1, UserAnswer: "b"}, {QuestionID: 77, UserAnswer: "b"}},
+			invalid: "question 77 was not served in this attempt",
+		},
+	}
+
+	s := NewTestService(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored, score, err := s.scoreAnswers(9, served, tt.answers)
+			if tt.invalid != "" {
+				if !errors.Is(err, ErrInvalidSubmission) || !strings.Contains(err.Error(), tt.invalid) {
+					t.Fatalf("error %v, want an invalid submission: %s", err, tt.invalid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if score != tt.score {
+				t.Errorf("score %d, want %d", score, tt.score)
+			}
+			if !reflect.DeepEqual(stored, tt.stored) {
+				t.Errorf("stored %+v, want %+v", stored, tt.stored)
+			}
+		})
+	}
+}
@@ -1,11 +1,17 @@
 package services
 
 import (
+	"errors"
+	"strings"
+
 	"iq-go/internal/models"
+	"iq-go/internal/utils"
 
 	"gorm.io/gorm"
 )
 
+var ErrTokenRevoked = errors.New("token has been revoked")
+
 type UserService struct {
 	db *gorm.DB
 }
@@ -14,19 +20,69 @@ func NewUserService(db *gorm.DB) *UserService {
 	return &UserService{db: db}
 }
 
-func (s *UserService) CreateUser(user *models.User) error {
-	return s.db.Create(user).Error
+// CreateUser stores the user and grants it the named roles.
+func (s *UserService) CreateUser(user *models.User, roleNames ...string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if len(roleNames) > 0 {
+			var roles []models.Role
+			if err := tx.Preload("Permissions").Where("name IN ?", roleNames).Find(&roles).Error; err != nil {
+				return err
+			}
+			user.Roles = roles
+		}
+		return tx.Omit("Roles.*").Create(user).Error
+	})
+}
+
+// AddUserRole gives the user with the email in the organization the named role on
+// top of the roles they have. It is meant for operators, so it does not check who
+// may grant the role.
+func (s *UserService) AddUserRole(orgID uint, email, roleName string) (*models.User, error) {
+	user, err := s.GetUserByEmail(orgID, email)
+	if err != nil {
+		return nil, err
+	}
+	var role models.Role
+	if err := s.db.Where("name = ?", roleName).First(&role).Error; err != nil {
+		return nil, ErrUnknownRole
+	}
+	if err := s.db.Model(user).Association("Roles").Append(&role); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CheckToken verifies that a token issued to the user at tokenVersion still
+// stands: the account is active and neither its roles nor their permissions have
+// changed since.
+func (s *UserService) CheckToken(userID uint, tokenVersion int) error {
+	var user models.User
+	err := s.db.Select("id", "token_version").First(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && user.TokenVersion != tokenVersion) {
+		return ErrTokenRevoked
+	}
+	return err
+}
+
+// revokeTokens makes the selected users, deactivated ones included, sign in again
+// to get a token with their current roles.
+func revokeTokens(tx *gorm.DB, query interface{}, args ...interface{}) error {
+	return tx.Unscoped().Model(&models.User{}).Where(query, args...).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
 }
 
 func (s *UserService) GetUserByID(id uint) (*models.User, error) {
 	var user models.User
-	err := s.db.First(&user, id).Error
+	err := s.db.Preload("Roles.Permissions").First(&user, id).Error
 	return &user, err
 }
 
-func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
+func (s *UserService) GetUserByEmail(orgID uint, email string) (*models.User, error) {
 	var user models.User
-	err := s.db.Where("email = ?", email).First(&user).Error
+	err := s.db.Scopes(inOrganization(orgID)).
+		Preload("Roles.Permissions").
+		Where("email = ?", email).
+		First(&user).Error
 	return &user, err
 }
 
@@ -37,3 +93,15 @@ func (s *UserService) UpdateUser(user *models.User) error {
 func (s *UserService) DeleteUser(id uint) error {
 	return s.db.Delete(&models.User{}, id).Error
 }
+
+// unusablePassword returns a hash of a random value, for accounts that only sign
+// in through an invitation or a learning platform, so the password login cannot
+// be used for them.
+func unusablePassword() (string, error) {
+	secret, err := utils.GenerateSignedToken()
+	if err != nil {
+		return "", err
+	}
+	random, _, _ := strings.Cut(secret, ".")
+	return utils.HashPassword(random)
+}
@@ -0,0 +1,594 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"iq-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhook   = errors.New("invalid webhook endpoint")
+	ErrWebhookAddress   = errors.New("webhook host has no public address")
+)
+
+// nonPublicNets are address ranges outside the ones covered by the net.IP
+// predicates that webhooks must not reach either.
+var nonPublicNets = mustParseCIDRs(
+	"0.0.0.0/8",     // this network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved, including broadcast
+	"64:ff9b::/96",  // NAT64, which can reach private IPv4 addresses
+)
+
+const (
+	webhookMaxAttempts = 8
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+	webhookLease       = 2 * time.Minute // how long a claimed delivery is hidden from other dispatchers
+	webhookTimeout     = 10 * time.Second
+	webhookBatchSize   = 20
+)
+
+type WebhookService struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{
+		db:     db,
+		client: publicClient(webhookTimeout),
+	}
+}
+
+// publicClient returns a client for URLs that come from outside, such as webhook
+// endpoints and learning platforms. It only connects to public addresses.
+func publicClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialPublic}, // no proxy, which would dial for us
+		// A redirect could lead anywhere, so it is reported as the response.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+type WebhookRequest struct {
+	URL         string                `json:"url" binding:"required"`
+	Description string                `json:"description"`
+	Events      []models.WebhookEvent `json:"events" binding:"required"`
+	Active      *bool                 `json:"active"`
+}
+
+// WebhookPayload is the JSON body of every webhook request.
+type WebhookPayload struct {
+	ID             string              `json:"id"`
+	Event          models.WebhookEvent `json:"event"`
+	OrganizationID uint                `json:"organization_id"`
+	CreatedAt      time.Time           `json:"created_at"`
+	Data           interface{}         `json:"data"`
+}
+
+type AttemptEventData struct {
+	AttemptID   uint       `json:"attempt_id"`
+	TestID      uint       `json:"test_id"`
+	UserID      uint       `json:"user_id"`
+	UserEmail   string     `json:"user_email"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	TimeTaken   int        `json:"time_taken,omitempty"`
+	Answered    int        `json:"answered,omitempty"`
+}
+
+type ResultEventData struct {
+	ResultID       uint      `json:"result_id"`
+	TestID         uint      `json:"test_id"`
+	UserID         uint      `json:"user_id"`
+	UserEmail      string    `json:"user_email"`
+	Score          int       `json:"score"`
+	TotalQuestions int       `json:"total_questions"`
+	Percentage     float64   `json:"percentage"`
+	TimeTaken      int       `json:"time_taken"`
+	Counted        bool      `json:"counted"`
+	TimeMultiplier float64   `json:"time_multiplier"`
+	CompletedAt    time.Time `json:"completed_at"`
+}
+
+func (s *WebhookService) ListEndpoints(orgID uint) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := s.db.Scopes(inOrganization(orgID)).Order("id").Find(&endpoints).Error
+	return endpoints, err
+}
+
+// CreateEndpoint registers an endpoint and returns its signing secret, which is
+// only shown here and when it is rotated.
+func (s *WebhookService) CreateEndpoint(orgID uint, req WebhookRequest) (*models.WebhookEndpoint, string, error) {
+	if err := validateWebhookRequest(req); err != nil {
+		return nil, "", err
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	endpoint := &models.WebhookEndpoint{
+		OrganizationID: orgID,
+		URL:            req.URL,
+		Description:    req.Description,
+		Events:         req.Events,
+		Secret:         secret,
+		Active:         req.Active == nil || *req.Active,
+	}
+	if err := s.db.Create(endpoint).Error; err != nil {
+		return nil, "", err
+	}
+	return endpoint, secret, nil
+}
+
+func (s *WebhookService) UpdateEndpoint(orgID, endpointID uint, req WebhookRequest) (*models.WebhookEndpoint, error) {
+	if err := validateWebhookRequest(req); err != nil {
+		return nil, err
+	}
+	endpoint, err := s.findEndpoint(orgID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.URL = req.URL
+	endpoint.Description = req.Description
+	endpoint.Events = req.Events
+	if req.Active != nil {
+		endpoint.Active = *req.Active
+	}
+	return endpoint, s.db.Save(endpoint).Error
+}
+
+// DeleteEndpoint removes an endpoint. Its pending deliveries are abandoned.
+func (s *WebhookService) DeleteEndpoint(orgID, endpointID uint) error {
+	endpoint, err := s.findEndpoint(orgID, endpointID)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(endpoint).Error
+}
+
+func (s *WebhookService) RotateSecret(orgID, endpointID uint) (*models.WebhookEndpoint, string, error) {
+	endpoint, err := s.findEndpoint(orgID, endpointID)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.db.Model(endpoint).Update("secret", secret).Error; err != nil {
+		return nil, "", err
+	}
+	return endpoint, secret, nil
+}
+
+// ListDeliveries returns an endpoint's most recent deliveries, optionally only
+// those with the given status.
+func (s *WebhookService) ListDeliveries(orgID, endpointID uint, status models.DeliveryStatus, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.findEndpoint(orgID, endpointID); err != nil {
+		return nil, err
+	}
+
+	query := s.db.Where("endpoint_id = ?", endpointID).Order("id DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var deliveries []models.WebhookDelivery
+	err := query.Find(&deliveries).Error
+	return deliveries, err
+}
+
+// GetDelivery returns a delivery with its log of attempts.
+func (s *WebhookService) GetDelivery(orgID, deliveryID uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := s.db.Scopes(inOrganization(orgID)).
+		Preload("Log", func(db *gorm.DB) *gorm.DB { return db.Order("attempted_at") }).
+		First(&delivery, deliveryID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDeliveryNotFound
+	}
+	return &delivery, err
+}
+
+// ReplayDelivery queues the payload of an earlier delivery to be sent again as a
+// new delivery, whatever the outcome of the original. The event ID is kept so
+// receivers can recognise the duplicate.
+func (s *WebhookService) ReplayDelivery(orgID, deliveryID uint) (*models.WebhookDelivery, error) {
+	original, err := s.GetDelivery(orgID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.findEndpoint(orgID, original.EndpointID); err != nil {
+		return nil, err
+	}
+
+	replay := &models.WebhookDelivery{
+		OrganizationID: original.OrganizationID,
+		EndpointID:     original.EndpointID,
+		EventID:        original.EventID,
+		Event:          original.Event,
+		Payload:        original.Payload,
+		Status:         models.DeliveryPending,
+		NextAttemptAt:  time.Now(),
+		ReplayOf:       &original.ID,
+	}
+	return replay, s.db.Create(replay).Error
+}
+
+// RunDispatcher sends due deliveries every interval until the context is cancelled.
+// Several dispatchers may run at once; each delivery is claimed by one of them.
+func (s *WebhookService) RunDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			sent, err := s.DeliverDue(ctx)
+			if err != nil {
+				log.Printf("Webhook dispatch failed: %v", err)
+			}
+			if err != nil || sent < webhookBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue claims a batch of due deliveries, sends them and returns how many
+// were attempted.
+func (s *WebhookService) DeliverDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	var due []models.WebhookDelivery
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(webhookBatchSize).
+			Find(&due).Error
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		// Push the claimed deliveries into the future so they are not sent twice if
+		// this dispatcher dies; the outcome of each attempt sets the real time.
+		ids := make([]uint, len(due))
+		for i, delivery := range due {
+			ids[i] = delivery.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(webhookLease)).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range due {
+		if err := s.deliver(ctx, &due[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(due), nil
+}
+
+func (s *WebhookService) deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	var endpoint models.WebhookEndpoint
+	err := s.db.First(&endpoint, delivery.EndpointID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !endpoint.Active) {
+		return s.db.Model(delivery).Updates(map[string]interface{}{
+			"status":     models.DeliveryFailed,
+			"last_error": "endpoint deleted or disabled",
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	attempt := models.WebhookDeliveryAttempt{DeliveryID: delivery.ID, AttemptedAt: started}
+	statusCode, sendErr := s.send(ctx, &endpoint, delivery)
+	attempt.DurationMs = time.Since(started).Milliseconds()
+	attempt.StatusCode = statusCode
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	} else if statusCode < 200 || statusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", statusCode)
+	}
+
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":         attempts,
+		"last_status_code": statusCode,
+		"last_error":       attempt.Error,
+	}
+	switch {
+	case attempt.Error == "":
+		updates["status"] = models.DeliveryDelivered
+		updates["delivered_at"] = time.Now()
+	case attempts >= webhookMaxAttempts:
+		updates["status"] = models.DeliveryFailed
+	default:
+		updates["next_attempt_at"] = time.Now().Add(webhookBackoff(attempts))
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(delivery).Updates(updates).Error
+	})
+}
+
+// send posts the payload and returns the status code. The response body is not
+// read, so nothing the endpoint's host returns ends up in the delivery log.
+func (s *WebhookService) send(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "iq-go-webhooks/1")
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	req.Header.Set("X-Webhook-ID", delivery.EventID)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Signature", "t="+timestamp+",v1="+SignWebhookPayload(endpoint.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// dialPublic connects to the first public address the host resolves to, so an
+// endpoint cannot reach the server's own network. The checked address is the one
+// dialed, so a second DNS answer cannot swap in another.
+func dialPublic(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: webhookTimeout}
+	err = fmt.Errorf("%w: %s", ErrWebhookAddress, host)
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			continue
+		}
+		var conn net.Conn
+		conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(addr.IP.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// publicIP reports whether the address is routable on the internet: not loopback,
+// private, link-local, multicast or otherwise reserved.
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, block := range nonPublicNets {
+		if block.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, block, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = block
+	}
+	return nets
+}
+
+// SignWebhookPayload computes the v1 signature: the hex HMAC-SHA256 of the
+// timestamp, a dot and the raw body, keyed with the endpoint secret. Receivers
+// should recompute it and reject old timestamps to prevent replays.
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the wait after the given number of failed attempts: 30s,
+// doubling each time, capped at six hours.
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff << (attempts - 1)
+	if backoff <= 0 || backoff > webhookMaxBackoff {
+		return webhookMaxBackoff
+	}
+	return backoff
+}
+
+func (s *WebhookService) findEndpoint(orgID, endpointID uint) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	err := s.db.Scopes(inOrganization(orgID)).First(&endpoint, endpointID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	return &endpoint, err
+}
+
+func validateWebhookRequest(req WebhookRequest) error {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	// Hostnames are checked when each request is dialed, since their addresses can change.
+	if ip := net.ParseIP(target.Hostname()); (ip != nil && !publicIP(ip)) || target.Hostname() == "localhost" {
+		return fmt.Errorf("%w: url must point to a public address", ErrInvalidWebhook)
+	}
+	if len(req.Events) == 0 {
+		return fmt.Errorf("%w: subscribe to at least one event", ErrInvalidWebhook)
+	}
+	for _, event := range req.Events {
+		known := false
+		for _, candidate := range models.WebhookEvents {
+			known = known || event == candidate
+		}
+		if !known {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// enqueueWebhookEvent queues an event for every active endpoint of the organization
+// subscribed to it, inside the caller's transaction, so an event is only sent if
+// the change that raised it is committed. The payload data is only built when
+// some endpoint is listening.
+func enqueueWebhookEvent(tx *gorm.DB, orgID uint, event models.WebhookEvent, data func() (interface{}, error)) error {
+	var endpoints []models.WebhookEndpoint
+	if err := tx.Scopes(inOrganization(orgID)).Where("active").Find(&endpoints).Error; err != nil {
+		return err
+	}
+	var subscribers []models.WebhookEndpoint
+	for _, endpoint := range endpoints {
+		if endpoint.Subscribes(event) {
+			subscribers = append(subscribers, endpoint)
+		}
+	}
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	eventData, err := data()
+	if err != nil {
+		return err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	payload := WebhookPayload{
+		ID:             "evt_" + hex.EncodeToString(id),
+		Event:          event,
+		OrganizationID: orgID,
+		CreatedAt:      time.Now().UTC(),
+		Data:           eventData,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(subscribers))
+	for i, endpoint := range subscribers {
+		deliveries[i] = models.WebhookDelivery{
+			OrganizationID: orgID,
+			EndpointID:     endpoint.ID,
+			EventID:        payload.ID,
+			Event:          event,
+			Payload:        string(body),
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  payload.CreatedAt,
+		}
+	}
+	return tx.Create(&deliveries).Error
+}
+
+func userEmail(tx *gorm.DB, userID uint) (string, error) {
+	var email string
+	err := tx.Model(&models.User{}).Select("email").Where("id = ?", userID).Scan(&email).Error
+	return email, err
+}
+
+// enqueueSubmissionEvents raises attempt.submitted and result.scored for a
+// completed attempt, after its counted flag has been settled.
+func enqueueSubmissionEvents(tx *gorm.DB, result *models.TestResult, answered int) error {
+	err := enqueueWebhookEvent(tx, result.OrganizationID, models.EventAttemptSubmitted, func() (interface{}, error) {
+		email, err := userEmail(tx, result.UserID)
+		return AttemptEventData{
+			AttemptID:   result.ID,
+			TestID:      result.TestID,
+			UserID:      result.UserID,
+			UserEmail:   email,
+			StartedAt:   result.StartedAt,
+			CompletedAt: result.CompletedAt,
+			TimeTaken:   result.TimeTaken,
+			Answered:    answered,
+		}, err
+	})
+	if err != nil {
+		return err
+	}
+
+	return enqueueWebhookEvent(tx, result.OrganizationID, models.EventResultScored, func() (interface{}, error) {
+		data := ResultEventData{
+			ResultID:       result.ID,
+			TestID:         result.TestID,
+			UserID:         result.UserID,
+			Score:          result.Score,
+			TotalQuestions: result.TotalQuestions,
+			Percentage:     percentage(result.Score, result.TotalQuestions),
+			TimeTaken:      result.TimeTaken,
+			TimeMultiplier: result.TimeMultiplier,
+			CompletedAt:    *result.CompletedAt,
+		}
+		var err error
+		if data.UserEmail, err = userEmail(tx, result.UserID); err != nil {
+			return nil, err
+		}
+		err = tx.Model(&models.TestResult{}).Select("counted").Where("id = ?", result.ID).Scan(&data.Counted).Error
+		return data, err
+	})
+}
@@ -0,0 +1,315 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrorCode identifies an error for programs. Codes are part of the API contract:
+// clients branch on them, so a code never changes meaning once published. Messages
+// are for people and may change at any time.
+type ErrorCode string
+
+// Codes used when a response gives no more specific one.
+const (
+	CodeInvalidRequest ErrorCode = "invalid_request"
+	CodeUnauthorized   ErrorCode = "unauthorized"
+	CodeForbidden      ErrorCode = "forbidden"
+	CodeNotFound       ErrorCode = "not_found"
+	CodeConflict       ErrorCode = "conflict"
+	CodeGone           ErrorCode = "gone"
+	CodeUnprocessable  ErrorCode = "unprocessable"
+	CodeRateLimited    ErrorCode = "rate_limited"
+	CodeInternal       ErrorCode = "internal_error"
+)
+
+const (
+	CodeValidationFailed ErrorCode = "validation_failed"
+	CodeMalformedBody    ErrorCode = "malformed_body"
+	CodeAPIVersionGone   ErrorCode = "api_version_removed"
+
+	// Authentication and tenancy
+	CodeTokenRequired        ErrorCode = "token_required"
+	CodeTokenInvalid         ErrorCode = "token_invalid"
+	CodeTokenRevoked         ErrorCode = "token_revoked"
+	CodeAPIKeyInvalid        ErrorCode = "api_key_invalid"
+	CodeTenantMismatch       ErrorCode = "tenant_mismatch"
+	CodePermissionDenied     ErrorCode = "permission_denied"
+	CodeOrganizationNotFound ErrorCode = "organization_not_found"
+	CodeEmailTaken           ErrorCode = "email_taken"
+	CodeInvalidCredentials   ErrorCode = "invalid_credentials"
+
+	// Attempts
+	CodeTestNotOpen         ErrorCode = "test_not_open"
+	CodeTestClosed          ErrorCode = "test_closed"
+	CodeAttemptLimitReached ErrorCode = "attempt_limit_reached"
+	CodeAttemptCooldown     ErrorCode = "attempt_cooldown"
+	CodeAttemptNotFound     ErrorCode = "attempt_not_found"
+	CodeAttemptCompleted    ErrorCode = "attempt_completed"
+	CodeAttemptExpired      ErrorCode = "attempt_expired"
+	CodeInvalidSubmission   ErrorCode = "invalid_submission"
+	CodeNoQuestions         ErrorCode = "no_questions"
+	CodeInvalidActivity     ErrorCode = "invalid_activity"
+
+	// Practice
+	CodePracticeDisabled     ErrorCode = "practice_disabled"
+	CodePracticeNotFound     ErrorCode = "practice_session_not_found"
+	CodePracticeFinished     ErrorCode = "practice_session_finished"
+	CodePracticeAttemptOpen  ErrorCode = "practice_attempt_open"
+	CodeQuestionAnswered     ErrorCode = "question_already_answered"
+	CodeQuestionNotInSession ErrorCode = "question_not_in_session"
+
+	// Proctored sessions
+	CodeSessionNotFound ErrorCode = "session_not_found"
+	CodeSessionState    ErrorCode = "session_state_conflict"
+	CodeTestUntimed     ErrorCode = "test_untimed"
+	CodeNotCandidate    ErrorCode = "not_a_session_candidate"
+	CodeSessionOver     ErrorCode = "session_over"
+	CodeInvalidSession  ErrorCode = "invalid_session"
+	CodeSessionRequired ErrorCode = "session_required"
+	CodeSessionPaused   ErrorCode = "session_paused"
+
+	// Accommodations
+	CodeAccommodationNotFound ErrorCode = "accommodation_not_found"
+	CodeInvalidAccommodation  ErrorCode = "invalid_accommodation"
+
+	// Invitations
+	CodeInvitationNotFound ErrorCode = "invitation_not_found"
+	CodeInvitationExpired  ErrorCode = "invitation_expired"
+	CodeInvitationUsed     ErrorCode = "invitation_used"
+	CodeEmailInOtherOrg    ErrorCode = "email_in_other_organization"
+	CodeEmailIsStaff       ErrorCode = "email_is_staff_account"
+
+	// Certificates
+	CodeResultIncomplete   ErrorCode = "result_incomplete"
+	CodeCertificateRevoked ErrorCode = "certificate_revoked"
+
+	// Integrations
+	CodeAPIKeyNotFound      ErrorCode = "api_key_not_found"
+	CodeAPIKeyInactive      ErrorCode = "api_key_inactive"
+	CodeAPIKeyScope         ErrorCode = "api_key_scope"
+	CodeWebhookNotFound     ErrorCode = "webhook_not_found"
+	CodeDeliveryNotFound    ErrorCode = "delivery_not_found"
+	CodeInvalidWebhook      ErrorCode = "invalid_webhook"
+	CodeLTIPlatformNotFound ErrorCode = "lti_platform_not_found"
+	CodeLTIPlatformExists   ErrorCode = "lti_platform_exists"
+	CodeLTIStaffRole        ErrorCode = "lti_invalid_staff_role"
+	CodeUnknownRole         ErrorCode = "unknown_role"
+	CodeUnknownPermission   ErrorCode = "unknown_permission"
+)
+
+// ErrorCodes lists every code, for the API documentation.
+var ErrorCodes = []ErrorCode{
+	CodeInvalidRequest,
+	CodeUnauthorized,
+	CodeForbidden,
+	CodeNotFound,
+	CodeConflict,
+	CodeGone,
+	CodeUnprocessable,
+	CodeRateLimited,
+	CodeInternal,
+	CodeValidationFailed,
+	CodeMalformedBody,
+	CodeAPIVersionGone,
+	CodeTokenRequired,
+	CodeTokenInvalid,
+	CodeTokenRevoked,
+	CodeAPIKeyInvalid,
+	CodeTenantMismatch,
+	CodePermissionDenied,
+	CodeOrganizationNotFound,
+	CodeEmailTaken,
+	CodeInvalidCredentials,
+	CodeTestNotOpen,
+	CodeTestClosed,
+	CodeAttemptLimitReached,
+	CodeAttemptCooldown,
+	CodeAttemptNotFound,
+	CodeAttemptCompleted,
+	CodeAttemptExpired,
+	CodeInvalidSubmission,
+	CodeNoQuestions,
+	CodeInvalidActivity,
+	CodePracticeDisabled,
+	CodePracticeNotFound,
+	CodePracticeFinished,
+	CodePracticeAttemptOpen,
+	CodeQuestionAnswered,
+	CodeQuestionNotInSession,
+	CodeSessionNotFound,
+	CodeSessionState,
+	CodeTestUntimed,
+	CodeNotCandidate,
+	CodeSessionOver,
+	CodeInvalidSession,
+	CodeSessionRequired,
+	CodeSessionPaused,
+	CodeAccommodationNotFound,
+	CodeInvalidAccommodation,
+	CodeInvitationNotFound,
+	CodeInvitationExpired,
+	CodeInvitationUsed,
+	CodeEmailInOtherOrg,
+	CodeEmailIsStaff,
+	CodeResultIncomplete,
+	CodeCertificateRevoked,
+	CodeAPIKeyNotFound,
+	CodeAPIKeyInactive,
+	CodeAPIKeyScope,
+	CodeWebhookNotFound,
+	CodeDeliveryNotFound,
+	CodeInvalidWebhook,
+	CodeLTIPlatformNotFound,
+	CodeLTIPlatformExists,
+	CodeLTIStaffRole,
+	CodeUnknownRole,
+	CodeUnknownPermission,
+}
+
+// statusCodes gives the code for responses that do not name one.
+var statusCodes = map[int]ErrorCode{
+	http.StatusBadRequest:          CodeInvalidRequest,
+	http.StatusUnauthorized:        CodeUnauthorized,
+	http.StatusForbidden:           CodeForbidden,
+	http.StatusNotFound:            CodeNotFound,
+	http.StatusConflict:            CodeConflict,
+	http.StatusGone:                CodeGone,
+	http.StatusUnprocessableEntity: CodeUnprocessable,
+	http.StatusTooManyRequests:     CodeRateLimited,
+}
+
+func codeForStatus(status int) ErrorCode {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	return CodeInternal
+}
+
+// APIError is the error object of versioned API responses.
+type APIError struct {
+	Code      ErrorCode    `json:"code"`
+	Message   string       `json:"message"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// FieldError describes one invalid field of a request. Field is the JSON path of
+// the field, such as recipients[0].email.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// UseJSONFieldNames makes binding errors name fields by their JSON (or form) name
+// rather than their Go name.
+func UseJSONFieldNames() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		tag := field.Tag.Get("json")
+		if tag == "" {
+			tag = field.Tag.Get("form")
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+}
+
+// BindingErrorResponse responds to a request body that could not be bound. It
+// lists the invalid fields without exposing decoder or validator internals.
+func BindingErrorResponse(c *gin.Context, err error) {
+	var validationErrors validator.ValidationErrors
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &validationErrors):
+		details := make([]FieldError, len(validationErrors))
+		for i, fieldError := range validationErrors {
+			details[i] = FieldError{
+				Field:   fieldPath(fieldError.Namespace()),
+				Rule:    fieldError.Tag(),
+				Message: ruleMessage(fieldError),
+			}
+		}
+		writeError(c, http.StatusBadRequest, CodeValidationFailed, "Request is invalid", details)
+	case errors.As(err, &typeError):
+		writeError(c, http.StatusBadRequest, CodeValidationFailed, "Request is invalid", []FieldError{{
+			Field:   typeError.Field,
+			Rule:    "type",
+			Message: "must be " + jsonTypeName(typeError.Type),
+		}})
+	case errors.As(err, &syntaxError), errors.Is(err, io.ErrUnexpectedEOF):
+		writeError(c, http.StatusBadRequest, CodeMalformedBody, "Request body is not valid JSON", nil)
+	case errors.Is(err, io.EOF):
+		writeError(c, http.StatusBadRequest, CodeMalformedBody, "Request body is required", nil)
+	default:
+		writeError(c, http.StatusBadRequest, CodeMalformedBody, "Request body could not be read", nil)
+	}
+}
+
+// fieldPath drops the struct name validator puts in front of every namespace.
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+func ruleMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return "must be at least " + fieldError.Param()
+	case "max":
+		return "must be at most " + fieldError.Param()
+	case "gt":
+		return "must be greater than " + fieldError.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fieldError.Param(), " ", ", ")
+	case "url":
+		return "must be a URL"
+	default:
+		return fmt.Sprintf("fails the %s rule", fieldError.Tag())
+	}
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
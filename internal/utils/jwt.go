@@ -4,27 +4,57 @@ import (
 	"time"
 
 	"iq-go/internal/config"
+	"iq-go/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID         uint     `json:"user_id"`
+	OrganizationID uint     `json:"organization_id"`
+	Email          string   `json:"email"`
+	Roles          []string `json:"roles,omitempty"`
+	Permissions    []string `json:"permissions,omitempty"`
+	InvitationID   uint     `json:"invitation_id,omitempty"`
+	TokenVersion   int      `json:"token_version,omitempty"` // the user's TokenVersion when issued
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uint, email string) (string, error) {
-	cfg := config.Load()
+// GenerateToken issues a token for the user. The user's roles must be preloaded
+// with their permissions so they can be embedded in the claims.
+func GenerateToken(user *models.User) (string, error) {
+	return signClaims(newClaims(user, time.Now().Add(24*time.Hour)))
+}
+
+// GenerateInvitationToken issues a token for a candidate who started a test through
+// an invitation link; submissions made with it are tied to that invitation. Anyone
+// holding the link gets the token, so it only ever carries the candidate role,
+// whatever roles the account has.
+func GenerateInvitationToken(user *models.User, invitationID uint) (string, error) {
+	claims := newClaims(user, time.Now().Add(24*time.Hour))
+	claims.Roles = []string{models.RoleCandidate}
+	claims.Permissions = models.DefaultRolePermissions[models.RoleCandidate]
+	claims.InvitationID = invitationID
+	return signClaims(claims)
+}
 
-	claims := Claims{
-		UserID: userID,
-		Email:  email,
+func newClaims(user *models.User, expiresAt time.Time) Claims {
+	return Claims{
+		UserID:         user.ID,
+		OrganizationID: user.OrganizationID,
+		Email:          user.Email,
+		Roles:          user.RoleNames(),
+		Permissions:    user.PermissionNames(),
+		TokenVersion:   user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
+}
+
+func signClaims(claims Claims) (string, error) {
+	cfg := config.Load()
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(cfg.JWTSecret))
@@ -4,11 +4,26 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Context keys set by the router for every API request.
+const (
+	RequestIDKey  = "request_id"
+	APIVersionKey = "api_version"
+)
+
 type Response struct {
 	Success bool        `json:"success"`
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Error   *APIError   `json:"error,omitempty"`
+}
+
+// LegacyErrorResponse is the error body of the deprecated unversioned API, which
+// keeps error as a string. Code and RequestID were added without breaking it.
+type LegacyErrorResponse struct {
+	Success   bool      `json:"success"`
+	Error     string    `json:"error"`
+	Code      ErrorCode `json:"code"`
+	RequestID string    `json:"request_id,omitempty"`
 }
 
 func SuccessResponse(c *gin.Context, statusCode int, message string, data interface{}) {
@@ -19,9 +34,48 @@ func SuccessResponse(c *gin.Context, statusCode int, message string, data interf
 	})
 }
 
+// ErrorResponse responds with the generic code for the status.
 func ErrorResponse(c *gin.Context, statusCode int, message string) {
+	writeError(c, statusCode, codeForStatus(statusCode), message, nil)
+}
+
+// CodedErrorResponse responds with a specific error code.
+func CodedErrorResponse(c *gin.Context, statusCode int, code ErrorCode, message string) {
+	writeError(c, statusCode, code, message, nil)
+}
+
+func writeError(c *gin.Context, statusCode int, code ErrorCode, message string, details []FieldError) {
+	requestID := c.GetString(RequestIDKey)
+	if c.GetString(APIVersionKey) == "" {
+		c.JSON(statusCode, LegacyErrorResponse{
+			Success:   false,
+			Error:     legacyMessage(message, details),
+			Code:      code,
+			RequestID: requestID,
+		})
+		return
+	}
+
 	c.JSON(statusCode, Response{
 		Success: false,
-		Error:   message,
+		Error: &APIError{
+			Code:      code,
+			Message:   message,
+			Details:   details,
+			RequestID: requestID,
+		},
 	})
 }
+
+// legacyMessage folds field details into the message, since the unversioned API
+// has nowhere else to put them.
+func legacyMessage(message string, details []FieldError) string {
+	for i, detail := range details {
+		separator := ", "
+		if i == 0 {
+			separator = ": "
+		}
+		message += separator + detail.Field + " " + detail.Message
+	}
+	return message
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"iq-go/internal/config"
+)
+
+// signingKey returns the Ed25519 key used for certificates. Without an explicit
+// CERTIFICATE_SIGNING_KEY seed it is derived from the JWT secret, so signatures
+// stay valid across restarts.
+func signingKey() ed25519.PrivateKey {
+	cfg := config.Load()
+
+	if seed, err := base64.StdEncoding.DecodeString(cfg.CertificateSigningKey); err == nil && len(seed) == ed25519.SeedSize {
+		return ed25519.NewKeyFromSeed(seed)
+	}
+	seed := sha256.Sum256([]byte("certificate-signing:" + cfg.JWTSecret))
+	return ed25519.NewKeyFromSeed(seed[:])
+}
+
+func SignPayload(payload []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(signingKey(), payload))
+}
+
+func VerifyPayload(payload []byte, signature string) bool {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(SigningPublicKey(), payload, sig)
+}
+
+func SigningPublicKey() ed25519.PublicKey {
+	return signingKey().Public().(ed25519.PublicKey)
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"iq-go/internal/config"
+)
+
+// GenerateSignedToken returns a random URL-safe token followed by an HMAC of it,
+// so forged tokens can be rejected before touching the database.
+func GenerateSignedToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	value := base64.RawURLEncoding.EncodeToString(buf)
+	return value + "." + signValue(value), nil
+}
+
+func VerifySignedToken(token string) bool {
+	value, signature, found := strings.Cut(token, ".")
+	if !found {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(signValue(value)))
+}
+
+// HashToken is how single-use tokens are stored at rest.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func signValue(value string) string {
+	cfg := config.Load()
+
+	mac := hmac.New(sha256.New, []byte(cfg.JWTSecret))
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
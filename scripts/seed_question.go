@@ -15,11 +15,17 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	organization, err := database.SeedDefaultOrganization(db, cfg.DefaultOrganization)
+	if err != nil {
+		log.Fatal("Failed to seed default organization:", err)
+	}
+
 	// Create default test
 	test := models.Test{
-		Name:        "Cognitive Assessment",
-		Description: "A comprehensive cognitive assessment test covering analytical reasoning, working memory, processing speed, attention & focus, and emotional regulation",
-		Duration:    60, // 60 minutes
+		OrganizationID: organization.ID,
+		Name:           "Cognitive Assessment",
+		Description:    "A comprehensive cognitive assessment test covering analytical reasoning, working memory, processing speed, attention & focus, and emotional regulation",
+		Duration:       60, // 60 minutes
 	}
 	db.Create(&test)
 
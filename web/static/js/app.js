@@ -53,7 +53,13 @@ async function apiRequest(url, options = {}) {
         const data = await response.json();
 
         if (!response.ok) {
-            throw new Error(data.error || 'Request failed');
+            // Branch on error.code, which is stable; error.message is for display.
+            const apiError = data.error || {};
+            const error = new Error(apiError.message || 'Request failed');
+            error.code = apiError.code;
+            error.details = apiError.details || [];
+            error.requestId = apiError.request_id;
+            throw error;
         }
 
         return data;
@@ -66,7 +72,7 @@ async function apiRequest(url, options = {}) {
 // Logout function
 async function logout() {
     try {
-        await apiRequest('/api/logout', { method: 'POST' });
+        await apiRequest('/api/v1/logout', { method: 'POST' });
     } catch (error) {
         console.error('Logout error:', error);
     } finally {
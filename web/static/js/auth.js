@@ -15,7 +15,7 @@ async function handleLogin(event) {
     try {
         showLoading(submitButton);
         
-        const response = await apiRequest('/api/login', {
+        const response = await apiRequest('/api/v1/login', {
             method: 'POST',
             body: JSON.stringify(loginData)
         });
@@ -59,7 +59,7 @@ async function handleRegister(event) {
     try {
         showLoading(submitButton);
         
-        const response = await apiRequest('/api/register', {
+        const response = await apiRequest('/api/v1/register', {
             method: 'POST',
             body: JSON.stringify(registerData)
         });
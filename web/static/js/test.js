@@ -1,6 +1,7 @@
 // Test interface functionality
 
 let questions = [];
+let attemptId = null;
 let currentQuestionIndex = 0;
 let answers = {};
 let testStartTime = Date.now();
@@ -8,11 +9,22 @@ let questionStartTime = Date.now();
 let testTimer = null;
 let questionTimer = null;
 let displayTimer = null;
+let answerReportTimer = null;
+let deadline = null; // when the attempt is due; null when untimed
+let sessionPaused = false;
+let autoSubmitted = false;
+let testId = parseInt(new URLSearchParams(window.location.search).get('test_id') || '1', 10);
+const sessionId = new URLSearchParams(window.location.search).get('session_id');
 
 async function initializeTest() {
     try {
-        const response = await apiRequest('/api/questions?test_id=1');
-        questions = response.data;
+        const response = sessionId
+            ? await startSessionAttempt()
+            : await apiRequest(`/api/v1/tests/${testId}/start`, { method: 'POST' });
+        attemptId = response.data.attempt.id;
+        testId = response.data.attempt.test_id;
+        deadline = response.data.attempt.deadline ? new Date(response.data.attempt.deadline) : null;
+        questions = response.data.questions;
         
         if (questions.length === 0) {
             showNotification('No questions available', 'error');
@@ -22,9 +34,12 @@ async function initializeTest() {
         setupQuestionNavigation();
         showQuestion(0);
         startTestTimer();
+        if (sessionId) {
+            followSession();
+        }
         
     } catch (error) {
-        showNotification('Failed to load test questions', 'error');
+        showNotification(error.message || 'Failed to load test questions', 'error');
         console.error('Error loading questions:', error);
     }
 }
@@ -60,6 +75,7 @@ function showQuestion(index) {
     
     currentQuestionIndex = index;
     questionStartTime = Date.now();
+    reportActivity('question_advanced', index);
     
     const question = questions[index];
     
@@ -264,14 +280,83 @@ function showQuestionDisplay(question) {
 function saveAnswer(answer) {
     answers[currentQuestionIndex] = answer;
     updateQuestionNavigation();
+
+    // Typed answers change on every key, so report them once typing pauses.
+    const index = currentQuestionIndex;
+    clearTimeout(answerReportTimer);
+    answerReportTimer = setTimeout(() => reportActivity('answer_saved', index), 1000);
+}
+
+// Tell proctors watching the test what the candidate is doing. Failures are
+// ignored so they never interrupt the test.
+function reportActivity(type, questionIndex) {
+    if (!attemptId || sessionPaused) return;
+    apiRequest(`/api/v1/attempts/${attemptId}/activity`, {
+        method: 'POST',
+        body: JSON.stringify({ type, question_index: questionIndex })
+    }).catch(() => {});
+}
+
+window.addEventListener('blur', () => reportActivity('focus_lost'));
+
+// Waits in the session's lobby until the proctor starts or resumes it, then
+// starts the attempt.
+async function startSessionAttempt() {
+    let lobby = (await apiRequest(`/api/v1/sessions/${sessionId}/join`, { method: 'POST' })).data;
+    if (lobby.status === 'scheduled') {
+        showNotification('Waiting for the proctor to start the session', 'info');
+    } else if (lobby.status === 'paused') {
+        showNotification('The session is paused', 'info');
+    }
+    while (lobby.status === 'scheduled' || lobby.status === 'paused') {
+        await new Promise(resolve => setTimeout(resolve, 5000));
+        lobby = (await apiRequest(`/api/v1/sessions/${sessionId}/lobby`)).data;
+    }
+    return apiRequest(`/api/v1/sessions/${sessionId}/attempt`, { method: 'POST' });
 }
 
+// Follows pauses, extensions and terminations of a session attempt.
+function followSession() {
+    const sessionTimer = setInterval(async () => {
+        try {
+            const lobby = (await apiRequest(`/api/v1/sessions/${sessionId}/lobby`)).data;
+            deadline = lobby.deadline ? new Date(lobby.deadline) : null;
+            sessionPaused = lobby.status === 'paused';
+            if (lobby.terminated || lobby.status === 'ended') {
+                clearInterval(sessionTimer);
+                clearInterval(testTimer);
+                attemptId = null;
+                showNotification('The proctor has ended your attempt', 'error');
+                setTimeout(() => {
+                    window.location.href = '/results';
+                }, 2000);
+            }
+        } catch (error) {
+            console.error('Error following session:', error);
+        }
+    }, 10000);
+}
+
+// Shows the time left before the deadline and submits when it runs out, or the
+// time elapsed for untimed attempts.
 function startTestTimer() {
     const timerElement = document.getElementById('timer');
     
     testTimer = setInterval(() => {
-        const elapsed = Math.floor((Date.now() - testStartTime) / 1000);
-        timerElement.textContent = formatTime(elapsed);
+        if (sessionPaused) {
+            timerElement.textContent = 'Paused';
+        } else if (deadline) {
+            const remaining = Math.max(0, Math.floor((deadline - Date.now()) / 1000));
+            timerElement.textContent = formatTime(remaining);
+            if (remaining === 0 && attemptId && !autoSubmitted) {
+                autoSubmitted = true;
+                showNotification('Time is up; submitting your answers', 'info');
+                confirmSubmit();
+            }
+        } else {
+            const elapsed = Math.floor((Date.now() - testStartTime) / 1000);
+            timerElement.textContent = formatTime(elapsed);
+        }
     }, 1000);
 }
 
@@ -373,21 +458,23 @@ async function confirmSubmit() {
             });
         }
         
-        const totalTime = Math.floor((Date.now() - testStartTime) / 1000);
-        
         const submitData = {
-            test_id: 1,
-            answers: testAnswers,
-            time_taken: totalTime
+            test_id: testId,
+            attempt_id: attemptId,
+            answers: testAnswers
         };
         
-        const response = await apiRequest('/api/submit', {
+        const response = await apiRequest('/api/v1/submit', {
             method: 'POST',
             body: JSON.stringify(submitData)
         });
         
         if (response.success) {
             showNotification('Test submitted successfully!', 'success');
+
+            // The attempt is over; nothing more to report for it.
+            clearTimeout(answerReportTimer);
+            attemptId = null;
             
             // Clear test timer
             if (testTimer) {
@@ -401,7 +488,7 @@ async function confirmSubmit() {
         }
         
     } catch (error) {
-        showNotification('Failed to submit test', 'error');
+        showNotification(error.message || 'Failed to submit test', 'error');
         console.error('Submit error:', error);
     } finally {
         hideLoading(submitButton);